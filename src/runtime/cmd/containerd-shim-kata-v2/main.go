@@ -14,6 +14,7 @@ import (
 	shim "github.com/kata-containers/kata-containers/src/runtime/pkg/containerd-shim-v2"
 	"github.com/kata-containers/kata-containers/src/runtime/pkg/katautils"
 	"github.com/kata-containers/kata-containers/src/runtime/pkg/types"
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/urunc"
 )
 
 func shimConfig(config *shimapi.Config) {
@@ -28,5 +29,11 @@ func main() {
 		os.Exit(0)
 	}
 
+	// Hidden subcommand starting a unikernel monitor with the security
+	// settings of its container.
+	if len(os.Args) > 1 && os.Args[1] == urunc.InitCommand {
+		urunc.Init(os.Args[2:])
+	}
+
 	shimapi.Run(types.DefaultKataRuntimeName, shim.New, shimConfig)
 }
@@ -14,7 +14,9 @@ import (
 	"text/template"
 	"time"
 
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/gc"
 	kataMonitor "github.com/kata-containers/kata-containers/src/runtime/pkg/kata-monitor"
+	vc "github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
 	"github.com/sirupsen/logrus"
 )
 
@@ -23,6 +25,9 @@ const defaultListenAddress = "127.0.0.1:8090"
 var monitorListenAddr = flag.String("listen-address", defaultListenAddress, "The address to listen on for HTTP requests.")
 var runtimeEndpoint = flag.String("runtime-endpoint", "/run/containerd/containerd.sock", "Endpoint of CRI container runtime service.")
 var logLevel = flag.String("log-level", "info", "Log level of logrus(trace/debug/info/warn/error/fatal/panic).")
+var gcInterval = flag.Duration("gc-interval", 0, "Interval of the garbage collection of the orphaned sandboxes, 0 disables it.")
+var gcGrace = flag.Duration("gc-grace", 10*time.Minute, "Minimum age of a sandbox to consider it orphaned.")
+var gcDryRun = flag.Bool("gc-dry-run", false, "Only log the orphaned sandboxes, without tearing them down.")
 
 // These values are overridden via ldflags
 var (
@@ -99,6 +104,9 @@ func main() {
 		"listen-address":   *monitorListenAddr,
 		"runtime-endpoint": *runtimeEndpoint,
 		"log-level":        *logLevel,
+		"gc-interval":      *gcInterval,
+		"gc-grace":         *gcGrace,
+		"gc-dry-run":       *gcDryRun,
 	}
 
 	logrus.WithFields(announceFields).Info("announce")
@@ -109,6 +117,15 @@ func main() {
 		panic(err)
 	}
 
+	if *gcInterval > 0 {
+		km.StartGarbageCollector(*gcInterval, gc.Options{
+			List:     vc.ListHostSandboxes,
+			Teardown: vc.CleanupSandbox,
+			Grace:    *gcGrace,
+			DryRun:   *gcDryRun,
+		})
+	}
+
 	// setup handlers, currently only metrics are supported
 	m := http.NewServeMux()
 	endpoints = []endpoint{
@@ -208,4 +225,5 @@ func initLog() {
 	kataMonitorLog.Logger.Formatter = &logrus.TextFormatter{TimestampFormat: time.RFC3339Nano}
 
 	kataMonitor.SetLogger(kataMonitorLog)
+	gc.SetLogger(kataMonitorLog)
 }
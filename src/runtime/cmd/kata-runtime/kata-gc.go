@@ -0,0 +1,100 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/gc"
+	katamonitor "github.com/kata-containers/kata-containers/src/runtime/pkg/kata-monitor"
+	vc "github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
+	"github.com/urfave/cli"
+)
+
+var kataGCCLICommand = cli.Command{
+	Name:  "gc",
+	Usage: "tear down the sandboxes left behind by a dead shim",
+	Description: `Cross-check the persisted sandboxes against the shim sockets,
+   the live processes and the pods ready in the container manager. The
+   orphaned sandboxes have their processes killed and their resources
+   released. The container manager is only skipped with --force.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "only report the orphaned sandboxes",
+		},
+		cli.DurationFlag{
+			Name:  "grace",
+			Value: 10 * time.Minute,
+			Usage: "minimum age of a sandbox to consider it orphaned",
+		},
+		cli.StringFlag{
+			Name:  "runtime-endpoint",
+			Usage: "endpoint of the CRI container runtime service, e.g. /run/containerd/containerd.sock",
+		},
+		cli.BoolFlag{
+			Name:  "force",
+			Usage: "collect the sandboxes without checking the container manager",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		ctx, err := cliContextToContext(c)
+		if err != nil {
+			return err
+		}
+
+		opts := gc.Options{
+			List:     vc.ListHostSandboxes,
+			Teardown: vc.CleanupSandbox,
+			DryRun:   c.Bool("dry-run"),
+			Grace:    c.Duration("grace"),
+		}
+
+		if endpoint := c.String("runtime-endpoint"); endpoint != "" {
+			opts.FilterUnknown = func(sandboxList []string) ([]string, error) {
+				return katamonitor.UnknownSandboxes(endpoint, sandboxList)
+			}
+		} else if !c.Bool("force") {
+			return fmt.Errorf("--runtime-endpoint is required to check the sandboxes against the container manager, use --force to skip the check")
+		}
+
+		results, err := gc.Collect(ctx, opts)
+		if err != nil {
+			return err
+		}
+
+		w := tabwriter.NewWriter(defaultOutputFile, 0, 8, 1, ' ', 0)
+		fmt.Fprintln(w, "SANDBOX\tSTATUS\tRESTORABLE\tPROCESSES\tERROR")
+		failed := 0
+		for _, result := range results {
+			status := "kept: " + result.Reason
+			if result.Orphaned {
+				status = "removed"
+				if opts.DryRun {
+					status = "orphaned"
+				}
+			}
+
+			errMsg := ""
+			if result.Err != nil {
+				failed++
+				status = "failed"
+				errMsg = result.Err.Error()
+			}
+
+			fmt.Fprintf(w, "%s\t%s\t%t\t%v\t%s\n", result.ID, status, result.Restorable, result.Pids, errMsg)
+		}
+		w.Flush()
+
+		if failed > 0 {
+			return fmt.Errorf("failed to tear down %d orphaned sandboxes", failed)
+		}
+
+		return nil
+	},
+}
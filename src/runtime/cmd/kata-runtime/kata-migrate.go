@@ -0,0 +1,62 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"encoding/json"
+	"errors"
+
+	containerdshim "github.com/kata-containers/kata-containers/src/runtime/pkg/containerd-shim-v2"
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/katautils"
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/utils/shimclient"
+	"github.com/urfave/cli"
+)
+
+var kataMigrateCLICommand = cli.Command{
+	Name:      "migrate",
+	Usage:     "live migrate a running sandbox to a sandbox created with an incoming migration",
+	ArgsUsage: "<sandbox-id>",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "to",
+			Usage: "the URI the destination sandbox waits for the migration at (unix:<path> or tcp:<host>:<port>)",
+		},
+		cli.StringFlag{
+			Name:  "state",
+			Usage: "the directory to save the sandbox state to, where the destination sandbox loads it from",
+		},
+	},
+	Action: func(context *cli.Context) error {
+		sandboxID := context.Args().Get(0)
+
+		if err := katautils.VerifyContainerID(sandboxID); err != nil {
+			return err
+		}
+
+		uri := context.String("to")
+		if uri == "" {
+			return errors.New("missing the migration URI")
+		}
+
+		dir := context.String("state")
+		if dir == "" {
+			return errors.New("missing the state directory")
+		}
+
+		return Migrate(sandboxID, uri, dir)
+	},
+}
+
+// Migrate live migrates a sandbox through its shim. The migration copies the
+// whole memory of the VM, it is not bound to the default timeout.
+func Migrate(sandboxID, uri, dir string) error {
+	encoded, err := json.Marshal(containerdshim.MigrateRequest{URI: uri, StatePath: dir})
+	if err != nil {
+		return err
+	}
+
+	return shimclient.DoPost(sandboxID, 0, containerdshim.MigrateUrl, encoded)
+}
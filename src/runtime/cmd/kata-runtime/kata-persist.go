@@ -0,0 +1,99 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/rootless"
+	"github.com/urfave/cli"
+)
+
+var persistSubCmds = []cli.Command{
+	migratePersistCommand,
+	repairPersistCommand,
+}
+
+var kataPersistCommand = cli.Command{
+	Name:        "persist",
+	Usage:       "manage the persisted state of the sandboxes",
+	Subcommands: persistSubCmds,
+	Action: func(context *cli.Context) {
+		cli.ShowSubcommandHelp(context)
+	},
+}
+
+var migratePersistCommand = cli.Command{
+	Name:  "migrate",
+	Usage: "copy the state of the sandboxes saved by the fs persist driver to another driver",
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "to",
+			Value: persist.BoltName,
+			Usage: "the persist driver to migrate to",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		from := persist.RootFSName
+		if rootless.IsRootless() {
+			from = persist.RootlessFSName
+		}
+
+		migrated, err := persist.Migrate(from, c.String("to"))
+		for _, sid := range migrated {
+			fmt.Fprintln(defaultOutputFile, sid)
+		}
+
+		return err
+	},
+}
+
+var repairPersistCommand = cli.Command{
+	Name:  "repair",
+	Usage: "remove the corrupted and orphaned sandbox states, which cannot be restored nor deleted",
+	Description: `The sandboxes whose own state is corrupted are only reported:
+   their hypervisor, mounts and network namespace may still be around, and
+   "kata-runtime gc" tears them down once their shim is gone.`,
+	Flags: []cli.Flag{
+		cli.BoolFlag{
+			Name:  "dry-run",
+			Usage: "only report the problems found",
+		},
+		cli.DurationFlag{
+			Name:  "grace",
+			Value: 10 * time.Minute,
+			Usage: "minimum age of a sandbox without state to consider it orphaned",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		actions, err := persist.Repair(c.Bool("dry-run"), c.Duration("grace"))
+
+		w := tabwriter.NewWriter(defaultOutputFile, 0, 8, 1, ' ', 0)
+		fmt.Fprintln(w, "SANDBOX\tCONTAINER\tPROBLEM\tSTATUS\tPATH")
+		tearDown := 0
+		for _, action := range actions {
+			status := "removed"
+			switch {
+			case action.TearDown:
+				status = "left to gc"
+				tearDown++
+			case c.Bool("dry-run"):
+				status = "found"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\n", action.SandboxID, action.ContainerID, action.Problem, status, action.Path)
+		}
+		w.Flush()
+
+		if tearDown > 0 {
+			fmt.Fprintf(defaultOutputFile, "run \"kata-runtime gc\" to tear down the %d sandboxes left to gc\n", tearDown)
+		}
+
+		return err
+	},
+}
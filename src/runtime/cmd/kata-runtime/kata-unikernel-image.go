@@ -0,0 +1,134 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/urunc/image"
+	"github.com/urfave/cli"
+)
+
+const (
+	unikernelImageCmdName = "unikernel-image"
+
+	layoutFormat  = "layout"
+	archiveFormat = "archive"
+)
+
+var unikernelImageSubCmds = []cli.Command{
+	unikernelImageBuildCommand,
+}
+
+var kataUnikernelImageCommand = cli.Command{
+	Name:        unikernelImageCmdName,
+	Usage:       "manage OCI images holding a unikernel for urunc",
+	Subcommands: unikernelImageSubCmds,
+	Action: func(context *cli.Context) {
+		cli.ShowSubcommandHelp(context)
+	},
+}
+
+var unikernelImageBuildCommand = cli.Command{
+	Name:  "build",
+	Usage: "build an OCI image holding a unikernel, without a container engine",
+	Description: `The image holds the unikernel under /unikernel/, the bitstreams and data
+   files at its root, and the metadata urunc consumes. With --format=archive
+   the result can be loaded with "ctr images import".`,
+	Flags: []cli.Flag{
+		cli.StringFlag{
+			Name:  "image, i",
+			Usage: "the image reference, e.g. docker.io/urunc/hello:latest",
+		},
+		cli.StringFlag{
+			Name:  "unikernel, u",
+			Usage: "the unikernel binary to package",
+		},
+		cli.StringFlag{
+			Name:  "type",
+			Usage: "the unikernel type (hvt, qemu or binary), derived from the binary name by default",
+		},
+		cli.StringFlag{
+			Name:  "cmdline",
+			Usage: "the command line passed to the unikernel",
+		},
+		cli.StringSliceFlag{
+			Name:  "bitstream",
+			Usage: "an FPGA bitstream to add to the image (repeatable)",
+		},
+		cli.StringSliceFlag{
+			Name:  "data, e",
+			Usage: "an extra file to add to the image (repeatable)",
+		},
+		cli.BoolFlag{
+			Name:  "block-image",
+			Usage: "pack the data files into an ext4 block image attached to the unikernel as its disk (requires mkfs.ext4 from e2fsprogs >= 1.43)",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Value: archiveFormat,
+			Usage: "the output format: 'archive' (tarball of an OCI image layout) or 'layout' (directory)",
+		},
+		cli.StringFlag{
+			Name:  "output, o",
+			Usage: "the output file or directory",
+		},
+		cli.StringFlag{
+			Name:  "arch",
+			Usage: "the image architecture, the host one by default",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		output := c.String("output")
+		if output == "" {
+			return errors.New("missing output path")
+		}
+
+		img, err := image.Build(image.Config{
+			Name:         c.String("image"),
+			Unikernel:    c.String("unikernel"),
+			Type:         c.String("type"),
+			Cmdline:      c.String("cmdline"),
+			Bitstreams:   c.StringSlice("bitstream"),
+			DataFiles:    c.StringSlice("data"),
+			BlockImage:   c.Bool("block-image"),
+			Architecture: c.String("arch"),
+		})
+		if err != nil {
+			return err
+		}
+
+		switch c.String("format") {
+		case layoutFormat:
+			err = img.WriteLayout(output)
+		case archiveFormat:
+			err = writeImageArchive(img, output)
+		default:
+			return fmt.Errorf("unknown output format %q", c.String("format"))
+		}
+		if err != nil {
+			return err
+		}
+
+		fmt.Fprintf(defaultOutputFile, "%s %s\n", img.Name(), img.Manifest().Digest)
+		return nil
+	},
+}
+
+func writeImageArchive(img *image.Image, output string) error {
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+
+	if err := img.WriteArchive(f); err != nil {
+		f.Close()
+		os.Remove(output)
+		return err
+	}
+
+	return f.Close()
+}
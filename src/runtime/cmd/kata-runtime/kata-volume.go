@@ -8,6 +8,8 @@ package main
 import (
 	"encoding/json"
 	"net/url"
+	"os"
+	"strings"
 
 	containerdshim "github.com/kata-containers/kata-containers/src/runtime/pkg/containerd-shim-v2"
 	"github.com/kata-containers/kata-containers/src/runtime/pkg/direct-volume"
@@ -26,7 +28,9 @@ var volumeSubCmds = []cli.Command{
 var (
 	mountInfo  string
 	volumePath string
+	sandboxID  string
 	size       uint64
+	attach     bool
 )
 
 var kataVolumeCommand = cli.Command{
@@ -40,7 +44,7 @@ var kataVolumeCommand = cli.Command{
 
 var addCommand = cli.Command{
 	Name:  "add",
-	Usage: "add a direct assigned block volume device to the Kata Containers runtime",
+	Usage: "add a direct assigned volume to the Kata Containers runtime",
 	Flags: []cli.Flag{
 		cli.StringFlag{
 			Name:        "volume-path",
@@ -52,15 +56,31 @@ var addCommand = cli.Command{
 			Usage:       "the mount info for the Kata Containers runtime to manage the volume",
 			Destination: &mountInfo,
 		},
+		cli.BoolFlag{
+			Name:        "attach",
+			Usage:       "attach the volume to its running sandbox",
+			Destination: &attach,
+		},
+		cli.StringFlag{
+			Name:        "sandbox-id",
+			Usage:       "the sandbox to attach the volume to, defaults to the sandbox recorded for the volume",
+			Destination: &sandboxID,
+		},
 	},
 	Action: func(c *cli.Context) error {
-		return volume.Add(volumePath, mountInfo)
+		if err := volume.Add(volumePath, mountInfo); err != nil {
+			return err
+		}
+		if !attach {
+			return nil
+		}
+		return Attach(volumePath, sandboxID)
 	},
 }
 
 var removeCommand = cli.Command{
 	Name:  "remove",
-	Usage: "remove a direct assigned block volume device from the Kata Containers runtime",
+	Usage: "remove a direct assigned volume from the Kata Containers runtime",
 	Flags: []cli.Flag{
 		cli.StringFlag{
 			Name:        "volume-path",
@@ -69,6 +89,9 @@ var removeCommand = cli.Command{
 		},
 	},
 	Action: func(c *cli.Context) error {
+		if err := Detach(volumePath); err != nil {
+			return err
+		}
 		return volume.Remove(volumePath)
 	},
 }
@@ -143,3 +166,44 @@ func Resize(volumePath string, size uint64) error {
 	}
 	return shimclient.DoPost(sandboxId, defaultTimeout, containerdshim.DirectVolumeResizeUrl, encoded)
 }
+
+// Attach attaches a direct volume to a running sandbox, which defaults to the
+// sandbox recorded for the volume.
+func Attach(volumePath string, sandboxId string) error {
+	if sandboxId == "" {
+		var err error
+		if sandboxId, err = volume.GetSandboxIdForVolume(volumePath); err != nil {
+			return err
+		}
+	}
+	attachReq := containerdshim.AttachRequest{
+		VolumePath: volumePath,
+	}
+	encoded, err := json.Marshal(attachReq)
+	if err != nil {
+		return err
+	}
+	return shimclient.DoPost(sandboxId, defaultTimeout, containerdshim.DirectVolumeAttachUrl, encoded)
+}
+
+// Detach releases the device attached for a direct volume by its sandbox, if
+// the sandbox is still running.
+func Detach(volumePath string) error {
+	sandboxId, err := volume.GetSandboxIdForVolume(volumePath)
+	if err != nil {
+		// The volume is not used by any sandbox.
+		return nil
+	}
+	if _, err := os.Stat(strings.TrimPrefix(containerdshim.SocketAddress(sandboxId), "unix://")); os.IsNotExist(err) {
+		// The sandbox is gone with its devices.
+		return nil
+	}
+	detachReq := containerdshim.DetachRequest{
+		VolumePath: volumePath,
+	}
+	encoded, err := json.Marshal(detachReq)
+	if err != nil {
+		return err
+	}
+	return shimclient.DoPost(sandboxId, defaultTimeout, containerdshim.DirectVolumeDetachUrl, encoded)
+}
@@ -14,12 +14,16 @@ import (
 	taskAPI "github.com/containerd/containerd/runtime/v2/task"
 	"github.com/opencontainers/runtime-spec/specs-go"
 
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/urunc"
 	vc "github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
 )
 
 type container struct {
-	s           *service
-	ttyio       *ttyIO
+	s     *service
+	ttyio *ttyIO
+	// unikernel is the monitor running the container unikernel, when
+	// using urunc.
+	unikernel   *urunc.Process
 	spec        *specs.Spec
 	exitTime    time.Time
 	execs       map[string]*exec
@@ -93,18 +93,21 @@ func create(ctx context.Context, s *service, r *taskAPI.CreateTaskRequest) (*con
 		// create tracer
 		// This is the earliest location we can create the tracer because we must wait
 		// until the runtime config is loaded
-		jaegerConfig := &katatrace.JaegerConfig{
+		tracingConfig := &katatrace.TracingConfig{
+			Exporter:       s.config.TracingExporter,
+			Endpoint:       s.config.TracingEndpoint,
+			SamplingRatio:  s.config.TracingSamplingRatio,
 			JaegerEndpoint: s.config.JaegerEndpoint,
 			JaegerUser:     s.config.JaegerUser,
 			JaegerPassword: s.config.JaegerPassword,
 		}
-		_, err = katatrace.CreateTracer("kata", jaegerConfig)
+		_, err = katatrace.CreateTracer("kata", tracingConfig)
 		if err != nil {
 			return nil, err
 		}
 
 		// create root span
-		rootSpan, newCtx := katatrace.Trace(s.ctx, shimLog, "root span", shimTracingTags)
+		rootSpan, newCtx := katatrace.Trace(katatrace.ExtractTraceContext(s.ctx, ctx), shimLog, "root span", shimTracingTags)
 		s.rootCtx = newCtx
 		defer rootSpan.End()
 
@@ -139,6 +142,12 @@ func create(ctx context.Context, s *service, r *taskAPI.CreateTaskRequest) (*con
 			}
 		}()
 
+		if r.Checkpoint != "" {
+			if err := restoreFromCheckpoint(s.config, r.Checkpoint); err != nil {
+				return nil, err
+			}
+		}
+
 		katautils.HandleFactory(ctx, vci, s.config)
 		rootless.SetRootless(s.config.HypervisorConfig.Rootless)
 		if rootless.IsRootless() {
@@ -170,9 +179,13 @@ func create(ctx context.Context, s *service, r *taskAPI.CreateTaskRequest) (*con
 	case vc.PodContainer:
 		logrus.WithFields(logF).WithField("containerType", "PodContainer").Error("")
 
-		span, ctx := katatrace.Trace(s.ctx, shimLog, "create", shimTracingTags)
+		span, ctx := katatrace.Trace(katatrace.ExtractTraceContext(s.ctx, ctx), shimLog, "create", shimTracingTags)
 		defer span.End()
 
+		if r.Checkpoint != "" {
+			return nil, fmt.Errorf("cannot restore container %s from a checkpoint, only the sandbox can be", r.ID)
+		}
+
 		if s.sandbox == nil {
 			return nil, fmt.Errorf("BUG: Cannot start the container, since the sandbox hasn't been created")
 		}
@@ -195,6 +208,12 @@ func create(ctx context.Context, s *service, r *taskAPI.CreateTaskRequest) (*con
 		}
 	}
 
+	if runtimeConfig.HypervisorConfig.Unikernel {
+		if err = runUnikernelCreateHooks(ctx, s, containerType, ociSpec, r.ID, bundlePath); err != nil {
+			return nil, err
+		}
+	}
+
 	container, err := newContainer(s, r, containerType, ociSpec, rootFs.Mounted)
 	if err != nil {
 		return nil, err
@@ -203,6 +222,25 @@ func create(ctx context.Context, s *service, r *taskAPI.CreateTaskRequest) (*con
 	return container, nil
 }
 
+// restoreFromCheckpoint sets the VM of the sandbox to be restored from the
+// checkpoint in dir, as taken by service.Checkpoint.
+func restoreFromCheckpoint(config *oci.RuntimeConfig, dir string) error {
+	if config.HypervisorType != vc.ClhHypervisor {
+		return fmt.Errorf("cannot restore from a checkpoint with hypervisor %s", config.HypervisorType)
+	}
+
+	// The VM of a factory is not restored from the checkpoint.
+	if config.FactoryConfig.Template || config.FactoryConfig.VMCacheNumber > 0 {
+		return errors.New("cannot restore from a checkpoint with a VM factory")
+	}
+
+	config.HypervisorConfig.BootFromTemplate = true
+	config.HypervisorConfig.MemoryPath = dir
+	config.HypervisorConfig.DevicesStatePath = dir
+
+	return nil
+}
+
 func loadSpec(r *taskAPI.CreateTaskRequest) (*specs.Spec, string, error) {
 	// Checks the MUST and MUST NOT from OCI runtime specification
 	bundlePath, err := validBundle(r.ID, r.Bundle)
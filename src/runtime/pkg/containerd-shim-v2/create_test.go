@@ -21,6 +21,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	ktu "github.com/kata-containers/kata-containers/src/runtime/pkg/katatestutils"
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/oci"
 	vc "github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
 	vcAnnotations "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/annotations"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/compatoci"
@@ -430,3 +431,21 @@ func TestCreateLoadRuntimeConfig(t *testing.T) {
 	_, err = loadRuntimeConfig(s, r, anno)
 	assert.NoError(err)
 }
+
+func TestRestoreFromCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &oci.RuntimeConfig{HypervisorType: vc.QemuHypervisor}
+	assert.Error(restoreFromCheckpoint(config, "/checkpoint"))
+
+	config.HypervisorType = vc.ClhHypervisor
+	config.FactoryConfig.Template = true
+	assert.Error(restoreFromCheckpoint(config, "/checkpoint"))
+	assert.False(config.HypervisorConfig.BootFromTemplate)
+
+	config.FactoryConfig.Template = false
+	assert.NoError(restoreFromCheckpoint(config, "/checkpoint"))
+	assert.True(config.HypervisorConfig.BootFromTemplate)
+	assert.Equal("/checkpoint", config.HypervisorConfig.MemoryPath)
+	assert.Equal("/checkpoint", config.HypervisorConfig.DevicesStatePath)
+}
@@ -8,10 +8,14 @@ package containerdshim
 import (
 	"context"
 
+	"strings"
+
 	cgroupsv1 "github.com/containerd/cgroups/stats/v1"
+	cgroupsv2 "github.com/containerd/cgroups/v2/stats"
 	"github.com/containerd/typeurl"
 
 	google_protobuf "github.com/gogo/protobuf/types"
+	resCtrl "github.com/kata-containers/kata-containers/src/runtime/pkg/resourcecontrol"
 	vc "github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
 )
 
@@ -21,7 +25,13 @@ func marshalMetrics(ctx context.Context, s *service, containerID string) (*googl
 		return nil, err
 	}
 
-	metrics := statsToMetrics(&stats)
+	// containerd expects the metrics of the cgroups version of the host.
+	var metrics interface{}
+	if resCtrl.IsCgroupV2() {
+		metrics = statsToMetricsV2(&stats)
+	} else {
+		metrics = statsToMetrics(&stats)
+	}
 
 	data, err := typeurl.MarshalAny(metrics)
 	if err != nil {
@@ -192,3 +202,121 @@ func setNetworkStats(vcNetwork []*vc.NetworkStats) []*cgroupsv1.NetworkStat {
 
 	return networkStats
 }
+
+// statsToMetricsV2 converts the stats of a container to cgroups v2 metrics,
+// which have no network statistics.
+func statsToMetricsV2(stats *vc.ContainerStats) *cgroupsv2.Metrics {
+	metrics := &cgroupsv2.Metrics{}
+
+	if stats.CgroupStats != nil {
+		metrics = &cgroupsv2.Metrics{
+			Hugetlb: setHugetlbStatsV2(stats.CgroupStats.HugetlbStats),
+			Pids:    setPidsStatsV2(stats.CgroupStats.PidsStats),
+			CPU:     setCPUStatsV2(stats.CgroupStats.CPUStats),
+			Memory:  setMemoryStatsV2(stats.CgroupStats.MemoryStats),
+			Io:      setIOStatsV2(stats.CgroupStats.BlkioStats),
+		}
+	}
+
+	return metrics
+}
+
+func setHugetlbStatsV2(vcHugetlb map[string]vc.HugetlbStats) []*cgroupsv2.HugeTlbStat {
+	var hugetlbStats []*cgroupsv2.HugeTlbStat
+	for pagesize, v := range vcHugetlb {
+		hugetlbStats = append(
+			hugetlbStats,
+			&cgroupsv2.HugeTlbStat{
+				Current:  v.Usage,
+				Max:      v.MaxUsage,
+				Pagesize: pagesize,
+			})
+	}
+
+	return hugetlbStats
+}
+
+func setPidsStatsV2(vcPids vc.PidsStats) *cgroupsv2.PidsStat {
+	return &cgroupsv2.PidsStat{
+		Current: vcPids.Current,
+		Limit:   vcPids.Limit,
+	}
+}
+
+func setCPUStatsV2(vcCPU vc.CPUStats) *cgroupsv2.CPUStat {
+	return &cgroupsv2.CPUStat{
+		UsageUsec:     vcCPU.CPUUsage.TotalUsage / 1000,
+		UserUsec:      vcCPU.CPUUsage.UsageInUsermode / 1000,
+		SystemUsec:    vcCPU.CPUUsage.UsageInKernelmode / 1000,
+		NrPeriods:     vcCPU.ThrottlingData.Periods,
+		NrThrottled:   vcCPU.ThrottlingData.ThrottledPeriods,
+		ThrottledUsec: vcCPU.ThrottlingData.ThrottledTime / 1000,
+	}
+}
+
+func setMemoryStatsV2(vcMemory vc.MemoryStats) *cgroupsv2.MemoryStat {
+	memoryStats := &cgroupsv2.MemoryStat{
+		Usage:      vcMemory.Usage.Usage,
+		UsageLimit: vcMemory.Usage.Limit,
+		SwapLimit:  vcMemory.SwapUsage.Limit,
+	}
+
+	// The swap usage of cgroups v1 includes the memory usage.
+	if vcMemory.SwapUsage.Usage > vcMemory.Usage.Usage {
+		memoryStats.SwapUsage = vcMemory.SwapUsage.Usage - vcMemory.Usage.Usage
+	}
+
+	// The guest reports the memory statistics of its own cgroups version.
+	stat := func(v2Name, v1Name string) uint64 {
+		if v, ok := vcMemory.Stats[v2Name]; ok {
+			return v
+		}
+		return vcMemory.Stats[v1Name]
+	}
+
+	memoryStats.Anon = stat("anon", "rss")
+	memoryStats.File = stat("file", "cache")
+	memoryStats.FileMapped = stat("file_mapped", "mapped_file")
+	memoryStats.InactiveFile = stat("inactive_file", "total_inactive_file")
+	memoryStats.Pgfault = stat("pgfault", "pgfault")
+	memoryStats.Pgmajfault = stat("pgmajfault", "pgmajfault")
+
+	return memoryStats
+}
+
+func setIOStatsV2(vcBlkio vc.BlkioStats) *cgroupsv2.IOStat {
+	var entries []*cgroupsv2.IOEntry
+	devices := map[[2]uint64]*cgroupsv2.IOEntry{}
+
+	entry := func(v vc.BlkioStatEntry) *cgroupsv2.IOEntry {
+		key := [2]uint64{v.Major, v.Minor}
+		if e, ok := devices[key]; ok {
+			return e
+		}
+
+		e := &cgroupsv2.IOEntry{Major: v.Major, Minor: v.Minor}
+		devices[key] = e
+		entries = append(entries, e)
+		return e
+	}
+
+	for _, v := range vcBlkio.IoServiceBytesRecursive {
+		switch strings.ToLower(v.Op) {
+		case "read":
+			entry(v).Rbytes = v.Value
+		case "write":
+			entry(v).Wbytes = v.Value
+		}
+	}
+
+	for _, v := range vcBlkio.IoServicedRecursive {
+		switch strings.ToLower(v.Op) {
+		case "read":
+			entry(v).Rios = v.Value
+		case "write":
+			entry(v).Wios = v.Value
+		}
+	}
+
+	return &cgroupsv2.IOStat{Usage: entries}
+}
@@ -11,6 +11,7 @@ import (
 	"testing"
 
 	"github.com/containerd/cgroups/stats/v1"
+	cgroupsv2 "github.com/containerd/cgroups/v2/stats"
 	vc "github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/vcmock"
 	"github.com/stretchr/testify/assert"
@@ -57,3 +58,46 @@ func TestStatNetworkMetric(t *testing.T) {
 	metrics := statsToMetrics(&resp)
 	assert.Equal(expectedNetwork, metrics.Network)
 }
+
+func TestStatsToMetricsV2(t *testing.T) {
+	assert := assert.New(t)
+
+	stats := &vc.ContainerStats{
+		CgroupStats: &vc.CgroupStats{
+			CPUStats: vc.CPUStats{
+				CPUUsage: vc.CPUUsage{
+					TotalUsage:        3000000,
+					UsageInUsermode:   2000000,
+					UsageInKernelmode: 1000000,
+				},
+			},
+			MemoryStats: vc.MemoryStats{
+				Usage:     vc.MemoryData{Usage: 100, Limit: 1000},
+				SwapUsage: vc.MemoryData{Usage: 150},
+				Stats:     map[string]uint64{"rss": 60, "cache": 40},
+			},
+			BlkioStats: vc.BlkioStats{
+				IoServiceBytesRecursive: []vc.BlkioStatEntry{
+					{Major: 8, Minor: 0, Op: "Read", Value: 4096},
+					{Major: 8, Minor: 0, Op: "Write", Value: 8192},
+					{Major: 8, Minor: 0, Op: "Total", Value: 12288},
+				},
+				IoServicedRecursive: []vc.BlkioStatEntry{
+					{Major: 8, Minor: 0, Op: "Read", Value: 1},
+					{Major: 8, Minor: 0, Op: "Write", Value: 2},
+				},
+			},
+		},
+	}
+
+	metrics := statsToMetricsV2(stats)
+	assert.Equal(&cgroupsv2.CPUStat{UsageUsec: 3000, UserUsec: 2000, SystemUsec: 1000}, metrics.CPU)
+	assert.Equal(uint64(100), metrics.Memory.Usage)
+	assert.Equal(uint64(1000), metrics.Memory.UsageLimit)
+	assert.Equal(uint64(50), metrics.Memory.SwapUsage)
+	assert.Equal(uint64(60), metrics.Memory.Anon)
+	assert.Equal(uint64(40), metrics.Memory.File)
+	assert.Equal([]*cgroupsv2.IOEntry{
+		{Major: 8, Minor: 0, Rbytes: 4096, Wbytes: 8192, Rios: 1, Wios: 2},
+	}, metrics.Io.Usage)
+}
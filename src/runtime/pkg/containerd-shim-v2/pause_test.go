@@ -8,8 +8,10 @@ package containerdshim
 
 import (
 	"context"
+	"fmt"
 	"testing"
 
+	"github.com/containerd/containerd/api/types/task"
 	"github.com/containerd/containerd/namespaces"
 	taskAPI "github.com/containerd/containerd/runtime/v2/task"
 
@@ -201,3 +203,141 @@ func TestResumeContainerFail(t *testing.T) {
 	_, err := s.Resume(ctx, reqResume)
 	assert.Error(err)
 }
+
+func TestPauseResumeSandbox(t *testing.T) {
+	assert := assert.New(t)
+	var err error
+
+	paused := false
+	sandbox := &vcmock.Sandbox{
+		MockID: testSandboxID,
+	}
+
+	sandbox.PauseFunc = func() error {
+		paused = true
+		return nil
+	}
+	sandbox.ResumeFunc = func() error {
+		paused = false
+		return nil
+	}
+	sandbox.StatusContainerFunc = func(contID string) (vc.ContainerStatus, error) {
+		state := types.StateRunning
+		if paused {
+			state = types.StatePaused
+		}
+		return vc.ContainerStatus{
+			ID:          contID,
+			Annotations: make(map[string]string),
+			State: types.ContainerState{
+				State: state,
+			},
+		}, nil
+	}
+
+	s := &service{
+		id:         testSandboxID,
+		sandbox:    sandbox,
+		containers: make(map[string]*container),
+		events:     make(chan interface{}, 10),
+	}
+
+	s.containers[testSandboxID], err = newContainer(s, &taskAPI.CreateTaskRequest{ID: testSandboxID}, vc.PodSandbox, nil, true)
+	assert.NoError(err)
+	s.containers[testContainerID], err = newContainer(s, &taskAPI.CreateTaskRequest{ID: testContainerID}, vc.PodContainer, nil, true)
+	assert.NoError(err)
+	for _, c := range s.containers {
+		c.status = task.StatusRunning
+	}
+
+	ctx := namespaces.WithNamespace(context.Background(), "UnitTest")
+
+	// Pausing the sandbox container pauses every container of the sandbox.
+	_, err = s.Pause(ctx, &taskAPI.PauseRequest{ID: testSandboxID})
+	assert.NoError(err)
+	for _, c := range s.containers {
+		assert.Equal(task.StatusPaused, c.status)
+	}
+	assert.Len(s.events, 2)
+
+	_, err = s.Resume(ctx, &taskAPI.ResumeRequest{ID: testSandboxID})
+	assert.NoError(err)
+	for _, c := range s.containers {
+		assert.Equal(task.StatusRunning, c.status)
+	}
+	assert.Len(s.events, 4)
+}
+
+func TestPauseSandboxFail(t *testing.T) {
+	assert := assert.New(t)
+	var err error
+
+	sandbox := &vcmock.Sandbox{
+		MockID: testSandboxID,
+	}
+
+	sandbox.PauseFunc = func() error {
+		return fmt.Errorf("pause failed")
+	}
+	sandbox.StatusContainerFunc = func(contID string) (vc.ContainerStatus, error) {
+		return vc.ContainerStatus{
+			ID:          contID,
+			Annotations: make(map[string]string),
+			State: types.ContainerState{
+				State: types.StateRunning,
+			},
+		}, nil
+	}
+
+	s := &service{
+		id:         testSandboxID,
+		sandbox:    sandbox,
+		containers: make(map[string]*container),
+	}
+
+	s.containers[testSandboxID], err = newContainer(s, &taskAPI.CreateTaskRequest{ID: testSandboxID}, vc.PodSandbox, nil, true)
+	assert.NoError(err)
+
+	ctx := namespaces.WithNamespace(context.Background(), "UnitTest")
+
+	// The sandbox keeps running when its VM could not be paused.
+	_, err = s.Pause(ctx, &taskAPI.PauseRequest{ID: testSandboxID})
+	assert.Error(err)
+	assert.Equal(task.StatusRunning, s.containers[testSandboxID].status)
+}
+
+func TestPauseSandboxNotSupported(t *testing.T) {
+	assert := assert.New(t)
+	var err error
+
+	paused := ""
+	sandbox := &vcmock.Sandbox{
+		MockID: testSandboxID,
+	}
+
+	sandbox.PauseFunc = func() error {
+		return types.ErrVMPauseNotSupported
+	}
+	sandbox.PauseContainerFunc = func(contID string) error {
+		paused = contID
+		return nil
+	}
+
+	s := &service{
+		id:         testSandboxID,
+		sandbox:    sandbox,
+		containers: make(map[string]*container),
+		events:     make(chan interface{}, 10),
+	}
+
+	s.containers[testSandboxID], err = newContainer(s, &taskAPI.CreateTaskRequest{ID: testSandboxID}, vc.PodSandbox, nil, true)
+	assert.NoError(err)
+
+	ctx := namespaces.WithNamespace(context.Background(), "UnitTest")
+
+	// Only the sandbox container is paused when the VM can't be.
+	_, err = s.Pause(ctx, &taskAPI.PauseRequest{ID: testSandboxID})
+	assert.NoError(err)
+	assert.Equal(testSandboxID, paused)
+	assert.Equal(task.StatusPaused, s.containers[testSandboxID].status)
+}
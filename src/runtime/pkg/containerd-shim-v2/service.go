@@ -10,8 +10,6 @@ import (
 	"io"
 	"os"
 	sysexec "os/exec"
-	"strconv"
-	"strings"
 	"sync"
 	"syscall"
 	"time"
@@ -36,8 +34,6 @@ import (
 	vc "github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/compatoci"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
-	ps "github.com/mitchellh/go-ps"
-	psutil "github.com/shirou/gopsutil/process"
 )
 
 // shimTracingTags defines tags for the trace span
@@ -312,7 +308,7 @@ func getTopic(e interface{}) string {
 
 // Cleanup is a binary call that cleans up resources used by the shim
 func (s *service) Cleanup(ctx context.Context) (_ *taskAPI.DeleteResponse, err error) {
-	span, spanCtx := katatrace.Trace(s.rootCtx, shimLog, "Cleanup", shimTracingTags)
+	span, spanCtx := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Cleanup", shimTracingTags)
 	defer span.End()
 
 	//Since the binary cleanup will return the DeleteResponse from stdout to
@@ -435,7 +431,7 @@ func (s *service) Start(ctx context.Context, r *taskAPI.StartRequest) (_ *taskAP
 	shimLog.WithField("container", r.ID).Debug("Start() start")
 	logrus.WithFields(logF).WithField("container", r.ID).Error("")
 	defer shimLog.WithFields(logF).WithField("container", r.ID).Error("Start() end")
-	span, spanCtx := katatrace.Trace(s.rootCtx, shimLog, "Start", shimTracingTags)
+	span, spanCtx := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Start", shimTracingTags)
 	defer span.End()
 
 	start := time.Now()
@@ -501,7 +497,7 @@ func (s *service) Start(ctx context.Context, r *taskAPI.StartRequest) (_ *taskAP
 func (s *service) Delete(ctx context.Context, r *taskAPI.DeleteRequest) (_ *taskAPI.DeleteResponse, err error) {
 	shimLog.WithField("container", r.ID).Debug("Delete() start")
 	defer shimLog.WithField("container", r.ID).Debug("Delete() end")
-	span, spanCtx := katatrace.Trace(s.rootCtx, shimLog, "Delete", shimTracingTags)
+	span, spanCtx := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Delete", shimTracingTags)
 	defer span.End()
 
 	start := time.Now()
@@ -569,7 +565,7 @@ func (s *service) Delete(ctx context.Context, r *taskAPI.DeleteRequest) (_ *task
 func (s *service) Exec(ctx context.Context, r *taskAPI.ExecProcessRequest) (_ *ptypes.Empty, err error) {
 	shimLog.WithField("container", r.ID).Debug("Exec() start")
 	defer shimLog.WithField("container", r.ID).Debug("Exec() end")
-	span, _ := katatrace.Trace(s.rootCtx, shimLog, "Exec", shimTracingTags)
+	span, _ := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Exec", shimTracingTags)
 	defer span.End()
 
 	start := time.Now()
@@ -612,7 +608,7 @@ func (s *service) Exec(ctx context.Context, r *taskAPI.ExecProcessRequest) (_ *p
 func (s *service) ResizePty(ctx context.Context, r *taskAPI.ResizePtyRequest) (_ *ptypes.Empty, err error) {
 	shimLog.WithField("container", r.ID).Debug("ResizePty() start")
 	defer shimLog.WithField("container", r.ID).Debug("ResizePty() end")
-	span, spanCtx := katatrace.Trace(s.rootCtx, shimLog, "ResizePty", shimTracingTags)
+	span, spanCtx := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "ResizePty", shimTracingTags)
 	defer span.End()
 
 	start := time.Now()
@@ -657,7 +653,7 @@ func (s *service) ResizePty(ctx context.Context, r *taskAPI.ResizePtyRequest) (_
 func (s *service) State(ctx context.Context, r *taskAPI.StateRequest) (_ *taskAPI.StateResponse, err error) {
 	shimLog.WithField("container", r.ID).Debug("State() start")
 	defer shimLog.WithField("container", r.ID).Debug("State() end")
-	span, _ := katatrace.Trace(s.rootCtx, shimLog, "State", shimTracingTags)
+	span, _ := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "State", shimTracingTags)
 	defer span.End()
 
 	start := time.Now()
@@ -719,7 +715,7 @@ func (s *service) State(ctx context.Context, r *taskAPI.StateRequest) (_ *taskAP
 func (s *service) Pause(ctx context.Context, r *taskAPI.PauseRequest) (_ *ptypes.Empty, err error) {
 	shimLog.WithField("container", r.ID).Debug("Pause() start")
 	defer shimLog.WithField("container", r.ID).Debug("Pause() end")
-	span, spanCtx := katatrace.Trace(s.rootCtx, shimLog, "Pause", shimTracingTags)
+	span, spanCtx := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Pause", shimTracingTags)
 	defer span.End()
 
 	start := time.Now()
@@ -739,6 +735,14 @@ func (s *service) Pause(ctx context.Context, r *taskAPI.PauseRequest) (_ *ptypes
 
 	c.status = task.StatusPausing
 
+	// Pausing the sandbox container pauses the VM, and with it all the
+	// containers of the sandbox, if the hypervisor supports it.
+	if c.cType.IsSandbox() {
+		if err := s.setSandboxPaused(spanCtx, true); !errors.Is(err, types.ErrVMPauseNotSupported) {
+			return empty, err
+		}
+	}
+
 	err = s.sandbox.PauseContainer(spanCtx, r.ID)
 	if err == nil {
 		c.status = task.StatusPaused
@@ -761,7 +765,7 @@ func (s *service) Pause(ctx context.Context, r *taskAPI.PauseRequest) (_ *ptypes
 func (s *service) Resume(ctx context.Context, r *taskAPI.ResumeRequest) (_ *ptypes.Empty, err error) {
 	shimLog.WithField("container", r.ID).Debug("Resume() start")
 	defer shimLog.WithField("container", r.ID).Debug("Resume() end")
-	span, spanCtx := katatrace.Trace(s.rootCtx, shimLog, "Resume", shimTracingTags)
+	span, spanCtx := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Resume", shimTracingTags)
 	defer span.End()
 
 	start := time.Now()
@@ -779,6 +783,12 @@ func (s *service) Resume(ctx context.Context, r *taskAPI.ResumeRequest) (_ *ptyp
 		return nil, err
 	}
 
+	if c.cType.IsSandbox() {
+		if err := s.setSandboxPaused(spanCtx, false); !errors.Is(err, types.ErrVMPauseNotSupported) {
+			return empty, err
+		}
+	}
+
 	err = s.sandbox.ResumeContainer(spanCtx, c.id)
 	if err == nil {
 		c.status = task.StatusRunning
@@ -797,50 +807,54 @@ func (s *service) Resume(ctx context.Context, r *taskAPI.ResumeRequest) (_ *ptyp
 	return empty, err
 }
 
-// Kill a process with the provided signal
-func (s *service) Kill(ctx context.Context, r *taskAPI.KillRequest) (_ *ptypes.Empty, err error) {
-	logF := logrus.Fields{"src": "uruncio", "file": "cs/service.go", "func": "service.Kill"}
-	logrus.WithFields(logF).Error("")
-	processes, err := ps.Processes()
-	if err != nil {
-		logrus.WithFields(logF).Error("processes failed")
+// setSandboxPaused pauses or resumes the VM of the sandbox, then updates the
+// status of every container from the sandbox one, so that it reflects what
+// actually happened even if the operation failed half way.
+func (s *service) setSandboxPaused(ctx context.Context, pause bool) error {
+	var err error
+	if pause {
+		err = s.sandbox.Pause(ctx)
+	} else {
+		err = s.sandbox.Resume(ctx)
 	}
-
-	for _, process := range processes {
-		if strings.Contains(process.Executable(), "unikata") {
-			logrus.WithFields(logF).WithField("proc", process.Executable()).Error("")
-		}
+	if errors.Is(err, types.ErrVMPauseNotSupported) {
+		return err
 	}
 
-	tempid := os.Getpid()
-	strPid := strconv.Itoa(tempid)
-
-	logrus.WithFields(logF).Error(strPid)
-
-	output, err := sysexec.Command("ps", "-ef").CombinedOutput()
-	if err != nil {
-		logrus.WithFields(logF).Error("ps failed")
-	}
+	for _, c := range s.containers {
+		previous := c.status
+		if status, err := s.getContainerStatus(c.id); err != nil {
+			c.status = task.StatusUnknown
+		} else {
+			c.status = status
+		}
 
-	outputLines := strings.Split(string(output), "\n")
-	for _, l := range outputLines {
-		if strings.Contains(l, "unikata") {
-			logrus.WithFields(logF).Error(l)
+		if c.status == previous {
+			continue
+		}
+		switch c.status {
+		case task.StatusPaused:
+			s.send(&eventstypes.TaskPaused{
+				ContainerID: c.id,
+			})
+		case task.StatusRunning:
+			s.send(&eventstypes.TaskResumed{
+				ContainerID: c.id,
+			})
 		}
 	}
-	newP, _ := psutil.NewProcess(int32(tempid))
 
-	childProcesses, _ := newP.Children()
-	for _, child := range childProcesses {
-		name, _ := child.Name()
-		logrus.WithFields(logF).WithField("killing", name)
-		child.Kill()
-	}
-	logrus.WithFields(logF).Error(newP)
+	return err
+}
+
+// Kill a process with the provided signal
+func (s *service) Kill(ctx context.Context, r *taskAPI.KillRequest) (_ *ptypes.Empty, err error) {
+	logF := logrus.Fields{"src": "uruncio", "file": "cs/service.go", "func": "service.Kill"}
+	logrus.WithFields(logF).Error("")
 
 	shimLog.WithField("container", r.ID).Debug("Kill() start")
 	defer shimLog.WithField("container", r.ID).Debug("Kill() end")
-	span, spanCtx := katatrace.Trace(s.rootCtx, shimLog, "Kill", shimTracingTags)
+	span, spanCtx := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Kill", shimTracingTags)
 	defer span.End()
 
 	start := time.Now()
@@ -900,6 +914,14 @@ func (s *service) Kill(ctx context.Context, r *taskAPI.KillRequest) (_ *ptypes.E
 		return empty, nil
 	}
 
+	if r.ExecID == "" && c.unikernel != nil {
+		err = c.unikernel.Signal(signum)
+		if err == os.ErrProcessDone {
+			err = nil
+		}
+		return empty, err
+	}
+
 	return empty, s.sandbox.SignalProcess(spanCtx, c.id, processID, signum, r.All)
 }
 
@@ -909,7 +931,7 @@ func (s *service) Kill(ctx context.Context, r *taskAPI.KillRequest) (_ *ptypes.E
 func (s *service) Pids(ctx context.Context, r *taskAPI.PidsRequest) (_ *taskAPI.PidsResponse, err error) {
 	shimLog.WithField("container", r.ID).Debug("Pids() start")
 	defer shimLog.WithField("container", r.ID).Debug("Pids() end")
-	span, _ := katatrace.Trace(s.rootCtx, shimLog, "Pids", shimTracingTags)
+	span, _ := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Pids", shimTracingTags)
 	defer span.End()
 
 	var processes []*task.ProcessInfo
@@ -934,7 +956,7 @@ func (s *service) Pids(ctx context.Context, r *taskAPI.PidsRequest) (_ *taskAPI.
 func (s *service) CloseIO(ctx context.Context, r *taskAPI.CloseIORequest) (_ *ptypes.Empty, err error) {
 	shimLog.WithField("container", r.ID).Debug("CloseIO() start")
 	defer shimLog.WithField("container", r.ID).Debug("CloseIO() end")
-	span, _ := katatrace.Trace(s.rootCtx, shimLog, "CloseIO", shimTracingTags)
+	span, _ := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "CloseIO", shimTracingTags)
 	defer span.End()
 
 	start := time.Now()
@@ -978,7 +1000,7 @@ func (s *service) CloseIO(ctx context.Context, r *taskAPI.CloseIORequest) (_ *pt
 func (s *service) Checkpoint(ctx context.Context, r *taskAPI.CheckpointTaskRequest) (_ *ptypes.Empty, err error) {
 	shimLog.WithField("container", r.ID).Debug("Checkpoint() start")
 	defer shimLog.WithField("container", r.ID).Debug("Checkpoint() end")
-	span, _ := katatrace.Trace(s.rootCtx, shimLog, "Checkpoint", shimTracingTags)
+	span, spanCtx := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Checkpoint", shimTracingTags)
 	defer span.End()
 
 	start := time.Now()
@@ -987,14 +1009,40 @@ func (s *service) Checkpoint(ctx context.Context, r *taskAPI.CheckpointTaskReque
 		rpcDurationsHistogram.WithLabelValues("checkpoint").Observe(float64(time.Since(start).Nanoseconds() / int64(time.Millisecond)))
 	}()
 
-	return nil, errdefs.ToGRPCf(errdefs.ErrNotImplemented, "service Checkpoint")
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	c, err := s.getContainer(r.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// The VM is checkpointed as a whole, along with all the containers of
+	// the sandbox.
+	if !c.cType.IsSandbox() {
+		return nil, errdefs.ToGRPCf(errdefs.ErrNotImplemented, "checkpoint of container %s: only the sandbox can be checkpointed", r.ID)
+	}
+
+	if r.Path == "" {
+		return nil, errdefs.ToGRPCf(errdefs.ErrInvalidArgument, "checkpoint path is empty")
+	}
+
+	if err := s.sandbox.Checkpoint(spanCtx, r.Path); err != nil {
+		return nil, err
+	}
+
+	s.send(&eventstypes.TaskCheckpointed{
+		ContainerID: c.id,
+	})
+
+	return empty, nil
 }
 
 // Connect returns shim information such as the shim's pid
 func (s *service) Connect(ctx context.Context, r *taskAPI.ConnectRequest) (_ *taskAPI.ConnectResponse, err error) {
 	shimLog.WithField("container", r.ID).Debug("Connect() start")
 	defer shimLog.WithField("container", r.ID).Debug("Connect() end")
-	span, _ := katatrace.Trace(s.rootCtx, shimLog, "Connect", shimTracingTags)
+	span, _ := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Connect", shimTracingTags)
 	defer span.End()
 
 	start := time.Now()
@@ -1016,7 +1064,7 @@ func (s *service) Connect(ctx context.Context, r *taskAPI.ConnectRequest) (_ *ta
 func (s *service) Shutdown(ctx context.Context, r *taskAPI.ShutdownRequest) (_ *ptypes.Empty, err error) {
 	shimLog.WithField("container", r.ID).Debug("Shutdown() start")
 	defer shimLog.WithField("container", r.ID).Debug("Shutdown() end")
-	span, _ := katatrace.Trace(s.rootCtx, shimLog, "Shutdown", shimTracingTags)
+	span, _ := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Shutdown", shimTracingTags)
 
 	start := time.Now()
 	defer func() {
@@ -1061,7 +1109,7 @@ func (s *service) Shutdown(ctx context.Context, r *taskAPI.ShutdownRequest) (_ *
 func (s *service) Stats(ctx context.Context, r *taskAPI.StatsRequest) (_ *taskAPI.StatsResponse, err error) {
 	shimLog.WithField("container", r.ID).Debug("Stats() start")
 	defer shimLog.WithField("container", r.ID).Debug("Stats() end")
-	span, spanCtx := katatrace.Trace(s.rootCtx, shimLog, "Stats", shimTracingTags)
+	span, spanCtx := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Stats", shimTracingTags)
 	defer span.End()
 
 	start := time.Now()
@@ -1094,7 +1142,7 @@ func (s *service) Stats(ctx context.Context, r *taskAPI.StatsRequest) (_ *taskAP
 func (s *service) Update(ctx context.Context, r *taskAPI.UpdateTaskRequest) (_ *ptypes.Empty, err error) {
 	shimLog.WithField("container", r.ID).Debug("Update() start")
 	defer shimLog.WithField("container", r.ID).Debug("Update() end")
-	span, spanCtx := katatrace.Trace(s.rootCtx, shimLog, "Update", shimTracingTags)
+	span, spanCtx := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Update", shimTracingTags)
 	defer span.End()
 
 	start := time.Now()
@@ -1131,7 +1179,7 @@ func (s *service) Wait(ctx context.Context, r *taskAPI.WaitRequest) (_ *taskAPI.
 	logF := logrus.Fields{"src": "uruncio", "file": "cs/service.go", "func": "Wait"}
 	shimLog.WithFields(logF).WithField("container", r.ID).Error("Wait() start")
 	defer shimLog.WithField("container", r.ID).Debug("Wait() end")
-	span, _ := katatrace.Trace(s.rootCtx, shimLog, "Wait", shimTracingTags)
+	span, _ := katatrace.Trace(katatrace.ExtractTraceContext(s.rootCtx, ctx), shimLog, "Wait", shimTracingTags)
 	defer span.End()
 
 	var ret uint32
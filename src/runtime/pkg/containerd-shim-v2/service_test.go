@@ -12,8 +12,11 @@ import (
 	"strings"
 	"testing"
 
+	"github.com/containerd/containerd/namespaces"
 	taskAPI "github.com/containerd/containerd/runtime/v2/task"
 	ktu "github.com/kata-containers/kata-containers/src/runtime/pkg/katatestutils"
+	vc "github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/vcmock"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -72,3 +75,39 @@ func TestServiceCreate(t *testing.T) {
 		}
 	}
 }
+
+func TestServiceCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	var checkpointed string
+	sandbox := &vcmock.Sandbox{
+		MockID: testSandboxID,
+		CheckpointFunc: func(dir string) error {
+			checkpointed = dir
+			return nil
+		},
+	}
+
+	s, err := newService(testSandboxID)
+	assert.NoError(err)
+	s.sandbox = sandbox
+
+	s.containers[testSandboxID], err = newContainer(s, &taskAPI.CreateTaskRequest{ID: testSandboxID}, vc.PodSandbox, nil, true)
+	assert.NoError(err)
+	s.containers[testContainerID], err = newContainer(s, &taskAPI.CreateTaskRequest{ID: testContainerID}, vc.PodContainer, nil, true)
+	assert.NoError(err)
+
+	ctx := namespaces.WithNamespace(context.Background(), "UnitTest")
+
+	// Only the sandbox can be checkpointed, to a given path.
+	_, err = s.Checkpoint(ctx, &taskAPI.CheckpointTaskRequest{ID: testContainerID, Path: "/checkpoint"})
+	assert.Error(err)
+	_, err = s.Checkpoint(ctx, &taskAPI.CheckpointTaskRequest{ID: testSandboxID})
+	assert.Error(err)
+	assert.Empty(checkpointed)
+
+	_, err = s.Checkpoint(ctx, &taskAPI.CheckpointTaskRequest{ID: testSandboxID, Path: "/checkpoint"})
+	assert.NoError(err)
+	assert.Equal("/checkpoint", checkpointed)
+	assert.Len(s.events, 1)
+}
@@ -34,6 +34,9 @@ import (
 const (
 	DirectVolumeStatUrl   = "/direct-volume/stats"
 	DirectVolumeResizeUrl = "/direct-volume/resize"
+	DirectVolumeAttachUrl = "/direct-volume/attach"
+	DirectVolumeDetachUrl = "/direct-volume/detach"
+	MigrateUrl            = "/migrate"
 )
 
 var (
@@ -46,6 +49,26 @@ type ResizeRequest struct {
 	Size       uint64
 }
 
+// AttachRequest asks to attach a direct assigned volume to the running
+// sandbox.
+type AttachRequest struct {
+	VolumePath string
+}
+
+// DetachRequest asks to release the device attached for a direct assigned
+// volume.
+type DetachRequest struct {
+	VolumePath string
+}
+
+// MigrateRequest asks to live migrate the sandbox to the sandbox created
+// with an incoming migration at URI, saving the state of the sandbox to
+// StatePath for the destination sandbox.
+type MigrateRequest struct {
+	URI       string
+	StatePath string
+}
+
 // agentURL returns URL for agent
 func (s *service) agentURL(w http.ResponseWriter, r *http.Request) {
 	url, err := s.sandbox.GetAgentURL()
@@ -184,6 +207,105 @@ func (s *service) serveVolumeResize(w http.ResponseWriter, r *http.Request) {
 	w.Write([]byte(""))
 }
 
+func (s *service) serveVolumeAttach(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		shimMgtLog.WithError(err).Error("failed to read request body")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	var attachReq AttachRequest
+	err = json.Unmarshal(body, &attachReq)
+	if err != nil {
+		shimMgtLog.WithError(err).Error("failed to unmarshal the http request body")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.sandbox.AttachDirectVolume(context.Background(), attachReq.VolumePath)
+	if err != nil {
+		shimMgtLog.WithError(err).WithField("volume-path", attachReq.VolumePath).Error("failed to attach the volume")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Write([]byte(""))
+}
+
+func (s *service) serveVolumeDetach(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		shimMgtLog.WithError(err).Error("failed to read request body")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	var detachReq DetachRequest
+	err = json.Unmarshal(body, &detachReq)
+	if err != nil {
+		shimMgtLog.WithError(err).Error("failed to unmarshal the http request body")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.sandbox.DetachDirectVolume(context.Background(), detachReq.VolumePath)
+	if err != nil {
+		shimMgtLog.WithError(err).WithField("volume-path", detachReq.VolumePath).Error("failed to detach the volume")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	w.Write([]byte(""))
+}
+
+func (s *service) serveMigrate(w http.ResponseWriter, r *http.Request) {
+	body, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		shimMgtLog.WithError(err).Error("failed to read request body")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	var migrateReq MigrateRequest
+	err = json.Unmarshal(body, &migrateReq)
+	if err != nil {
+		shimMgtLog.WithError(err).Error("failed to unmarshal the http request body")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	if migrateReq.StatePath == "" {
+		shimMgtLog.Error("missing the state path of the migration")
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte("missing the state path of the migration"))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	err = s.sandbox.Migrate(context.Background(), migrateReq.URI, migrateReq.StatePath)
+	if err != nil {
+		shimMgtLog.WithError(err).WithField("uri", migrateReq.URI).Error("failed to migrate the sandbox")
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	exitMigrated(context.Background(), s)
+	w.Write([]byte(""))
+}
+
 func (s *service) startManagementServer(ctx context.Context, ociSpec *specs.Spec) {
 	// metrics socket will under sandbox's bundle path
 	metricsAddress := SocketAddress(s.id)
@@ -208,6 +330,9 @@ func (s *service) startManagementServer(ctx context.Context, ociSpec *specs.Spec
 	m.Handle("/agent-url", http.HandlerFunc(s.agentURL))
 	m.Handle(DirectVolumeStatUrl, http.HandlerFunc(s.serveVolumeStats))
 	m.Handle(DirectVolumeResizeUrl, http.HandlerFunc(s.serveVolumeResize))
+	m.Handle(DirectVolumeAttachUrl, http.HandlerFunc(s.serveVolumeAttach))
+	m.Handle(DirectVolumeDetachUrl, http.HandlerFunc(s.serveVolumeDetach))
+	m.Handle(MigrateUrl, http.HandlerFunc(s.serveMigrate))
 	s.mountPprofHandle(m, ociSpec)
 
 	// register shim metrics
@@ -6,12 +6,18 @@
 package containerdshim
 
 import (
+	"bytes"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"strings"
 	"testing"
 
+	"github.com/containerd/containerd/api/types/task"
+	taskAPI "github.com/containerd/containerd/runtime/v2/task"
+
+	vc "github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/vcmock"
 
 	"github.com/stretchr/testify/assert"
@@ -61,3 +67,54 @@ go_threads 23
 	body = rr.Body.String()
 	assert.Equal(true, len(strings.Split(body, "\n")) > 0)
 }
+
+func TestServeMigrate(t *testing.T) {
+	assert := assert.New(t)
+
+	var migratedTo, statePath string
+	sandbox := &vcmock.Sandbox{
+		MockID: testSandboxID,
+		MigrateFunc: func(uri, dir string) error {
+			migratedTo = uri
+			statePath = dir
+			return nil
+		},
+	}
+
+	s, err := newService(testSandboxID)
+	assert.NoError(err)
+	s.sandbox = sandbox
+
+	s.containers[testSandboxID], err = newContainer(s, &taskAPI.CreateTaskRequest{ID: testSandboxID}, vc.PodSandbox, nil, true)
+	assert.NoError(err)
+	s.containers[testContainerID], err = newContainer(s, &taskAPI.CreateTaskRequest{ID: testContainerID}, vc.PodContainer, nil, true)
+	assert.NoError(err)
+
+	// The state of the sandbox must be saved for the destination.
+	body, err := json.Marshal(MigrateRequest{URI: "tcp:0:4444"})
+	assert.NoError(err)
+	rr := httptest.NewRecorder()
+	s.serveMigrate(rr, httptest.NewRequest(http.MethodPost, MigrateUrl, bytes.NewReader(body)))
+	assert.Equal(http.StatusBadRequest, rr.Code)
+	assert.Empty(migratedTo)
+
+	body, err = json.Marshal(MigrateRequest{URI: "tcp:0:4444", StatePath: "/run/migration"})
+	assert.NoError(err)
+	rr = httptest.NewRecorder()
+	s.serveMigrate(rr, httptest.NewRequest(http.MethodPost, MigrateUrl, bytes.NewReader(body)))
+	assert.Equal(http.StatusOK, rr.Code)
+	assert.Equal("tcp:0:4444", migratedTo)
+	assert.Equal("/run/migration", statePath)
+
+	// The containers keep running in the destination sandbox, their exit
+	// is reported on the source.
+	for _, id := range []string{testSandboxID, testContainerID} {
+		c := s.containers[id]
+		assert.Equal(task.StatusStopped, c.status)
+		assert.Equal(uint32(0), <-c.exitCh)
+
+		e := <-s.ec
+		assert.Contains([]string{testSandboxID, testContainerID}, e.id)
+		assert.Empty(e.execid)
+	}
+}
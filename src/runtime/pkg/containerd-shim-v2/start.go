@@ -9,7 +9,6 @@ import (
 	"context"
 	"errors"
 	"fmt"
-	"os"
 
 	"github.com/containerd/containerd/api/types/task"
 	"github.com/kata-containers/kata-containers/src/runtime/pkg/katautils"
@@ -20,7 +19,6 @@ func startContainer(ctx context.Context, s *service, c *container) (retErr error
 	shimLog.WithField("container", c.id).Debug("start container")
 	logF := logrus.Fields{"src": "uruncio", "file": "cs/start.go", "func": "startContainer"}
 	unikernelCreated := false
-	var cmd *Command
 
 	defer func() {
 		if retErr != nil {
@@ -43,37 +41,29 @@ func startContainer(ctx context.Context, s *service, c *container) (retErr error
 
 	shimLog.WithField("container", c.id).Debug("start container")
 
-	// start a container
-	// hopefully we can get the agent.ExecData field
-	execData := s.sandbox.Agent().GetExecData()
-	// logrus.WithFields(logF).Error(execData.BinaryPath)
+	execData := s.sandbox.GetExecData()
 	logData := logrus.Fields{
-		"path":      execData.BinaryPath,
-		"btype":     execData.BinaryType,
-		"ip":        execData.IPAddress,
-		"mask":      execData.Mask,
-		"gw":        execData.Gateway,
-		"tap":       execData.Tap,
 		"ctype":     c.cType,
 		"hpid":      s.hpid,
 		"shimpid":   s.pid,
-		"unikernel": s.config.HypervisorConfig.Unikernel,
+		"unikernel": s.unikernel(),
+	}
+	if execData.Unikernel != nil {
+		logData["path"] = execData.Unikernel.Path
+		logData["btype"] = execData.Unikernel.Type
 	}
 	logrus.WithFields(logF).WithFields(logData).Error("")
 
 	// Check if config has unikernel set to true and binary exists in rootfs
-	binaryType := execData.BinaryType
-	if s.config.HypervisorConfig.Unikernel && binaryType == "" {
+	if s.unikernel() && execData.Unikernel == nil {
 		return errors.New("unikernel not found in rootfs")
 	}
 
 	if c.cType.IsSandbox() {
 		logrus.WithFields(logF).WithField("cType", "sandbox").Error("")
 
-		if s.config.HypervisorConfig.Unikernel {
-			logrus.WithFields(logF).WithField("unikernelHypervisor", s.config.HypervisorConfig.Unikernel).Error("")
-			unikernelFile := s.sandbox.Agent().GetExecData().BinaryPath
-			logrus.WithField("unikernelFile", unikernelFile).WithFields(logF).Error("")
+		if s.unikernel() {
+			logrus.WithFields(logF).WithField("unikernelHypervisor", s.unikernel()).Error("")
 			logrus.WithFields(logF).Error("starting sandbox")
 			s.sandbox.Start(ctx)
 			logrus.WithFields(logF).Error("sandbox started")
@@ -85,8 +75,6 @@ func startContainer(ctx context.Context, s *service, c *container) (retErr error
 			}
 			shimLog.WithFields(logF).Error("container started")
 
-			shimLog.WithFields(logF).WithField("ip", s.sandbox.Agent().GetExecData().IPAddress).Error("net info")
-
 			unikernelCreated = true
 		} else {
 
@@ -109,9 +97,8 @@ func startContainer(ctx context.Context, s *service, c *container) (retErr error
 		}
 	} else {
 
-		if s.config.HypervisorConfig.Unikernel {
-			unikernelFile := s.sandbox.Agent().GetExecData().BinaryPath
-			shimLog.WithField("unikernelFile", unikernelFile).WithFields(logF).Error("is unikernel and is not sandbox")
+		if s.unikernel() {
+			shimLog.WithFields(logF).Error("is unikernel and is not sandbox")
 			shimLog.WithFields(logF).Error("starting container")
 
 			_, err := s.sandbox.StartContainer(ctx, c.id+"-unikernel")
@@ -120,8 +107,6 @@ func startContainer(ctx context.Context, s *service, c *container) (retErr error
 			}
 			shimLog.WithFields(logF).Error("container started")
 
-			shimLog.WithFields(logF).WithField("ip", s.sandbox.Agent().GetExecData().IPAddress).Error("net info")
-
 			unikernelCreated = true
 		} else {
 
@@ -133,12 +118,31 @@ func startContainer(ctx context.Context, s *service, c *container) (retErr error
 		}
 	}
 
-	// Run post-start OCI hooks.
-	shimLog.WithFields(logF).Error("post-start OCI hook")
 	netNs := s.sandbox.GetNetNs()
 	logrus.WithFields(logF).WithField("netNs", netNs).Error("")
 
-	err := katautils.EnterNetNS(s.sandbox.GetNetNs(), func() error {
+	if unikernelCreated {
+		// The monitor is the user-specified program of unikernel containers,
+		// the startContainer hooks run right before it is executed.
+		err := katautils.EnterNetNS(netNs, func() error {
+			return katautils.StartContainerHooks(ctx, *c.spec, c.id, c.bundle)
+		})
+		if err != nil {
+			return err
+		}
+
+		shimLog.WithFields(logF).Error("ready to start unikernel")
+
+		// The agent state is updated by StartContainer.
+		if err := startUnikernel(ctx, s, c, s.sandbox.GetExecData()); err != nil {
+			return err
+		}
+	}
+
+	// Run post-start OCI hooks.
+	shimLog.WithFields(logF).Error("post-start OCI hook")
+
+	err := katautils.EnterNetNS(netNs, func() error {
 		return katautils.PostStartHooks(ctx, *c.spec, s.sandbox.ID(), c.bundle)
 	})
 	if err != nil {
@@ -147,45 +151,7 @@ func startContainer(ctx context.Context, s *service, c *container) (retErr error
 		shimLog.WithError(err).Warn("Failed to run post-start hooks")
 	}
 
-	if unikernelCreated {
-		shimLog.WithFields(logF).Error("ready to start unikernel")
-
-		cmd = CreateCommand(s.sandbox.Agent().GetExecData(), c)
-
-		shimLog.WithField("unikPath", cmd.cmdString).WithFields(logF).Error("letsgo")
-		err := cmd.SetIO(ctx)
-		if err != nil {
-			return err
-		}
-		os.Setenv("XILINX_XRT", "/opt/xilinx/xrt")
-		os.Setenv("PATH", "/opt/xilinx/xrt/bin:/opt/xilinx/xrt/bin:/opt/xilinx/xrt/bin:/opt/xilinx/xrt/bin:/home/zirong/.local/bin:/home/zirong/.pyenv/plugins/pyenv-virtualenv/shims:/home/zirong/.pyenv/shims:/home/zirong/.nix-profile/bin:/nix/var/nix/profiles/default/bin:/home/zirong/.local/bin:/home/zirong/.pyenv/plugins/pyenv-virtualenv/shims:/home/zirong/.pyenv/bin:/home/zirong/.nix-profile/bin:/nix/var/nix/profiles/default/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin:/usr/games:/usr/local/games:/snap/bin:/home/zirong/funkyos//bin:/home/zirong/funkyos//bin:/usr/local/go/bin:/home/zirong/go/bin")
-		os.Setenv("LD_LIBRARY_PATH", "/opt/xilinx/xrt/lib:/opt/xilinx/xrt/lib:/opt/xilinx/xrt/lib:/opt/xilinx/xrt/lib:")
-		os.Setenv("PYTHONPATH", "/opt/xilinx/xrt/python:/opt/xilinx/xrt/python:/opt/xilinx/xrt/python:/opt/xilinx/xrt/python:")
-		
-		err = cmd.Start()
-		if err != nil {
-			return err
-		}
-		
-		shimLog.WithField("unikPath", cmd.cmdString).WithFields(logF).Error("waitcmd")
-		go cmd.Wait()
-
-		// cmd run will connect the pipes or return them
-		// we will also need a goroutine to Wait for the command
-		// to run in order to notify the container's channels
-		// and terminate gracefully
-		// err = cmd.Wait()
-		// if err != nil {
-		// 	return err
-		//}
-		// ananos' diff
-		// go wait(ctx, s, c, "")
-		// return nil
-
-		// } else if s.sandbox.Agent().GetExecData().BinaryType != "pause" {
-		//return nil
-
-	} else {
+	if !unikernelCreated {
 		c.status = task.StatusRunning
 		shimLog.WithField("c.status", c.status).WithFields(logF).Error("cs/start.go/startContainer")
 
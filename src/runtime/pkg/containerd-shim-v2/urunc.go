@@ -1,302 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
 package containerdshim
 
 import (
 	"context"
-	"encoding/json"
-	"path/filepath"
-	"io"
-	"io/ioutil"
-	"net"
-	osexec "os/exec"
-	"strings"
-	"time"
+	"errors"
+
 	"github.com/containerd/containerd/api/types/task"
-	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/katautils"
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/urunc"
+	vc "github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
+	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
 )
 
-// {"cmdline":"redis-server","net":{"if":"ukvmif0","cloner":"True","type":"inet","method":"static","addr":"10.10.10.2","mask":"16"}}
-
-type HvtArgsNetwork struct {
-	If     string `json:"if"`
-	Cloner string `json:"cloner"`
-	Type   string `json:"type"`
-	Method string `json:"method"`
-	Addr   string `json:"addr"`
-	Mask   string `json:"mask"`
-	Gw     string `json:"gw"`
-}
-
-type HvtArgsBlock struct {
-	Source string `json:"source"`
-	Path   string `json:"path"`
-	Fstype string `json:"fstype"`
-	Mount  string `json:"mountpoint"`
-}
-
-type HvtArgs struct {
-	Cmdline string         `json:"cmdline"`
-	Net     HvtArgsNetwork `json:"net"`
-	Blk     HvtArgsBlock   `json:"blk,omitempty"`
-	Env     []string       `json:"env,omitempty"`
-	Cwd     string         `json:"cwd,omitempty"`
-	Mem     string         `json:"mem,omitempty"`
-}
-
-type Command struct {
-	cmdString string
-	container *container
-	id        string
-	stdin     string
-	stdout    string
-	stderr    string
-	bundle    string
-	exec      *osexec.Cmd
-}
-
-func CmdLine(execData virtcontainers.ExecData) string {
-
-	// BinaryType string
-	// BinaryPath string
-	// IPAddress  string
-	// Mask       string
-	// Tap        string
-	// Container  *Container
-	logF := logrus.Fields{"src": "uruncio", "file": "cs/urunc.go", "func": "CmdLine"}
-	shimLog.WithField("BinaryType", execData.BinaryType).WithFields(logF).Error("ExecData")
-	shimLog.WithField("BinaryPath", execData.BinaryPath).WithFields(logF).Error("ExecData")
-	shimLog.WithField("IPAddress", execData.IPAddress).WithFields(logF).Error("ExecData")
-	shimLog.WithField("Mask", execData.Mask).WithFields(logF).Error("ExecData")
-	shimLog.WithField("Tap", execData.Tap).WithFields(logF).Error("ExecData")
-	shimLog.WithField("Container", execData.Container.ID()).WithFields(logF).Error("ExecData")
+// The monitors running hvt and qemu unikernels, overridden by the tests.
+var (
+	hvtPath  = urunc.DefaultHvtPath
+	qemuPath = urunc.DefaultQemuPath
+)
 
-	switch execData.BinaryType {
-	case "pause":
-		return execData.BinaryPath
-	case "hvt":
-		return HvtCmd(execData)
-	case "qemu":
-		return QemuCmd(execData)
-	case "binary":
-		return execData.BinaryPath
-	default:
-		return ""
-	}
+// unikernel reports whether the containers of s are unikernels run by a
+// monitor on the host.
+func (s *service) unikernel() bool {
+	return s.config != nil && s.config.HypervisorConfig.Unikernel
 }
 
-func HvtCmd(execData virtcontainers.ExecData) string {
-	logF := logrus.Fields{"src": "uruncio", "file": "cs/urunc.go", "func": "HvtCmd"}
-	logrus.WithFields(logF).Error("")
-	ifaces, _ := net.Interfaces()
-	execData.Tap = ifaces[len(ifaces)-1].Name
-	execData.Tap = "tap100"
-	nsParts := strings.Split(execData.NetNs, "/")
-	ns := nsParts[len(nsParts)-1]
-	logrus.WithFields(logF).WithField("NetNs1", execData.NetNs).Error("")
-	logrus.WithFields(logF).WithField("NetNs2", ns).Error("")
-
-	HvtMonitor := "/opt/kata/bin/solo5-hvt"
-	// ./tenders/hvt/solo5-hvt --net:service0=tap192  tests/test_net/test_net.hvt
-	// return HvtMonitor + "--net:service0=" + execData.Tap + " " + execData.BinaryPath
-	// /solo5-hvt --net=tap100 -- redis.hvt '{"cmdline":"redis-server","net":{"if":"ukvmif0","cloner":"True","type":"inet","method":"static","addr":"10.10.10.2","mask":"16"}}'
-
-	hvtNet := HvtArgsNetwork{
-		If:     "ukvmif0",
-		Cloner: "True",
-		Type:   "inet",
-		Method: "static",
-		Addr:   execData.IPAddress,
-		Mask:   "0",
-		Gw:   execData.Gateway,
+// newUnikernelProcess returns the monitor running the unikernel described
+// by execData. The process security settings of spec apply to the monitor,
+// it being the only host-visible process of the container.
+func newUnikernelProcess(execData vc.ExecData, spec *specs.Spec, disableSeccomp bool) (*urunc.Process, error) {
+	if execData.Unikernel == nil {
+		return nil, errors.New("unikernel not found in rootfs")
 	}
 
-	hvtBlock := HvtArgsBlock{
-		Source:	"etfs",
-		Path:	"/dev/ld0a",
-		Fstype:	"blk",
-		Mount:	"/data",
+	config := &urunc.Config{
+		HvtPath:     hvtPath,
+		QemuPath:    qemuPath,
+		NetNs:       execData.NetNs,
+		BlockDevice: execData.BlkDevice,
+		Network:     execData.Network,
+		Security:    urunc.SecurityFromSpec(spec),
 	}
-
-	hvtArgs := HvtArgs{
-		Cmdline: filepath.Base(execData.BinaryPath),//"redis-server",
-		Net:     hvtNet,
-		Blk:     hvtBlock,
-	}
-
-	b, _ := json.Marshal(hvtArgs)
-	cmdString := ""
-	nsString := ""
-	if ns != "" {
-		nsString = "ip netns exec " + ns + " "
-	} else {
-		nsString = ""
+	if config.Security.Seccomp != nil && !disableSeccomp && !urunc.SeccompSupported() {
+		shimLog.Warn("built without seccomp support, not applying the container seccomp profile to the monitor")
+		disableSeccomp = true
 	}
-	if execData.BlkDevice != "" {
-		cmdString = nsString + HvtMonitor + " --net=" + execData.Tap + " --disk=" + execData.BlkDevice + " " + execData.BinaryPath + " " + "test"//string(b)
-	} else {
-		cmdString = nsString + HvtMonitor + " --net=" + execData.Tap + " " + execData.BinaryPath + " " + string(b)
-	}
-
-	// stripped := strings.Replace(cmdString, "\\", "", -1)
-	// unquoted := fmt.Sprintln(stripped)
-	// unqoted, err := strconv.Unquote(stripped)
-	// if err != nil {
-	// 	logrus.WithFields(logF).WithField("err", err.Error()).Error("")
-	// }
-	logrus.WithFields(logF).WithField("cmdline", string(b)).Error("")
-	logrus.WithFields(logF).WithField("cmd", cmdString).Error("")
-	// cmdParts := strings.Split(stripped, " ")
-
-	// name, args := cmdParts[0], cmdParts[1:]
-	// output, _ := osexec.Command(name, args...).CombinedOutput()
-	// logrus.WithFields(logF).WithField("out", string(output)).Error("")
-
-	logrus.WithFields(logF).WithField("ifaces", len(ifaces)).Error("")
-	for _, iface := range ifaces {
-		logrus.WithFields(logF).WithField("name", iface.Name).Error("")
+	if disableSeccomp {
+		config.Security.Seccomp = nil
 	}
 
-	// output, _ := osexec.Command(name, args...).CombinedOutput()
-	// logrus.WithFields(logF).WithField("out", string(output)).Error("")\
-	// na doume kai to GW mhpws xreiazetai!
-
-	return cmdString
-
-}
-
-func QemuCmd(execData virtcontainers.ExecData) string {
-	qemuCmd := "qemu-system-x86_64 -cpu host"
-	qemuCmd += " -enable-kvm"
-	qemuCmd += " -m 128"
-	qemuCmd += " -nodefaults -no-acpi "
-	qemuCmd += " -display none -serial stdio "
-	qemuCmd += " -device isa-debug-exit "
-	qemuCmd += " -net nic,model=virtio "
-	qemuCmd += " -net tap,script=no,ifname=" + execData.Tap
-	qemuCmd += " -kernel " + execData.BinaryPath
-	qemuCmd += " -append \"netdev.ipv4_addr=" + execData.IPAddress + "netdev.ipv4_gw_addr=" + execData.Gateway + " netdev.ipv4_subnet_mask=255.255.255.255 --"
-
-	// qemu-system-x86_64 \
-	//     -cpu host \
-	//     -enable-kvm \
-	//     -m 128 \
-	//     -nodefaults -no-acpi \
-	//     -display none -serial stdio \
-	//     -device isa-debug-exit \
-	//     -net nic,model=virtio \
-	//     -net tap,script=no,ifname=tap106 \
-	//     -kernel /app-helloworld_kvm-x86_64 \
-	//     -append "netdev.ipv4_addr=$IP netdev.ipv4_gw_addr=169.254.1.1 netdev.ipv4_subnet_mask=255.255.255.255 --"
-	return qemuCmd
-
+	return urunc.NewProcess(config, execData.Unikernel)
 }
 
-func CreateCommand(execData virtcontainers.ExecData, container *container) *Command {
-	logF := logrus.Fields{"src": "uruncio", "file": "cs/urunc.go", "func": "CreateCommand"}
-	cmdString := CmdLine(execData)
-	files, _ := ioutil.ReadDir("/run/containerd/io.containerd.runtime.v2.task/default/"+ container.id +"/rootfs")
-	for _, file := range files {
-		if !file.IsDir(){
-			if strings.HasSuffix(file.Name(),".xclbin"){
-			    cmdString = cmdString+" "+file.Name()
-			 } else {
-			    continue
-		    }
+// runUnikernelCreateHooks runs the creation hooks katautils leaves to the
+// caller: createRuntime for pod containers, the sandbox one having run it in
+// CreateSandbox, and createContainer, in the container network namespace.
+func runUnikernelCreateHooks(ctx context.Context, s *service, containerType vc.ContainerType, spec *specs.Spec, id, bundle string) error {
+	if containerType == vc.PodContainer {
+		if err := katautils.CreateRuntimeHooks(ctx, *spec, id, bundle); err != nil {
+			return err
 		}
 	}
-	shimLog.WithField("BinaryType", execData.BinaryType).WithFields(logF).Error("exec info")
-	shimLog.WithField("cmdString", cmdString).WithFields(logF).Error("exec info")
 
-	args := strings.Split(cmdString, " ")
-	var newCmd *osexec.Cmd
-	if len(args) == 1 {
-		shimLog.WithField("Stringlen", len(args)).WithField("cmdString", args[0]).WithFields(logF).Error("exec info")
-		newCmd = osexec.Command(args[0])
-	} else {
-		name, args := args[0], args[1:]
-		newCmd = osexec.Command(name, args...)
-		newCmd.Env = append(newCmd.Environ(), "XILINX_XRT=/opt/xilinx/xrt")
-		newCmd.Env = append(newCmd.Environ(), "PATH=/opt/xilinx/xrt/bin:/opt/xilinx/xrt/bin:/opt/xilinx/xrt/bin:/opt/xilinx/xrt/bin:/opt/xilinx/xrt/bin:/opt/xilinx/xrt/bin:/opt/xilinx/xrt/bin:/home/zirong/.local/bin:/home/zirong/.pyenv/plugins/pyenv-virtualenv/shims:/home/zirong/.pyenv/shims:/home/zirong/.nix-profile/bin:/nix/var/nix/profiles/default/bin:/home/zirong/.local/bin:/home/zirong/.pyenv/plugins/pyenv-virtualenv/shims:/home/zirong/.pyenv/bin:/home/zirong/.nix-profile/bin:/nix/var/nix/profiles/default/bin:/usr/local/sbin:/usr/local/bin:/usr/sbin:/usr/bin:/sbin:/bin:/usr/games:/usr/local/games:/snap/bin:/home/zirong/funkyos//bin:/home/zirong/funkyos//bin:/usr/local/go/bin:/home/zirong/go/bin")
-		newCmd.Env = append(newCmd.Environ(), "LD_LIBRARY_PATH=/opt/xilinx/xrt/lib:/opt/xilinx/xrt/lib:/opt/xilinx/xrt/lib:/opt/xilinx/xrt/lib:/opt/xilinx/xrt/lib:/opt/xilinx/xrt/lib:/opt/xilinx/xrt/lib:")
-		newCmd.Env = append(newCmd.Environ(), "PYTHONPATH=/opt/xilinx/xrt/python:/opt/xilinx/xrt/python:/opt/xilinx/xrt/python:/opt/xilinx/xrt/python:/opt/xilinx/xrt/python:/opt/xilinx/xrt/python:/opt/xilinx/xrt/python:")
-	}
-	return &Command{cmdString: cmdString, container: container, id: container.id, stdin: container.stdin, stdout: container.stdout, stderr: container.stderr, bundle: container.bundle, exec: newCmd}
-}
-
-func (c *Command) ioPipes() (io.WriteCloser, io.ReadCloser, io.ReadCloser, error) {
-	stdin, err := c.exec.StdinPipe()
-	if err != nil {
-		return nil, nil, nil, err
-	}
-
-	stdout, err := c.exec.StdoutPipe()
-	if err != nil {
-		return nil, nil, nil, err
-	}
-
-	stderr, err := c.exec.StderrPipe()
-	if err != nil {
-		return nil, nil, nil, err
-	}
-	return stdin, stdout, stderr, nil
+	return katautils.EnterNetNS(s.sandbox.GetNetNs(), func() error {
+		return katautils.CreateContainerHooks(ctx, *spec, id, bundle)
+	})
 }
 
-func (c *Command) SetIO(ctx context.Context) error {
-	logF := logrus.Fields{"src": "uruncio", "file": "cs/urunc.go", "func": "SetIO"}
-	shimLog.WithFields(logF).WithField("path", c.exec.Path).Error("stdout, stderr redirected")
-
-	stdin, stdout, stderr, err := c.ioPipes()
-	shimLog.WithFields(logF).Error("ioPipes retrieved")
+// startUnikernel starts the monitor running the unikernel of c, its io
+// being connected to the container one. The monitor is reaped by wait().
+func startUnikernel(ctx context.Context, s *service, c *container, execData vc.ExecData) error {
+	logF := logrus.Fields{"src": "uruncio", "file": "cs/urunc.go", "func": "startUnikernel"}
 
+	p, err := newUnikernelProcess(execData, c.spec, s.config.HypervisorConfig.DisableSeccomp)
 	if err != nil {
-		shimLog.WithFields(logF).WithField("err", err.Error()).Error("ioPipes retrieved")
-
 		return err
 	}
+	shimLog.WithFields(logF).WithField("args", p.Args()).Error("starting unikernel")
 
-	c.container.stdinPipe = stdin
-	shimLog.WithFields(logF).Error("container stdin redirected")
-
-	if c.container.stdin != "" || c.container.stdout != "" || c.container.stderr != "" {
-		tty, err := newTtyIO(ctx, c.stdin, c.stdout, c.stderr, c.container.terminal)
+	hasIO := c.stdin != "" || c.stdout != "" || c.stderr != ""
+	if hasIO {
+		stdin, stdout, stderr, err := p.StdioPipes()
 		if err != nil {
 			return err
 		}
-		c.container.ttyio = tty
-		shimLog.WithFields(logF).Error("container ttyio set")
+		c.stdinPipe = stdin
 
-		go ioCopy(shimLog.WithField("container", c.id), c.container.exitIOch, c.container.stdinCloser, tty, stdin, stdout, stderr)
-	}
-	return nil
-}
-func (c *Command) Start() error {
-	logF := logrus.Fields{"src": "uruncio", "file": "cs/urunc.go", "func": "Start"}
-
-	err := c.exec.Start()
-	shimLog.WithFields(logF).WithField("path", c.exec.Path).Error("CMD STARTED")
-	c.container.status = task.StatusRunning
-	return err
-}
-
-func (c *Command) Wait() error {
-	logF := logrus.Fields{"src": "uruncio", "file": "cs/urunc.go", "func": "Wait"}
-
-	shimLog.WithFields(logF).Error("Wait Start")
-	time.Sleep(500 * time.Millisecond)
+		tty, err := newTtyIO(ctx, c.stdin, c.stdout, c.stderr, c.terminal)
+		if err != nil {
+			return err
+		}
+		c.ttyio = tty
 
-	c.exec.Wait()
-	shimLog.WithFields(logF).Error("exec returned")
+		if err := p.Start(); err != nil {
+			tty.close()
+			return err
+		}
 
-	shimLog.WithFields(logF).Error("cmd completed")
+		go ioCopy(shimLog.WithField("container", c.id), c.exitIOch, c.stdinCloser, tty, stdin, stdout, stderr)
+	} else {
+		if err := p.Start(); err != nil {
+			return err
+		}
 
-	shimLog.WithFields(logF).Error("exitIOch close start")
-	close(c.container.exitIOch)
-	shimLog.WithFields(logF).Error("exitIOch closed")
+		// close the io exit channel, since there is no io for this container,
+		// otherwise the following wait goroutine will hang on this channel.
+		close(c.exitIOch)
+		// close the stdin closer channel to notify that it's safe to close process's
+		// io.
+		close(c.stdinCloser)
+	}
 
-	close(c.container.stdinCloser)
-	shimLog.WithFields(logF).Error("stdinCloser closed")
+	c.unikernel = p
+	c.status = task.StatusRunning
+	shimLog.WithFields(logF).WithField("pid", p.Pid()).Error("unikernel started")
 
-	c.container.status = task.StatusStopped
-	shimLog.WithFields(logF).Error("container.status: StatusStopped")
 	return nil
 }
@@ -0,0 +1,177 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package containerdshim
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/containerd/containerd/api/types/task"
+	"github.com/containerd/containerd/namespaces"
+	taskAPI "github.com/containerd/containerd/runtime/v2/task"
+	"github.com/stretchr/testify/assert"
+
+	ktu "github.com/kata-containers/kata-containers/src/runtime/pkg/katatestutils"
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/urunc"
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/urunc/urunctest"
+	vc "github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/compatoci"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/vcmock"
+)
+
+// newUnikernelService returns a service whose mock sandbox runs the
+// unikernel of a typ fixture bundle with the mock monitor, along with the
+// bundle and the file the monitor records its execution to.
+func newUnikernelService(t *testing.T, typ string) (s *service, bundle, record string) {
+	assert := assert.New(t)
+
+	monitor := urunctest.Monitor(t)
+	savedHvt, savedQemu := hvtPath, qemuPath
+	hvtPath, qemuPath = monitor, monitor
+	t.Cleanup(func() {
+		hvtPath, qemuPath = savedHvt, savedQemu
+	})
+
+	record = filepath.Join(t.TempDir(), "record")
+	t.Setenv(urunctest.RecordEnv, record)
+
+	bundle = urunctest.Bundle(t, typ)
+	spec, err := compatoci.ParseConfigJSON(bundle)
+	assert.NoError(err)
+	spec.Annotations[testContainerTypeAnnotation] = testContainerTypeContainer
+	spec.Annotations[testSandboxIDAnnotation] = testSandboxID
+	assert.NoError(ktu.WriteOCIConfigFile(spec, filepath.Join(bundle, "config.json")))
+
+	u, err := urunc.Inspect(filepath.Join(bundle, "rootfs"))
+	assert.NoError(err)
+
+	sandbox := &vcmock.Sandbox{
+		MockID: testSandboxID,
+		MockExecData: vc.ExecData{
+			Unikernel: u,
+			Network:   urunc.Network{Tap: "tap0"},
+		},
+		CreateContainerFunc: func(containerConfig vc.ContainerConfig) (vc.VCContainer, error) {
+			return &vcmock.Container{}, nil
+		},
+	}
+
+	runtimeConfig, err := newTestRuntimeConfig(t.TempDir(), testConsole, true)
+	assert.NoError(err)
+	runtimeConfig.HypervisorType = vc.UruncHypervisor
+	runtimeConfig.HypervisorConfig.Unikernel = true
+
+	s = &service{
+		id:         testContainerID,
+		sandbox:    sandbox,
+		containers: make(map[string]*container),
+		config:     &runtimeConfig,
+		ctx:        namespaces.WithNamespace(context.Background(), "UnitTest"),
+		rootCtx:    context.Background(),
+		ec:         make(chan exit, bufferSize),
+	}
+
+	return s, bundle, record
+}
+
+func TestUnikernelKill(t *testing.T) {
+	for _, typ := range urunctest.Types {
+		t.Run(typ, func(t *testing.T) {
+			assert := assert.New(t)
+
+			s, bundle, record := newUnikernelService(t, typ)
+			ctx := s.ctx
+
+			_, err := s.Create(ctx, &taskAPI.CreateTaskRequest{
+				ID:     testContainerID,
+				Bundle: bundle,
+			})
+			assert.NoError(err)
+
+			_, err = s.Start(ctx, &taskAPI.StartRequest{ID: testContainerID})
+			assert.NoError(err)
+
+			r, err := urunctest.ReadRecord(record, 5*time.Second)
+			assert.NoError(err)
+			c := s.containers[testContainerID]
+			assert.Equal(c.unikernel.Args(), r.Args)
+			assert.Equal(filepath.Join(bundle, "rootfs"), r.Dir)
+
+			netns, err := os.Readlink("/proc/self/ns/net")
+			assert.NoError(err)
+			assert.Equal(netns, r.NetNs)
+
+			_, err = s.Kill(ctx, &taskAPI.KillRequest{
+				ID:     testContainerID,
+				Signal: uint32(syscall.SIGKILL),
+			})
+			assert.NoError(err)
+
+			resp, err := s.Wait(ctx, &taskAPI.WaitRequest{ID: testContainerID})
+			assert.NoError(err)
+			assert.Equal(uint32(128+syscall.SIGKILL), resp.ExitStatus)
+			assert.Equal(task.StatusStopped, c.status)
+
+			// The monitor is gone, killing the container again is a no-op.
+			_, err = s.Kill(ctx, &taskAPI.KillRequest{
+				ID:     testContainerID,
+				Signal: uint32(syscall.SIGKILL),
+			})
+			assert.NoError(err)
+
+			_, err = s.Delete(ctx, &taskAPI.DeleteRequest{ID: testContainerID})
+			assert.NoError(err)
+			assert.NotContains(s.containers, testContainerID)
+		})
+	}
+}
+
+func TestUnikernelExit(t *testing.T) {
+	assert := assert.New(t)
+
+	s, bundle, _ := newUnikernelService(t, "hvt")
+	t.Setenv(urunctest.ExitEnv, "3")
+	ctx := s.ctx
+
+	_, err := s.Create(ctx, &taskAPI.CreateTaskRequest{
+		ID:     testContainerID,
+		Bundle: bundle,
+	})
+	assert.NoError(err)
+
+	_, err = s.Start(ctx, &taskAPI.StartRequest{ID: testContainerID})
+	assert.NoError(err)
+
+	resp, err := s.Wait(ctx, &taskAPI.WaitRequest{ID: testContainerID})
+	assert.NoError(err)
+	assert.Equal(uint32(3), resp.ExitStatus)
+
+	_, err = s.Delete(ctx, &taskAPI.DeleteRequest{ID: testContainerID})
+	assert.NoError(err)
+}
+
+func TestUnikernelNotFound(t *testing.T) {
+	assert := assert.New(t)
+
+	s, bundle, _ := newUnikernelService(t, "hvt")
+	s.sandbox.(*vcmock.Sandbox).MockExecData = vc.ExecData{}
+	ctx := s.ctx
+
+	_, err := s.Create(ctx, &taskAPI.CreateTaskRequest{
+		ID:     testContainerID,
+		Bundle: bundle,
+	})
+	assert.NoError(err)
+
+	_, err = s.Start(ctx, &taskAPI.StartRequest{ID: testContainerID})
+	assert.Error(err)
+
+	resp, err := s.Wait(ctx, &taskAPI.WaitRequest{ID: testContainerID})
+	assert.NoError(err)
+	assert.Equal(uint32(exitCode255), resp.ExitStatus)
+}
@@ -55,7 +55,15 @@ func wait(ctx context.Context, s *service, c *container, execID string) (int32,
 		processID = execs.id
 	}
 
-	ret, err := s.sandbox.WaitProcess(ctx, c.id, processID)
+	var ret int32
+	if execID == "" && c.unikernel != nil {
+		// The monitor runs on the host, the agent knows nothing about it.
+		var code int
+		code, err = c.unikernel.Wait()
+		ret = int32(code)
+	} else {
+		ret, err = s.sandbox.WaitProcess(ctx, c.id, processID)
+	}
 	if err != nil {
 		shimLog.WithError(err).WithFields(logrus.Fields{
 			"container": c.id,
@@ -66,6 +74,13 @@ func wait(ctx context.Context, s *service, c *container, execID string) (int32,
 	timeStamp := time.Now()
 
 	s.mu.Lock()
+	// The exit of the processes of a migrated sandbox is reported by
+	// exitMigrated.
+	if (execID == "" && c.status == task.StatusStopped) || (execID != "" && execs.status == task.StatusStopped) {
+		s.mu.Unlock()
+		return ret, nil
+	}
+
 	if execID == "" {
 		// Take care of the use case where it is a sandbox.
 		// Right after the container representing the sandbox has
@@ -115,6 +130,44 @@ func wait(ctx context.Context, s *service, c *container, execID string) (int32,
 	return ret, nil
 }
 
+// exitMigrated reports the exit of the containers and execs of a sandbox
+// migrated away: they keep running in the destination sandbox, the source
+// one is deleted. It must be called with s.mu held.
+func exitMigrated(ctx context.Context, s *service) {
+	timeStamp := time.Now()
+
+	// cancel watcher
+	if s.monitor != nil {
+		s.monitor <- nil
+	}
+
+	if err := s.sandbox.Delete(ctx); err != nil {
+		shimLog.WithError(err).WithField("sandbox", s.sandbox.ID()).Warn("failed to delete the migrated sandbox")
+	}
+
+	for _, c := range s.containers {
+		for execID, execs := range c.execs {
+			if execs.status == task.StatusStopped {
+				continue
+			}
+			execs.status = task.StatusStopped
+			execs.exitTime = timeStamp
+			execs.exitCh <- 0
+			go cReap(s, 0, c.id, execID, timeStamp)
+		}
+
+		if c.status == task.StatusStopped {
+			continue
+		}
+		c.status = task.StatusStopped
+		c.exitTime = timeStamp
+		c.exitCh <- 0
+		go cReap(s, 0, c.id, "", timeStamp)
+
+		shimLog.WithField("container", c.id).Debug("The migrated container status is StatusStopped")
+	}
+}
+
 func watchSandbox(ctx context.Context, s *service) {
 	if s.monitor == nil {
 		return
@@ -19,13 +19,29 @@ const (
 	mountInfoFileName = "mountInfo.json"
 )
 
+// Types of the direct assigned volumes.
+const (
+	// BlockVolumeType is a host block device, attached to the VM and
+	// mounted with FsType.
+	BlockVolumeType = "block"
+
+	// VhostUserBlkVolumeType is the socket of a vhost-user-blk backend,
+	// attached to the VM and mounted with FsType.
+	VhostUserBlkVolumeType = "vhost-user-blk"
+
+	// DirectoryVolumeType is a host directory, e.g. an NFS mount, passed
+	// through the shared file system of the VM.
+	DirectoryVolumeType = "directory"
+)
+
 var kataDirectVolumeRootPath = "/run/kata-containers/shared/direct-volumes"
 
 // MountInfo contains the information needed by Kata to consume a host block device and mount it as a filesystem inside the guest VM.
 type MountInfo struct {
-	// The type of the volume (ie. block)
+	// The type of the volume (ie. block, vhost-user-blk or directory)
 	VolumeType string `json:"volume-type"`
-	// The device backing the volume.
+	// The device backing the volume: the block device, the vhost-user
+	// socket or the directory path.
 	Device string `json:"device"`
 	// The filesystem type to be mounted on the volume.
 	FsType string `json:"fstype"`
@@ -35,6 +51,33 @@ type MountInfo struct {
 	Options []string `json:"options,omitempty"`
 }
 
+// ReadOnly tells whether the volume must be mounted read-only.
+func (m *MountInfo) ReadOnly() bool {
+	for _, option := range m.Options {
+		if option == "ro" {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *MountInfo) validate() error {
+	switch m.VolumeType {
+	case "", BlockVolumeType, VhostUserBlkVolumeType, DirectoryVolumeType:
+	default:
+		return fmt.Errorf("unsupported volume type %q", m.VolumeType)
+	}
+
+	if m.VolumeType == VhostUserBlkVolumeType && m.Device == "" {
+		return fmt.Errorf("%s volume requires the vhost-user socket as device", VhostUserBlkVolumeType)
+	}
+	if m.VolumeType == DirectoryVolumeType && !filepath.IsAbs(m.Device) {
+		return fmt.Errorf("%s volume requires an absolute directory path as device", DirectoryVolumeType)
+	}
+
+	return nil
+}
+
 // Add writes the mount info of a direct volume into a filesystem path known to Kata Container.
 func Add(volumePath string, mountInfo string) error {
 	volumeDir := filepath.Join(kataDirectVolumeRootPath, volumePath)
@@ -54,6 +97,9 @@ func Add(volumePath string, mountInfo string) error {
 	if err := json.Unmarshal([]byte(mountInfo), &deserialized); err != nil {
 		return err
 	}
+	if err := deserialized.validate(); err != nil {
+		return err
+	}
 
 	return ioutil.WriteFile(filepath.Join(volumeDir, mountInfoFileName), []byte(mountInfo), 0600)
 }
@@ -92,6 +138,7 @@ func RecordSandboxId(sandboxId string, volumePath string) error {
 	return ioutil.WriteFile(filepath.Join(kataDirectVolumeRootPath, volumePath, sandboxId), []byte(""), 0600)
 }
 
+// GetSandboxIdForVolume returns the id of the sandbox using a direct volume.
 func GetSandboxIdForVolume(volumePath string) (string, error) {
 	files, err := ioutil.ReadDir(filepath.Join(kataDirectVolumeRootPath, volumePath))
 	if err != nil {
@@ -86,3 +86,34 @@ func TestRecordSandboxIdNoMountInfoFile(t *testing.T) {
 	assert.Error(t, err)
 	assert.True(t, errors.Is(err, os.ErrNotExist))
 }
+
+func TestAddVolumeTypes(t *testing.T) {
+	kataDirectVolumeRootPath = t.TempDir()
+	var volumePath = "/a/b/c"
+
+	for _, tc := range []struct {
+		mntInfo MountInfo
+		valid   bool
+	}{
+		{MountInfo{Device: "/dev/sda", FsType: "ext4"}, true},
+		{MountInfo{VolumeType: BlockVolumeType, Device: "/dev/sda", FsType: "ext4"}, true},
+		{MountInfo{VolumeType: VhostUserBlkVolumeType, Device: "/run/vhost/blk0.sock", FsType: "ext4"}, true},
+		{MountInfo{VolumeType: VhostUserBlkVolumeType, FsType: "ext4"}, false},
+		{MountInfo{VolumeType: DirectoryVolumeType, Device: "/mnt/nfs/vol0"}, true},
+		{MountInfo{VolumeType: DirectoryVolumeType, Device: "mnt/nfs/vol0"}, false},
+		{MountInfo{VolumeType: "tape", Device: "/dev/st0"}, false},
+	} {
+		buf, err := json.Marshal(tc.mntInfo)
+		assert.Nil(t, err)
+
+		err = Add(volumePath, string(buf))
+		if tc.valid {
+			assert.NoError(t, err, "volume %+v", tc.mntInfo)
+		} else {
+			assert.Error(t, err, "volume %+v", tc.mntInfo)
+		}
+	}
+
+	assert.True(t, (&MountInfo{Options: []string{"noload", "ro"}}).ReadOnly())
+	assert.False(t, (&MountInfo{Options: []string{"rw"}}).ReadOnly())
+}
@@ -0,0 +1,515 @@
+//go:build linux
+// +build linux
+
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package gc finds the sandboxes left on the host by a dead shim and tears
+// them down.
+//
+// The package only decides which sandboxes are orphaned: listing the
+// sandboxes and releasing their resources are left to the caller, so that
+// it does not depend on the runtime.
+package gc
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/moby/sys/mountinfo"
+	"github.com/sirupsen/logrus"
+)
+
+// Reasons for keeping or collecting a sandbox.
+const (
+	// ReasonRecent is a sandbox modified within the grace period, which
+	// may still be being created.
+	ReasonRecent = "recently modified"
+
+	// ReasonShimSocket is a sandbox whose shim answers on its socket.
+	ReasonShimSocket = "shim socket is listening"
+
+	// ReasonShimProcess is a sandbox whose shim process is still running.
+	ReasonShimProcess = "shim process is running"
+
+	// ReasonReady is a sandbox reported as ready by the container manager.
+	ReasonReady = "ready in the container manager"
+
+	// ReasonOrphaned is a sandbox without shim, unknown to the container
+	// manager.
+	ReasonOrphaned = "orphaned"
+)
+
+// shimPrefix is the prefix of the shim binary names
+const shimPrefix = "containerd-shim"
+
+// shimSocket is the name of the monitor socket of the shim, in the sandbox
+// storage directory.
+const shimSocket = "shim-monitor.sock"
+
+// pidFile is the name of the file holding the hypervisor pid, in the VM
+// directory of the sandbox.
+const pidFile = "pid"
+
+// killTimeout bounds the time waiting for the killed processes to exit.
+const killTimeout = 5 * time.Second
+
+var gcLog = logrus.WithField("source", "gc")
+
+// These are variables so that the tests can fake the host.
+var (
+	procRoot             = "/proc"
+	sandboxesStoragePath = "/run/vc/sbs"
+	netNSMounts          = listNetNSMounts
+)
+
+// SetLogger sets the logger for the gc package.
+func SetLogger(logger *logrus.Entry) {
+	fields := gcLog.Data
+	gcLog = logger.WithFields(fields)
+}
+
+// Sandbox describes a sandbox found on the host.
+type Sandbox struct {
+	// ModTime is the last modification time of the sandbox directories.
+	ModTime time.Time
+
+	ID string
+
+	// VMDir is the directory holding the hypervisor pid file and the
+	// sockets served by the hypervisor and virtiofsd.
+	VMDir string
+
+	// Restorable tells whether the sandbox state can be restored.
+	Restorable bool
+}
+
+// Options configures a garbage collection.
+type Options struct {
+	// List returns the sandboxes found on the host.
+	List func() ([]Sandbox, error)
+
+	// Teardown releases the resources of an orphaned sandbox, once its
+	// processes are killed. netNSPath is the network namespace the
+	// processes of the sandbox were running in, empty when unknown.
+	Teardown func(ctx context.Context, sandboxID, netNSPath string) error
+
+	// FilterUnknown returns the sandboxes of sandboxList which are not
+	// ready in the container manager. The container manager is not
+	// checked when nil.
+	FilterUnknown func(sandboxList []string) ([]string, error)
+
+	// Grace is the minimum age of a sandbox to consider it orphaned.
+	Grace time.Duration
+
+	// DryRun only reports the orphaned sandboxes.
+	DryRun bool
+}
+
+// Result is the decision taken for a sandbox found on the host.
+type Result struct {
+	// Err is the error met while tearing down an orphaned sandbox.
+	Err error
+
+	ID     string
+	Reason string
+
+	// NetNS is the network namespace of an orphaned sandbox.
+	NetNS string
+
+	// Pids are the live processes of an orphaned sandbox, which are
+	// killed before it is torn down.
+	Pids []int
+
+	Orphaned   bool
+	Restorable bool
+}
+
+type process struct {
+	cmdline []string
+	pid     int
+}
+
+// refersTo returns whether an argument of the process is a path in dir.
+func (p process) refersTo(dir string) bool {
+	for _, arg := range p.cmdline[1:] {
+		if strings.Contains(arg, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// isShimOf returns whether the process is the shim of the sandbox, which is
+// given the sandbox ID as a separate argument.
+func (p process) isShimOf(sandboxID string) bool {
+	if len(p.cmdline) == 0 || !strings.HasPrefix(filepath.Base(p.cmdline[0]), shimPrefix) {
+		return false
+	}
+
+	for _, arg := range p.cmdline[1:] {
+		if arg == sandboxID {
+			return true
+		}
+	}
+	return false
+}
+
+// Collect cross-checks the sandboxes found on the host against the shim
+// sockets, the live processes and the container manager, and tears down the
+// orphaned ones unless opts.DryRun is set. A sandbox is only collected when
+// all the checks agree it is orphaned: nothing is collected when the
+// container manager cannot be queried.
+func Collect(ctx context.Context, opts Options) ([]Result, error) {
+	if opts.List == nil || opts.Teardown == nil {
+		return nil, fmt.Errorf("missing sandbox list or teardown")
+	}
+
+	sandboxes, err := opts.List()
+	if err != nil {
+		return nil, err
+	}
+
+	processes, err := listProcesses()
+	if err != nil {
+		return nil, err
+	}
+
+	var results []Result
+	var candidates []Sandbox
+	for _, sandbox := range sandboxes {
+		if reason := keepReason(sandbox, processes, opts.Grace); reason != "" {
+			results = append(results, Result{ID: sandbox.ID, Reason: reason, Restorable: sandbox.Restorable})
+			continue
+		}
+		candidates = append(candidates, sandbox)
+	}
+
+	if opts.FilterUnknown != nil && len(candidates) > 0 {
+		var ids []string
+		for _, sandbox := range candidates {
+			ids = append(ids, sandbox.ID)
+		}
+
+		unknown, err := opts.FilterUnknown(ids)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list the sandboxes of the container manager: %w", err)
+		}
+
+		orphaned := make(map[string]bool, len(unknown))
+		for _, id := range unknown {
+			orphaned[id] = true
+		}
+
+		var remaining []Sandbox
+		for _, sandbox := range candidates {
+			if orphaned[sandbox.ID] {
+				remaining = append(remaining, sandbox)
+			} else {
+				results = append(results, Result{ID: sandbox.ID, Reason: ReasonReady, Restorable: sandbox.Restorable})
+			}
+		}
+		candidates = remaining
+	}
+
+	sockets := newSocketTable()
+	for _, sandbox := range candidates {
+		result := Result{
+			ID:         sandbox.ID,
+			Reason:     ReasonOrphaned,
+			Orphaned:   true,
+			Restorable: sandbox.Restorable,
+		}
+		result.Pids = sandboxPids(sandbox, processes, sockets)
+		result.NetNS = netNSPath(result.Pids)
+
+		gcLog.WithFields(logrus.Fields{
+			"sandbox": sandbox.ID,
+			"pids":    result.Pids,
+			"netns":   result.NetNS,
+			"dry-run": opts.DryRun,
+		}).Warn("orphaned sandbox")
+
+		if !opts.DryRun {
+			result.Err = teardown(ctx, opts, sandbox.ID, result.NetNS, result.Pids)
+			if result.Err != nil {
+				gcLog.WithField("sandbox", sandbox.ID).WithError(result.Err).Error("failed to tear down the orphaned sandbox")
+			}
+		}
+
+		results = append(results, result)
+	}
+
+	sort.Slice(results, func(i, j int) bool {
+		return results[i].ID < results[j].ID
+	})
+
+	return results, nil
+}
+
+// keepReason returns why the sandbox must be kept, or an empty string when it
+// may be orphaned.
+func keepReason(sandbox Sandbox, processes []process, grace time.Duration) string {
+	if time.Since(sandbox.ModTime) < grace {
+		return ReasonRecent
+	}
+
+	if shimListening(sandbox.ID) {
+		return ReasonShimSocket
+	}
+
+	for _, p := range processes {
+		if p.isShimOf(sandbox.ID) {
+			return ReasonShimProcess
+		}
+	}
+
+	return ""
+}
+
+func shimListening(sandboxID string) bool {
+	conn, err := net.DialTimeout("unix", filepath.Join(sandboxesStoragePath, sandboxID, shimSocket), time.Second)
+	if err != nil {
+		return false
+	}
+	conn.Close()
+	return true
+}
+
+// listProcesses returns the processes running on the host, except the
+// kernel threads and the current process.
+func listProcesses() ([]process, error) {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		return nil, err
+	}
+
+	var processes []process
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == os.Getpid() {
+			continue
+		}
+
+		data, err := os.ReadFile(filepath.Join(procRoot, entry.Name(), "cmdline"))
+		if err != nil || len(data) == 0 {
+			// Exited in the meantime, or a kernel thread
+			continue
+		}
+
+		processes = append(processes, process{
+			pid:     pid,
+			cmdline: strings.Split(strings.TrimSuffix(string(data), "\x00"), "\x00"),
+		})
+	}
+
+	return processes, nil
+}
+
+// sandboxPids returns the processes of the sandbox: the hypervisor whose pid
+// is saved in the VM directory, and the processes holding a socket bound in
+// the VM directory, such as the hypervisor and virtiofsd.
+//
+// The pid file outlives a hypervisor killed with its shim, and its pid may
+// be reused by an unrelated process: the saved pid is only trusted when its
+// process binds a socket in the VM directory or refers to it in its command
+// line, as the hypervisor does for its pid file and its sockets.
+func sandboxPids(sandbox Sandbox, processes []process, sockets *socketTable) []int {
+	if sandbox.VMDir == "" {
+		return nil
+	}
+
+	savedPid := -1
+	if data, err := os.ReadFile(filepath.Join(sandbox.VMDir, pidFile)); err == nil {
+		if pid, err := strconv.Atoi(strings.TrimSpace(string(data))); err == nil {
+			savedPid = pid
+		}
+	}
+
+	found := make(map[int]bool)
+	for _, p := range processes {
+		if sockets.bindsUnder(p.pid, sandbox.VMDir) || (p.pid == savedPid && p.refersTo(sandbox.VMDir)) {
+			found[p.pid] = true
+		}
+	}
+
+	if savedPid > 0 && !found[savedPid] && processExists(savedPid) {
+		gcLog.WithFields(logrus.Fields{
+			"sandbox": sandbox.ID,
+			"pid":     savedPid,
+		}).Warn("ignoring the stale hypervisor pid file")
+	}
+
+	var pids []int
+	for pid := range found {
+		pids = append(pids, pid)
+	}
+	sort.Ints(pids)
+
+	return pids
+}
+
+// socketTable caches the unix sockets bound in each network namespace, as
+// /proc/net/unix only lists the sockets of the namespace of the process.
+type socketTable struct {
+	// paths maps the network namespaces to the paths of their sockets,
+	// by socket inode.
+	paths map[string]map[string]string
+}
+
+func newSocketTable() *socketTable {
+	return &socketTable{paths: make(map[string]map[string]string)}
+}
+
+// bindsUnder returns whether the process pid holds a unix socket bound to a
+// path in dir.
+func (t *socketTable) bindsUnder(pid int, dir string) bool {
+	pidDir := filepath.Join(procRoot, strconv.Itoa(pid))
+
+	fds, err := os.ReadDir(filepath.Join(pidDir, "fd"))
+	if err != nil {
+		return false
+	}
+
+	var paths map[string]string
+	for _, fd := range fds {
+		target, err := os.Readlink(filepath.Join(pidDir, "fd", fd.Name()))
+		if err != nil || !strings.HasPrefix(target, "socket:[") {
+			continue
+		}
+
+		if paths == nil {
+			if paths = t.sockets(pidDir); paths == nil {
+				return false
+			}
+		}
+
+		inode := strings.TrimSuffix(strings.TrimPrefix(target, "socket:["), "]")
+		if path, ok := paths[inode]; ok && strings.HasPrefix(path, dir+string(filepath.Separator)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// sockets returns the paths of the unix sockets of the network namespace of
+// the process, by socket inode.
+func (t *socketTable) sockets(pidDir string) map[string]string {
+	netNS, err := os.Readlink(filepath.Join(pidDir, "ns", "net"))
+	if err != nil {
+		return nil
+	}
+
+	if paths, ok := t.paths[netNS]; ok {
+		return paths
+	}
+
+	f, err := os.Open(filepath.Join(pidDir, "net", "unix"))
+	if err != nil {
+		return nil
+	}
+	defer f.Close()
+
+	// Num RefCount Protocol Flags Type St Inode Path
+	paths := make(map[string]string)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 8 || !strings.HasPrefix(fields[7], "/") {
+			// The header, or an unbound or abstract socket
+			continue
+		}
+		paths[fields[6]] = fields[7]
+	}
+
+	t.paths[netNS] = paths
+	return paths
+}
+
+// netNSPath returns the mount point of the network namespace of the first
+// process of pids not running in the host namespace, so that the namespace
+// can still be found once the processes are killed.
+func netNSPath(pids []int) string {
+	var host syscall.Stat_t
+	if err := syscall.Stat(filepath.Join(procRoot, "self", "ns", "net"), &host); err != nil {
+		return ""
+	}
+
+	for _, pid := range pids {
+		var st syscall.Stat_t
+		if err := syscall.Stat(filepath.Join(procRoot, strconv.Itoa(pid), "ns", "net"), &st); err != nil {
+			continue
+		}
+		if st.Dev == host.Dev && st.Ino == host.Ino {
+			continue
+		}
+
+		mounts, err := netNSMounts()
+		if err != nil {
+			gcLog.WithError(err).Warn("failed to list the network namespaces")
+			return ""
+		}
+
+		for _, mount := range mounts {
+			var mst syscall.Stat_t
+			if err := syscall.Stat(mount, &mst); err == nil && mst.Dev == st.Dev && mst.Ino == st.Ino {
+				return mount
+			}
+		}
+	}
+
+	return ""
+}
+
+// listNetNSMounts returns the mount points of the network namespaces.
+func listNetNSMounts() ([]string, error) {
+	mounts, err := mountinfo.GetMounts(mountinfo.FSTypeFilter("nsfs"))
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	for _, m := range mounts {
+		paths = append(paths, m.Mountpoint)
+	}
+	return paths, nil
+}
+
+// teardown kills the processes left by the sandbox, then releases the
+// sandbox resources.
+func teardown(ctx context.Context, opts Options, sandboxID, netNSPath string, pids []int) error {
+	for _, pid := range pids {
+		if err := syscall.Kill(pid, syscall.SIGKILL); err != nil && err != syscall.ESRCH {
+			return fmt.Errorf("failed to kill process %d: %w", pid, err)
+		}
+	}
+
+	deadline := time.Now().Add(killTimeout)
+	for _, pid := range pids {
+		for processExists(pid) {
+			if time.Now().After(deadline) {
+				return fmt.Errorf("process %d still running after %v", pid, killTimeout)
+			}
+			time.Sleep(50 * time.Millisecond)
+		}
+	}
+
+	return opts.Teardown(ctx, sandboxID, netNSPath)
+}
+
+func processExists(pid int) bool {
+	_, err := os.Stat(filepath.Join(procRoot, strconv.Itoa(pid)))
+	return err == nil
+}
@@ -0,0 +1,259 @@
+//go:build linux
+// +build linux
+
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package gc
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeHost replaces the host inspected by Collect and returns its root
+// directory. The network namespaces are files in the ns directory, the host
+// one being "host".
+func fakeHost(t *testing.T, processes map[int]string) string {
+	dir := t.TempDir()
+
+	savedProcRoot, savedStorage, savedMounts := procRoot, sandboxesStoragePath, netNSMounts
+	t.Cleanup(func() {
+		procRoot, sandboxesStoragePath, netNSMounts = savedProcRoot, savedStorage, savedMounts
+	})
+
+	procRoot = filepath.Join(dir, "proc")
+	sandboxesStoragePath = filepath.Join(dir, "sbs")
+	netNSMounts = func() ([]string, error) {
+		entries, err := os.ReadDir(filepath.Join(dir, "ns"))
+		if err != nil {
+			return nil, err
+		}
+
+		var mounts []string
+		for _, entry := range entries {
+			mounts = append(mounts, filepath.Join(dir, "ns", entry.Name()))
+		}
+		return mounts, nil
+	}
+
+	for pid, cmdline := range processes {
+		pidDir := filepath.Join(procRoot, strconv.Itoa(pid))
+		assert.NoError(t, os.MkdirAll(pidDir, 0700))
+		assert.NoError(t, os.WriteFile(filepath.Join(pidDir, "cmdline"), []byte(strings.ReplaceAll(cmdline, " ", "\x00")+"\x00"), 0600))
+	}
+
+	fakeNetNS(t, dir, "self", "host")
+
+	return dir
+}
+
+// fakeNetNS moves the process pid to the network namespace netNS.
+func fakeNetNS(t *testing.T, dir, pid, netNS string) {
+	nsDir := filepath.Join(dir, "ns")
+	assert.NoError(t, os.MkdirAll(nsDir, 0700))
+	if _, err := os.Stat(filepath.Join(nsDir, netNS)); os.IsNotExist(err) {
+		assert.NoError(t, os.WriteFile(filepath.Join(nsDir, netNS), nil, 0600))
+	}
+
+	pidNSDir := filepath.Join(procRoot, pid, "ns")
+	assert.NoError(t, os.MkdirAll(pidNSDir, 0700))
+	os.Remove(filepath.Join(pidNSDir, "net"))
+	assert.NoError(t, os.Symlink(filepath.Join(nsDir, netNS), filepath.Join(pidNSDir, "net")))
+}
+
+// fakeSocket makes the process pid hold the unix socket inode bound to path.
+func fakeSocket(t *testing.T, pid int, inode int, path string) {
+	pidDir := filepath.Join(procRoot, strconv.Itoa(pid))
+	assert.NoError(t, os.MkdirAll(filepath.Join(pidDir, "fd"), 0700))
+	assert.NoError(t, os.MkdirAll(filepath.Join(pidDir, "net"), 0700))
+
+	fd := filepath.Join(pidDir, "fd", strconv.Itoa(inode))
+	assert.NoError(t, os.Symlink(fmt.Sprintf("socket:[%d]", inode), fd))
+
+	f, err := os.OpenFile(filepath.Join(pidDir, "net", "unix"), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	assert.NoError(t, err)
+	defer f.Close()
+	_, err = fmt.Fprintf(f, "0000000000000000: 00000002 00000000 00010000 0001 01 %d %s\n", inode, path)
+	assert.NoError(t, err)
+}
+
+// fakeOptions returns options listing sandboxes, and the torn down
+// sandboxes with their network namespace.
+func fakeOptions(sandboxes []Sandbox) (Options, *[]string) {
+	var removed []string
+	return Options{
+		List: func() ([]Sandbox, error) {
+			return sandboxes, nil
+		},
+		Teardown: func(ctx context.Context, sandboxID, netNSPath string) error {
+			removed = append(removed, sandboxID+"@"+filepath.Base(netNSPath))
+			return nil
+		},
+	}, &removed
+}
+
+func TestCollect(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := fakeHost(t, map[int]string{
+		100: "/usr/bin/containerd-shim-kata-v2 -namespace k8s.io -id shim",
+		200: "/usr/bin/qemu-system-x86_64 -name sandbox-orphan-vmm",
+		201: "/usr/libexec/virtiofsd --fd=3",
+		300: "/usr/bin/tail -f /var/log/orphan-vmm.log",
+		400: "/usr/bin/containerd-shim-kata-v2 -id lost-shim",
+		500: "/usr/sbin/sshd -D",
+	})
+
+	vmDir := func(id string) string {
+		return filepath.Join(dir, "vm", id)
+	}
+
+	old := time.Now().Add(-time.Hour)
+	sandboxes := []Sandbox{
+		{ID: "recent", ModTime: time.Now(), VMDir: vmDir("recent"), Restorable: true},
+		{ID: "socket", ModTime: old, VMDir: vmDir("socket"), Restorable: true},
+		{ID: "shim", ModTime: old, VMDir: vmDir("shim"), Restorable: true},
+		{ID: "ready", ModTime: old, VMDir: vmDir("ready"), Restorable: true},
+		{ID: "orphan-vmm", ModTime: old, VMDir: vmDir("orphan-vmm"), Restorable: true},
+		{ID: "lost", ModTime: old, VMDir: vmDir("lost")},
+		{ID: "stale-pid", ModTime: old, VMDir: vmDir("stale-pid")},
+		{ID: "qemu-pid", ModTime: old, VMDir: vmDir("qemu-pid")},
+	}
+
+	// The hypervisor runs in the pod network namespace and saves its pid,
+	// virtiofsd serves its socket from the host one.
+	assert.NoError(os.MkdirAll(vmDir("orphan-vmm"), 0700))
+	assert.NoError(os.WriteFile(filepath.Join(vmDir("orphan-vmm"), pidFile), []byte("200"), 0600))
+	fakeNetNS(t, dir, "200", "pod")
+	fakeSocket(t, 200, 1000, filepath.Join(vmDir("orphan-vmm"), "qmp.sock"))
+	fakeNetNS(t, dir, "201", "host")
+	fakeSocket(t, 201, 1001, filepath.Join(vmDir("orphan-vmm"), "vhost-fs.sock"))
+	// The pid of a hypervisor killed with its shim, reused by another
+	// process, is not trusted.
+	assert.NoError(os.MkdirAll(vmDir("stale-pid"), 0700))
+	assert.NoError(os.WriteFile(filepath.Join(vmDir("stale-pid"), pidFile), []byte("500"), 0600))
+	// A hypervisor without socket is trusted when it refers to its VM
+	// directory.
+	assert.NoError(os.MkdirAll(vmDir("qemu-pid"), 0700))
+	assert.NoError(os.WriteFile(filepath.Join(vmDir("qemu-pid"), pidFile), []byte("700"), 0600))
+	qemuCmdline := "/usr/bin/qemu-system-x86_64\x00-pidfile\x00" + filepath.Join(vmDir("qemu-pid"), pidFile) + "\x00"
+	assert.NoError(os.MkdirAll(filepath.Join(procRoot, "700"), 0700))
+	assert.NoError(os.WriteFile(filepath.Join(procRoot, "700", "cmdline"), []byte(qemuCmdline), 0600))
+	// A socket in a sandbox directory sharing the same prefix
+	fakeNetNS(t, dir, "300", "host")
+	fakeSocket(t, 300, 1002, vmDir("orphan-vmm")+"-other/qmp.sock")
+
+	assert.NoError(os.MkdirAll(filepath.Join(sandboxesStoragePath, "socket"), 0700))
+	l, err := net.Listen("unix", filepath.Join(sandboxesStoragePath, "socket", shimSocket))
+	assert.NoError(err)
+	defer l.Close()
+
+	var checked []string
+	opts, removed := fakeOptions(sandboxes)
+	opts.Grace = 10 * time.Minute
+	opts.DryRun = true
+	opts.FilterUnknown = func(sandboxList []string) ([]string, error) {
+		checked = append(checked, sandboxList...)
+		return []string{"orphan-vmm", "lost", "stale-pid", "qemu-pid"}, nil
+	}
+
+	results, err := Collect(context.Background(), opts)
+	assert.NoError(err)
+	assert.Equal([]string{"ready", "orphan-vmm", "lost", "stale-pid", "qemu-pid"}, checked)
+	assert.Empty(*removed)
+
+	reasons := make(map[string]Result)
+	for _, result := range results {
+		reasons[result.ID] = result
+	}
+	assert.Len(reasons, len(sandboxes))
+	assert.Equal(ReasonRecent, reasons["recent"].Reason)
+	assert.Equal(ReasonShimSocket, reasons["socket"].Reason)
+	assert.Equal(ReasonShimProcess, reasons["shim"].Reason)
+	assert.Equal(ReasonReady, reasons["ready"].Reason)
+	assert.False(reasons["ready"].Orphaned)
+
+	assert.True(reasons["orphan-vmm"].Orphaned)
+	assert.Equal([]int{200, 201}, reasons["orphan-vmm"].Pids)
+	assert.Equal(filepath.Join(dir, "ns", "pod"), reasons["orphan-vmm"].NetNS)
+	assert.True(reasons["lost"].Orphaned)
+	assert.False(reasons["lost"].Restorable)
+	assert.Empty(reasons["lost"].Pids)
+	assert.Empty(reasons["lost"].NetNS)
+	assert.True(reasons["stale-pid"].Orphaned)
+	assert.Empty(reasons["stale-pid"].Pids)
+	assert.Equal([]int{700}, reasons["qemu-pid"].Pids)
+
+	// Nothing is collected when the container manager cannot be queried.
+	opts.DryRun = false
+	opts.FilterUnknown = func(sandboxList []string) ([]string, error) {
+		return nil, errors.New("no container manager")
+	}
+	_, err = Collect(context.Background(), opts)
+	assert.Error(err)
+	assert.Empty(*removed)
+}
+
+func TestCollectTeardown(t *testing.T) {
+	assert := assert.New(t)
+
+	fakeHost(t, nil)
+
+	old := time.Now().Add(-time.Hour)
+	opts, removed := fakeOptions([]Sandbox{
+		{ID: "orphan", ModTime: old},
+		{ID: "recent", ModTime: time.Now()},
+	})
+	opts.Grace = time.Minute
+
+	results, err := Collect(context.Background(), opts)
+	assert.NoError(err)
+	assert.Len(results, 2)
+	assert.Equal([]string{"orphan@."}, *removed)
+	assert.NoError(results[0].Err)
+
+	opts.Teardown = func(ctx context.Context, sandboxID, netNSPath string) error {
+		return errors.New("cleanup failed")
+	}
+	results, err = Collect(context.Background(), opts)
+	assert.NoError(err)
+	assert.Error(results[0].Err)
+
+	_, err = Collect(context.Background(), Options{})
+	assert.Error(err)
+}
+
+func TestListProcesses(t *testing.T) {
+	assert := assert.New(t)
+
+	fakeHost(t, map[int]string{
+		1:           "/sbin/init",
+		os.Getpid(): "/usr/bin/kata-runtime gc",
+	})
+	// Kernel threads have an empty command line.
+	assert.NoError(os.MkdirAll(filepath.Join(procRoot, "2"), 0700))
+	assert.NoError(os.WriteFile(filepath.Join(procRoot, "2", "cmdline"), nil, 0600))
+
+	processes, err := listProcesses()
+	assert.NoError(err)
+	assert.Equal([]process{{pid: 1, cmdline: []string{"/sbin/init"}}}, processes)
+	assert.False(processes[0].isShimOf("init"))
+
+	shim := process{cmdline: []string{"containerd-shim-kata-v2", "-id", "abc"}}
+	assert.True(shim.isShimOf("abc"))
+	assert.False(shim.isShimOf("ab"))
+	assert.False(process{cmdline: []string{"/usr/bin/sleep", "abc"}}.isShimOf("abc"))
+}
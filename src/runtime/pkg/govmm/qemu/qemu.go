@@ -707,6 +707,9 @@ const (
 
 	// VHOSTUSER is a vhost-user port (socket)
 	VHOSTUSER NetDeviceType = "vhostuser"
+
+	// VHOSTVDPA is a vhost-vdpa port (character device)
+	VHOSTVDPA NetDeviceType = "vhost-vdpa"
 )
 
 // QemuNetdevParam converts to the QEMU -netdev parameter notation
@@ -734,6 +737,8 @@ func (n NetDeviceType) QemuNetdevParam(netdev *NetDevice, config *Config) string
 			log.Fatal("vhost-user devices are not supported on IBM Z")
 		}
 		return "vhost-user" // -netdev type=vhost-user (no device)
+	case VHOSTVDPA:
+		return "vhost-vdpa" // -netdev type=vhost-vdpa -device virtio-net-pci
 	default:
 		return ""
 
@@ -767,6 +772,8 @@ func (n NetDeviceType) QemuDeviceParam(netdev *NetDevice, config *Config) Device
 			log.Fatal("vhost-user devices are not supported on IBM Z")
 		}
 		return "" // -netdev type=vhost-user (no device)
+	case VHOSTVDPA:
+		device = "virtio-net" // -netdev type=vhost-vdpa -device virtio-net-pci
 	default:
 		return ""
 	}
@@ -818,6 +825,9 @@ type NetDevice struct {
 	// VHost enables virtio device emulation from the host kernel instead of from qemu.
 	VHost bool
 
+	// VhostDev is the path to the vhost-vdpa character device.
+	VhostDev string
+
 	// MACAddress is the networking device interface MAC address.
 	MACAddress string
 
@@ -853,6 +863,8 @@ func (netdev NetDevice) Valid() bool {
 		return true
 	case MACVTAP:
 		return true
+	case VHOSTVDPA:
+		return netdev.VhostDev != ""
 	default:
 		return false
 	}
@@ -940,6 +952,11 @@ func (netdev NetDevice) QemuNetdevParams(config *Config) []string {
 	netdevParams = append(netdevParams, netdevType)
 	netdevParams = append(netdevParams, fmt.Sprintf("id=%s", netdev.ID))
 
+	if netdev.Type == VHOSTVDPA {
+		netdevParams = append(netdevParams, fmt.Sprintf("vhostdev=%s", netdev.VhostDev))
+		return netdevParams
+	}
+
 	if netdev.VHost {
 		netdevParams = append(netdevParams, "vhost=on")
 		if len(netdev.VhostFDs) > 0 {
@@ -2139,6 +2156,10 @@ type BalloonDevice struct {
 	DisableModern bool
 	ID            string
 
+	// FreePageReporting lets the guest report its free pages to the host,
+	// so their memory can be reclaimed without inflating the balloon.
+	FreePageReporting bool
+
 	// ROMFile specifies the ROM file being used for this device.
 	ROMFile string
 
@@ -2181,6 +2202,9 @@ func (b BalloonDevice) QemuParams(config *Config) []string {
 	} else {
 		deviceParams = append(deviceParams, "deflate-on-oom=off")
 	}
+	if b.FreePageReporting {
+		deviceParams = append(deviceParams, "free-page-reporting=on")
+	}
 	if s := b.Transport.disableModern(config, b.DisableModern); s != "" {
 		deviceParams = append(deviceParams, s)
 	}
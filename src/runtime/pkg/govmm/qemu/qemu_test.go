@@ -185,6 +185,36 @@ func TestAppendDeviceNetwork(t *testing.T) {
 	testAppend(netdev, deviceNetworkString, t)
 }
 
+func TestAppendDeviceNetworkVhostVdpa(t *testing.T) {
+	netdev := NetDevice{
+		Driver:     VirtioNet,
+		Type:       VHOSTVDPA,
+		ID:         "vdpa0",
+		IFName:     "eth0",
+		VhostDev:   "/dev/vhost-vdpa-0",
+		MACAddress: "01:02:de:ad:be:ef",
+		ROMFile:    romfile,
+	}
+
+	if !netdev.Valid() {
+		t.Fatalf("vhost-vdpa netdev should be valid")
+	}
+
+	if netdev.Transport.isVirtioPCI(nil) {
+		netdev.Bus = "/pci-bus/pcie.0"
+		netdev.Addr = "255"
+	} else if netdev.Transport.isVirtioCCW(nil) {
+		netdev.DevNo = DevNo
+	}
+
+	testAppend(netdev, deviceNetworkVhostVdpaString, t)
+
+	netdev.VhostDev = ""
+	if netdev.Valid() {
+		t.Fatalf("vhost-vdpa netdev should not be valid without a vhost device")
+	}
+}
+
 func TestAppendDeviceNetworkMq(t *testing.T) {
 	foo, _ := ioutil.TempFile(os.TempDir(), "govmm-qemu-test")
 	bar, _ := ioutil.TempFile(os.TempDir(), "govmm-qemu-test")
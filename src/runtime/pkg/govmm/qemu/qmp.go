@@ -831,6 +831,88 @@ func (q *QMP) ExecuteBlockdevAddWithDriverCache(ctx context.Context, driver, dev
 	return q.executeCommand(ctx, "blockdev-add", args, nil)
 }
 
+// ExecuteBlockdevAddWithThrottle has one more parameter throttleGroupID
+// than ExecuteBlockdevAddWithDriverCache.
+// The block device is added behind a throttle filter node, named blockdevID,
+// which limits its I/O to the limits of the throttle group throttleGroupID.
+// The throttle group must have been added with ExecuteThrottleGroupAdd, and
+// it can be shared between several block devices.
+func (q *QMP) ExecuteBlockdevAddWithThrottle(ctx context.Context, driver, device, blockdevID, throttleGroupID string, direct, noFlush, ro bool) error {
+	_, blockdevArgs := q.blockdevAddBaseArgs(driver, device, blockdevID, ro)
+
+	// The node name is the one of the throttle node.
+	delete(blockdevArgs, "node-name")
+	blockdevArgs["cache"] = map[string]interface{}{
+		"direct":   direct,
+		"no-flush": noFlush,
+	}
+
+	args := map[string]interface{}{
+		"driver":         "throttle",
+		"node-name":      blockdevID,
+		"read-only":      ro,
+		"throttle-group": throttleGroupID,
+		"file":           blockdevArgs,
+	}
+
+	return q.executeCommand(ctx, "blockdev-add", args, nil)
+}
+
+// ThrottleLimits are the I/O limits of a throttle group. Bandwidths are in
+// bytes per second, and a zero limit means unlimited.
+type ThrottleLimits struct {
+	BpsRead   uint64
+	BpsWrite  uint64
+	IopsRead  uint64
+	IopsWrite uint64
+}
+
+func (limits ThrottleLimits) qmpArgs() map[string]interface{} {
+	// QEMU only updates the limits it is given, hence unlimited is set
+	// explicitly.
+	return map[string]interface{}{
+		"bps-read":   limits.BpsRead,
+		"bps-write":  limits.BpsWrite,
+		"iops-read":  limits.IopsRead,
+		"iops-write": limits.IopsWrite,
+	}
+}
+
+// ExecuteThrottleGroupAdd adds a throttle group, id, with the given limits
+// by sending an object-add command.
+func (q *QMP) ExecuteThrottleGroupAdd(ctx context.Context, id string, limits ThrottleLimits) error {
+	args := map[string]interface{}{
+		"qom-type": "throttle-group",
+		"id":       id,
+		"limits":   limits.qmpArgs(),
+	}
+
+	return q.executeCommand(ctx, "object-add", args, nil)
+}
+
+// ExecuteThrottleGroupSet updates the limits of the throttle group id, which
+// applies to the block devices throttled by the group right away.
+func (q *QMP) ExecuteThrottleGroupSet(ctx context.Context, id string, limits ThrottleLimits) error {
+	args := map[string]interface{}{
+		"path":     id,
+		"property": "limits",
+		"value":    limits.qmpArgs(),
+	}
+
+	return q.executeCommand(ctx, "qom-set", args, nil)
+}
+
+// ExecuteThrottleGroupDel deletes the throttle group id by sending an
+// object-del command. The block devices it throttles must have been deleted
+// first.
+func (q *QMP) ExecuteThrottleGroupDel(ctx context.Context, id string) error {
+	args := map[string]interface{}{
+		"id": id,
+	}
+
+	return q.executeCommand(ctx, "object-del", args, nil)
+}
+
 // ExecuteDeviceAdd adds the guest portion of a device to a QEMU instance
 // using the device_add command.  blockdevID should match the blockdevID passed
 // to a previous call to ExecuteBlockdevAdd.  devID is the id of the device to
@@ -991,6 +1073,20 @@ func (q *QMP) ExecuteNetdevAddByFds(ctx context.Context, netdevType, netdevID st
 	return q.executeCommand(ctx, "netdev_add", args, nil)
 }
 
+// ExecuteNetdevAddVhostVdpa adds a vhost-vdpa Net device to a QEMU instance
+// using the netdev_add command. netdevID is the id of the device to add.
+// Must be valid QMP identifier. vhostdev is the path to the vhost-vdpa
+// character device.
+func (q *QMP) ExecuteNetdevAddVhostVdpa(ctx context.Context, netdevID, vhostdev string) error {
+	args := map[string]interface{}{
+		"type":     "vhost-vdpa",
+		"id":       netdevID,
+		"vhostdev": vhostdev,
+	}
+
+	return q.executeCommand(ctx, "netdev_add", args, nil)
+}
+
 // ExecuteNetdevDel deletes a Net device from a QEMU instance
 // using the netdev_del command. netdevID is the id of the device to delete.
 func (q *QMP) ExecuteNetdevDel(ctx context.Context, netdevID string) error {
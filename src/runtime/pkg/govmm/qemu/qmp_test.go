@@ -433,6 +433,65 @@ func TestQMPBlockdevAddWithCache(t *testing.T) {
 	<-disconnectedCh
 }
 
+// Checks that the blockdev-add with throttle command is correctly sent.
+//
+// We start a QMPLoop, send the blockdev-add with throttle command and stop
+// the loop.
+//
+// The blockdev-add with throttle command should be correctly sent and the QMP
+// loop should exit gracefully.
+func TestQMPBlockdevAddWithThrottle(t *testing.T) {
+	connectedCh := make(chan *QMPVersion)
+	disconnectedCh := make(chan struct{})
+	buf := newQMPTestCommandBuffer(t)
+	buf.AddCommand("blockdev-add", nil, "return", nil)
+	cfg := QMPConfig{Logger: qmpTestLogger{}}
+	q := startQMPLoop(buf, cfg, connectedCh, disconnectedCh)
+	q.version = checkVersion(t, connectedCh)
+	err := q.ExecuteBlockdevAddWithThrottle(context.Background(), "host_device", "/dev/rbd0",
+		fmt.Sprintf("drive_%s", volumeUUID), fmt.Sprintf("throttle_%s", volumeUUID), false, false, false)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	q.Shutdown()
+	<-disconnectedCh
+}
+
+// Checks that the throttle group object-add, qom-set and object-del commands
+// are correctly sent.
+//
+// We start a QMPLoop, add, update and delete a throttle group and stop the
+// loop.
+//
+// The commands should be correctly sent and the QMP loop should exit
+// gracefully.
+func TestQMPThrottleGroup(t *testing.T) {
+	connectedCh := make(chan *QMPVersion)
+	disconnectedCh := make(chan struct{})
+	buf := newQMPTestCommandBuffer(t)
+	buf.AddCommand("object-add", nil, "return", nil)
+	buf.AddCommand("qom-set", nil, "return", nil)
+	buf.AddCommand("object-del", nil, "return", nil)
+	cfg := QMPConfig{Logger: qmpTestLogger{}}
+	q := startQMPLoop(buf, cfg, connectedCh, disconnectedCh)
+	q.version = checkVersion(t, connectedCh)
+	id := fmt.Sprintf("throttle_%s", volumeUUID)
+	err := q.ExecuteThrottleGroupAdd(context.Background(), id, ThrottleLimits{BpsRead: 1 << 20})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	err = q.ExecuteThrottleGroupSet(context.Background(), id, ThrottleLimits{IopsWrite: 100})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	err = q.ExecuteThrottleGroupDel(context.Background(), id)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	q.Shutdown()
+	<-disconnectedCh
+}
+
 // Checks that the netdev_add command is correctly sent.
 //
 // We start a QMPLoop, send the netdev_add command and stop the loop.
@@ -477,6 +536,28 @@ func TestQMPNetdevChardevAdd(t *testing.T) {
 	<-disconnectedCh
 }
 
+// Checks that the netdev_add command for a vhost-vdpa device is correctly sent.
+//
+// We start a QMPLoop, send the netdev_add command and stop the loop.
+//
+// The netdev_add command should be correctly sent and the QMP loop should
+// exit gracefully.
+func TestQMPNetdevAddVhostVdpa(t *testing.T) {
+	connectedCh := make(chan *QMPVersion)
+	disconnectedCh := make(chan struct{})
+	buf := newQMPTestCommandBuffer(t)
+	buf.AddCommand("netdev_add", nil, "return", nil)
+	cfg := QMPConfig{Logger: qmpTestLogger{}}
+	q := startQMPLoop(buf, cfg, connectedCh, disconnectedCh)
+	q.version = checkVersion(t, connectedCh)
+	err := q.ExecuteNetdevAddVhostVdpa(context.Background(), "vdpa0", "/dev/vhost-vdpa-0")
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	q.Shutdown()
+	<-disconnectedCh
+}
+
 // Checks that the netdev_add command with fds is correctly sent.
 //
 // We start a QMPLoop, send the netdev_add command with fds and stop the loop.
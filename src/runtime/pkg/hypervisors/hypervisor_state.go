@@ -34,6 +34,8 @@ type HypervisorState struct {
 	UUID string
 	// clh sepcific: refer to 'virtcontainers/clh.go:CloudHypervisorState'
 	APISocket string
+	// MemoryHotplugMethod is how memory is resized, E.g. Acpi/VirtioMem.
+	MemoryHotplugMethod string
 
 	// Belows are qemu specific
 	// Refs: virtcontainers/qemu.go:QemuState
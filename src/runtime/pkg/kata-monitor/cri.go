@@ -114,15 +114,14 @@ func parseEndpoint(endpoint string) (string, string, error) {
 	}
 }
 
-// syncSandboxes gets pods metadata from the container manager and updates the sandbox cache.
-func (km *KataMonitor) syncSandboxes(sandboxList []string) ([]string, error) {
-	runtimeClient, runtimeConn, err := getRuntimeClient(km.runtimeEndpoint)
+// listReadyPods returns the pods which the container manager reports as ready.
+func listReadyPods(runtimeEndpoint string) ([]*pb.PodSandbox, error) {
+	runtimeClient, runtimeConn, err := getRuntimeClient(runtimeEndpoint)
 	if err != nil {
-		return sandboxList, err
+		return nil, err
 	}
 	defer closeConnection(runtimeConn)
 
-	// TODO: if len(sandboxList) is 1, better we just runtimeClient.PodSandboxStatus(...) targeting the single sandbox
 	filter := &pb.PodSandboxFilter{
 		State: &pb.PodSandboxStateValue{
 			State: pb.PodSandboxState_SANDBOX_READY,
@@ -135,11 +134,38 @@ func (km *KataMonitor) syncSandboxes(sandboxList []string) ([]string, error) {
 	monitorLog.Tracef("ListPodSandboxRequest: %v", request)
 	r, err := runtimeClient.ListPodSandbox(context.Background(), request)
 	if err != nil {
-		return sandboxList, err
+		return nil, err
 	}
 	monitorLog.Tracef("ListPodSandboxResponse: %v", r)
 
-	for _, pod := range r.Items {
+	return r.Items, nil
+}
+
+// UnknownSandboxes returns the sandboxes of sandboxList which the container
+// manager listening on runtimeEndpoint does not report as ready.
+func UnknownSandboxes(runtimeEndpoint string, sandboxList []string) ([]string, error) {
+	pods, err := listReadyPods(normalizeEndpoint(runtimeEndpoint))
+	if err != nil {
+		return nil, err
+	}
+
+	unknown := append([]string{}, sandboxList...)
+	for _, pod := range pods {
+		unknown = removeFromSandboxList(unknown, pod.Id)
+	}
+
+	return unknown, nil
+}
+
+// syncSandboxes gets pods metadata from the container manager and updates the sandbox cache.
+func (km *KataMonitor) syncSandboxes(sandboxList []string) ([]string, error) {
+	// TODO: if len(sandboxList) is 1, better we just runtimeClient.PodSandboxStatus(...) targeting the single sandbox
+	pods, err := listReadyPods(km.runtimeEndpoint)
+	if err != nil {
+		return sandboxList, err
+	}
+
+	for _, pod := range pods {
 		for _, sandbox := range sandboxList {
 			if pod.Id == sandbox {
 				km.sandboxCache.setCRIMetadata(sandbox, sandboxCRIMetadata{
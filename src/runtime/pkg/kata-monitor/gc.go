@@ -0,0 +1,42 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package katamonitor
+
+import (
+	"context"
+	"time"
+
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/gc"
+)
+
+// StartGarbageCollector tears down the orphaned sandboxes every interval,
+// checking the sandboxes against the container manager of the monitor.
+func (km *KataMonitor) StartGarbageCollector(interval time.Duration, opts gc.Options) {
+	opts.FilterUnknown = func(sandboxList []string) ([]string, error) {
+		return UnknownSandboxes(km.runtimeEndpoint, sandboxList)
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for range ticker.C {
+			results, err := gc.Collect(context.Background(), opts)
+			if err != nil {
+				monitorLog.WithError(err).Error("garbage collection failed")
+				continue
+			}
+
+			collected := 0
+			for _, result := range results {
+				if result.Orphaned && result.Err == nil {
+					collected++
+				}
+			}
+			monitorLog.WithField("dry-run", opts.DryRun).Debugf("garbage collection completed, %d orphaned sandboxes", collected)
+		}
+	}()
+}
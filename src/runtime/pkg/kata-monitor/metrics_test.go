@@ -106,6 +106,34 @@ func TestParsePrometheusMetrics(t *testing.T) {
 	assert.Equal(sandboxID, *mf.Metric[0].Label[0].Value, "label value should be", sandboxID)
 }
 
+func TestParseClhMetrics(t *testing.T) {
+	assert := assert.New(t)
+	sandboxID := "sandboxID-abc"
+	sandboxMetadata := sandboxCRIMetadata{"123", "pod-name", "pod-namespace"}
+
+	body := `# HELP kata_clh_block Block device counters.
+# TYPE kata_clh_block gauge
+kata_clh_block{device="_disk0",item="read_bytes"} 4096
+kata_clh_block{device="_disk0",item="write_bytes"} 512
+`
+
+	list, err := parsePrometheusMetrics(sandboxID, sandboxMetadata, []byte(body))
+	assert.NoError(err)
+	assert.Equal(1, len(list))
+
+	// The VM metrics keep their name, and are labelled with the sandbox.
+	mf := list[0]
+	assert.Equal("kata_clh_block", *mf.Name)
+	assert.Equal(2, len(mf.Metric))
+
+	m := mf.Metric[0]
+	assert.Equal(6, len(m.Label))
+	assert.Equal("device", *m.Label[0].Name)
+	assert.Equal("_disk0", *m.Label[0].Value)
+	assert.Equal("sandbox_id", *m.Label[2].Name)
+	assert.Equal(sandboxID, *m.Label[2].Value)
+}
+
 func TestEncodeMetricFamily(t *testing.T) {
 	assert := assert.New(t)
 	prometheus.MustRegister(runningShimCount)
@@ -47,12 +47,8 @@ func NewKataMonitor(runtimeEndpoint string) (*KataMonitor, error) {
 		return nil, errors.New("runtime endpoint missing")
 	}
 
-	if !strings.HasPrefix(runtimeEndpoint, "unix") {
-		runtimeEndpoint = "unix://" + runtimeEndpoint
-	}
-
 	km := &KataMonitor{
-		runtimeEndpoint: runtimeEndpoint,
+		runtimeEndpoint: normalizeEndpoint(runtimeEndpoint),
 		sandboxCache: &sandboxCache{
 			Mutex:     &sync.Mutex{},
 			sandboxes: make(map[string]sandboxCRIMetadata),
@@ -67,6 +63,14 @@ func NewKataMonitor(runtimeEndpoint string) (*KataMonitor, error) {
 	return km, nil
 }
 
+// normalizeEndpoint adds the unix scheme to the endpoints given as a path.
+func normalizeEndpoint(runtimeEndpoint string) string {
+	if !strings.HasPrefix(runtimeEndpoint, "unix") {
+		return "unix://" + runtimeEndpoint
+	}
+	return runtimeEndpoint
+}
+
 func removeFromSandboxList(sandboxList []string, sandboxToRemove string) []string {
 	for i, sandbox := range sandboxList {
 		if sandbox == sandboxToRemove {
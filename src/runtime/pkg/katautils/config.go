@@ -10,6 +10,7 @@ package katautils
 import (
 	"errors"
 	"fmt"
+	"net"
 	"os"
 	"path/filepath"
 	goruntime "runtime"
@@ -23,6 +24,7 @@ import (
 	vc "github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/device/config"
 	exp "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/experimental"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/utils"
 	"github.com/sirupsen/logrus"
 )
@@ -96,6 +98,7 @@ type hypervisor struct {
 	FileBackedMemRootDir    string   `toml:"file_mem_backend"`
 	GuestHookPath           string   `toml:"guest_hook_path"`
 	GuestMemoryDumpPath     string   `toml:"guest_memory_dump_path"`
+	MmdsIPv4Address         string   `toml:"mmds_ipv4_address"`
 	HypervisorPathList      []string `toml:"valid_hypervisor_paths"`
 	JailerPathList          []string `toml:"valid_jailer_paths"`
 	CtlPathList             []string `toml:"valid_ctlpaths"`
@@ -106,6 +109,8 @@ type hypervisor struct {
 	FileBackedMemRootList   []string `toml:"valid_file_mem_backends"`
 	EntropySourceList       []string `toml:"valid_entropy_sources"`
 	EnableAnnotations       []string `toml:"enable_annotations"`
+	MmdsAnnotations         []string `toml:"mmds_annotations"`
+	MmdsSecretsPathList     []string `toml:"valid_mmds_secrets_paths"`
 	RxRateLimiterMaxRate    uint64   `toml:"rx_rate_limiter_max_rate"`
 	TxRateLimiterMaxRate    uint64   `toml:"tx_rate_limiter_max_rate"`
 	MemOffset               uint64   `toml:"memory_offset"`
@@ -116,6 +121,11 @@ type hypervisor struct {
 	DefaultBridges          uint32   `toml:"default_bridges"`
 	Msize9p                 uint32   `toml:"msize_9p"`
 	PCIeRootPort            uint32   `toml:"pcie_root_port"`
+	JailUID                 uint32   `toml:"jail_uid"`
+	JailGID                 uint32   `toml:"jail_gid"`
+	BalloonReclaimInterval  uint32   `toml:"balloon_reclaim_interval"`
+	BalloonReclaimMinFree   uint32   `toml:"balloon_reclaim_min_free"`
+	BalloonReclaimLimit     uint32   `toml:"balloon_reclaim_limit"`
 	NumVCPUs                int32    `toml:"default_vcpus"`
 	BlockDeviceCacheSet     bool     `toml:"block_device_cache_set"`
 	BlockDeviceCacheDirect  bool     `toml:"block_device_cache_direct"`
@@ -140,6 +150,10 @@ type hypervisor struct {
 	DisableSeccomp          bool     `toml:"disable_seccomp"`
 	DisableSeLinux          bool     `toml:"disable_selinux"`
 	Unikernel               bool     `toml:"unikernel"`
+	EnableMmds              bool     `toml:"enable_mmds"`
+	EnableJail              bool     `toml:"enable_jail"`
+	EnableBalloon           bool     `toml:"enable_balloon"`
+	FreePageReporting       bool     `toml:"enable_free_page_reporting"`
 }
 
 type runtime struct {
@@ -147,11 +161,15 @@ type runtime struct {
 	JaegerEndpoint            string   `toml:"jaeger_endpoint"`
 	JaegerUser                string   `toml:"jaeger_user"`
 	JaegerPassword            string   `toml:"jaeger_password"`
+	TracingExporter           string   `toml:"tracing_exporter"`
+	TracingEndpoint           string   `toml:"tracing_endpoint"`
+	PersistDriver             string   `toml:"persist_driver"`
 	VfioMode                  string   `toml:"vfio_mode"`
 	SandboxBindMounts         []string `toml:"sandbox_bind_mounts"`
 	Experimental              []string `toml:"experimental"`
 	Debug                     bool     `toml:"enable_debug"`
 	Tracing                   bool     `toml:"enable_tracing"`
+	TracingSamplingRatio      float64  `toml:"tracing_sampling_ratio"`
 	DisableNewNetNs           bool     `toml:"disable_new_netns"`
 	DisableGuestSeccomp       bool     `toml:"disable_guest_seccomp"`
 	SandboxCgroupOnly         bool     `toml:"sandbox_cgroup_only"`
@@ -459,6 +477,19 @@ func (h hypervisor) guestHookPath() string {
 	return h.GuestHookPath
 }
 
+func (h hypervisor) mmdsIPv4Address() (string, error) {
+	if h.MmdsIPv4Address == "" {
+		return "", nil
+	}
+
+	ip := net.ParseIP(h.MmdsIPv4Address)
+	if ip == nil || ip.To4() == nil || !ip.IsLinkLocalUnicast() {
+		return "", fmt.Errorf("Invalid MMDS address %q: must be an IPv4 link-local address", h.MmdsIPv4Address)
+	}
+
+	return h.MmdsIPv4Address, nil
+}
+
 func (h hypervisor) vhostUserStorePath() string {
 	if h.VhostUserStorePath == "" {
 		return defaultVhostUserStorePath
@@ -561,6 +592,11 @@ func newFirecrackerHypervisorConfig(h hypervisor) (vc.HypervisorConfig, error) {
 	rxRateLimiterMaxRate := h.getRxRateLimiterCfg()
 	txRateLimiterMaxRate := h.getTxRateLimiterCfg()
 
+	mmdsIPv4Address, err := h.mmdsIPv4Address()
+	if err != nil {
+		return vc.HypervisorConfig{}, err
+	}
+
 	return vc.HypervisorConfig{
 		HypervisorPath:        hypervisor,
 		HypervisorPathList:    h.HypervisorPathList,
@@ -589,6 +625,10 @@ func newFirecrackerHypervisorConfig(h hypervisor) (vc.HypervisorConfig, error) {
 		RxRateLimiterMaxRate:  rxRateLimiterMaxRate,
 		TxRateLimiterMaxRate:  txRateLimiterMaxRate,
 		EnableAnnotations:     h.EnableAnnotations,
+		EnableMmds:            h.EnableMmds,
+		MmdsIPv4Address:       mmdsIPv4Address,
+		MmdsAnnotations:       h.MmdsAnnotations,
+		MmdsSecretsPathList:   h.MmdsSecretsPathList,
 	}, nil
 }
 
@@ -717,6 +757,11 @@ func newQemuHypervisorConfig(h hypervisor) (vc.HypervisorConfig, error) {
 		ConfidentialGuest:       h.ConfidentialGuest,
 		GuestSwap:               h.GuestSwap,
 		Rootless:                h.Rootless,
+		EnableBalloon:           h.EnableBalloon,
+		FreePageReporting:       h.FreePageReporting,
+		BalloonReclaimInterval:  h.BalloonReclaimInterval,
+		BalloonReclaimMinFree:   h.BalloonReclaimMinFree,
+		BalloonReclaimLimit:     h.BalloonReclaimLimit,
 	}, nil
 }
 
@@ -828,6 +873,10 @@ func newClhHypervisorConfig(h hypervisor) (vc.HypervisorConfig, error) {
 		return vc.HypervisorConfig{}, errors.New("clh only support virtio-fs or virtio-fs-nydus")
 	}
 
+	if h.EnableJail && h.JailUID == 0 {
+		return vc.HypervisorConfig{}, errors.New("enable_jail requires a non-root jail_uid")
+	}
+
 	if h.VirtioFSDaemon == "" {
 		return vc.HypervisorConfig{},
 			fmt.Errorf("cannot enable %s without daemon path in configuration file", sharedFS)
@@ -880,6 +929,11 @@ func newClhHypervisorConfig(h hypervisor) (vc.HypervisorConfig, error) {
 		DisableSeccomp:          h.DisableSeccomp,
 		ConfidentialGuest:       h.ConfidentialGuest,
 		DisableSeLinux:          h.DisableSeLinux,
+		RxRateLimiterMaxRate:    h.getRxRateLimiterCfg(),
+		TxRateLimiterMaxRate:    h.getTxRateLimiterCfg(),
+		EnableJail:              h.EnableJail,
+		JailUID:                 h.JailUID,
+		JailGID:                 h.JailGID,
 	}, nil
 }
 
@@ -947,6 +1001,7 @@ func newUruncHypervisorConfig(h hypervisor) (vc.HypervisorConfig, error) {
 		RxRateLimiterMaxRate:  rxRateLimiterMaxRate,
 		TxRateLimiterMaxRate:  txRateLimiterMaxRate,
 		EnableAnnotations:     h.EnableAnnotations,
+		DisableSeccomp:        h.DisableSeccomp,
 		Unikernel:             h.Unikernel,
 	}, nil
 }
@@ -1191,6 +1246,11 @@ func LoadConfiguration(configPath string, ignoreLogging bool) (resolvedConfigPat
 	config.Trace = tomlConf.Runtime.Tracing
 	katatrace.SetTracing(config.Trace)
 
+	config.PersistDriver = tomlConf.Runtime.PersistDriver
+	if !persist.ValidDriverName(config.PersistDriver) {
+		return "", config, fmt.Errorf("unsupported persist_driver %q", config.PersistDriver)
+	}
+
 	if tomlConf.Runtime.InterNetworkModel != "" {
 		err = config.InterNetworkModel.SetModel(tomlConf.Runtime.InterNetworkModel)
 		if err != nil {
@@ -1232,6 +1292,9 @@ func LoadConfiguration(configPath string, ignoreLogging bool) (resolvedConfigPat
 	config.JaegerEndpoint = tomlConf.Runtime.JaegerEndpoint
 	config.JaegerUser = tomlConf.Runtime.JaegerUser
 	config.JaegerPassword = tomlConf.Runtime.JaegerPassword
+	config.TracingExporter = tomlConf.Runtime.TracingExporter
+	config.TracingEndpoint = tomlConf.Runtime.TracingEndpoint
+	config.TracingSamplingRatio = tomlConf.Runtime.TracingSamplingRatio
 	for _, f := range tomlConf.Runtime.Experimental {
 		feature := exp.Get(f)
 		if feature == nil {
@@ -1323,6 +1386,27 @@ func checkConfig(config oci.RuntimeConfig) error {
 		return err
 	}
 
+	if err := checkTracingConfig(config); err != nil {
+		return err
+	}
+
+	return nil
+}
+
+// checkTracingConfig ensures the tracing exporter and sampling are valid.
+func checkTracingConfig(config oci.RuntimeConfig) error {
+	if !katatrace.ValidExporter(config.TracingExporter) {
+		return fmt.Errorf("unsupported tracing_exporter %q", config.TracingExporter)
+	}
+
+	if config.TracingExporter == katatrace.FileExporter && config.TracingEndpoint == "" {
+		return fmt.Errorf("tracing_endpoint must be set to a file path with the %q tracing_exporter", katatrace.FileExporter)
+	}
+
+	if config.TracingSamplingRatio < 0 || config.TracingSamplingRatio > 1 {
+		return fmt.Errorf("tracing_sampling_ratio must be between 0 and 1, got %v", config.TracingSamplingRatio)
+	}
+
 	return nil
 }
 
@@ -1341,14 +1425,19 @@ func checkNetNsConfig(config oci.RuntimeConfig) error {
 // checkFactoryConfig ensures the VM factory configuration is valid.
 func checkFactoryConfig(config oci.RuntimeConfig) error {
 	if config.FactoryConfig.Template {
+		// The network interfaces of the sandbox are hot plugged in a VM
+		// created from a template, which firecracker can't do.
+		if config.HypervisorType == vc.FirecrackerHypervisor {
+			return errors.New("Factory option enable_template is not supported by firecracker")
+		}
 		if config.HypervisorConfig.InitrdPath == "" {
 			return errors.New("Factory option enable_template requires an initrd image")
 		}
 	}
 
 	if config.FactoryConfig.VMCacheNumber > 0 {
-		if config.HypervisorType != vc.QemuHypervisor {
-			return errors.New("VM cache just support qemu")
+		if config.HypervisorType != vc.QemuHypervisor && config.HypervisorType != vc.ClhHypervisor {
+			return errors.New("VM cache just support qemu and cloud-hypervisor")
 		}
 	}
 
@@ -19,6 +19,7 @@ import (
 
 	"github.com/kata-containers/kata-containers/src/runtime/pkg/govmm"
 	ktu "github.com/kata-containers/kata-containers/src/runtime/pkg/katatestutils"
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/katautils/katatrace"
 	"github.com/kata-containers/kata-containers/src/runtime/pkg/oci"
 	vc "github.com/kata-containers/kata-containers/src/runtime/virtcontainers"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/utils"
@@ -1053,6 +1054,26 @@ func TestHypervisorDefaultsGuestHookPath(t *testing.T) {
 	assert.Equal(guestHookPath, testGuestHookPath, "custom guest hook path wrong")
 }
 
+func TestHypervisorMmdsIPv4Address(t *testing.T) {
+	assert := assert.New(t)
+
+	h := hypervisor{}
+	addr, err := h.mmdsIPv4Address()
+	assert.NoError(err)
+	assert.Empty(addr)
+
+	for _, invalid := range []string{"foo", "10.0.0.1", "fe80::1"} {
+		h.MmdsIPv4Address = invalid
+		_, err = h.mmdsIPv4Address()
+		assert.Error(err, invalid)
+	}
+
+	h.MmdsIPv4Address = "169.254.170.2"
+	addr, err = h.mmdsIPv4Address()
+	assert.NoError(err)
+	assert.Equal("169.254.170.2", addr)
+}
+
 func TestHypervisorDefaultsVhostUserStorePath(t *testing.T) {
 	assert := assert.New(t)
 
@@ -1519,6 +1540,44 @@ func TestCheckNetNsConfig(t *testing.T) {
 	assert.Error(err)
 }
 
+func TestCheckTracingConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	// nolint: govet
+	type testData struct {
+		exporter    string
+		endpoint    string
+		ratio       float64
+		expectError bool
+	}
+
+	data := []testData{
+		{"", "", 0, false},
+		{katatrace.OTLPGRPCExporter, "", 0.5, false},
+		{katatrace.FileExporter, "/tmp/traces.json", 1, false},
+
+		{"zipkin", "", 0, true},
+		{katatrace.FileExporter, "", 0, true},
+		{katatrace.OTLPHTTPExporter, "", 1.5, true},
+		{katatrace.OTLPHTTPExporter, "", -0.5, true},
+	}
+
+	for i, d := range data {
+		config := oci.RuntimeConfig{
+			TracingExporter:      d.exporter,
+			TracingEndpoint:      d.endpoint,
+			TracingSamplingRatio: d.ratio,
+		}
+
+		err := checkTracingConfig(config)
+		if d.expectError {
+			assert.Error(err, "test %d (%+v)", i, d)
+		} else {
+			assert.NoError(err, "test %d (%+v)", i, d)
+		}
+	}
+}
+
 func TestCheckFactoryConfig(t *testing.T) {
 	assert := assert.New(t)
 
@@ -1528,19 +1587,24 @@ func TestCheckFactoryConfig(t *testing.T) {
 		expectError    bool
 		imagePath      string
 		initrdPath     string
+		hypervisorType vc.HypervisorType
 	}
 
 	data := []testData{
-		{false, false, "", ""},
-		{false, false, "image", ""},
-		{false, false, "", "initrd"},
+		{false, false, "", "", vc.QemuHypervisor},
+		{false, false, "image", "", vc.QemuHypervisor},
+		{false, false, "", "initrd", vc.QemuHypervisor},
+
+		{true, false, "", "initrd", vc.QemuHypervisor},
+		{true, true, "image", "", vc.QemuHypervisor},
 
-		{true, false, "", "initrd"},
-		{true, true, "image", ""},
+		{false, false, "", "initrd", vc.FirecrackerHypervisor},
+		{true, true, "", "initrd", vc.FirecrackerHypervisor},
 	}
 
 	for i, d := range data {
 		config := oci.RuntimeConfig{
+			HypervisorType: d.hypervisorType,
 			HypervisorConfig: vc.HypervisorConfig{
 				ImagePath:  d.imagePath,
 				InitrdPath: d.initrdPath,
@@ -130,6 +130,28 @@ func PreStartHooks(ctx context.Context, spec specs.Spec, cid, bundlePath string)
 	return runHooks(ctx, spec, spec.Hooks.Prestart, cid, bundlePath, "pre-start")
 }
 
+// CreateContainerHooks run the hooks after the runtime environment has been
+// created, in the container namespace
+func CreateContainerHooks(ctx context.Context, spec specs.Spec, cid, bundlePath string) error {
+	// If no hook available, nothing needs to be done.
+	if spec.Hooks == nil {
+		return nil
+	}
+
+	return runHooks(ctx, spec, spec.Hooks.CreateContainer, cid, bundlePath, "createContainer")
+}
+
+// StartContainerHooks run the hooks just before the user-specified program
+// is executed, in the container namespace
+func StartContainerHooks(ctx context.Context, spec specs.Spec, cid, bundlePath string) error {
+	// If no hook available, nothing needs to be done.
+	if spec.Hooks == nil {
+		return nil
+	}
+
+	return runHooks(ctx, spec, spec.Hooks.StartContainer, cid, bundlePath, "startContainer")
+}
+
 // PostStartHooks run the hooks just after start container
 func PostStartHooks(ctx context.Context, spec specs.Spec, cid, bundlePath string) error {
 	// If no hook available, nothing needs to be done.
@@ -123,6 +123,39 @@ func TestPreStartHooks(t *testing.T) {
 	assert.Error(err)
 }
 
+func TestCreationHooks(t *testing.T) {
+	if tc.NotValid(ktu.NeedRoot()) {
+		t.Skip(ktu.TestDisabledNeedRoot)
+	}
+
+	ctx := context.Background()
+
+	for _, test := range []struct {
+		run      func(ctx context.Context, spec specs.Spec, cid, bundlePath string) error
+		setHooks func(hooks *specs.Hooks, h []specs.Hook)
+		name     string
+	}{
+		{CreateRuntimeHooks, func(hooks *specs.Hooks, h []specs.Hook) { hooks.CreateRuntime = h }, "createRuntime"},
+		{CreateContainerHooks, func(hooks *specs.Hooks, h []specs.Hook) { hooks.CreateContainer = h }, "createContainer"},
+		{StartContainerHooks, func(hooks *specs.Hooks, h []specs.Hook) { hooks.StartContainer = h }, "startContainer"},
+	} {
+		assert := assert.New(t)
+
+		// Hooks field is nil, or hooks list is empty
+		assert.NoError(test.run(ctx, specs.Spec{}, "", ""), test.name)
+		assert.NoError(test.run(ctx, specs.Spec{Hooks: &specs.Hooks{}}, "", ""), test.name)
+
+		// Run with timeout 0
+		spec := specs.Spec{Hooks: &specs.Hooks{}}
+		test.setHooks(spec.Hooks, []specs.Hook{createHook(0)})
+		assert.NoError(test.run(ctx, spec, testSandboxID, testBundlePath), test.name)
+
+		// Failure due to wrong hook
+		test.setHooks(spec.Hooks, []specs.Hook{createWrongHook()})
+		assert.Error(test.run(ctx, spec, testSandboxID, testBundlePath), test.name)
+	}
+}
+
 func TestPostStartHooks(t *testing.T) {
 	if tc.NotValid(ktu.NeedRoot()) {
 		t.Skip(ktu.TestDisabledNeedRoot)
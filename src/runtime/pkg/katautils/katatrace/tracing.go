@@ -8,20 +8,30 @@ package katatrace
 import (
 	"context"
 	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"github.com/containerd/ttrpc"
 
 	"github.com/sirupsen/logrus"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/attribute"
 	"go.opentelemetry.io/otel/exporters/jaeger"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
 	"go.opentelemetry.io/otel/propagation"
 	"go.opentelemetry.io/otel/sdk/resource"
 	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.4.0"
 	"go.opentelemetry.io/otel/trace"
 	otelTrace "go.opentelemetry.io/otel/trace"
+	"google.golang.org/grpc/metadata"
 )
 
-// kataSpanExporter is used to ensure that Jaeger logs each span.
+// kataSpanExporter is used to ensure that each exported span is logged.
 // This is essential as it is used by:
 //
 // https: //github.com/kata-containers/tests/blob/master/tracing/tracing-test.sh
@@ -29,7 +39,7 @@ type kataSpanExporter struct{}
 
 var _ sdktrace.SpanExporter = (*kataSpanExporter)(nil)
 
-// ExportSpans exports SpanData to Jaeger.
+// ExportSpans logs the exported SpanData.
 func (e *kataSpanExporter) ExportSpans(ctx context.Context, spans []sdktrace.ReadOnlySpan) error {
 	for _, span := range spans {
 		kataTraceLogger.Tracef("Reporting span %+v", span)
@@ -55,15 +65,173 @@ func SetTracing(isTracing bool) {
 	tracing = isTracing
 }
 
-// JaegerConfig defines necessary Jaeger config for exporting traces.
-type JaegerConfig struct {
+// Supported tracing exporters.
+const (
+	// JaegerExporter sends the spans to a Jaeger HTTP Thrift collector.
+	// It is the default exporter, although Jaeger is deprecated in favour
+	// of OTLP, which Jaeger collectors also accept.
+	JaegerExporter = "jaeger"
+
+	// OTLPGRPCExporter sends the spans to an OpenTelemetry collector
+	// using OTLP over gRPC.
+	OTLPGRPCExporter = "otlp-grpc"
+
+	// OTLPHTTPExporter sends the spans to an OpenTelemetry collector
+	// using OTLP over HTTP.
+	OTLPHTTPExporter = "otlp-http"
+
+	// FileExporter appends the spans as JSON lines to a file.
+	FileExporter = "file"
+
+	// StdoutExporter writes the spans as JSON lines to stdout.
+	StdoutExporter = "stdout"
+)
+
+const (
+	defaultOTLPGRPCEndpoint = "localhost:4317"
+	defaultOTLPHTTPEndpoint = "http://localhost:4318/v1/traces"
+)
+
+// TracingConfig defines the configuration used to export traces.
+type TracingConfig struct {
+	// Exporter is the name of the exporter. Defaults to JaegerExporter.
+	Exporter string
+
+	// Endpoint is the OTLP collector endpoint, or the path of the file
+	// written by FileExporter.
+	Endpoint string
+
 	JaegerEndpoint string
 	JaegerUser     string
 	JaegerPassword string
+
+	// SamplingRatio is the fraction of the root spans which are sampled.
+	// Spans with a parent follow the sampling decision of the parent.
+	// Defaults to 1.
+	SamplingRatio float64
+}
+
+// ValidExporter returns whether name is a supported tracing exporter.
+func ValidExporter(name string) bool {
+	switch name {
+	case "", JaegerExporter, OTLPGRPCExporter, OTLPHTTPExporter, FileExporter, StdoutExporter:
+		return true
+	}
+	return false
+}
+
+func newExporter(config *TracingConfig) (sdktrace.SpanExporter, error) {
+	switch config.Exporter {
+	case "", JaegerExporter:
+		collectorEndpoint := config.JaegerEndpoint
+		if collectorEndpoint == "" {
+			collectorEndpoint = "http://localhost:14268/api/traces"
+		}
+
+		return jaeger.New(
+			jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(collectorEndpoint),
+				jaeger.WithUsername(config.JaegerUser),
+				jaeger.WithPassword(config.JaegerPassword),
+			),
+		)
+	case OTLPGRPCExporter:
+		endpoint := config.Endpoint
+		if endpoint == "" {
+			endpoint = defaultOTLPGRPCEndpoint
+		}
+
+		// The connection is established on the first export.
+		return otlptracegrpc.New(context.Background(),
+			otlptracegrpc.WithEndpoint(endpoint),
+			otlptracegrpc.WithInsecure(),
+		)
+	case OTLPHTTPExporter:
+		opts, err := otlpHTTPOptions(config.Endpoint)
+		if err != nil {
+			return nil, err
+		}
+		return otlptracehttp.New(context.Background(), opts...)
+	case FileExporter:
+		if config.Endpoint == "" {
+			return nil, fmt.Errorf("missing path of the %s tracing exporter", FileExporter)
+		}
+		return newFileExporter(config.Endpoint)
+	case StdoutExporter:
+		return stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	default:
+		return nil, fmt.Errorf("unsupported tracing exporter %q", config.Exporter)
+	}
+}
+
+// otlpHTTPOptions returns the options of the OTLP HTTP exporter sending the
+// spans to the full url of a collector, e.g. http://localhost:4318/v1/traces.
+func otlpHTTPOptions(endpoint string) ([]otlptracehttp.Option, error) {
+	if endpoint == "" {
+		endpoint = defaultOTLPHTTPEndpoint
+	}
+
+	u, err := url.Parse(endpoint)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("invalid OTLP HTTP endpoint %q", endpoint)
+	}
+
+	opts := []otlptracehttp.Option{otlptracehttp.WithEndpoint(u.Host)}
+	if u.Path != "" {
+		opts = append(opts, otlptracehttp.WithURLPath(u.Path))
+	}
+
+	switch u.Scheme {
+	case "http":
+		opts = append(opts, otlptracehttp.WithInsecure())
+	case "https":
+	default:
+		return nil, fmt.Errorf("invalid OTLP HTTP endpoint %q: unsupported scheme %q", endpoint, u.Scheme)
+	}
+
+	return opts, nil
+}
+
+// fileExporter appends the spans as JSON lines to a file, for offline
+// analysis of the traces without a collector.
+type fileExporter struct {
+	*stdouttrace.Exporter
+	file *os.File
+}
+
+func newFileExporter(path string) (*fileExporter, error) {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, err
+	}
+
+	exporter, err := stdouttrace.New(stdouttrace.WithWriter(f))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &fileExporter{Exporter: exporter, file: f}, nil
+}
+
+// Shutdown stops the exporter and closes the file.
+func (e *fileExporter) Shutdown(ctx context.Context) error {
+	err := e.Exporter.Shutdown(ctx)
+	if closeErr := e.file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func newSampler(ratio float64) sdktrace.Sampler {
+	if ratio <= 0 || ratio > 1 {
+		ratio = 1
+	}
+
+	return sdktrace.ParentBased(sdktrace.TraceIDRatioBased(ratio))
 }
 
 // CreateTracer create a tracer
-func CreateTracer(name string, config *JaegerConfig) (*sdktrace.TracerProvider, error) {
+func CreateTracer(name string, config *TracingConfig) (*sdktrace.TracerProvider, error) {
 	if !tracing {
 		otel.SetTracerProvider(trace.NewNoopTracerProvider())
 		return nil, nil
@@ -72,31 +240,24 @@ func CreateTracer(name string, config *JaegerConfig) (*sdktrace.TracerProvider,
 	// build kata exporter to log reporting span records
 	kataExporter := &kataSpanExporter{}
 
-	// build jaeger exporter
-	collectorEndpoint := config.JaegerEndpoint
-	if collectorEndpoint == "" {
-		collectorEndpoint = "http://localhost:14268/api/traces"
-	}
-
-	jaegerExporter, err := jaeger.New(
-		jaeger.WithCollectorEndpoint(jaeger.WithEndpoint(collectorEndpoint),
-			jaeger.WithUsername(config.JaegerUser),
-			jaeger.WithPassword(config.JaegerPassword),
-		),
-	)
-
+	exporter, err := newExporter(config)
 	if err != nil {
 		return nil, err
 	}
 
-	// build tracer provider, that combining both jaeger exporter and kata exporter.
-	tp := sdktrace.NewTracerProvider(
-		sdktrace.WithSampler(sdktrace.AlwaysSample()),
+	exporterName := config.Exporter
+	if exporterName == "" {
+		exporterName = JaegerExporter
+	}
+
+	// build tracer provider, that combining both the configured exporter and kata exporter.
+	tp = sdktrace.NewTracerProvider(
+		sdktrace.WithSampler(newSampler(config.SamplingRatio)),
 		sdktrace.WithSyncer(kataExporter),
-		sdktrace.WithSyncer(jaegerExporter),
+		sdktrace.WithSyncer(exporter),
 		sdktrace.WithResource(resource.NewSchemaless(
 			semconv.ServiceNameKey.String(name),
-			attribute.String("exporter", "jaeger"),
+			attribute.String("exporter", exporterName),
 			attribute.String("lib", "opentelemetry"),
 		)),
 	)
@@ -106,6 +267,50 @@ func CreateTracer(name string, config *JaegerConfig) (*sdktrace.TracerProvider,
 	return tp, nil
 }
 
+// metadataCarrier adapts the metadata of a ttrpc or gRPC request, whose
+// keys are lower case, to a propagation.TextMapCarrier.
+type metadataCarrier map[string][]string
+
+func (c metadataCarrier) Get(key string) string {
+	if values := c[strings.ToLower(key)]; len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+func (c metadataCarrier) Set(key, value string) {
+	c[strings.ToLower(key)] = []string{value}
+}
+
+func (c metadataCarrier) Keys() []string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	return keys
+}
+
+// ExtractTraceContext returns parent with the trace context propagated in the
+// metadata of the incoming ttrpc or gRPC request, so that the spans started
+// from it belong to the trace of the caller. parent is returned unchanged
+// when the request does not carry any trace context.
+func ExtractTraceContext(parent, request context.Context) context.Context {
+	if !tracing || request == nil {
+		return parent
+	}
+
+	var md map[string][]string
+	if ttrpcMD, ok := ttrpc.GetMetadata(request); ok {
+		md = ttrpcMD
+	} else if grpcMD, ok := metadata.FromIncomingContext(request); ok {
+		md = grpcMD
+	} else {
+		return parent
+	}
+
+	return otel.GetTextMapPropagator().Extract(parent, metadataCarrier(md))
+}
+
 // StopTracing ends all tracing, reporting the spans to the collector.
 func StopTracing(ctx context.Context) {
 	if !tracing {
@@ -0,0 +1,173 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package katatrace
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/containerd/ttrpc"
+	"github.com/stretchr/testify/assert"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	testTraceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+	testSpanID  = "00f067aa0ba902b7"
+)
+
+func recordSpan(t *testing.T, exporter sdktrace.SpanExporter, parent context.Context) {
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	_, span := provider.Tracer("kata").Start(parent, "create", trace.WithAttributes(attribute.String("container", "foo")))
+	span.End()
+	assert.NoError(t, provider.Shutdown(context.Background()))
+}
+
+func TestValidExporter(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, name := range []string{"", JaegerExporter, OTLPGRPCExporter, OTLPHTTPExporter, FileExporter, StdoutExporter} {
+		assert.True(ValidExporter(name), name)
+	}
+	assert.False(ValidExporter("zipkin"))
+}
+
+func TestNewSampler(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal("ParentBased{root:TraceIDRatioBased{0.1},remoteParentSampled:AlwaysOnSampler,remoteParentNotSampled:AlwaysOffSampler,localParentSampled:AlwaysOnSampler,localParentNotSampled:AlwaysOffSampler}",
+		newSampler(0.1).Description())
+
+	// An unset ratio samples all the root spans.
+	assert.Contains(newSampler(0).Description(), "root:AlwaysOnSampler")
+}
+
+func TestExtractTraceContext(t *testing.T) {
+	assert := assert.New(t)
+
+	savedTracing := tracing
+	SetTracing(true)
+	defer SetTracing(savedTracing)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	parent := context.Background()
+	assert.Equal(parent, ExtractTraceContext(parent, context.Background()))
+
+	md := ttrpc.MD{}
+	md.Set("traceparent", "00-"+testTraceID+"-"+testSpanID+"-01")
+	ctx := ExtractTraceContext(parent, ttrpc.WithMetadata(context.Background(), md))
+
+	sc := trace.SpanContextFromContext(ctx)
+	assert.True(sc.IsRemote())
+	assert.True(sc.IsSampled())
+	assert.Equal(testTraceID, sc.TraceID().String())
+	assert.Equal(testSpanID, sc.SpanID().String())
+}
+
+func TestFileExporter(t *testing.T) {
+	assert := assert.New(t)
+
+	path := filepath.Join(t.TempDir(), "traces.json")
+
+	// The spans are appended to the file.
+	for i := 0; i < 2; i++ {
+		exporter, err := newFileExporter(path)
+		assert.NoError(err)
+		recordSpan(t, exporter, context.Background())
+	}
+
+	f, err := os.Open(path)
+	assert.NoError(err)
+	defer f.Close()
+
+	var lines int
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var span struct {
+			Name       string
+			Attributes []struct {
+				Key   string
+				Value struct {
+					Value interface{}
+				}
+			}
+		}
+		assert.NoError(json.Unmarshal(scanner.Bytes(), &span))
+		assert.Equal("create", span.Name)
+		assert.Len(span.Attributes, 1)
+		assert.Equal("container", span.Attributes[0].Key)
+		assert.Equal("foo", span.Attributes[0].Value.Value)
+		lines++
+	}
+	assert.NoError(scanner.Err())
+	assert.Equal(2, lines)
+}
+
+func TestOTLPHTTPOptions(t *testing.T) {
+	assert := assert.New(t)
+
+	for _, endpoint := range []string{"", "http://localhost:4318/v1/traces", "https://collector:4318"} {
+		_, err := otlpHTTPOptions(endpoint)
+		assert.NoError(err, endpoint)
+	}
+
+	for _, endpoint := range []string{"localhost:4318", "grpc://localhost:4317", "/v1/traces"} {
+		_, err := otlpHTTPOptions(endpoint)
+		assert.Error(err, endpoint)
+	}
+}
+
+func TestOTLPHTTPExporter(t *testing.T) {
+	assert := assert.New(t)
+
+	requests := make(chan []byte, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal("application/x-protobuf", r.Header.Get("Content-Type"))
+		body, err := io.ReadAll(r.Body)
+		assert.NoError(err)
+		requests <- body
+	}))
+	defer server.Close()
+
+	traceID, err := trace.TraceIDFromHex(testTraceID)
+	assert.NoError(err)
+	spanID, err := trace.SpanIDFromHex(testSpanID)
+	assert.NoError(err)
+	parent := trace.ContextWithRemoteSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID:    traceID,
+		SpanID:     spanID,
+		TraceFlags: trace.FlagsSampled,
+		Remote:     true,
+	}))
+
+	exporter, err := newExporter(&TracingConfig{Exporter: OTLPHTTPExporter, Endpoint: server.URL})
+	assert.NoError(err)
+	recordSpan(t, exporter, parent)
+
+	var request coltracepb.ExportTraceServiceRequest
+	assert.NoError(proto.Unmarshal(<-requests, &request))
+	assert.Len(request.ResourceSpans, 1)
+	assert.Len(request.ResourceSpans[0].InstrumentationLibrarySpans, 1)
+
+	spans := request.ResourceSpans[0].InstrumentationLibrarySpans[0].Spans
+	assert.Len(spans, 1)
+	assert.Equal("create", spans[0].Name)
+	assert.Equal(traceID[:], spans[0].TraceId)
+	assert.Equal(spanID[:], spans[0].ParentSpanId)
+}
@@ -105,11 +105,18 @@ type RuntimeConfig struct {
 	//Experimental features enabled
 	Experimental []exp.Feature
 
-	Console        string
-	JaegerEndpoint string
-	JaegerUser     string
-	JaegerPassword string
-	HypervisorType vc.HypervisorType
+	Console         string
+	JaegerEndpoint  string
+	JaegerUser      string
+	JaegerPassword  string
+	TracingExporter string
+	TracingEndpoint string
+	PersistDriver   string
+	HypervisorType  vc.HypervisorType
+
+	// TracingSamplingRatio is the fraction of the traces started by the
+	// runtime which are sampled.
+	TracingSamplingRatio float64
 
 	FactoryConfig    FactoryConfig
 	HypervisorConfig vc.HypervisorConfig
@@ -521,6 +528,13 @@ func addHypervisorPathOverrides(ocispec specs.Spec, config *vc.SandboxConfig, ru
 		config.HypervisorConfig.JailerPath = value
 	}
 
+	if value, ok := ocispec.Annotations[vcAnnotations.MmdsSecretsPath]; ok {
+		if !checkPathIsInGlobs(runtime.HypervisorConfig.MmdsSecretsPathList, value) {
+			return fmt.Errorf("metadata secrets path %v required from annotation is not valid", value)
+		}
+		config.HypervisorConfig.MmdsSecretsPath = value
+	}
+
 	if value, ok := ocispec.Annotations[vcAnnotations.CtlPath]; ok {
 		if !checkPathIsInGlobs(runtime.HypervisorConfig.HypervisorCtlPathList, value) {
 			return fmt.Errorf("hypervisor control %v required from annotation is not valid", value)
@@ -824,6 +838,14 @@ func addRuntimeConfigOverrides(ocispec specs.Spec, sbConfig *vc.SandboxConfig, r
 		}
 	}
 
+	if value, ok := ocispec.Annotations[vcAnnotations.MigrationIncomingURI]; ok {
+		sbConfig.HypervisorConfig.MigrationIncomingURI = value
+	}
+
+	if value, ok := ocispec.Annotations[vcAnnotations.MigrationIncomingStatePath]; ok {
+		sbConfig.HypervisorConfig.MigrationIncomingStatePath = value
+	}
+
 	return nil
 }
 
@@ -876,6 +898,8 @@ func SandboxConfig(ocispec specs.Spec, runtime RuntimeConfig, bundlePath, cid, c
 
 		Hostname: ocispec.Hostname,
 
+		PersistDriver: runtime.PersistDriver,
+
 		HypervisorType:   runtime.HypervisorType,
 		HypervisorConfig: runtime.HypervisorConfig,
 
@@ -919,8 +943,9 @@ func SandboxConfig(ocispec specs.Spec, runtime RuntimeConfig, bundlePath, cid, c
 
 	// If we are utilizing static resource management for the sandbox, ensure that the hypervisor is started
 	// with the base number of CPU/memory (which is equal to the default CPU/memory specified for the runtime
-	// configuration or annotations) as well as any specified workload resources.
-	if sandboxConfig.StaticResourceMgmt {
+	// configuration or annotations) as well as any specified workload resources. Firecracker can't resize a
+	// running VM, so it is always started this way.
+	if sandboxConfig.StaticResourceMgmt || sandboxConfig.HypervisorType == vc.FirecrackerHypervisor {
 		sandboxConfig.SandboxResources.BaseCPUs = sandboxConfig.HypervisorConfig.NumVCPUs
 		sandboxConfig.SandboxResources.BaseMemMB = sandboxConfig.HypervisorConfig.MemorySize
 
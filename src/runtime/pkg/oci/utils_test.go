@@ -188,6 +188,45 @@ func TestMinimalSandboxConfig(t *testing.T) {
 	assert.NoError(os.Remove(configPath))
 }
 
+func TestFirecrackerSandboxConfigSizing(t *testing.T) {
+	assert := assert.New(t)
+	configPath, err := createConfig("config.json", minimalConfig)
+	assert.NoError(err)
+	defer os.Remove(configPath)
+
+	savedFunc := config.GetHostPathFunc
+	config.GetHostPathFunc = func(devInfo config.DeviceInfo, vhostUserStoreEnabled bool,
+		vhostUserStorePath string) (string, error) {
+		return devInfo.ContainerPath, nil
+	}
+	defer func() {
+		config.GetHostPathFunc = savedFunc
+	}()
+
+	spec, err := compatoci.ParseConfigJSON(tempBundlePath)
+	assert.NoError(err)
+
+	runtimeConfig := RuntimeConfig{
+		HypervisorType: vc.FirecrackerHypervisor,
+		HypervisorConfig: vc.HypervisorConfig{
+			NumVCPUs:   1,
+			MemorySize: 2048,
+		},
+		Console:      consolePath,
+		SandboxCPUs:  2,
+		SandboxMemMB: 512,
+	}
+
+	// Firecracker can't resize a running VM, so it is started with the
+	// workload resources even without static resource management.
+	sandboxConfig, err := SandboxConfig(spec, runtimeConfig, tempBundlePath, containerID, consolePath, false, true)
+	assert.NoError(err)
+	assert.Equal(uint32(3), sandboxConfig.HypervisorConfig.NumVCPUs)
+	assert.Equal(uint32(2560), sandboxConfig.HypervisorConfig.MemorySize)
+	assert.Equal(uint32(1), sandboxConfig.SandboxResources.BaseCPUs)
+	assert.Equal(uint32(2048), sandboxConfig.SandboxResources.BaseMemMB)
+}
+
 func TestContainerType(t *testing.T) {
 	assert := assert.New(t)
 	tests := []struct {
@@ -545,6 +584,39 @@ func TestAddAssetAnnotations(t *testing.T) {
 	assert.Exactly(expectedAnnotations, config.Annotations)
 }
 
+func TestAddMmdsSecretsPathAnnotation(t *testing.T) {
+	assert := assert.New(t)
+
+	secretsPath := filepath.Join(t.TempDir(), "secrets")
+	err := os.Mkdir(secretsPath, dirMode)
+	assert.NoError(err)
+
+	config := vc.SandboxConfig{
+		Annotations: make(map[string]string),
+	}
+
+	ocispec := specs.Spec{
+		Annotations: map[string]string{
+			vcAnnotations.MmdsSecretsPath: secretsPath,
+		},
+	}
+
+	runtimeConfig := RuntimeConfig{
+		HypervisorType: vc.FirecrackerHypervisor,
+		Console:        consolePath,
+	}
+	runtimeConfig.HypervisorConfig.EnableAnnotations = []string{"mmds_secrets_path"}
+
+	// The secrets path must be allowed by the configuration
+	err = addAnnotations(ocispec, &config, runtimeConfig)
+	assert.Error(err)
+
+	runtimeConfig.HypervisorConfig.MmdsSecretsPathList = []string{filepath.Dir(secretsPath) + "/*"}
+	err = addAnnotations(ocispec, &config, runtimeConfig)
+	assert.NoError(err)
+	assert.Equal(secretsPath, config.HypervisorConfig.MmdsSecretsPath)
+}
+
 func TestAddAgentAnnotations(t *testing.T) {
 	assert := assert.New(t)
 
@@ -814,12 +886,16 @@ func TestAddRuntimeAnnotations(t *testing.T) {
 	ocispec.Annotations[vcAnnotations.SandboxCgroupOnly] = "true"
 	ocispec.Annotations[vcAnnotations.DisableNewNetNs] = "true"
 	ocispec.Annotations[vcAnnotations.InterNetworkModel] = "macvtap"
+	ocispec.Annotations[vcAnnotations.MigrationIncomingURI] = "tcp:0:4444"
+	ocispec.Annotations[vcAnnotations.MigrationIncomingStatePath] = "/run/migration"
 
 	addAnnotations(ocispec, &config, runtimeConfig)
 	assert.Equal(config.DisableGuestSeccomp, true)
 	assert.Equal(config.SandboxCgroupOnly, true)
 	assert.Equal(config.NetworkConfig.DisableNewNetwork, true)
 	assert.Equal(config.NetworkConfig.InterworkingModel, vc.NetXConnectMacVtapModel)
+	assert.Equal(config.HypervisorConfig.MigrationIncomingURI, "tcp:0:4444")
+	assert.Equal(config.HypervisorConfig.MigrationIncomingStatePath, "/run/migration")
 }
 
 func TestRegexpContains(t *testing.T) {
@@ -15,7 +15,6 @@ import (
 	"sync"
 
 	"github.com/containerd/cgroups"
-	v1 "github.com/containerd/cgroups/stats/v1"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
 )
@@ -126,9 +125,35 @@ func sandboxDevices() []specs.LinuxDeviceCgroup {
 	return devices
 }
 
+// JailDevices returns the devices cgroup rules of a jailed hypervisor, which
+// denies the access to all the devices but the listed ones.
+func JailDevices(allowed []string) []specs.LinuxDeviceCgroup {
+	devices := []specs.LinuxDeviceCgroup{
+		{
+			Allow:  false,
+			Access: "rwm",
+		},
+	}
+
+	for _, device := range allowed {
+		ldevice, err := DeviceToLinuxDevice(device)
+		if err != nil {
+			controllerLogger.WithField("source", "cgroups").Warnf("Could not add %s to the devices cgroup", device)
+			continue
+		}
+		devices = append(devices, ldevice)
+	}
+
+	return devices
+}
+
 func NewResourceController(path string, resources *specs.LinuxResources) (ResourceController, error) {
 	var err error
 
+	if IsCgroupV2() {
+		return newUnifiedCgroup(path, resources)
+	}
+
 	cgroupPath, err := ValidCgroupPath(path, IsSystemdCgroup(path))
 	if err != nil {
 		return nil, err
@@ -152,6 +177,10 @@ func NewSandboxResourceController(path string, resources *specs.LinuxResources,
 	sandboxResources := *resources
 	sandboxResources.Devices = append(sandboxResources.Devices, sandboxDevices()...)
 
+	if IsCgroupV2() {
+		return newSandboxUnifiedCgroup(path, &sandboxResources, sandboxCgroupOnly)
+	}
+
 	// Currently we know to handle systemd cgroup path only when it's the only cgroup (no overhead group), hence,
 	// if sandboxCgroupOnly is not true we treat it as cgroupfs path as it used to be, although it may be incorrect
 	if !IsSystemdCgroup(path) || !sandboxCgroupOnly {
@@ -193,6 +222,10 @@ func NewSandboxResourceController(path string, resources *specs.LinuxResources,
 }
 
 func LoadResourceController(path string) (ResourceController, error) {
+	if IsCgroupV2() {
+		return loadUnifiedCgroup(path)
+	}
+
 	cgHierarchy, cgPath, err := cgroupHierarchy(path)
 	if err != nil {
 		return nil, err
@@ -217,16 +250,58 @@ func (c *LinuxCgroup) Delete() error {
 	return c.cgroup.Delete()
 }
 
-func (c *LinuxCgroup) Stat() (*v1.Metrics, error) {
-	return c.cgroup.Stat(cgroups.ErrorHandler(cgroups.IgnoreNotExist))
+func (c *LinuxCgroup) Stat() (*Metrics, error) {
+	metrics, err := c.cgroup.Stat(cgroups.ErrorHandler(cgroups.IgnoreNotExist))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metrics{V1: metrics}, nil
 }
 
 func (c *LinuxCgroup) AddProcess(pid int, subsystems ...string) error {
-	return c.cgroup.Add(cgroups.Process{Pid: pid})
+	return c.cgroup.Add(cgroups.Process{Pid: pid}, subsystemNames(subsystems)...)
 }
 
 func (c *LinuxCgroup) AddThread(pid int, subsystems ...string) error {
-	return c.cgroup.AddTask(cgroups.Process{Pid: pid})
+	return c.cgroup.AddTask(cgroups.Process{Pid: pid}, subsystemNames(subsystems)...)
+}
+
+// SubsystemsExcept returns the names of the cgroups v1 subsystems of the
+// host, but the excluded ones. The unified hierarchy has no subsystems, for
+// which it returns nil.
+func SubsystemsExcept(excluded ...string) ([]string, error) {
+	if IsCgroupV2() {
+		return nil, nil
+	}
+
+	subsystems, err := cgroups.V1()
+	if err != nil {
+		return nil, err
+	}
+
+	skip := make(map[string]bool)
+	for _, name := range excluded {
+		skip[name] = true
+	}
+
+	var names []string
+	for _, s := range subsystems {
+		if name := string(s.Name()); !skip[name] {
+			names = append(names, name)
+		}
+	}
+
+	return names, nil
+}
+
+func subsystemNames(subsystems []string) []cgroups.Name {
+	names := make([]cgroups.Name, 0, len(subsystems))
+	for _, s := range subsystems {
+		names = append(names, cgroups.Name(s))
+	}
+
+	return names
 }
 
 func (c *LinuxCgroup) Update(resources *specs.LinuxResources) error {
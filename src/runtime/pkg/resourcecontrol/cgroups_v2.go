@@ -0,0 +1,560 @@
+//go:build linux
+// +build linux
+
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package resourcecontrol
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+
+	libcgroups "github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/cgroups/fs2"
+	"github.com/opencontainers/runc/libcontainer/cgroups/systemd"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/devices"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/sirupsen/logrus"
+)
+
+// UnifiedMountpoint is where the cgroups v2 unified hierarchy is mounted.
+var UnifiedMountpoint = "/sys/fs/cgroup"
+
+// UnifiedCgroup is a resource controller for the cgroups v2 unified
+// hierarchy.
+type UnifiedCgroup struct {
+	manager libcgroups.Manager
+	// path is the path of the controller, as given to its constructor.
+	path string
+	// group is the path of the cgroup, relative to UnifiedMountpoint.
+	group string
+	// resources are the resources of the cgroup, which the updates of
+	// its devices and cpusets are merged into.
+	resources specs.LinuxResources
+
+	sync.Mutex
+}
+
+// copyResources returns a copy of OCI resources, which doesn't share the
+// devices and CPU resources that a controller updates.
+func copyResources(resources *specs.LinuxResources) specs.LinuxResources {
+	r := *resources
+	r.Devices = append([]specs.LinuxDeviceCgroup(nil), resources.Devices...)
+	if resources.CPU != nil {
+		cpu := *resources.CPU
+		r.CPU = &cpu
+	}
+
+	return r
+}
+
+// unifiedCgroupGroup returns the path of the cgroup of a controller in the
+// unified hierarchy, relative to its mount point.
+func unifiedCgroupGroup(path string) (string, error) {
+	if !IsSystemdCgroup(path) {
+		return path, nil
+	}
+
+	slice, unit, err := getSliceAndUnit(path)
+	if err != nil {
+		return "", err
+	}
+
+	slicePath, err := systemd.ExpandSlice(slice)
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(slicePath, unit), nil
+}
+
+// CurrentUnifiedCgroup returns the cgroup of the current process in the
+// unified hierarchy, relative to its mount point.
+func CurrentUnifiedCgroup() (string, error) {
+	groups, err := libcgroups.ParseCgroupFile("/proc/self/cgroup")
+	if err != nil {
+		return "", err
+	}
+
+	group, ok := groups[""]
+	if !ok {
+		return "", fmt.Errorf("no cgroups v2 cgroup for the current process")
+	}
+
+	return group, nil
+}
+
+func newUnifiedCgroupManager(group string) (libcgroups.Manager, error) {
+	return fs2.NewManager(&configs.Cgroup{
+		Resources: &configs.Resources{},
+	}, filepath.Join(UnifiedMountpoint, group), false)
+}
+
+func newUnifiedCgroup(path string, resources *specs.LinuxResources) (ResourceController, error) {
+	cgroupPath, err := ValidCgroupPath(path, IsSystemdCgroup(path))
+	if err != nil {
+		return nil, err
+	}
+
+	group, err := unifiedCgroupGroup(cgroupPath)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, err := newUnifiedCgroupManager(group)
+	if err != nil {
+		return nil, err
+	}
+
+	// Create the cgroup, enabling its controllers, without adding any
+	// process to it.
+	if err := manager.Apply(-1); err != nil {
+		return nil, err
+	}
+
+	if err := manager.Set(unifiedResources(resources)); err != nil {
+		return nil, err
+	}
+
+	return &UnifiedCgroup{
+		manager:   manager,
+		path:      cgroupPath,
+		group:     group,
+		resources: copyResources(resources),
+	}, nil
+}
+
+func newSandboxUnifiedCgroup(path string, resources *specs.LinuxResources, sandboxCgroupOnly bool) (ResourceController, error) {
+	// Without sandbox_cgroup_only, the vCPU threads are moved to the
+	// sandbox cgroup, apart from the other VMM threads. cgroups v2 only
+	// allows that in a threaded subtree, in which most controllers,
+	// including the memory one, are unavailable.
+	if !sandboxCgroupOnly {
+		return nil, fmt.Errorf("sandbox_cgroup_only=false is not supported with cgroups v2")
+	}
+
+	if !IsSystemdCgroup(path) {
+		return newUnifiedCgroup(path, resources)
+	}
+
+	slice, unit, err := getSliceAndUnit(path)
+	if err != nil {
+		return nil, err
+	}
+
+	// systemd delegates the scope cgroup to the runtime, which then
+	// manages it like any other cgroup.
+	if err := createCgroupsSystemd(slice, unit, uint32(os.Getpid())); err != nil {
+		return nil, err
+	}
+
+	group, err := unifiedCgroupGroup(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, err := newUnifiedCgroupManager(group)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := manager.Set(unifiedResources(resources)); err != nil {
+		return nil, err
+	}
+
+	return &UnifiedCgroup{
+		manager:   manager,
+		path:      path,
+		group:     group,
+		resources: copyResources(resources),
+	}, nil
+}
+
+func loadUnifiedCgroup(path string) (ResourceController, error) {
+	group, err := unifiedCgroupGroup(path)
+	if err != nil {
+		return nil, err
+	}
+
+	manager, err := newUnifiedCgroupManager(group)
+	if err != nil {
+		return nil, err
+	}
+
+	if !manager.Exists() {
+		return nil, fmt.Errorf("cgroup %s does not exist", group)
+	}
+
+	return &UnifiedCgroup{
+		manager: manager,
+		path:    path,
+		group:   group,
+	}, nil
+}
+
+// unifiedDeviceRules converts OCI devices cgroup rules to the rules of the
+// cgroups v2 eBPF device filter.
+//
+// cgroups v1 rules apply on top of those of the parent cgroup, which
+// usually allows all the devices, while the cgroups v2 device filter denies
+// any device it doesn't allow. Hence, unless they start with a rule for all
+// devices, the rules start from one allowing all of them.
+func unifiedDeviceRules(cgroupDevices []specs.LinuxDeviceCgroup) []*devices.Rule {
+	var rules []*devices.Rule
+
+	wildcard := func(d specs.LinuxDeviceCgroup) bool {
+		return (d.Type == "" || d.Type == "a") && d.Major == nil && d.Minor == nil
+	}
+
+	if len(cgroupDevices) == 0 || !wildcard(cgroupDevices[0]) {
+		rules = append(rules, &devices.Rule{
+			Type:        devices.WildcardDevice,
+			Major:       devices.Wildcard,
+			Minor:       devices.Wildcard,
+			Permissions: "rwm",
+			Allow:       true,
+		})
+	}
+
+	for _, d := range cgroupDevices {
+		rule := &devices.Rule{
+			Type:        devices.WildcardDevice,
+			Major:       devices.Wildcard,
+			Minor:       devices.Wildcard,
+			Permissions: devices.Permissions(d.Access),
+			Allow:       d.Allow,
+		}
+		if d.Type != "" {
+			rule.Type = devices.Type(d.Type[0])
+		}
+		if d.Major != nil {
+			rule.Major = *d.Major
+		}
+		if d.Minor != nil {
+			rule.Minor = *d.Minor
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules
+}
+
+// unifiedResources converts OCI resources to those of a cgroups v2 cgroup.
+// Only the resources set are updated, and the devices are only controlled
+// when rules are given.
+//
+// A CPU quota or period is set as cpu.max, -1 as quota meaning no limit,
+// and the resources without cgroups v1 equivalent, such as memory.high, are
+// set through the OCI unified resources.
+func unifiedResources(resources *specs.LinuxResources) *configs.Resources {
+	r := &configs.Resources{
+		SkipDevices: len(resources.Devices) == 0,
+		Unified:     resources.Unified,
+	}
+
+	if !r.SkipDevices {
+		r.Devices = unifiedDeviceRules(resources.Devices)
+	}
+
+	if mem := resources.Memory; mem != nil {
+		if mem.Limit != nil {
+			r.Memory = *mem.Limit
+		}
+		if mem.Reservation != nil {
+			r.MemoryReservation = *mem.Reservation
+		}
+		if mem.Swap != nil {
+			r.MemorySwap = *mem.Swap
+		}
+	}
+
+	if cpu := resources.CPU; cpu != nil {
+		if cpu.Shares != nil {
+			r.CpuWeight = libcgroups.ConvertCPUSharesToCgroupV2Value(*cpu.Shares)
+		}
+		if cpu.Quota != nil {
+			r.CpuQuota = *cpu.Quota
+		}
+		if cpu.Period != nil {
+			r.CpuPeriod = *cpu.Period
+		}
+		r.CpusetCpus = cpu.Cpus
+		r.CpusetMems = cpu.Mems
+	}
+
+	if pids := resources.Pids; pids != nil {
+		r.PidsLimit = pids.Limit
+	}
+
+	if blkio := resources.BlockIO; blkio != nil {
+		if blkio.Weight != nil {
+			r.BlkioWeight = *blkio.Weight
+		}
+
+		throttle := func(devices []specs.LinuxThrottleDevice) []*configs.ThrottleDevice {
+			var throttleDevices []*configs.ThrottleDevice
+			for _, d := range devices {
+				throttleDevices = append(throttleDevices, configs.NewThrottleDevice(d.Major, d.Minor, d.Rate))
+			}
+			return throttleDevices
+		}
+
+		r.BlkioThrottleReadBpsDevice = throttle(blkio.ThrottleReadBpsDevice)
+		r.BlkioThrottleWriteBpsDevice = throttle(blkio.ThrottleWriteBpsDevice)
+		r.BlkioThrottleReadIOPSDevice = throttle(blkio.ThrottleReadIOPSDevice)
+		r.BlkioThrottleWriteIOPSDevice = throttle(blkio.ThrottleWriteIOPSDevice)
+	}
+
+	for _, l := range resources.HugepageLimits {
+		r.HugetlbLimit = append(r.HugetlbLimit, &configs.HugepageLimit{
+			Pagesize: l.Pagesize,
+			Limit:    l.Limit,
+		})
+	}
+
+	return r
+}
+
+// parsePSIData parses a line of a pressure file, such as
+// "some avg10=0.00 avg60=0.00 avg300=0.00 total=0".
+func parsePSIData(fields []string) (PSIData, error) {
+	var data PSIData
+
+	for _, field := range fields {
+		kv := strings.SplitN(field, "=", 2)
+		if len(kv) != 2 {
+			return data, fmt.Errorf("invalid PSI field %q", field)
+		}
+
+		var err error
+		switch kv[0] {
+		case "avg10":
+			data.Avg10, err = strconv.ParseFloat(kv[1], 64)
+		case "avg60":
+			data.Avg60, err = strconv.ParseFloat(kv[1], 64)
+		case "avg300":
+			data.Avg300, err = strconv.ParseFloat(kv[1], 64)
+		case "total":
+			data.Total, err = strconv.ParseUint(kv[1], 10, 64)
+		}
+		if err != nil {
+			return data, fmt.Errorf("invalid PSI field %q: %v", field, err)
+		}
+	}
+
+	return data, nil
+}
+
+// readPSIStats reads a pressure file of a cgroup, e.g. cpu.pressure. It
+// returns nil if the kernel doesn't report the pressure.
+func readPSIStats(dirPath, file string) (*PSIStats, error) {
+	f, err := os.Open(filepath.Join(dirPath, file))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	stats := &PSIStats{}
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		data, err := parsePSIData(fields[1:])
+		if err != nil {
+			return nil, err
+		}
+
+		switch fields[0] {
+		case "some":
+			stats.Some = data
+		case "full":
+			stats.Full = data
+		}
+	}
+
+	return stats, scanner.Err()
+}
+
+func readPressureStats(dirPath string) (*PressureStats, error) {
+	var err error
+	pressure := &PressureStats{}
+
+	if pressure.CPU, err = readPSIStats(dirPath, "cpu.pressure"); err != nil {
+		return nil, err
+	}
+	if pressure.Memory, err = readPSIStats(dirPath, "memory.pressure"); err != nil {
+		return nil, err
+	}
+	if pressure.IO, err = readPSIStats(dirPath, "io.pressure"); err != nil {
+		return nil, err
+	}
+
+	return pressure, nil
+}
+
+func (c *UnifiedCgroup) Logger() *logrus.Entry {
+	return controllerLogger.WithField("source", "cgroups-v2")
+}
+
+func (c *UnifiedCgroup) Delete() error {
+	return c.manager.Destroy()
+}
+
+func (c *UnifiedCgroup) Stat() (*Metrics, error) {
+	stats, err := c.manager.GetStats()
+	if err != nil {
+		return nil, err
+	}
+
+	pressure, err := readPressureStats(c.manager.Path(""))
+	if err != nil {
+		return nil, err
+	}
+
+	return &Metrics{V2: stats, Pressure: pressure}, nil
+}
+
+// AddProcess adds a process to the cgroup. The unified hierarchy has no
+// subsystems, which are ignored.
+func (c *UnifiedCgroup) AddProcess(pid int, subsystems ...string) error {
+	return libcgroups.WriteCgroupProc(c.manager.Path(""), pid)
+}
+
+// AddThread is not supported: the unified hierarchy only allows moving
+// the threads of a process to different cgroups in a threaded subtree.
+func (c *UnifiedCgroup) AddThread(pid int, subsystems ...string) error {
+	return fmt.Errorf("cannot add thread %d to %s: threads can't be added to cgroups v2 controllers", pid, c.group)
+}
+
+// Update updates the resources of the cgroup. The devices previously set
+// are kept if the update doesn't set any.
+func (c *UnifiedCgroup) Update(resources *specs.LinuxResources) error {
+	c.Lock()
+	defer c.Unlock()
+
+	updated := copyResources(resources)
+	if len(updated.Devices) == 0 {
+		updated.Devices = c.resources.Devices
+	}
+
+	return c.set(updated)
+}
+
+func (c *UnifiedCgroup) MoveTo(path string) error {
+	group, err := unifiedCgroupGroup(path)
+	if err != nil {
+		return err
+	}
+
+	pids, err := c.manager.GetPids()
+	if err != nil {
+		return err
+	}
+
+	dirPath := filepath.Join(UnifiedMountpoint, group)
+	for _, pid := range pids {
+		if err := libcgroups.WriteCgroupProc(dirPath, pid); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (c *UnifiedCgroup) AddDevice(deviceHostPath string) error {
+	deviceResource, err := DeviceToLinuxDevice(deviceHostPath)
+	if err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	resources := copyResources(&c.resources)
+	resources.Devices = append(resources.Devices, deviceResource)
+
+	return c.set(resources)
+}
+
+func (c *UnifiedCgroup) RemoveDevice(deviceHostPath string) error {
+	deviceResource, err := DeviceToLinuxDevice(deviceHostPath)
+	if err != nil {
+		return err
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	resources := copyResources(&c.resources)
+	resources.Devices = nil
+	for _, d := range c.resources.Devices {
+		if d.Type == deviceResource.Type &&
+			reflect.DeepEqual(d.Major, deviceResource.Major) &&
+			reflect.DeepEqual(d.Minor, deviceResource.Minor) {
+			continue
+		}
+		resources.Devices = append(resources.Devices, d)
+	}
+
+	return c.set(resources)
+}
+
+func (c *UnifiedCgroup) UpdateCpuSet(cpuset, memset string) error {
+	c.Lock()
+	defer c.Unlock()
+
+	resources := copyResources(&c.resources)
+	if resources.CPU == nil {
+		resources.CPU = &specs.LinuxCPU{}
+	}
+
+	if len(cpuset) > 0 {
+		resources.CPU.Cpus = cpuset
+	}
+
+	if len(memset) > 0 {
+		resources.CPU.Mems = memset
+	}
+
+	return c.set(resources)
+}
+
+// set sets the resources of the cgroup, which are kept if they are
+// successfully set. The caller must hold the controller lock.
+func (c *UnifiedCgroup) set(resources specs.LinuxResources) error {
+	if err := c.manager.Set(unifiedResources(&resources)); err != nil {
+		return err
+	}
+
+	c.resources = resources
+
+	return nil
+}
+
+func (c *UnifiedCgroup) Type() ResourceControllerType {
+	return LinuxUnifiedCgroups
+}
+
+func (c *UnifiedCgroup) ID() string {
+	return c.path
+}
+
+func (c *UnifiedCgroup) Parent() string {
+	return filepath.Dir(c.path)
+}
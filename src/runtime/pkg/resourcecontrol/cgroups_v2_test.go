@@ -0,0 +1,174 @@
+//go:build linux
+// +build linux
+
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package resourcecontrol
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	libcgroups "github.com/opencontainers/runc/libcontainer/cgroups"
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/devices"
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUnifiedCgroupGroup(t *testing.T) {
+	assert := assert.New(t)
+
+	group, err := unifiedCgroupGroup("/kata/afhts2e5d4g5s")
+	assert.NoError(err)
+	assert.Equal("/kata/afhts2e5d4g5s", group)
+
+	group, err = unifiedCgroupGroup("system-kata.slice:kata:afhts2e5d4g5s")
+	assert.NoError(err)
+	assert.Equal("/system.slice/system-kata.slice/kata-afhts2e5d4g5s.scope", group)
+}
+
+func TestNewSandboxUnifiedCgroupNotSandboxCgroupOnly(t *testing.T) {
+	_, err := newSandboxUnifiedCgroup("/kata/afhts2e5d4g5s", &specs.LinuxResources{}, false)
+	assert.Error(t, err)
+}
+
+func TestCopyResources(t *testing.T) {
+	assert := assert.New(t)
+
+	major := int64(10)
+	resources := &specs.LinuxResources{
+		Devices: []specs.LinuxDeviceCgroup{{Type: "c", Major: &major, Access: "rwm", Allow: true}},
+		CPU:     &specs.LinuxCPU{Cpus: "0-1"},
+	}
+
+	r := copyResources(resources)
+	assert.Equal(*resources, r)
+
+	r.Devices[0].Allow = false
+	r.CPU.Cpus = "2"
+	assert.True(resources.Devices[0].Allow)
+	assert.Equal("0-1", resources.CPU.Cpus)
+}
+
+func TestUnifiedDeviceRules(t *testing.T) {
+	assert := assert.New(t)
+
+	major := int64(10)
+	minor := int64(200)
+	tun := specs.LinuxDeviceCgroup{
+		Allow:  true,
+		Type:   "c",
+		Major:  &major,
+		Minor:  &minor,
+		Access: "rwm",
+	}
+	tunRule := &devices.Rule{
+		Type:        devices.CharDevice,
+		Major:       major,
+		Minor:       minor,
+		Permissions: "rwm",
+		Allow:       true,
+	}
+
+	// Rules relative to the parent ones start from allowing all devices.
+	rules := unifiedDeviceRules([]specs.LinuxDeviceCgroup{tun})
+	assert.Equal([]*devices.Rule{
+		{
+			Type:        devices.WildcardDevice,
+			Major:       devices.Wildcard,
+			Minor:       devices.Wildcard,
+			Permissions: "rwm",
+			Allow:       true,
+		},
+		tunRule,
+	}, rules)
+
+	// Jailed rules start from denying all devices.
+	rules = unifiedDeviceRules([]specs.LinuxDeviceCgroup{{Allow: false, Access: "rwm"}, tun})
+	assert.Equal([]*devices.Rule{
+		{
+			Type:        devices.WildcardDevice,
+			Major:       devices.Wildcard,
+			Minor:       devices.Wildcard,
+			Permissions: "rwm",
+			Allow:       false,
+		},
+		tunRule,
+	}, rules)
+}
+
+func TestUnifiedResources(t *testing.T) {
+	assert := assert.New(t)
+
+	limit := int64(512 << 20)
+	shares := uint64(1024)
+	quota := int64(-1)
+	period := uint64(100000)
+	resources := &specs.LinuxResources{
+		Memory: &specs.LinuxMemory{Limit: &limit},
+		CPU: &specs.LinuxCPU{
+			Shares: &shares,
+			Quota:  &quota,
+			Period: &period,
+			Cpus:   "0-1",
+		},
+		Unified: map[string]string{"memory.high": "268435456"},
+	}
+
+	r := unifiedResources(resources)
+	assert.True(r.SkipDevices)
+	assert.Equal(limit, r.Memory)
+	assert.Equal(uint64(39), r.CpuWeight)
+	assert.Equal(quota, r.CpuQuota)
+	assert.Equal(period, r.CpuPeriod)
+	assert.Equal("0-1", r.CpusetCpus)
+	assert.Equal("268435456", r.Unified["memory.high"])
+
+	blockIO := &specs.LinuxBlockIO{}
+	blockIO.ThrottleReadBpsDevice = []specs.LinuxThrottleDevice{{Rate: 1 << 20}}
+	blockIO.ThrottleReadBpsDevice[0].Major = 8
+	r = unifiedResources(&specs.LinuxResources{BlockIO: blockIO})
+	assert.Equal([]*configs.ThrottleDevice{configs.NewThrottleDevice(8, 0, 1<<20)}, r.BlkioThrottleReadBpsDevice)
+}
+
+func TestReadPressureStats(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, "cpu.pressure"), []byte(
+		"some avg10=1.50 avg60=0.75 avg300=0.25 total=123456\n"+
+			"full avg10=0.00 avg60=0.00 avg300=0.00 total=0\n"), 0644)
+	assert.NoError(err)
+
+	pressure, err := readPressureStats(dir)
+	assert.NoError(err)
+	assert.Equal(&PSIStats{
+		Some: PSIData{Avg10: 1.5, Avg60: 0.75, Avg300: 0.25, Total: 123456},
+	}, pressure.CPU)
+
+	// The kernel may not report the pressure of all the resources.
+	assert.Nil(pressure.Memory)
+	assert.Nil(pressure.IO)
+
+	err = os.WriteFile(filepath.Join(dir, "io.pressure"), []byte("some avg10\n"), 0644)
+	assert.NoError(err)
+	_, err = readPressureStats(dir)
+	assert.Error(err)
+}
+
+func TestMetricsUsageV2(t *testing.T) {
+	assert := assert.New(t)
+
+	stats := libcgroups.NewStats()
+	stats.CpuStats.CpuUsage.TotalUsage = 42000
+	stats.MemoryStats.Usage.Usage = 1 << 20
+
+	metrics := &Metrics{V2: stats}
+	assert.Equal(uint64(42000), metrics.CPUUsage())
+	assert.Equal(uint64(1<<20), metrics.MemoryUsage())
+}
@@ -7,6 +7,7 @@ package resourcecontrol
 
 import (
 	v1 "github.com/containerd/cgroups/stats/v1"
+	libcgroups "github.com/opencontainers/runc/libcontainer/cgroups"
 	"github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/sirupsen/logrus"
 )
@@ -26,7 +27,8 @@ func SetLogger(logger *logrus.Entry) {
 type ResourceControllerType string
 
 const (
-	LinuxCgroups ResourceControllerType = "cgroups"
+	LinuxCgroups        ResourceControllerType = "cgroups"
+	LinuxUnifiedCgroups ResourceControllerType = "cgroups-v2"
 )
 
 // String converts a resource type to a string.
@@ -34,11 +36,71 @@ func (rType *ResourceControllerType) String() string {
 	switch *rType {
 	case LinuxCgroups:
 		return string(LinuxCgroups)
+	case LinuxUnifiedCgroups:
+		return string(LinuxUnifiedCgroups)
 	default:
 		return "Unknown controller type"
 	}
 }
 
+// PSIData is the pressure stall information of tasks stalled on a resource.
+// The averages are the percentages of time stalled over the last 10, 60 and
+// 300 seconds, and the total is the time stalled in microseconds.
+type PSIData struct {
+	Avg10  float64
+	Avg60  float64
+	Avg300 float64
+	Total  uint64
+}
+
+// PSIStats is the pressure stall information of a resource, for some of
+// the tasks and for all of them.
+type PSIStats struct {
+	Some PSIData
+	Full PSIData
+}
+
+// PressureStats is the pressure stall information of a controller, for the
+// resources the kernel reports it for.
+type PressureStats struct {
+	CPU    *PSIStats
+	Memory *PSIStats
+	IO     *PSIStats
+}
+
+// Metrics are the statistics of a controller. Only the metrics of the
+// cgroups version of the controller are set, and the pressure stall
+// information is only available with cgroups v2.
+type Metrics struct {
+	V1       *v1.Metrics
+	V2       *libcgroups.Stats
+	Pressure *PressureStats
+}
+
+// CPUUsage returns the CPU time consumed, in nanoseconds.
+func (m *Metrics) CPUUsage() uint64 {
+	switch {
+	case m.V1 != nil && m.V1.CPU != nil && m.V1.CPU.Usage != nil:
+		return m.V1.CPU.Usage.Total
+	case m.V2 != nil:
+		return m.V2.CpuStats.CpuUsage.TotalUsage
+	}
+
+	return 0
+}
+
+// MemoryUsage returns the memory used, in bytes.
+func (m *Metrics) MemoryUsage() uint64 {
+	switch {
+	case m.V1 != nil && m.V1.Memory != nil && m.V1.Memory.Usage != nil:
+		return m.V1.Memory.Usage.Usage
+	case m.V2 != nil:
+		return m.V2.MemoryStats.Usage.Usage
+	}
+
+	return 0
+}
+
 // ResourceController represents a system resources controller.
 // On Linux this interface is implemented through the cgroups API.
 type ResourceController interface {
@@ -56,7 +118,7 @@ type ResourceController interface {
 	Delete() error
 
 	// Stat returns the statistics for the controller.
-	Stat() (*v1.Metrics, error)
+	Stat() (*Metrics, error)
 
 	// AddProcess adds a process to a set of controllers.
 	AddProcess(int, ...string) error
@@ -43,6 +43,12 @@ func ValidCgroupPath(path string, systemdCgroup bool) (string, error) {
 	return filepath.Join(DefaultResourceControllerID, filepath.Clean("/"+path)), nil
 }
 
+// IsCgroupV2 returns whether the host only mounts the cgroups v2 unified
+// hierarchy, in which case the resource controllers are cgroups v2 ones.
+func IsCgroupV2() bool {
+	return cgroups.Mode() == cgroups.Unified
+}
+
 func newProperty(name string, units interface{}) systemdDbus.Property {
 	return systemdDbus.Property{
 		Name:  name,
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	"path/filepath"
+	"strings"
+)
+
+// Annotations describing a unikernel image. They are set on the image
+// manifest and configuration, and are also stored in MetadataFile at the
+// root of the image, since containerd does not hand image annotations
+// over to the runtime.
+const (
+	uruncAnnotationsPrefix = "com.urunc.unikernel."
+
+	// AnnotationBinary is the path of the unikernel inside the rootfs.
+	AnnotationBinary = uruncAnnotationsPrefix + "binary"
+
+	// AnnotationType is the monitor type used to run the unikernel,
+	// one of the *Type constants.
+	AnnotationType = uruncAnnotationsPrefix + "type"
+
+	// AnnotationCmdline is the command line passed to the unikernel.
+	AnnotationCmdline = uruncAnnotationsPrefix + "cmdline"
+
+	// AnnotationBitstreams is a comma separated list of FPGA bitstreams
+	// stored at the root of the rootfs.
+	AnnotationBitstreams = uruncAnnotationsPrefix + "bitstreams"
+
+	// AnnotationBlock is the path of a block image inside the rootfs,
+	// to be attached to the unikernel as its disk.
+	AnnotationBlock = uruncAnnotationsPrefix + "block"
+)
+
+// Unikernel types, selecting the monitor running the unikernel.
+const (
+	HvtType    = "hvt"
+	QemuType   = "qemu"
+	BinaryType = "binary"
+	PauseType  = "pause"
+)
+
+const (
+	// UnikernelDir is the rootfs directory holding the unikernel binary.
+	UnikernelDir = "unikernel"
+
+	// MetadataFile is the rootfs file holding the image annotations.
+	MetadataFile = "urunc.json"
+
+	// BitstreamExt is the extension of the FPGA bitstreams.
+	BitstreamExt = ".xclbin"
+)
+
+// TypeFromPath derives the unikernel type from the binary file name.
+func TypeFromPath(path string) string {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".hvt":
+		return HvtType
+	case ".qm", ".qemu":
+		return QemuType
+	default:
+		return BinaryType
+	}
+}
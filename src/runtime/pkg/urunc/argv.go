@@ -0,0 +1,270 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// DefaultHvtPath is the solo5-hvt monitor installed with kata.
+	DefaultHvtPath = "/opt/kata/bin/solo5-hvt"
+
+	// DefaultQemuPath is the QEMU binary running qemu unikernels.
+	DefaultQemuPath = "qemu-system-x86_64"
+
+	// DefaultQemuMemoryMB is the memory given to qemu unikernels when
+	// none is configured.
+	DefaultQemuMemoryMB = 128
+
+	// NetFd is the descriptor number the first of Network.Fds gets in
+	// the monitor, as the descriptors are handed over as extra files.
+	NetFd = 3
+)
+
+// xrtDir is where the Xilinx runtime used by the FPGA enabled monitors is
+// installed.
+var xrtDir = "/opt/xilinx/xrt"
+
+// Network is the network device of the unikernel.
+type Network struct {
+	// Tap is the name of the host TAP device. The monitor opens it
+	// itself when no Fds are given.
+	Tap string
+
+	// HardAddr is the MAC address of the unikernel interface.
+	HardAddr string
+
+	// Fds are descriptors of the already opened device, one per queue.
+	// The monitors being single threaded, only the first one is used.
+	Fds []*os.File
+
+	IPAddress string
+	Mask      string
+	Gateway   string
+}
+
+func (n *Network) configured() bool {
+	return n.Tap != "" || len(n.Fds) > 0
+}
+
+// Config holds what is needed to run a unikernel on top of what its image
+// provides.
+type Config struct {
+	// HvtPath is the solo5-hvt monitor, DefaultHvtPath if empty.
+	HvtPath string
+
+	// QemuPath is the QEMU binary, DefaultQemuPath if empty.
+	QemuPath string
+
+	// MemoryMB is the unikernel memory, the monitor default if zero.
+	MemoryMB uint32
+
+	// NetNs is the network namespace the monitor runs in.
+	NetNs string
+
+	// BlockDevice is a host block device attached to the unikernel as
+	// its disk, e.g. the devmapper rootfs. It takes precedence over the
+	// block image of the unikernel image.
+	BlockDevice string
+
+	Network Network
+
+	// Security holds the settings applied to the monitor process.
+	Security Security
+}
+
+// rumprunConfig is the configuration rumprun unikernels read from their
+// command line, e.g.
+// {"cmdline":"redis-server","net":{"if":"ukvmif0","cloner":"True","type":"inet","method":"static","addr":"10.10.10.2","mask":"16"}}
+type rumprunConfig struct {
+	Cmdline string          `json:"cmdline"`
+	Net     *rumprunNetwork `json:"net,omitempty"`
+	Blk     *rumprunBlock   `json:"blk,omitempty"`
+	Env     []string        `json:"env,omitempty"`
+	Cwd     string          `json:"cwd,omitempty"`
+	Mem     string          `json:"mem,omitempty"`
+}
+
+type rumprunNetwork struct {
+	If     string `json:"if"`
+	Cloner string `json:"cloner"`
+	Type   string `json:"type"`
+	Method string `json:"method"`
+	Addr   string `json:"addr"`
+	Mask   string `json:"mask"`
+	Gw     string `json:"gw"`
+}
+
+type rumprunBlock struct {
+	Source string `json:"source"`
+	Path   string `json:"path"`
+	Fstype string `json:"fstype"`
+	Mount  string `json:"mountpoint"`
+}
+
+// Argv returns the command line running u.
+func (c *Config) Argv(u *Unikernel) ([]string, error) {
+	switch u.Type {
+	case PauseType:
+		return []string{u.Path}, nil
+	case BinaryType:
+		return append([]string{u.Path}, strings.Fields(u.Cmdline)...), nil
+	case HvtType:
+		return c.hvtArgv(u)
+	case QemuType:
+		return c.qemuArgv(u), nil
+	default:
+		return nil, fmt.Errorf("unsupported unikernel type %q", u.Type)
+	}
+}
+
+// Env returns the environment of the monitor running u.
+func (c *Config) Env(u *Unikernel) []string {
+	env := os.Environ()
+	if u.Type != HvtType && u.Type != QemuType {
+		return env
+	}
+
+	return append(env,
+		"XILINX_XRT="+xrtDir,
+		"PATH="+prependEnv(filepath.Join(xrtDir, "bin"), "PATH"),
+		"LD_LIBRARY_PATH="+prependEnv(filepath.Join(xrtDir, "lib"), "LD_LIBRARY_PATH"),
+		"PYTHONPATH="+prependEnv(filepath.Join(xrtDir, "python"), "PYTHONPATH"),
+	)
+}
+
+func prependEnv(dir, key string) string {
+	if v := os.Getenv(key); v != "" {
+		return dir + ":" + v
+	}
+	return dir
+}
+
+// disk returns the disk attached to u, if any.
+func (c *Config) disk(u *Unikernel) string {
+	if c.BlockDevice != "" {
+		return c.BlockDevice
+	}
+	return u.Block
+}
+
+func cmdline(u *Unikernel) string {
+	if u.Cmdline != "" {
+		return u.Cmdline
+	}
+	return filepath.Base(u.Path)
+}
+
+func (c *Config) hvtArgv(u *Unikernel) ([]string, error) {
+	monitor := c.HvtPath
+	if monitor == "" {
+		monitor = DefaultHvtPath
+	}
+	argv := []string{monitor}
+
+	config := rumprunConfig{Cmdline: cmdline(u)}
+
+	if c.Network.configured() {
+		// solo5 takes a pre-opened descriptor as "@<fd>".
+		dev := c.Network.Tap
+		if len(c.Network.Fds) > 0 {
+			dev = "@" + strconv.Itoa(NetFd)
+		}
+		argv = append(argv, "--net="+dev)
+
+		// The unikernel reaches everything through the gateway.
+		config.Net = &rumprunNetwork{
+			If:     "ukvmif0",
+			Cloner: "True",
+			Type:   "inet",
+			Method: "static",
+			Addr:   c.Network.IPAddress,
+			Mask:   "0",
+			Gw:     c.Network.Gateway,
+		}
+	}
+
+	if c.MemoryMB != 0 {
+		argv = append(argv, fmt.Sprintf("--mem=%d", c.MemoryMB))
+	}
+
+	if disk := c.disk(u); disk != "" {
+		argv = append(argv, "--disk="+disk)
+		config.Blk = &rumprunBlock{
+			Source: "etfs",
+			Path:   "/dev/ld0a",
+			Fstype: "blk",
+			Mount:  "/data",
+		}
+	}
+
+	b, err := json.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+	argv = append(argv, u.Path, string(b))
+
+	// The bitstreams are passed by name, the monitor runs from the
+	// rootfs.
+	for _, b := range u.Bitstreams {
+		argv = append(argv, filepath.Base(b))
+	}
+
+	return argv, nil
+}
+
+func (c *Config) qemuArgv(u *Unikernel) []string {
+	monitor := c.QemuPath
+	if monitor == "" {
+		monitor = DefaultQemuPath
+	}
+	mem := c.MemoryMB
+	if mem == 0 {
+		mem = DefaultQemuMemoryMB
+	}
+
+	argv := []string{monitor,
+		"-cpu", "host",
+		"-enable-kvm",
+		"-m", strconv.Itoa(int(mem)),
+		"-nodefaults", "-no-acpi",
+		"-display", "none", "-serial", "stdio",
+		"-device", "isa-debug-exit",
+	}
+
+	var appendArgs []string
+	if c.Network.configured() {
+		nic := "nic,model=virtio"
+		if c.Network.HardAddr != "" {
+			nic += ",macaddr=" + c.Network.HardAddr
+		}
+		tap := "tap,script=no,downscript=no,ifname=" + c.Network.Tap
+		if len(c.Network.Fds) > 0 {
+			tap = "tap,fd=" + strconv.Itoa(NetFd)
+		}
+		argv = append(argv, "-net", nic, "-net", tap)
+
+		appendArgs = append(appendArgs,
+			"netdev.ipv4_addr="+c.Network.IPAddress,
+			"netdev.ipv4_gw_addr="+c.Network.Gateway,
+			"netdev.ipv4_subnet_mask=255.255.255.255")
+	}
+
+	if disk := c.disk(u); disk != "" {
+		argv = append(argv, "-drive", "file="+disk+",format=raw,if=virtio")
+	}
+
+	appendArgs = append(appendArgs, "--")
+	if u.Cmdline != "" {
+		appendArgs = append(appendArgs, u.Cmdline)
+	}
+
+	return append(argv, "-kernel", u.Path, "-append", strings.Join(appendArgs, " "))
+}
@@ -0,0 +1,121 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestArgvPauseBinary(t *testing.T) {
+	assert := assert.New(t)
+	c := &Config{}
+
+	argv, err := c.Argv(&Unikernel{Type: PauseType, Path: "/rootfs/pause"})
+	assert.NoError(err)
+	assert.Equal([]string{"/rootfs/pause"}, argv)
+
+	argv, err = c.Argv(&Unikernel{Type: BinaryType, Path: "/rootfs/unikernel/app", Cmdline: "-a  -b"})
+	assert.NoError(err)
+	assert.Equal([]string{"/rootfs/unikernel/app", "-a", "-b"}, argv)
+
+	_, err = c.Argv(&Unikernel{Type: "firecracker"})
+	assert.Error(err)
+}
+
+func TestArgvHvt(t *testing.T) {
+	assert := assert.New(t)
+
+	u := &Unikernel{
+		Type:       HvtType,
+		Path:       "/rootfs/unikernel/redis.hvt",
+		Bitstreams: []string{"/rootfs/vadd.xclbin"},
+		Block:      "/rootfs/block.img",
+	}
+	c := &Config{
+		Network: Network{
+			Tap:       "tap0_kata",
+			IPAddress: "10.10.10.2",
+			Gateway:   "10.10.10.1",
+		},
+	}
+
+	argv, err := c.Argv(u)
+	assert.NoError(err)
+	assert.Equal([]string{DefaultHvtPath, "--net=tap0_kata", "--disk=/rootfs/block.img", u.Path}, argv[:4])
+	assert.Equal("vadd.xclbin", argv[5])
+
+	var config rumprunConfig
+	assert.NoError(json.Unmarshal([]byte(argv[4]), &config))
+	assert.Equal("redis.hvt", config.Cmdline)
+	assert.Equal("10.10.10.2", config.Net.Addr)
+	assert.Equal("10.10.10.1", config.Net.Gw)
+	assert.NotNil(config.Blk)
+
+	// A pre-opened device, a host block device and the image cmdline.
+	u.Cmdline = "redis-server /data/redis.conf"
+	c.HvtPath = "/usr/bin/solo5-hvt"
+	c.MemoryMB = 256
+	c.BlockDevice = "/dev/dm-1"
+	c.Network.Fds = []*os.File{os.Stdin}
+
+	argv, err = c.Argv(u)
+	assert.NoError(err)
+	assert.Equal([]string{"/usr/bin/solo5-hvt", "--net=@3", "--mem=256", "--disk=/dev/dm-1", u.Path}, argv[:5])
+	assert.NoError(json.Unmarshal([]byte(argv[5]), &config))
+	assert.Equal(u.Cmdline, config.Cmdline)
+
+	// No network nor disk.
+	argv, err = (&Config{}).Argv(&Unikernel{Type: HvtType, Path: "/hello.hvt"})
+	assert.NoError(err)
+	assert.Equal([]string{DefaultHvtPath, "/hello.hvt", `{"cmdline":"hello.hvt"}`}, argv)
+}
+
+func TestArgvQemu(t *testing.T) {
+	assert := assert.New(t)
+
+	u := &Unikernel{Type: QemuType, Path: "/rootfs/unikernel/app.qemu"}
+	c := &Config{
+		Network: Network{
+			Tap:       "tap0_kata",
+			HardAddr:  "02:42:ac:11:00:02",
+			IPAddress: "10.10.10.2",
+			Gateway:   "10.10.10.1",
+		},
+	}
+
+	argv, err := c.Argv(u)
+	assert.NoError(err)
+	assert.Equal(DefaultQemuPath, argv[0])
+	assert.Contains(argv, "128")
+	assert.Contains(argv, "nic,model=virtio,macaddr=02:42:ac:11:00:02")
+	assert.Contains(argv, "tap,script=no,downscript=no,ifname=tap0_kata")
+	assert.NotContains(argv, "-drive")
+	assert.Equal([]string{"-kernel", u.Path, "-append",
+		"netdev.ipv4_addr=10.10.10.2 netdev.ipv4_gw_addr=10.10.10.1 netdev.ipv4_subnet_mask=255.255.255.255 --"},
+		argv[len(argv)-4:])
+
+	u.Cmdline = "app -v"
+	c.MemoryMB = 64
+	c.BlockDevice = "/dev/dm-1"
+	c.Network.Fds = []*os.File{os.Stdin}
+
+	argv, err = c.Argv(u)
+	assert.NoError(err)
+	assert.Contains(argv, "64")
+	assert.Contains(argv, "tap,fd=3")
+	assert.Contains(argv, "file=/dev/dm-1,format=raw,if=virtio")
+	assert.Contains(argv[len(argv)-1], "-- app -v")
+}
+
+func TestEnv(t *testing.T) {
+	assert := assert.New(t)
+	c := &Config{}
+
+	assert.NotContains(c.Env(&Unikernel{Type: BinaryType}), "XILINX_XRT="+xrtDir)
+	assert.Contains(c.Env(&Unikernel{Type: HvtType}), "XILINX_XRT="+xrtDir)
+}
@@ -0,0 +1,11 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package urunc holds the host side of running unikernels with kata:
+// inspecting the unikernel image a container rootfs holds, building the
+// command line of the monitor running it, supervising the monitor process
+// and cleaning up after it.
+//
+// virtcontainers inspects the rootfs when a container is created, and the
+// shim builds and supervises the monitor when the container is started.
+package urunc
@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+// CopyFile copies the content and permissions of src to dst.
+func CopyFile(src, dst string) (err error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	si, err := in.Stat()
+	if err != nil {
+		return err
+	}
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, si.Mode().Perm())
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if e := out.Close(); e != nil && err == nil {
+			err = e
+		}
+	}()
+
+	if _, err = io.Copy(out, in); err != nil {
+		return err
+	}
+
+	if err = out.Sync(); err != nil {
+		return err
+	}
+
+	// The permissions given to OpenFile are subject to the umask.
+	return out.Chmod(si.Mode().Perm())
+}
+
+// CopyDir recursively copies the src directory to dst, preserving
+// permissions. dst must not exist. Symlinks are skipped.
+func CopyDir(src, dst string) error {
+	src = filepath.Clean(src)
+	dst = filepath.Clean(dst)
+
+	si, err := os.Stat(src)
+	if err != nil {
+		return err
+	}
+	if !si.IsDir() {
+		return fmt.Errorf("%s is not a directory", src)
+	}
+
+	if _, err := os.Stat(dst); err == nil {
+		return fmt.Errorf("%s already exists", dst)
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := os.MkdirAll(dst, si.Mode().Perm()); err != nil {
+		return err
+	}
+
+	entries, err := os.ReadDir(src)
+	if err != nil {
+		return err
+	}
+
+	for _, entry := range entries {
+		srcPath := filepath.Join(src, entry.Name())
+		dstPath := filepath.Join(dst, entry.Name())
+
+		switch {
+		case entry.IsDir():
+			err = CopyDir(srcPath, dstPath)
+		case entry.Type()&os.ModeSymlink != 0:
+			continue
+		default:
+			err = CopyFile(srcPath, dstPath)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RemoveContents removes everything under dir, but not dir itself. A missing
+// dir is not an error.
+func RemoveContents(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dir, entry.Name())); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Cleanup force unmounts mounts, then removes dirs. Paths already unmounted
+// or removed are not errors. It goes on past failures and returns the first
+// one.
+func Cleanup(mounts []string, dirs []string) error {
+	var firstErr error
+	setErr := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	for _, m := range mounts {
+		err := unix.Unmount(m, unix.MNT_FORCE)
+		if err != nil && err != unix.EINVAL && err != unix.ENOENT {
+			setErr(fmt.Errorf("unmount %s: %v", m, err))
+		}
+	}
+
+	for _, d := range dirs {
+		if err := os.RemoveAll(d); err != nil {
+			setErr(err)
+		}
+	}
+
+	return firstErr
+}
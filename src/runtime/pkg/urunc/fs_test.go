@@ -0,0 +1,80 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	ktu "github.com/kata-containers/kata-containers/src/runtime/pkg/katatestutils"
+	"github.com/stretchr/testify/assert"
+)
+
+var tc = ktu.NewTestConstraint(false)
+
+func TestCopyDir(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	src := filepath.Join(dir, "src")
+	dst := filepath.Join(dir, "dst")
+
+	writeRootfsFile(t, src, "unikernel/app.hvt")
+	assert.NoError(os.WriteFile(filepath.Join(src, "data"), []byte("data"), 0600))
+	assert.NoError(os.Symlink("data", filepath.Join(src, "link")))
+
+	assert.NoError(CopyDir(src, dst))
+
+	b, err := os.ReadFile(filepath.Join(dst, "data"))
+	assert.NoError(err)
+	assert.Equal("data", string(b))
+
+	fi, err := os.Stat(filepath.Join(dst, "data"))
+	assert.NoError(err)
+	assert.Equal(os.FileMode(0600), fi.Mode().Perm())
+
+	fi, err = os.Stat(filepath.Join(dst, "unikernel/app.hvt"))
+	assert.NoError(err)
+	assert.Equal(os.FileMode(0755), fi.Mode().Perm())
+
+	_, err = os.Lstat(filepath.Join(dst, "link"))
+	assert.True(os.IsNotExist(err))
+
+	// The destination must not exist.
+	assert.Error(CopyDir(src, dst))
+	assert.Error(CopyDir(filepath.Join(src, "data"), filepath.Join(dir, "other")))
+}
+
+func TestCleanup(t *testing.T) {
+	if tc.NotValid(ktu.NeedRoot()) {
+		t.Skip(ktu.TestDisabledNeedRoot)
+	}
+
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	remove := filepath.Join(dir, "remove")
+	writeRootfsFile(t, remove, "file")
+
+	// Neither a path which is not mounted nor a missing one is an error.
+	assert.NoError(Cleanup([]string{filepath.Join(dir, "missing"), dir}, []string{remove, filepath.Join(dir, "missing")}))
+	_, err := os.Stat(remove)
+	assert.True(os.IsNotExist(err))
+}
+
+func TestRemoveContents(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	writeRootfsFile(t, filepath.Join(dir, "sub"), "file")
+	writeRootfsFile(t, dir, "file")
+
+	assert.NoError(RemoveContents(dir))
+	assert.DirExists(dir)
+	entries, err := os.ReadDir(dir)
+	assert.NoError(err)
+	assert.Empty(entries)
+
+	assert.NoError(RemoveContents(filepath.Join(dir, "missing")))
+}
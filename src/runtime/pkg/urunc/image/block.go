@@ -0,0 +1,85 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package image
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+)
+
+const (
+	blockSize = 4096
+	// minBlockImageSize leaves room for the ext4 metadata of small images.
+	minBlockImageSize = 8 << 20
+)
+
+// mkfsPath is the tool used to create block images. mke2fs populates the
+// filesystem from a directory (-d), so neither root nor loop devices are
+// needed, but it must come from e2fsprogs 1.43 or later.
+var mkfsPath = "mkfs.ext4"
+
+// buildBlockImage returns a raw ext4 filesystem image holding files at its
+// root. A raw filesystem is what devmapper-backed unikernels expect as
+// their disk, so it can be attached as is.
+func buildBlockImage(files []string) ([]byte, error) {
+	if _, err := exec.LookPath(mkfsPath); err != nil {
+		return nil, fmt.Errorf("building a block image requires %s (e2fsprogs >= 1.43): %v", mkfsPath, err)
+	}
+
+	workDir, err := os.MkdirTemp("", "urunc-block")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(workDir)
+
+	rootDir := filepath.Join(workDir, "root")
+	if err := os.Mkdir(rootDir, dirMode); err != nil {
+		return nil, err
+	}
+
+	var size int64
+	for _, f := range files {
+		entry, err := fileEntry(f, filepath.Base(f))
+		if err != nil {
+			return nil, err
+		}
+		dst := filepath.Join(rootDir, entry.name)
+		if _, err := os.Stat(dst); err == nil {
+			return nil, fmt.Errorf("duplicate file %s in block image", entry.name)
+		}
+		if err := os.WriteFile(dst, entry.data, entry.mode); err != nil {
+			return nil, err
+		}
+		// Account for the partially used last block of every file.
+		size += (int64(len(entry.data))/blockSize + 1) * blockSize
+	}
+
+	// Leave a quarter of free space for the filesystem metadata and
+	// for the unikernel to write to.
+	size += size / 4
+	if size < minBlockImageSize {
+		size = minBlockImageSize
+	}
+
+	img := filepath.Join(workDir, blockImageName)
+	f, err := os.Create(img)
+	if err != nil {
+		return nil, err
+	}
+	err = f.Truncate(size)
+	f.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	out, err := exec.Command(mkfsPath, "-q", "-F", "-b", fmt.Sprint(blockSize),
+		"-E", "root_owner=0:0", "-d", rootDir, img).CombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s failed: %v: %s", mkfsPath, err, out)
+	}
+
+	return os.ReadFile(img)
+}
@@ -0,0 +1,457 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package image builds OCI images holding a unikernel, without relying on
+// a container engine. The result can be written as an OCI image layout
+// directory, or as an archive of it that `ctr images import` accepts.
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/urunc"
+	digest "github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+const (
+	// containerdImageNameAnnotation is the index annotation containerd
+	// uses to name the images it imports.
+	containerdImageNameAnnotation = "io.containerd.image.name"
+
+	blockImageName = "block.img"
+	defaultTag     = "latest"
+	dirMode        = 0755
+)
+
+// epoch is the modification time of every layer entry, so that building the
+// same content twice yields the same digests.
+var epoch = time.Unix(0, 0).UTC()
+
+// Config describes the content of a unikernel image.
+type Config struct {
+	// Name is the image reference, e.g. "docker.io/urunc/hello:latest".
+	// The "latest" tag is used when none is given.
+	Name string
+
+	// Unikernel is the host path of the unikernel binary.
+	Unikernel string
+
+	// Type is the unikernel type. It is derived from the unikernel file
+	// name when empty.
+	Type string
+
+	// Cmdline is the command line passed to the unikernel.
+	Cmdline string
+
+	// Bitstreams are host paths of FPGA bitstreams, stored at the root
+	// of the image.
+	Bitstreams []string
+
+	// DataFiles are host paths of additional files, stored at the root
+	// of the image.
+	DataFiles []string
+
+	// BlockImage packs DataFiles into an ext4 image instead, so that the
+	// unikernel can be given them as a disk, whatever the snapshotter.
+	BlockImage bool
+
+	// Architecture is the image architecture, runtime.GOARCH by default.
+	Architecture string
+}
+
+// Image is an OCI image held in memory.
+type Image struct {
+	name  string
+	index ocispec.Index
+	// blobs are kept in creation order to produce stable archives.
+	blobs []blob
+}
+
+type blob struct {
+	desc ocispec.Descriptor
+	data []byte
+}
+
+type layerEntry struct {
+	name string
+	mode os.FileMode
+	data []byte
+}
+
+// Build creates the image described by config.
+func Build(config Config) (*Image, error) {
+	if config.Unikernel == "" {
+		return nil, errors.New("missing unikernel binary")
+	}
+
+	name, err := normalizeName(config.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if config.Type == "" {
+		config.Type = urunc.TypeFromPath(config.Unikernel)
+	}
+
+	switch config.Type {
+	case urunc.HvtType, urunc.QemuType, urunc.BinaryType:
+	default:
+		return nil, fmt.Errorf("unsupported unikernel type %q", config.Type)
+	}
+
+	if config.Architecture == "" {
+		config.Architecture = runtime.GOARCH
+	}
+
+	unikernelName := path.Join(urunc.UnikernelDir, filepath.Base(config.Unikernel))
+	annotations := map[string]string{
+		urunc.AnnotationBinary: "/" + unikernelName,
+		urunc.AnnotationType:   config.Type,
+	}
+	if config.Cmdline != "" {
+		annotations[urunc.AnnotationCmdline] = config.Cmdline
+	}
+
+	entries := []layerEntry{}
+
+	entry, err := fileEntry(config.Unikernel, unikernelName)
+	if err != nil {
+		return nil, err
+	}
+	// The unikernel is executed directly for the binary type.
+	entry.mode |= 0111
+	entries = append(entries, entry)
+
+	var bitstreams []string
+	for _, b := range config.Bitstreams {
+		if filepath.Ext(b) != urunc.BitstreamExt {
+			return nil, fmt.Errorf("bitstream %s does not have the %s extension", b, urunc.BitstreamExt)
+		}
+		entry, err := fileEntry(b, filepath.Base(b))
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+		bitstreams = append(bitstreams, entry.name)
+	}
+	if len(bitstreams) > 0 {
+		annotations[urunc.AnnotationBitstreams] = strings.Join(bitstreams, ",")
+	}
+
+	if config.BlockImage && len(config.DataFiles) > 0 {
+		data, err := buildBlockImage(config.DataFiles)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, layerEntry{name: blockImageName, mode: 0644, data: data})
+		annotations[urunc.AnnotationBlock] = "/" + blockImageName
+	} else {
+		for _, f := range config.DataFiles {
+			entry, err := fileEntry(f, filepath.Base(f))
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	metadata, err := json.Marshal(annotations)
+	if err != nil {
+		return nil, err
+	}
+	entries = append(entries, layerEntry{name: urunc.MetadataFile, mode: 0644, data: metadata})
+
+	if err := checkDuplicates(entries); err != nil {
+		return nil, err
+	}
+
+	return assemble(name, config, annotations, entries)
+}
+
+// normalizeName adds the default tag to name when it has none.
+func normalizeName(name string) (string, error) {
+	if name == "" {
+		return "", errors.New("missing image name")
+	}
+
+	// A colon after the last slash separates the tag, otherwise it
+	// belongs to a registry host:port.
+	if !strings.Contains(name[strings.LastIndex(name, "/")+1:], ":") {
+		name = name + ":" + defaultTag
+	}
+
+	return name, nil
+}
+
+func fileEntry(hostPath, name string) (layerEntry, error) {
+	info, err := os.Stat(hostPath)
+	if err != nil {
+		return layerEntry{}, err
+	}
+	if !info.Mode().IsRegular() {
+		return layerEntry{}, fmt.Errorf("%s is not a regular file", hostPath)
+	}
+
+	data, err := os.ReadFile(hostPath)
+	if err != nil {
+		return layerEntry{}, err
+	}
+
+	return layerEntry{name: name, mode: info.Mode().Perm(), data: data}, nil
+}
+
+func checkDuplicates(entries []layerEntry) error {
+	seen := make(map[string]bool)
+	for _, e := range entries {
+		if seen[e.name] {
+			return fmt.Errorf("duplicate file %s in image", e.name)
+		}
+		seen[e.name] = true
+	}
+	return nil
+}
+
+// buildLayer returns the uncompressed and the gzip-compressed layer tarball.
+func buildLayer(entries []layerEntry) ([]byte, []byte, error) {
+	var tarBuf bytes.Buffer
+	tw := tar.NewWriter(&tarBuf)
+
+	sorted := make([]layerEntry, len(entries))
+	copy(sorted, entries)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].name < sorted[j].name })
+
+	dirs := make(map[string]bool)
+	for _, e := range sorted {
+		for dir := path.Dir(e.name); dir != "."; dir = path.Dir(dir) {
+			if dirs[dir] {
+				continue
+			}
+			dirs[dir] = true
+			if err := tw.WriteHeader(&tar.Header{
+				Typeflag: tar.TypeDir,
+				Name:     dir + "/",
+				Mode:     dirMode,
+				ModTime:  epoch,
+				Format:   tar.FormatPAX,
+			}); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     e.name,
+			Mode:     int64(e.mode),
+			Size:     int64(len(e.data)),
+			ModTime:  epoch,
+			Format:   tar.FormatPAX,
+		}); err != nil {
+			return nil, nil, err
+		}
+		if _, err := tw.Write(e.data); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	var gzBuf bytes.Buffer
+	gw := gzip.NewWriter(&gzBuf)
+	if _, err := gw.Write(tarBuf.Bytes()); err != nil {
+		return nil, nil, err
+	}
+	if err := gw.Close(); err != nil {
+		return nil, nil, err
+	}
+
+	return tarBuf.Bytes(), gzBuf.Bytes(), nil
+}
+
+func assemble(name string, config Config, annotations map[string]string, entries []layerEntry) (*Image, error) {
+	img := &Image{name: name}
+
+	diff, layer, err := buildLayer(entries)
+	if err != nil {
+		return nil, err
+	}
+	layerDesc := img.addBlob(ocispec.MediaTypeImageLayerGzip, layer)
+
+	created := epoch
+	imgConfig := ocispec.Image{
+		Created:      &created,
+		Architecture: config.Architecture,
+		OS:           "linux",
+		Config: ocispec.ImageConfig{
+			Entrypoint: []string{annotations[urunc.AnnotationBinary]},
+			Labels:     annotations,
+		},
+		RootFS: ocispec.RootFS{
+			Type:    "layers",
+			DiffIDs: []digest.Digest{digest.FromBytes(diff)},
+		},
+	}
+	if config.Cmdline != "" {
+		imgConfig.Config.Cmd = strings.Fields(config.Cmdline)
+	}
+
+	configData, err := json.Marshal(imgConfig)
+	if err != nil {
+		return nil, err
+	}
+	configDesc := img.addBlob(ocispec.MediaTypeImageConfig, configData)
+
+	manifest := ocispec.Manifest{
+		Versioned:   specs.Versioned{SchemaVersion: 2},
+		Config:      configDesc,
+		Layers:      []ocispec.Descriptor{layerDesc},
+		Annotations: annotations,
+	}
+	manifestData, err := json.Marshal(manifest)
+	if err != nil {
+		return nil, err
+	}
+	manifestDesc := img.addBlob(ocispec.MediaTypeImageManifest, manifestData)
+	manifestDesc.Platform = &ocispec.Platform{
+		Architecture: config.Architecture,
+		OS:           "linux",
+	}
+	manifestDesc.Annotations = map[string]string{
+		ocispec.AnnotationRefName:     name[strings.LastIndex(name, ":")+1:],
+		containerdImageNameAnnotation: name,
+	}
+
+	img.index = ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Manifests: []ocispec.Descriptor{manifestDesc},
+	}
+
+	return img, nil
+}
+
+func (img *Image) addBlob(mediaType string, data []byte) ocispec.Descriptor {
+	desc := ocispec.Descriptor{
+		MediaType: mediaType,
+		Digest:    digest.FromBytes(data),
+		Size:      int64(len(data)),
+	}
+	img.blobs = append(img.blobs, blob{desc: desc, data: data})
+	return desc
+}
+
+// Name returns the image reference.
+func (img *Image) Name() string {
+	return img.name
+}
+
+// Manifest returns the descriptor of the image manifest.
+func (img *Image) Manifest() ocispec.Descriptor {
+	return img.index.Manifests[0]
+}
+
+// layoutFiles returns the files of the OCI image layout, in a stable order.
+func (img *Image) layoutFiles() ([]layerEntry, error) {
+	layout, err := json.Marshal(ocispec.ImageLayout{Version: ocispec.ImageLayoutVersion})
+	if err != nil {
+		return nil, err
+	}
+	index, err := json.Marshal(img.index)
+	if err != nil {
+		return nil, err
+	}
+
+	files := []layerEntry{
+		{name: ocispec.ImageLayoutFile, mode: 0644, data: layout},
+		{name: "index.json", mode: 0644, data: index},
+	}
+	for _, b := range img.blobs {
+		files = append(files, layerEntry{
+			name: path.Join("blobs", b.desc.Digest.Algorithm().String(), b.desc.Digest.Encoded()),
+			mode: 0644,
+			data: b.data,
+		})
+	}
+
+	return files, nil
+}
+
+// WriteLayout writes the image as an OCI image layout in dir, which is
+// created if needed.
+func (img *Image) WriteLayout(dir string) error {
+	files, err := img.layoutFiles()
+	if err != nil {
+		return err
+	}
+
+	for _, f := range files {
+		p := filepath.Join(dir, filepath.FromSlash(f.name))
+		if err := os.MkdirAll(filepath.Dir(p), dirMode); err != nil {
+			return err
+		}
+		if err := os.WriteFile(p, f.data, f.mode); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// WriteArchive writes the image as a tar archive of its OCI image layout.
+func (img *Image) WriteArchive(w io.Writer) error {
+	files, err := img.layoutFiles()
+	if err != nil {
+		return err
+	}
+
+	tw := tar.NewWriter(w)
+	dirs := make(map[string]bool)
+	for _, f := range files {
+		if dir := path.Dir(f.name); dir != "." && !dirs[dir] {
+			for _, d := range []string{path.Dir(dir), dir} {
+				if d == "." || dirs[d] {
+					continue
+				}
+				dirs[d] = true
+				if err := tw.WriteHeader(&tar.Header{
+					Typeflag: tar.TypeDir,
+					Name:     d + "/",
+					Mode:     dirMode,
+					ModTime:  epoch,
+				}); err != nil {
+					return err
+				}
+			}
+		}
+
+		if err := tw.WriteHeader(&tar.Header{
+			Typeflag: tar.TypeReg,
+			Name:     f.name,
+			Mode:     int64(f.mode),
+			Size:     int64(len(f.data)),
+			ModTime:  epoch,
+		}); err != nil {
+			return err
+		}
+		if _, err := tw.Write(f.data); err != nil {
+			return err
+		}
+	}
+
+	return tw.Close()
+}
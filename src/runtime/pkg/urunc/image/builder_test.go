@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package image
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/urunc"
+	digest "github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"github.com/stretchr/testify/assert"
+)
+
+func writeTestFile(t *testing.T, dir, name, content string) string {
+	p := filepath.Join(dir, name)
+	assert.NoError(t, os.WriteFile(p, []byte(content), 0644))
+	return p
+}
+
+// readTar returns the entry headers of a tarball, by name.
+func readTar(t *testing.T, r io.Reader) map[string]*tar.Header {
+	files := make(map[string]*tar.Header)
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		assert.NoError(t, err)
+		files[hdr.Name] = hdr
+	}
+	return files
+}
+
+func TestNormalizeName(t *testing.T) {
+	assert := assert.New(t)
+
+	for name, expected := range map[string]string{
+		"hello":                     "hello:latest",
+		"urunc/hello:v1":            "urunc/hello:v1",
+		"localhost:5000/urunc/test": "localhost:5000/urunc/test:latest",
+		"localhost:5000/test:v2":    "localhost:5000/test:v2",
+	} {
+		actual, err := normalizeName(name)
+		assert.NoError(err)
+		assert.Equal(expected, actual)
+	}
+
+	_, err := normalizeName("")
+	assert.Error(err)
+}
+
+func TestBuildInvalid(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+	unikernel := writeTestFile(t, dir, "hello.hvt", "unikernel")
+
+	_, err := Build(Config{Name: "hello"})
+	assert.Error(err)
+
+	_, err = Build(Config{Unikernel: unikernel})
+	assert.Error(err)
+
+	_, err = Build(Config{Name: "hello", Unikernel: unikernel, Type: "firecracker"})
+	assert.Error(err)
+
+	_, err = Build(Config{Name: "hello", Unikernel: filepath.Join(dir, "missing")})
+	assert.Error(err)
+
+	notBitstream := writeTestFile(t, dir, "bitstream.bin", "bits")
+	_, err = Build(Config{Name: "hello", Unikernel: unikernel, Bitstreams: []string{notBitstream}})
+	assert.Error(err)
+
+	// A data file must not override the image metadata.
+	dataDir := filepath.Join(dir, "data")
+	assert.NoError(os.Mkdir(dataDir, 0755))
+	clash := writeTestFile(t, dataDir, urunc.MetadataFile, "{}")
+	_, err = Build(Config{Name: "hello", Unikernel: unikernel, DataFiles: []string{clash}})
+	assert.Error(err)
+}
+
+func TestBuildLayout(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	unikernel := writeTestFile(t, dir, "hello.hvt", "unikernel")
+	bitstream := writeTestFile(t, dir, "vadd.xclbin", "bitstream")
+	data := writeTestFile(t, dir, "data.txt", "data")
+
+	img, err := Build(Config{
+		Name:         "urunc/hello",
+		Unikernel:    unikernel,
+		Cmdline:      "hello world",
+		Bitstreams:   []string{bitstream},
+		DataFiles:    []string{data},
+		Architecture: "amd64",
+	})
+	assert.NoError(err)
+	assert.Equal("urunc/hello:latest", img.Name())
+
+	layoutDir := filepath.Join(dir, "layout")
+	assert.NoError(img.WriteLayout(layoutDir))
+
+	readBlob := func(d digest.Digest) []byte {
+		b, err := os.ReadFile(filepath.Join(layoutDir, "blobs", d.Algorithm().String(), d.Encoded()))
+		assert.NoError(err)
+		assert.Equal(d, digest.FromBytes(b))
+		return b
+	}
+
+	var index ocispec.Index
+	b, err := os.ReadFile(filepath.Join(layoutDir, "index.json"))
+	assert.NoError(err)
+	assert.NoError(json.Unmarshal(b, &index))
+	assert.Len(index.Manifests, 1)
+	assert.Equal("urunc/hello:latest", index.Manifests[0].Annotations[containerdImageNameAnnotation])
+	assert.Equal("latest", index.Manifests[0].Annotations[ocispec.AnnotationRefName])
+	assert.Equal(img.Manifest().Digest, index.Manifests[0].Digest)
+
+	_, err = os.Stat(filepath.Join(layoutDir, ocispec.ImageLayoutFile))
+	assert.NoError(err)
+
+	var manifest ocispec.Manifest
+	assert.NoError(json.Unmarshal(readBlob(index.Manifests[0].Digest), &manifest))
+	assert.Equal("/unikernel/hello.hvt", manifest.Annotations[urunc.AnnotationBinary])
+	assert.Equal(urunc.HvtType, manifest.Annotations[urunc.AnnotationType])
+	assert.Equal("hello world", manifest.Annotations[urunc.AnnotationCmdline])
+	assert.Equal("vadd.xclbin", manifest.Annotations[urunc.AnnotationBitstreams])
+	assert.Len(manifest.Layers, 1)
+
+	var config ocispec.Image
+	assert.NoError(json.Unmarshal(readBlob(manifest.Config.Digest), &config))
+	assert.Equal("amd64", config.Architecture)
+	assert.Equal(manifest.Annotations, config.Config.Labels)
+
+	layer := readBlob(manifest.Layers[0].Digest)
+	gr, err := gzip.NewReader(bytes.NewReader(layer))
+	assert.NoError(err)
+	diff, err := io.ReadAll(gr)
+	assert.NoError(err)
+	assert.Equal(config.RootFS.DiffIDs, []digest.Digest{digest.FromBytes(diff)})
+
+	files := readTar(t, bytes.NewReader(diff))
+	assert.Contains(files, "unikernel/")
+	assert.Contains(files, "unikernel/hello.hvt")
+	assert.Contains(files, "vadd.xclbin")
+	assert.Contains(files, "data.txt")
+	assert.Contains(files, urunc.MetadataFile)
+	assert.NotZero(files["unikernel/hello.hvt"].Mode & 0111)
+
+	// Building the same content again yields the same image.
+	again, err := Build(Config{
+		Name:         "urunc/hello",
+		Unikernel:    unikernel,
+		Cmdline:      "hello world",
+		Bitstreams:   []string{bitstream},
+		DataFiles:    []string{data},
+		Architecture: "amd64",
+	})
+	assert.NoError(err)
+	assert.Equal(img.Manifest().Digest, again.Manifest().Digest)
+}
+
+func TestBuildArchive(t *testing.T) {
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	unikernel := writeTestFile(t, dir, "hello.binary", "unikernel")
+	img, err := Build(Config{Name: "hello:v1", Unikernel: unikernel})
+	assert.NoError(err)
+
+	var buf bytes.Buffer
+	assert.NoError(img.WriteArchive(&buf))
+
+	files := readTar(t, &buf)
+	assert.Contains(files, ocispec.ImageLayoutFile)
+	assert.Contains(files, "index.json")
+	assert.Contains(files, "blobs/")
+	assert.Contains(files, "blobs/sha256/")
+	assert.Contains(files, "blobs/sha256/"+img.Manifest().Digest.Encoded())
+}
+
+func TestBuildBlockImage(t *testing.T) {
+	if _, err := exec.LookPath(mkfsPath); err != nil {
+		t.Skipf("%s not available", mkfsPath)
+	}
+
+	assert := assert.New(t)
+	dir := t.TempDir()
+
+	unikernel := writeTestFile(t, dir, "redis.hvt", "unikernel")
+	data := writeTestFile(t, dir, "redis.conf", "port 6379")
+
+	img, err := Build(Config{
+		Name:       "urunc/redis",
+		Unikernel:  unikernel,
+		DataFiles:  []string{data},
+		BlockImage: true,
+	})
+	assert.NoError(err)
+
+	var manifest ocispec.Manifest
+	var layer []byte
+	for _, b := range img.blobs {
+		switch b.desc.MediaType {
+		case ocispec.MediaTypeImageManifest:
+			assert.NoError(json.Unmarshal(b.data, &manifest))
+		case ocispec.MediaTypeImageLayerGzip:
+			layer = b.data
+		}
+	}
+	assert.Equal("/"+blockImageName, manifest.Annotations[urunc.AnnotationBlock])
+
+	gr, err := gzip.NewReader(bytes.NewReader(layer))
+	assert.NoError(err)
+	files := readTar(t, gr)
+	assert.Contains(files, blockImageName)
+	assert.NotContains(files, "redis.conf")
+	assert.Zero(files[blockImageName].Size % blockSize)
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// The mock monitor stands for the monitor of unikernel containers in tests,
+// see pkg/urunc/urunctest.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/urunc/urunctest"
+)
+
+func main() {
+	if err := record(); err != nil {
+		fmt.Fprintf(os.Stderr, "mock monitor: %v\n", err)
+		os.Exit(1)
+	}
+
+	if code, ok := os.LookupEnv(urunctest.ExitEnv); ok {
+		c, err := strconv.Atoi(code)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "mock monitor: invalid exit code %q\n", code)
+			os.Exit(1)
+		}
+		os.Exit(c)
+	}
+
+	// Run until signalled.
+	for {
+		time.Sleep(time.Hour)
+	}
+}
+
+// record writes how the monitor was run to the file named by RecordEnv.
+// The file is renamed into place so that readers never see it partially
+// written.
+func record() error {
+	path := os.Getenv(urunctest.RecordEnv)
+	if path == "" {
+		return nil
+	}
+
+	r := urunctest.Record{
+		Args: os.Args,
+		Env:  os.Environ(),
+	}
+
+	var err error
+	if r.Dir, err = os.Getwd(); err != nil {
+		return err
+	}
+	if r.NetNs, err = os.Readlink("/proc/self/ns/net"); err != nil {
+		return err
+	}
+
+	b, err := json.Marshal(r)
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".record")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(b); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
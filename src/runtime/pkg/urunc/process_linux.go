@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"syscall"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"golang.org/x/sys/unix"
+)
+
+// Process is the monitor running a unikernel. Once started, it is signalled
+// through a pidfd, so a signal can never reach another process reusing its
+// pid after it has been reaped.
+type Process struct {
+	argv  []string
+	cmd   *exec.Cmd
+	netNs string
+
+	mu sync.Mutex
+	// pidfd is -1 before Start, after Wait, or if the kernel does not
+	// support pidfds, in which case the pid is signalled.
+	pidfd  int
+	exited bool
+}
+
+// NewProcess returns the monitor running u, not started yet.
+func NewProcess(c *Config, u *Unikernel) (*Process, error) {
+	argv, err := c.Argv(u)
+	if err != nil {
+		return nil, err
+	}
+
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Env = c.Env(u)
+	if !c.Security.empty() {
+		config, err := json.Marshal(c.Security)
+		if err != nil {
+			return nil, err
+		}
+		cmd = exec.Command(selfExe, append([]string{InitCommand}, argv...)...)
+		cmd.Env = append(c.Env(u), initEnv+"="+string(config))
+	}
+	// Files of the image, e.g. bitstreams, are referenced relative to
+	// the rootfs.
+	cmd.Dir = u.Rootfs
+	if len(c.Network.Fds) > 0 {
+		cmd.ExtraFiles = c.Network.Fds[:1]
+	}
+
+	return &Process{
+		argv:  argv,
+		cmd:   cmd,
+		netNs: c.NetNs,
+		pidfd: -1,
+	}, nil
+}
+
+// Args returns the command line of the monitor.
+func (p *Process) Args() []string {
+	return p.argv
+}
+
+// StdioPipes returns pipes connected to the standard streams of the
+// process. It must be called before Start.
+func (p *Process) StdioPipes() (io.WriteCloser, io.ReadCloser, io.ReadCloser, error) {
+	stdin, err := p.cmd.StdinPipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	stdout, err := p.cmd.StdoutPipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	stderr, err := p.cmd.StderrPipe()
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return stdin, stdout, stderr, nil
+}
+
+// Start starts the process in its network namespace.
+func (p *Process) Start() error {
+	start := func(ns.NetNS) error {
+		return p.cmd.Start()
+	}
+
+	var err error
+	if p.netNs == "" {
+		err = start(nil)
+	} else {
+		err = ns.WithNetNSPath(p.netNs, start)
+	}
+	if err != nil {
+		return err
+	}
+
+	// The process cannot be reaped before Wait, so the pid still refers
+	// to it.
+	fd, err := unix.PidfdOpen(p.cmd.Process.Pid, 0)
+	if err != nil && err != unix.ENOSYS {
+		p.cmd.Process.Kill()
+		p.cmd.Wait()
+		return err
+	}
+	if err == nil {
+		p.mu.Lock()
+		p.pidfd = fd
+		p.mu.Unlock()
+	}
+
+	return nil
+}
+
+// Pid returns the pid of the started process.
+func (p *Process) Pid() int {
+	if p.cmd.Process == nil {
+		return 0
+	}
+	return p.cmd.Process.Pid
+}
+
+// Signal sends sig to the process. It returns os.ErrProcessDone once the
+// process has exited.
+func (p *Process) Signal(sig syscall.Signal) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.cmd.Process == nil {
+		return errors.New("process not started")
+	}
+	if p.exited {
+		return os.ErrProcessDone
+	}
+
+	if p.pidfd < 0 {
+		return p.cmd.Process.Signal(sig)
+	}
+
+	err := unix.PidfdSendSignal(p.pidfd, sig, nil, 0)
+	if err == unix.ESRCH {
+		return os.ErrProcessDone
+	}
+	return err
+}
+
+// Wait waits for the process to exit and returns its exit code, which
+// follows the shell convention of 128+n for a process killed by signal n.
+// It must be called once.
+func (p *Process) Wait() (int, error) {
+	err := p.cmd.Wait()
+
+	p.mu.Lock()
+	p.exited = true
+	if p.pidfd >= 0 {
+		unix.Close(p.pidfd)
+		p.pidfd = -1
+	}
+	p.mu.Unlock()
+
+	state := p.cmd.ProcessState
+	if state == nil {
+		return -1, err
+	}
+
+	if status, ok := state.Sys().(syscall.WaitStatus); ok && status.Signaled() {
+		return 128 + int(status.Signal()), nil
+	}
+
+	// A non zero exit status is not an error of Wait.
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		err = nil
+	}
+
+	return state.ExitCode(), err
+}
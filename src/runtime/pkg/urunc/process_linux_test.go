@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/urunc/urunctest"
+	"github.com/stretchr/testify/assert"
+)
+
+// scriptUnikernel returns a binary unikernel running script.
+func scriptUnikernel(t *testing.T, script string) *Unikernel {
+	rootfs := t.TempDir()
+	path := filepath.Join(rootfs, "app")
+	assert.NoError(t, os.WriteFile(path, []byte("#!/bin/sh\n"+script+"\n"), 0755))
+	return &Unikernel{Rootfs: rootfs, Type: BinaryType, Path: path}
+}
+
+func TestProcessExitCode(t *testing.T) {
+	assert := assert.New(t)
+
+	u := scriptUnikernel(t, `pwd; echo "$@"; exit 3`)
+	u.Cmdline = "hello world"
+	p, err := NewProcess(&Config{}, u)
+	assert.NoError(err)
+	assert.Equal([]string{u.Path, "hello", "world"}, p.Args())
+
+	_, stdout, _, err := p.StdioPipes()
+	assert.NoError(err)
+	assert.NoError(p.Start())
+	assert.NotZero(p.Pid())
+
+	out, err := io.ReadAll(stdout)
+	assert.NoError(err)
+	assert.Equal(u.Rootfs+"\nhello world\n", string(out))
+
+	code, err := p.Wait()
+	assert.NoError(err)
+	assert.Equal(3, code)
+
+	assert.Equal(os.ErrProcessDone, p.Signal(syscall.SIGTERM))
+}
+
+func TestProcessSignal(t *testing.T) {
+	assert := assert.New(t)
+
+	p, err := NewProcess(&Config{}, scriptUnikernel(t, "exec sleep 60"))
+	assert.NoError(err)
+
+	assert.Error(p.Signal(syscall.SIGTERM))
+
+	assert.NoError(p.Start())
+	assert.NoError(p.Signal(syscall.SIGKILL))
+
+	code, err := p.Wait()
+	assert.NoError(err)
+	assert.Equal(128+int(syscall.SIGKILL), code)
+}
+
+func TestProcessStartError(t *testing.T) {
+	assert := assert.New(t)
+
+	u := scriptUnikernel(t, "exit 0")
+	assert.NoError(os.Chmod(u.Path, 0644))
+
+	p, err := NewProcess(&Config{}, u)
+	assert.NoError(err)
+	assert.Error(p.Start())
+
+	p, err = NewProcess(&Config{NetNs: "/nonexistent/netns"}, scriptUnikernel(t, "exit 0"))
+	assert.NoError(err)
+	assert.Error(p.Start())
+}
+
+func TestProcessMockMonitor(t *testing.T) {
+	monitor := urunctest.Monitor(t)
+	netns, err := os.Readlink("/proc/self/ns/net")
+	assert.NoError(t, err)
+
+	for _, typ := range urunctest.Types {
+		t.Run(typ, func(t *testing.T) {
+			assert := assert.New(t)
+
+			record := filepath.Join(t.TempDir(), "record")
+			t.Setenv(urunctest.RecordEnv, record)
+			t.Setenv(urunctest.ExitEnv, "7")
+
+			u, err := Inspect(filepath.Join(urunctest.Bundle(t, typ), "rootfs"))
+			assert.NoError(err)
+			assert.Equal(typ, u.Type)
+
+			c := &Config{
+				HvtPath:  monitor,
+				QemuPath: monitor,
+				Network:  Network{Tap: "tap0"},
+			}
+			p, err := NewProcess(c, u)
+			assert.NoError(err)
+			assert.NoError(p.Start())
+
+			code, err := p.Wait()
+			assert.NoError(err)
+			assert.Equal(7, code)
+
+			r, err := urunctest.ReadRecord(record, 5*time.Second)
+			assert.NoError(err)
+			assert.Equal(p.Args(), r.Args)
+			assert.Equal(u.Rootfs, r.Dir)
+			assert.Equal(netns, r.NetNs)
+
+			_, xrt := r.Getenv("XILINX_XRT")
+			assert.Equal(typ != BinaryType, xrt)
+		})
+	}
+}
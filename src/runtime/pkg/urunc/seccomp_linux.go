@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	"fmt"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	"github.com/opencontainers/runc/libcontainer/seccomp"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// SeccompSupported returns whether seccomp profiles can be applied to the
+// monitor, which requires building with the seccomp tag and libseccomp.
+func SeccompSupported() bool {
+	major, _, _ := seccomp.Version()
+	return major > 0
+}
+
+// seccompConfig converts the seccomp profile of a container spec to the
+// libcontainer one, as runc does.
+func seccompConfig(config *specs.LinuxSeccomp) (*configs.Seccomp, error) {
+	if len(config.Flags) != 0 {
+		return nil, fmt.Errorf("seccomp flags are not supported")
+	}
+
+	defaultAction, err := seccomp.ConvertStringToAction(string(config.DefaultAction))
+	if err != nil {
+		return nil, err
+	}
+
+	c := &configs.Seccomp{
+		DefaultAction:   defaultAction,
+		DefaultErrnoRet: config.DefaultErrnoRet,
+		Syscalls:        []*configs.Syscall{},
+	}
+
+	for _, arch := range config.Architectures {
+		a, err := seccomp.ConvertStringToArch(string(arch))
+		if err != nil {
+			return nil, err
+		}
+		c.Architectures = append(c.Architectures, a)
+	}
+
+	for _, call := range config.Syscalls {
+		action, err := seccomp.ConvertStringToAction(string(call.Action))
+		if err != nil {
+			return nil, err
+		}
+
+		var args []*configs.Arg
+		for _, arg := range call.Args {
+			op, err := seccomp.ConvertStringToOperator(string(arg.Op))
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, &configs.Arg{
+				Index:    arg.Index,
+				Value:    arg.Value,
+				ValueTwo: arg.ValueTwo,
+				Op:       op,
+			})
+		}
+
+		for _, name := range call.Names {
+			c.Syscalls = append(c.Syscalls, &configs.Syscall{
+				Name:     name,
+				Action:   action,
+				ErrnoRet: call.ErrnoRet,
+				Args:     args,
+			})
+		}
+	}
+
+	return c, nil
+}
+
+// loadSeccomp installs the seccomp profile on the calling thread, which
+// the filter is inherited from across execve.
+func loadSeccomp(config *specs.LinuxSeccomp) error {
+	c, err := seccompConfig(config)
+	if err != nil {
+		return err
+	}
+
+	return seccomp.InitSeccomp(c)
+}
@@ -0,0 +1,68 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	"testing"
+
+	"github.com/opencontainers/runc/libcontainer/configs"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
+)
+
+func TestSeccompConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	errno := uint(unix.ENOSYS)
+	config := &specs.LinuxSeccomp{
+		DefaultAction:   specs.ActErrno,
+		DefaultErrnoRet: &errno,
+		Architectures:   []specs.Arch{specs.ArchX86_64, specs.ArchX32},
+		Syscalls: []specs.LinuxSyscall{
+			{
+				Names:  []string{"read", "write"},
+				Action: specs.ActAllow,
+			},
+			{
+				Names:  []string{"ioctl"},
+				Action: specs.ActTrap,
+				Args: []specs.LinuxSeccompArg{
+					{Index: 1, Value: 0xff, ValueTwo: 0x0f, Op: specs.OpMaskedEqual},
+				},
+			},
+		},
+	}
+
+	c, err := seccompConfig(config)
+	assert.NoError(err)
+	assert.Equal(&configs.Seccomp{
+		DefaultAction:   configs.Errno,
+		DefaultErrnoRet: &errno,
+		Architectures:   []string{"amd64", "x32"},
+		Syscalls: []*configs.Syscall{
+			{Name: "read", Action: configs.Allow},
+			{Name: "write", Action: configs.Allow},
+			{
+				Name:   "ioctl",
+				Action: configs.Trap,
+				Args: []*configs.Arg{
+					{Index: 1, Value: 0xff, ValueTwo: 0x0f, Op: configs.MaskEqualTo},
+				},
+			},
+		},
+	}, c)
+
+	for _, invalid := range []*specs.LinuxSeccomp{
+		{DefaultAction: specs.ActNotify},
+		{DefaultAction: specs.ActAllow, Flags: []specs.LinuxSeccompFlag{"SECCOMP_FILTER_FLAG_LOG"}},
+		{DefaultAction: specs.ActAllow, Architectures: []specs.Arch{"SCMP_ARCH_UNKNOWN"}},
+		{DefaultAction: specs.ActAllow, Syscalls: []specs.LinuxSyscall{
+			{Names: []string{"read"}, Action: specs.ActAllow, Args: []specs.LinuxSeccompArg{{Op: "SCMP_CMP_UNKNOWN"}}},
+		}},
+	} {
+		_, err := seccompConfig(invalid)
+		assert.Error(err)
+	}
+}
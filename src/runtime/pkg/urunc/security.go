@@ -0,0 +1,34 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+)
+
+// Security holds the process security settings of a container spec. The
+// monitor being the only host process of a unikernel container, they are
+// applied to it.
+type Security struct {
+	NoNewPrivileges bool                `json:"noNewPrivileges,omitempty"`
+	AppArmorProfile string              `json:"apparmorProfile,omitempty"`
+	Seccomp         *specs.LinuxSeccomp `json:"seccomp,omitempty"`
+}
+
+// SecurityFromSpec returns the process security settings of spec.
+func SecurityFromSpec(spec *specs.Spec) Security {
+	var s Security
+	if spec.Process != nil {
+		s.NoNewPrivileges = spec.Process.NoNewPrivileges
+		s.AppArmorProfile = spec.Process.ApparmorProfile
+	}
+	if spec.Linux != nil {
+		s.Seccomp = spec.Linux.Seccomp
+	}
+	return s
+}
+
+func (s *Security) empty() bool {
+	return !s.NoNewPrivileges && s.AppArmorProfile == "" && s.Seccomp == nil
+}
@@ -0,0 +1,119 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"runtime"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// InitCommand is the hidden subcommand of the shim binary which
+	// runs Init.
+	InitCommand = "urunc-init"
+
+	// initEnv carries the Security of the monitor to the init step.
+	initEnv = "_URUNC_INIT"
+
+	// initFailure is the exit code of the init step when it cannot
+	// execute the monitor.
+	initFailure = 126
+
+	// selfExe re-executes the current binary, which runs the init step
+	// on InitCommand.
+	selfExe = "/proc/self/exe"
+
+	appArmorEnabledPath = "/sys/module/apparmor/parameters/enabled"
+)
+
+// Init applies the security settings of a monitor to the current process,
+// then executes the monitor, argv. Go cannot run code between fork and
+// execve, so a monitor with security settings is started as the
+// InitCommand of the current binary, as runc init does. It does not return.
+func Init(argv []string) {
+	// The settings are per thread, and inherited across execve from
+	// the thread executing it.
+	runtime.LockOSThread()
+
+	err := initMonitor(os.Getenv(initEnv), argv)
+	fmt.Fprintf(os.Stderr, "urunc init: %v\n", err)
+	os.Exit(initFailure)
+}
+
+func initMonitor(config string, argv []string) error {
+	if config == "" {
+		return fmt.Errorf("%s is not set", initEnv)
+	}
+	if err := os.Unsetenv(initEnv); err != nil {
+		return err
+	}
+
+	var s Security
+	if err := json.Unmarshal([]byte(config), &s); err != nil {
+		return err
+	}
+
+	if len(argv) == 0 {
+		return fmt.Errorf("no monitor to execute")
+	}
+	// Look the monitor up while the filter does not apply yet.
+	path, err := exec.LookPath(argv[0])
+	if err != nil {
+		return err
+	}
+
+	if s.AppArmorProfile != "" {
+		if err := applyAppArmor(s.AppArmorProfile); err != nil {
+			return err
+		}
+	}
+
+	if s.NoNewPrivileges {
+		if err := unix.Prctl(unix.PR_SET_NO_NEW_PRIVS, 1, 0, 0, 0); err != nil {
+			return fmt.Errorf("setting no_new_privs: %v", err)
+		}
+	}
+
+	// Last, so that the profile does not have to allow the syscalls
+	// above.
+	if s.Seccomp != nil {
+		if err := loadSeccomp(s.Seccomp); err != nil {
+			return err
+		}
+	}
+
+	return unix.Exec(path, argv, os.Environ())
+}
+
+// applyAppArmor makes the next execve of the calling thread switch to
+// profile.
+func applyAppArmor(profile string) error {
+	enabled, err := os.ReadFile(appArmorEnabledPath)
+	if err != nil || !strings.HasPrefix(string(enabled), "Y") {
+		return fmt.Errorf("AppArmor profile %q requested but AppArmor is not enabled", profile)
+	}
+
+	// Kernels with stackable LSMs have a dedicated AppArmor attribute.
+	attr := "/proc/thread-self/attr/apparmor/exec"
+	if _, err := os.Stat(attr); err != nil {
+		attr = "/proc/thread-self/attr/exec"
+	}
+
+	f, err := os.OpenFile(attr, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if _, err := f.WriteString("exec " + profile); err != nil {
+		return fmt.Errorf("setting AppArmor profile %q: %v", profile, err)
+	}
+	return nil
+}
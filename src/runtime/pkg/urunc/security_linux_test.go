@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMain(m *testing.M) {
+	// The test binary stands for the shim starting the monitors.
+	if len(os.Args) > 1 && os.Args[1] == InitCommand {
+		Init(os.Args[2:])
+	}
+
+	os.Exit(m.Run())
+}
+
+// printStatus prints the security fields of the unikernel status.
+const printStatus = `while read k v; do
+	case $k in NoNewPrivs:|Seccomp:) echo $k $v;; esac
+done < /proc/self/status`
+
+func runSecured(t *testing.T, security Security, script string) (string, string, int) {
+	u := scriptUnikernel(t, script)
+	p, err := NewProcess(&Config{Security: security}, u)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{u.Path}, p.Args())
+
+	_, stdout, stderr, err := p.StdioPipes()
+	assert.NoError(t, err)
+	assert.NoError(t, p.Start())
+
+	out, err := io.ReadAll(stdout)
+	assert.NoError(t, err)
+	errOut, err := io.ReadAll(stderr)
+	assert.NoError(t, err)
+
+	code, err := p.Wait()
+	assert.NoError(t, err)
+	return string(out), string(errOut), code
+}
+
+func TestSecurityFromSpec(t *testing.T) {
+	assert := assert.New(t)
+
+	s := SecurityFromSpec(&specs.Spec{})
+	assert.True(s.empty())
+
+	seccomp := &specs.LinuxSeccomp{DefaultAction: specs.ActAllow}
+	s = SecurityFromSpec(&specs.Spec{
+		Process: &specs.Process{NoNewPrivileges: true, ApparmorProfile: "unikernel"},
+		Linux:   &specs.Linux{Seccomp: seccomp},
+	})
+	assert.Equal(Security{NoNewPrivileges: true, AppArmorProfile: "unikernel", Seccomp: seccomp}, s)
+}
+
+func TestProcessNoNewPrivileges(t *testing.T) {
+	out, _, code := runSecured(t, Security{NoNewPrivileges: true}, printStatus)
+	assert.Zero(t, code)
+	assert.Equal(t, "NoNewPrivs: 1\nSeccomp: 0\n", out)
+}
+
+func TestProcessSeccomp(t *testing.T) {
+	if !SeccompSupported() {
+		t.Skip("built without seccomp support")
+	}
+
+	assert := assert.New(t)
+
+	privateMode := specs.LinuxSeccompArg{Value: 0700, Op: specs.OpEqualTo}
+	security := Security{
+		NoNewPrivileges: true,
+		Seccomp: &specs.LinuxSeccomp{
+			DefaultAction: specs.ActAllow,
+			Syscalls: []specs.LinuxSyscall{
+				{
+					// Directories private to their owner cannot
+					// be created.
+					Names:  []string{"mkdir"},
+					Action: specs.ActErrno,
+					Args:   []specs.LinuxSeccompArg{withIndex(privateMode, 1)},
+				},
+				{
+					Names:  []string{"mkdirat"},
+					Action: specs.ActErrno,
+					Args:   []specs.LinuxSeccompArg{withIndex(privateMode, 2)},
+				},
+			},
+		},
+	}
+
+	out, _, code := runSecured(t, security, printStatus+`
+mkdir -m 755 public || exit 1
+mkdir -m 700 private && exit 2
+exit 0`)
+	assert.Zero(code)
+	assert.Equal("NoNewPrivs: 1\nSeccomp: 2\n", out)
+}
+
+func withIndex(arg specs.LinuxSeccompArg, index uint) specs.LinuxSeccompArg {
+	arg.Index = index
+	return arg
+}
+
+func TestProcessInitFailure(t *testing.T) {
+	if enabled, err := os.ReadFile(appArmorEnabledPath); err == nil && strings.HasPrefix(string(enabled), "Y") {
+		t.Skip("AppArmor is enabled")
+	}
+
+	assert := assert.New(t)
+
+	_, errOut, code := runSecured(t, Security{AppArmorProfile: "unikernel"}, "exit 0")
+	assert.Equal(initFailure, code)
+	assert.Contains(errOut, "AppArmor is not enabled")
+}
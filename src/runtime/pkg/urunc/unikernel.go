@@ -0,0 +1,202 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// pauseBinary is the rootfs file identifying a pause (sandbox) image.
+const pauseBinary = "pause"
+
+// ErrNotUnikernel is returned by Inspect when a rootfs holds neither a
+// unikernel nor a pause binary.
+var ErrNotUnikernel = errors.New("rootfs does not hold a unikernel")
+
+// Unikernel describes the unikernel found in a container rootfs. All paths
+// are absolute host paths.
+type Unikernel struct {
+	// Rootfs is the rootfs directory the unikernel was found in.
+	Rootfs string
+
+	// Type is one of the *Type constants.
+	Type string
+
+	// Path is the unikernel binary.
+	Path string
+
+	// Cmdline is the command line passed to the unikernel, if the image
+	// sets one.
+	Cmdline string
+
+	// Bitstreams are the FPGA bitstreams stored along the unikernel.
+	Bitstreams []string
+
+	// Block is a block image stored in the rootfs, to be attached to
+	// the unikernel as its disk.
+	Block string
+}
+
+// Inspect looks up the unikernel held by rootfs. Images built by
+// "kata-runtime unikernel-image" are described by their MetadataFile,
+// older images are expected to hold a single binary under UnikernelDir,
+// its type being derived from its name.
+func Inspect(rootfs string) (*Unikernel, error) {
+	rootfs, err := filepath.Abs(rootfs)
+	if err != nil {
+		return nil, err
+	}
+
+	if _, err := os.Stat(filepath.Join(rootfs, pauseBinary)); err == nil {
+		return &Unikernel{
+			Rootfs: rootfs,
+			Type:   PauseType,
+			Path:   filepath.Join(rootfs, pauseBinary),
+		}, nil
+	}
+
+	u := &Unikernel{Rootfs: rootfs}
+
+	annotations, err := readMetadata(rootfs)
+	if err != nil {
+		return nil, err
+	}
+
+	if annotations != nil {
+		if err := u.fromAnnotations(annotations); err != nil {
+			return nil, err
+		}
+	} else {
+		if u.Path, err = findBinary(rootfs); err != nil {
+			return nil, err
+		}
+		u.Type = TypeFromPath(u.Path)
+		if u.Bitstreams, err = findBitstreams(rootfs); err != nil {
+			return nil, err
+		}
+	}
+
+	if _, err := os.Stat(u.Path); err != nil {
+		return nil, fmt.Errorf("unikernel binary: %v", err)
+	}
+
+	return u, nil
+}
+
+// readMetadata returns the annotations stored in the rootfs MetadataFile,
+// or nil if there is none.
+func readMetadata(rootfs string) (map[string]string, error) {
+	b, err := os.ReadFile(filepath.Join(rootfs, MetadataFile))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var annotations map[string]string
+	if err := json.Unmarshal(b, &annotations); err != nil {
+		return nil, fmt.Errorf("invalid %s: %v", MetadataFile, err)
+	}
+	return annotations, nil
+}
+
+func (u *Unikernel) fromAnnotations(annotations map[string]string) error {
+	binary := annotations[AnnotationBinary]
+	if binary == "" {
+		return fmt.Errorf("%s does not set %s", MetadataFile, AnnotationBinary)
+	}
+
+	var err error
+	if u.Path, err = u.rootfsPath(binary); err != nil {
+		return err
+	}
+
+	u.Type = annotations[AnnotationType]
+	switch u.Type {
+	case "":
+		u.Type = TypeFromPath(u.Path)
+	case HvtType, QemuType, BinaryType:
+	default:
+		return fmt.Errorf("unsupported unikernel type %q", u.Type)
+	}
+
+	u.Cmdline = annotations[AnnotationCmdline]
+
+	if bitstreams := annotations[AnnotationBitstreams]; bitstreams != "" {
+		for _, b := range strings.Split(bitstreams, ",") {
+			p, err := u.rootfsPath(b)
+			if err != nil {
+				return err
+			}
+			u.Bitstreams = append(u.Bitstreams, p)
+		}
+	}
+
+	if block := annotations[AnnotationBlock]; block != "" {
+		if u.Block, err = u.rootfsPath(block); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// rootfsPath resolves a path found in the image metadata, refusing the ones
+// escaping the rootfs.
+func (u *Unikernel) rootfsPath(p string) (string, error) {
+	clean := filepath.Clean("/" + p)
+	if clean == "/" {
+		return "", fmt.Errorf("invalid rootfs path %q", p)
+	}
+	return filepath.Join(u.Rootfs, clean), nil
+}
+
+func findBinary(rootfs string) (string, error) {
+	entries, err := os.ReadDir(filepath.Join(rootfs, UnikernelDir))
+	if os.IsNotExist(err) {
+		return "", ErrNotUnikernel
+	}
+	if err != nil {
+		return "", err
+	}
+
+	var binaries []string
+	for _, e := range entries {
+		if !e.IsDir() {
+			binaries = append(binaries, e.Name())
+		}
+	}
+
+	switch len(binaries) {
+	case 0:
+		return "", ErrNotUnikernel
+	case 1:
+		return filepath.Join(rootfs, UnikernelDir, binaries[0]), nil
+	default:
+		return "", fmt.Errorf("multiple files found in /%s: %s", UnikernelDir, strings.Join(binaries, ", "))
+	}
+}
+
+func findBitstreams(rootfs string) ([]string, error) {
+	entries, err := os.ReadDir(rootfs)
+	if err != nil {
+		return nil, err
+	}
+
+	var bitstreams []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), BitstreamExt) {
+			bitstreams = append(bitstreams, filepath.Join(rootfs, e.Name()))
+		}
+	}
+	sort.Strings(bitstreams)
+	return bitstreams, nil
+}
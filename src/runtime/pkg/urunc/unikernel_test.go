@@ -0,0 +1,135 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package urunc
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeRootfsFile(t *testing.T, rootfs, name string) string {
+	p := filepath.Join(rootfs, name)
+	assert.NoError(t, os.MkdirAll(filepath.Dir(p), 0755))
+	assert.NoError(t, os.WriteFile(p, []byte(name), 0755))
+	return p
+}
+
+func writeMetadata(t *testing.T, rootfs string, annotations map[string]string) {
+	b, err := json.Marshal(annotations)
+	assert.NoError(t, err)
+	assert.NoError(t, os.WriteFile(filepath.Join(rootfs, MetadataFile), b, 0644))
+}
+
+func TestInspectPause(t *testing.T) {
+	assert := assert.New(t)
+	rootfs := t.TempDir()
+	pause := writeRootfsFile(t, rootfs, "pause")
+
+	u, err := Inspect(rootfs)
+	assert.NoError(err)
+	assert.Equal(PauseType, u.Type)
+	assert.Equal(pause, u.Path)
+}
+
+func TestInspectNotUnikernel(t *testing.T) {
+	assert := assert.New(t)
+	rootfs := t.TempDir()
+
+	_, err := Inspect(rootfs)
+	assert.Equal(ErrNotUnikernel, err)
+
+	assert.NoError(os.Mkdir(filepath.Join(rootfs, UnikernelDir), 0755))
+	_, err = Inspect(rootfs)
+	assert.Equal(ErrNotUnikernel, err)
+}
+
+func TestInspectUnikernelDir(t *testing.T) {
+	assert := assert.New(t)
+	rootfs := t.TempDir()
+
+	binary := writeRootfsFile(t, rootfs, "unikernel/redis.hvt")
+	vadd := writeRootfsFile(t, rootfs, "vadd.xclbin")
+	mult := writeRootfsFile(t, rootfs, "mult.xclbin")
+	writeRootfsFile(t, rootfs, "redis.conf")
+
+	u, err := Inspect(rootfs)
+	assert.NoError(err)
+	assert.Equal(&Unikernel{
+		Rootfs:     rootfs,
+		Type:       HvtType,
+		Path:       binary,
+		Bitstreams: []string{mult, vadd},
+	}, u)
+
+	writeRootfsFile(t, rootfs, "unikernel/other.hvt")
+	_, err = Inspect(rootfs)
+	assert.Error(err)
+}
+
+func TestInspectMetadata(t *testing.T) {
+	assert := assert.New(t)
+	rootfs := t.TempDir()
+
+	binary := writeRootfsFile(t, rootfs, "unikernel/app")
+	vadd := writeRootfsFile(t, rootfs, "vadd.xclbin")
+	block := writeRootfsFile(t, rootfs, "block.img")
+	writeMetadata(t, rootfs, map[string]string{
+		AnnotationBinary:     "/unikernel/app",
+		AnnotationType:       QemuType,
+		AnnotationCmdline:    "app --verbose",
+		AnnotationBitstreams: "vadd.xclbin",
+		AnnotationBlock:      "/block.img",
+	})
+
+	u, err := Inspect(rootfs)
+	assert.NoError(err)
+	assert.Equal(&Unikernel{
+		Rootfs:     rootfs,
+		Type:       QemuType,
+		Path:       binary,
+		Cmdline:    "app --verbose",
+		Bitstreams: []string{vadd},
+		Block:      block,
+	}, u)
+}
+
+func TestInspectInvalidMetadata(t *testing.T) {
+	assert := assert.New(t)
+	rootfs := t.TempDir()
+	writeRootfsFile(t, rootfs, "unikernel/app.hvt")
+
+	for _, annotations := range []map[string]string{
+		{},
+		{AnnotationBinary: "/unikernel/missing.hvt"},
+		{AnnotationBinary: "/unikernel/app.hvt", AnnotationType: "firecracker"},
+		{AnnotationBinary: "/"},
+	} {
+		writeMetadata(t, rootfs, annotations)
+		_, err := Inspect(rootfs)
+		assert.Error(err, "%v", annotations)
+	}
+
+	assert.NoError(os.WriteFile(filepath.Join(rootfs, MetadataFile), []byte("{"), 0644))
+	_, err := Inspect(rootfs)
+	assert.Error(err)
+}
+
+func TestInspectMetadataEscape(t *testing.T) {
+	assert := assert.New(t)
+	rootfs := t.TempDir()
+	binary := writeRootfsFile(t, rootfs, "etc/passwd")
+	writeMetadata(t, rootfs, map[string]string{
+		AnnotationBinary: "../../../etc/passwd",
+	})
+
+	// Paths are resolved within the rootfs.
+	u, err := Inspect(rootfs)
+	assert.NoError(err)
+	assert.Equal(binary, u.Path)
+	assert.Equal(BinaryType, u.Type)
+}
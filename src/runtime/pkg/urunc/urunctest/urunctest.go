@@ -0,0 +1,150 @@
+// SPDX-License-Identifier: Apache-2.0
+//
+
+// Package urunctest provides a mock unikernel monitor and fixture bundles,
+// so that running unikernel containers can be tested without KVM or root.
+//
+// The mock monitor (see pkg/urunc/mockmonitor) stands for solo5-hvt, QEMU or
+// a binary unikernel. It records how it was run to the file named by
+// RecordEnv, then exits with the code in ExitEnv or, when unset, runs until
+// it is signalled.
+package urunctest
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"testing"
+	"time"
+)
+
+const (
+	// RecordEnv names the file the mock monitor records its Record to.
+	RecordEnv = "URUNC_MOCK_MONITOR_RECORD"
+
+	// ExitEnv is the code the mock monitor exits with. The monitor runs
+	// until it is signalled if ExitEnv is not set.
+	ExitEnv = "URUNC_MOCK_MONITOR_EXIT"
+
+	// UnikernelBinary is the binary unikernel of the "binary" bundle,
+	// relative to its rootfs. It is a copy of the mock monitor.
+	UnikernelBinary = "unikernel/hello"
+)
+
+// Types are the unikernel types a fixture bundle exists for.
+var Types = []string{"hvt", "qemu", "binary"}
+
+// Record is what the mock monitor records of its execution.
+type Record struct {
+	Args  []string `json:"args"`
+	Env   []string `json:"env"`
+	Dir   string   `json:"dir"`
+	NetNs string   `json:"netns"`
+}
+
+// dir returns the directory of this package sources.
+func dir() string {
+	_, file, _, _ := runtime.Caller(0)
+	return filepath.Dir(file)
+}
+
+// Monitor returns the path of the mock monitor. The binary built by
+// "make -C pkg/urunc/mockmonitor" is used if present, otherwise it is built
+// in a temporary directory of t.
+func Monitor(t testing.TB) string {
+	src := filepath.Join(dir(), "..", "mockmonitor")
+	path := filepath.Join(src, "monitor")
+	if _, err := os.Stat(path); err == nil {
+		return path
+	}
+
+	path = filepath.Join(t.TempDir(), "monitor")
+	cmd := exec.Command("go", "build", "-o", path, ".")
+	cmd.Dir = src
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("failed to build the mock monitor: %v: %s", err, out)
+	}
+	return path
+}
+
+// Bundle copies the fixture bundle of the unikernel type typ to a temporary
+// directory of t and returns its path. The unikernel of the "binary" bundle
+// is the mock monitor.
+func Bundle(t testing.TB, typ string) string {
+	src := filepath.Join(dir(), "testdata", "bundles", typ)
+	bundle := filepath.Join(t.TempDir(), typ)
+
+	err := filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return os.MkdirAll(filepath.Join(bundle, rel), 0755)
+		}
+		return copyFile(path, filepath.Join(bundle, rel), 0644)
+	})
+	if err != nil {
+		t.Fatalf("failed to copy the %s bundle: %v", typ, err)
+	}
+
+	if typ == "binary" {
+		dst := filepath.Join(bundle, "rootfs", UnikernelBinary)
+		if err := copyFile(Monitor(t), dst, 0755); err != nil {
+			t.Fatalf("failed to copy the mock monitor: %v", err)
+		}
+	}
+
+	return bundle
+}
+
+func copyFile(src, dst string, perm os.FileMode) error {
+	b, err := os.ReadFile(src)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(dst), 0755); err != nil {
+		return err
+	}
+	return os.WriteFile(dst, b, perm)
+}
+
+// ReadRecord reads the Record written to path by the mock monitor, waiting
+// up to timeout for it to be written.
+func ReadRecord(path string, timeout time.Duration) (*Record, error) {
+	deadline := time.Now().Add(timeout)
+	for {
+		b, err := os.ReadFile(path)
+		if err == nil {
+			var r Record
+			if err := json.Unmarshal(b, &r); err != nil {
+				return nil, err
+			}
+			return &r, nil
+		}
+		if !os.IsNotExist(err) {
+			return nil, err
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("mock monitor did not record %s", path)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+// Getenv returns the value of key in the environment of r.
+func (r *Record) Getenv(key string) (string, bool) {
+	for _, kv := range r.Env {
+		if len(kv) > len(key) && kv[:len(key)] == key && kv[len(key)] == '=' {
+			return kv[len(key)+1:], true
+		}
+	}
+	return "", false
+}
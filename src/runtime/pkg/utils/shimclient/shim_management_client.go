@@ -72,8 +72,18 @@ func DoPost(sandboxID string, timeoutInSeconds time.Duration, urlPath string, pa
 	}
 
 	resp, err := client.Post(fmt.Sprintf("http://shim/%s", urlPath), "application/json", bytes.NewBuffer(payload))
+	if err != nil {
+		return err
+	}
+
 	defer func() {
 		resp.Body.Close()
 	}()
-	return err
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("%s: %s", resp.Status, string(body))
+	}
+
+	return nil
 }
@@ -85,6 +85,12 @@ func createSandboxFromConfig(ctx context.Context, sandboxConfig SandboxConfig, f
 		}
 	}()
 
+	if s.checkpointNetwork != nil {
+		if err = s.restoreCheckpointNetwork(); err != nil {
+			return nil, err
+		}
+	}
+
 	// Set the sandbox host cgroups.
 	if err := s.setupResourceController(); err != nil {
 		return nil, err
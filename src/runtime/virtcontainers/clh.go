@@ -30,6 +30,7 @@ import (
 
 	hv "github.com/kata-containers/kata-containers/src/runtime/pkg/hypervisors"
 	"github.com/kata-containers/kata-containers/src/runtime/pkg/katautils/katatrace"
+	resCtrl "github.com/kata-containers/kata-containers/src/runtime/pkg/resourcecontrol"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/device/config"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
 	vcTypes "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
@@ -58,6 +59,17 @@ const (
 const (
 	clhStateCreated = "Created"
 	clhStateRunning = "Running"
+	clhStatePaused  = "Paused"
+)
+
+const (
+	// Memory hotplug methods of cloud-hypervisor
+	clhHotplugMethodAcpi      = "Acpi"
+	clhHotplugMethodVirtioMem = "VirtioMem"
+
+	// Granularity of the memory cloud-hypervisor plugs and unplugs
+	// through virtio-mem.
+	clhVirtioMemBlockSizeMB = 128
 )
 
 const (
@@ -69,11 +81,15 @@ const (
 	// Use longer time timeout for it.
 	clhHotPlugAPITimeout  = 5
 	clhStopSandboxTimeout = 3
-	clhSocket             = "clh.sock"
-	clhAPISocket          = "clh-api.sock"
-	virtioFsSocket        = "virtiofsd.sock"
-	defaultClhPath        = "/usr/local/bin/cloud-hypervisor"
-	virtioFsCacheAlways   = "always"
+	clhSnapshotAPITimeout = 60
+	// Migrations copy the whole memory of the VM
+	clhMigrationAPITimeout = 600
+	clhSocket              = "clh.sock"
+	clhAPISocket           = "clh-api.sock"
+	virtioFsSocket         = "virtiofsd.sock"
+	clhSnapshotConfig      = "config.json"
+	defaultClhPath         = "/usr/local/bin/cloud-hypervisor"
+	virtioFsCacheAlways    = "always"
 )
 
 // Interface that hides the implementation of openAPI client
@@ -98,8 +114,24 @@ type clhClient interface {
 	VmAddDevicePut(ctx context.Context, vmAddDevice chclient.VmAddDevice) (chclient.PciDeviceInfo, *http.Response, error)
 	// Add a new disk device to the VM
 	VmAddDiskPut(ctx context.Context, diskConfig chclient.DiskConfig) (chclient.PciDeviceInfo, *http.Response, error)
+	// Add a new network device to the VM
+	VmAddNetPut(ctx context.Context, netConfig chclient.NetConfig) (chclient.PciDeviceInfo, *http.Response, error)
 	// Remove a device from the VM
 	VmRemoveDevicePut(ctx context.Context, vmRemoveDevice chclient.VmRemoveDevice) (*http.Response, error)
+	// Pause the VM
+	PauseVM(ctx context.Context) (*http.Response, error)
+	// Resume the VM
+	ResumeVM(ctx context.Context) (*http.Response, error)
+	// Snapshot the VM
+	VmSnapshotPut(ctx context.Context, vmSnapshotConfig chclient.VmSnapshotConfig) (*http.Response, error)
+	// Restore the VM from a snapshot
+	VmRestorePut(ctx context.Context, restoreConfig chclient.RestoreConfig) (*http.Response, error)
+	// Send the VM to a VM receiving a migration
+	VmSendMigrationPut(ctx context.Context, sendMigrationData chclient.SendMigrationData) (*http.Response, error)
+	// Receive a migrated VM
+	VmReceiveMigrationPut(ctx context.Context, receiveMigrationData chclient.ReceiveMigrationData) (*http.Response, error)
+	// Get the counters of the VM devices
+	VmCountersGet(ctx context.Context) (map[string]map[string]int64, *http.Response, error)
 }
 
 type clhClientApi struct {
@@ -139,18 +171,51 @@ func (c *clhClientApi) VmAddDiskPut(ctx context.Context, diskConfig chclient.Dis
 	return c.ApiInternal.VmAddDiskPut(ctx).DiskConfig(diskConfig).Execute()
 }
 
+func (c *clhClientApi) VmAddNetPut(ctx context.Context, netConfig chclient.NetConfig) (chclient.PciDeviceInfo, *http.Response, error) {
+	return c.ApiInternal.VmAddNetPut(ctx).NetConfig(netConfig).Execute()
+}
+
 func (c *clhClientApi) VmRemoveDevicePut(ctx context.Context, vmRemoveDevice chclient.VmRemoveDevice) (*http.Response, error) {
 	return c.ApiInternal.VmRemoveDevicePut(ctx).VmRemoveDevice(vmRemoveDevice).Execute()
 }
 
+func (c *clhClientApi) PauseVM(ctx context.Context) (*http.Response, error) {
+	return c.ApiInternal.PauseVM(ctx).Execute()
+}
+
+func (c *clhClientApi) ResumeVM(ctx context.Context) (*http.Response, error) {
+	return c.ApiInternal.ResumeVM(ctx).Execute()
+}
+
+func (c *clhClientApi) VmSnapshotPut(ctx context.Context, vmSnapshotConfig chclient.VmSnapshotConfig) (*http.Response, error) {
+	return c.ApiInternal.VmSnapshotPut(ctx).VmSnapshotConfig(vmSnapshotConfig).Execute()
+}
+
+func (c *clhClientApi) VmRestorePut(ctx context.Context, restoreConfig chclient.RestoreConfig) (*http.Response, error) {
+	return c.ApiInternal.VmRestorePut(ctx).RestoreConfig(restoreConfig).Execute()
+}
+
+func (c *clhClientApi) VmSendMigrationPut(ctx context.Context, sendMigrationData chclient.SendMigrationData) (*http.Response, error) {
+	return c.ApiInternal.VmSendMigrationPut(ctx).SendMigrationData(sendMigrationData).Execute()
+}
+
+func (c *clhClientApi) VmReceiveMigrationPut(ctx context.Context, receiveMigrationData chclient.ReceiveMigrationData) (*http.Response, error) {
+	return c.ApiInternal.VmReceiveMigrationPut(ctx).ReceiveMigrationData(receiveMigrationData).Execute()
+}
+
+func (c *clhClientApi) VmCountersGet(ctx context.Context) (map[string]map[string]int64, *http.Response, error) {
+	return c.ApiInternal.VmCountersGet(ctx).Execute()
+}
+
 //
 // Cloud hypervisor state
 //
 type CloudHypervisorState struct {
-	apiSocket         string
-	PID               int
-	VirtiofsDaemonPid int
-	state             clhState
+	apiSocket           string
+	memoryHotplugMethod string
+	PID                 int
+	VirtiofsDaemonPid   int
+	state               clhState
 }
 
 func (s *CloudHypervisorState) reset() {
@@ -168,6 +233,11 @@ type cloudHypervisor struct {
 	vmconfig       chclient.VmConfig
 	state          CloudHypervisorState
 	config         HypervisorConfig
+
+	// jailController is the devices cgroup of the jail, and jailMounts
+	// the resources bind mounted in it.
+	jailController resCtrl.ResourceController
+	jailMounts     []string
 }
 
 var clhKernelParams = []Param{
@@ -197,6 +267,16 @@ func (clh *cloudHypervisor) setConfig(config *HypervisorConfig) error {
 		return err
 	}
 
+	if err := checkJailConfig(config); err != nil {
+		return err
+	}
+
+	if config.MigrationIncomingURI != "" {
+		if err := checkMigrationConfig(config, config.MigrationIncomingURI); err != nil {
+			return err
+		}
+	}
+
 	clh.config = *config
 
 	return nil
@@ -363,6 +443,9 @@ func (clh *cloudHypervisor) CreateVM(ctx context.Context, id string, network Net
 	clh.id = id
 	clh.state.state = clhNotReady
 
+	// register cloud-hypervisor specific metrics
+	registerCloudHypervisorMetrics()
+
 	clh.Logger().WithField("function", "CreateVM").Info("creating Sandbox")
 
 	if clh.state.PID > 0 {
@@ -406,8 +489,24 @@ func (clh *cloudHypervisor) CreateVM(ctx context.Context, id string, network Net
 		if err != nil {
 			return nil
 		}
+		hotplugSize := utils.MemUnit(hostMemKb) * utils.KiB
+
+		clh.state.memoryHotplugMethod = clhHotplugMethodAcpi
+		if clh.config.VirtioMem {
+			// The virtio-mem region comes in addition to the boot
+			// memory, and is plugged by blocks.
+			bootMem := utils.MemUnit(clh.config.MemorySize) * utils.MiB
+			blockSize := utils.MemUnit(clhVirtioMemBlockSizeMB) * utils.MiB
+			if hotplugSize > bootMem {
+				hotplugSize = (hotplugSize - bootMem) / blockSize * blockSize
+			} else {
+				hotplugSize = 0
+			}
+			clh.state.memoryHotplugMethod = clhHotplugMethodVirtioMem
+		}
+		clh.vmconfig.Memory.HotplugMethod = func(s string) *string { return &s }(clh.state.memoryHotplugMethod)
 		// OpenAPI only supports int64 values
-		clh.vmconfig.Memory.HotplugSize = func(i int64) *int64 { return &i }(int64((utils.MemUnit(hostMemKb) * utils.KiB).ToBytes()))
+		clh.vmconfig.Memory.HotplugSize = func(i int64) *int64 { return &i }(int64(hotplugSize.ToBytes()))
 	}
 	// Set initial amount of cpu's for the virtual machine
 	clh.vmconfig.Cpus = chclient.NewCpusConfig(int32(clh.config.NumVCPUs), int32(clh.config.DefaultMaxVCPUs))
@@ -496,24 +595,7 @@ func (clh *cloudHypervisor) CreateVM(ctx context.Context, id string, network Net
 		return err
 	}
 	clh.state.apiSocket = apiSocketPath
-
-	cfg := chclient.NewConfiguration()
-	cfg.HTTPClient = &http.Client{
-		Transport: &http.Transport{
-			DialContext: func(ctx context.Context, network, path string) (net.Conn, error) {
-				addr, err := net.ResolveUnixAddr("unix", clh.state.apiSocket)
-				if err != nil {
-					return nil, err
-				}
-
-				return net.DialUnix("unix", nil, addr)
-			},
-		},
-	}
-
-	clh.APIClient = &clhClientApi{
-		ApiInternal: chclient.NewAPIClient(cfg).DefaultApi,
-	}
+	clh.APIClient = clh.newAPIClient()
 
 	clh.virtiofsDaemon, err = clh.createVirtiofsDaemon(filepath.Join(GetSharePath(clh.id)))
 	if err != nil {
@@ -563,6 +645,18 @@ func (clh *cloudHypervisor) StartVM(ctx context.Context, timeout int) error {
 		defer label.SetProcessLabel("")
 	}
 
+	// virtiofsd creates its socket in the jail, which is set up first.
+	if clh.config.EnableJail {
+		clhPath, err := clh.clhPath()
+		if err != nil {
+			return err
+		}
+
+		if err = clh.setupJail(ctx, clhPath); err != nil {
+			return err
+		}
+	}
+
 	err = clh.setupVirtiofsDaemon(ctx)
 	if err != nil {
 		return err
@@ -581,7 +675,17 @@ func (clh *cloudHypervisor) StartVM(ctx context.Context, timeout int) error {
 	}
 	clh.state.PID = pid
 
-	if err := clh.bootVM(ctx); err != nil {
+	// VMs booted from template are restored paused, and resumed by the
+	// factory or the sandbox.
+	if clh.config.BootFromTemplate {
+		if err = clh.restoreVM(); err != nil {
+			return err
+		}
+	} else if clh.config.MigrationIncomingURI != "" {
+		if err = clh.receiveVM(); err != nil {
+			return err
+		}
+	} else if err = clh.bootVM(ctx); err != nil {
 		return err
 	}
 
@@ -622,6 +726,12 @@ func clhDriveIndexToID(i int) string {
 	return "clh_drive_" + strconv.Itoa(i)
 }
 
+// clhNetID returns the cloud-hypervisor device ID of a network
+// interface, so that it can be removed again after a hotplug.
+func clhNetID(tap *TapInterface) string {
+	return "clh_net_" + tap.ID
+}
+
 // Various cloud-hypervisor APIs report a PCI address in "BB:DD.F"
 // form within the PciDeviceInfo struct.  This is a broken API,
 // because there's no way clh can reliably know the guest side bdf for
@@ -665,8 +775,15 @@ func (clh *cloudHypervisor) hotplugAddBlockDevice(drive *config.BlockDrive) erro
 		return fmt.Errorf("pmem device hotplug not supported")
 	}
 
+	path := drive.File
+	if clh.config.EnableJail {
+		if path, err = clh.jailHotplugDrive(drive.File, driveID); err != nil {
+			return err
+		}
+	}
+
 	// Create the clh disk config via the constructor to ensure default values are properly assigned
-	clhDisk := *chclient.NewDiskConfig(drive.File)
+	clhDisk := *chclient.NewDiskConfig(path)
 	clhDisk.Readonly = &drive.ReadOnly
 	clhDisk.VhostUser = func(b bool) *bool { return &b }(false)
 	clhDisk.Id = &driveID
@@ -674,6 +791,9 @@ func (clh *cloudHypervisor) hotplugAddBlockDevice(drive *config.BlockDrive) erro
 	pciInfo, _, err := cl.VmAddDiskPut(ctx, clhDisk)
 
 	if err != nil {
+		if clh.config.EnableJail {
+			clh.releaseJailResource(driveID)
+		}
 		return fmt.Errorf("failed to hotplug block device %+v %s", drive, openAPIClientError(err))
 	}
 
@@ -683,6 +803,10 @@ func (clh *cloudHypervisor) hotplugAddBlockDevice(drive *config.BlockDrive) erro
 }
 
 func (clh *cloudHypervisor) hotPlugVFIODevice(device *config.VFIODev) error {
+	if clh.config.EnableJail {
+		return fmt.Errorf("VFIO devices are not supported with a jailed cloud-hypervisor")
+	}
+
 	cl := clh.client()
 	ctx, cancel := context.WithTimeout(context.Background(), clhHotPlugAPITimeout*time.Second)
 	defer cancel()
@@ -716,6 +840,30 @@ func (clh *cloudHypervisor) hotPlugVFIODevice(device *config.VFIODev) error {
 	return err
 }
 
+func (clh *cloudHypervisor) hotplugAddNetDevice(endpoint Endpoint) error {
+	net, err := clh.netConfig(endpoint)
+	if err != nil {
+		return err
+	}
+
+	cl := clh.client()
+	ctx, cancel := context.WithTimeout(context.Background(), clhHotPlugAPITimeout*time.Second)
+	defer cancel()
+
+	pciInfo, _, err := cl.VmAddNetPut(ctx, *net)
+	if err != nil {
+		return fmt.Errorf("failed to hotplug network device %s: %s", endpoint.Name(), openAPIClientError(err))
+	}
+
+	pciPath, err := clhPciInfoToPath(pciInfo)
+	if err != nil {
+		return err
+	}
+	endpoint.SetPciPath(pciPath)
+
+	return nil
+}
+
 func (clh *cloudHypervisor) HotplugAddDevice(ctx context.Context, devInfo interface{}, devType DeviceType) (interface{}, error) {
 	span, _ := katatrace.Trace(ctx, clh.Logger(), "HotplugAddDevice", clhTracingTags, map[string]string{"sandbox_id": clh.id})
 	defer span.End()
@@ -727,6 +875,9 @@ func (clh *cloudHypervisor) HotplugAddDevice(ctx context.Context, devInfo interf
 	case VfioDev:
 		device := devInfo.(*config.VFIODev)
 		return nil, clh.hotPlugVFIODevice(device)
+	case NetDev:
+		endpoint := devInfo.(Endpoint)
+		return nil, clh.hotplugAddNetDevice(endpoint)
 	default:
 		return nil, fmt.Errorf("cannot hotplug device: unsupported device type '%v'", devType)
 	}
@@ -744,6 +895,12 @@ func (clh *cloudHypervisor) HotplugRemoveDevice(ctx context.Context, devInfo int
 		deviceID = clhDriveIndexToID(devInfo.(*config.BlockDrive).Index)
 	case VfioDev:
 		deviceID = devInfo.(*config.VFIODev).ID
+	case NetDev:
+		tap, err := clhTapInterface(devInfo.(Endpoint))
+		if err != nil {
+			return nil, err
+		}
+		deviceID = clhNetID(tap)
 	default:
 		clh.Logger().WithFields(log.Fields{"devInfo": devInfo,
 			"deviceType": devType}).Error("HotplugRemoveDevice: unsupported device")
@@ -760,6 +917,8 @@ func (clh *cloudHypervisor) HotplugRemoveDevice(ctx context.Context, devInfo int
 	_, err := cl.VmRemoveDevicePut(ctx, remove)
 	if err != nil {
 		err = fmt.Errorf("failed to hotplug remove (unplug) device %+v: %s", devInfo, openAPIClientError(err))
+	} else if devType == BlockDev && clh.config.EnableJail {
+		clh.releaseJailResource(deviceID)
 	}
 
 	return nil, err
@@ -771,7 +930,9 @@ func (clh *cloudHypervisor) HypervisorConfig() HypervisorConfig {
 
 func (clh *cloudHypervisor) ResizeMemory(ctx context.Context, reqMemMB uint32, memoryBlockSizeMB uint32, probe bool) (uint32, MemoryDevice, error) {
 
-	// TODO: Add support for virtio-mem
+	if clh.state.memoryHotplugMethod == clhHotplugMethodVirtioMem {
+		return clh.resizeVirtioMem(ctx, reqMemMB)
+	}
 
 	if probe {
 		return 0, MemoryDevice{}, errors.New("probe memory is not supported for cloud-hypervisor")
@@ -835,6 +996,50 @@ func (clh *cloudHypervisor) ResizeMemory(ctx context.Context, reqMemMB uint32, m
 	return uint32(newMem.ToMiB()), MemoryDevice{SizeMB: int(hotplugSize.ToMiB())}, nil
 }
 
+// resizeVirtioMem grows or shrinks the memory of the VM to reqMemMB, by
+// plugging or unplugging virtio-mem blocks. The boot memory is never
+// unplugged.
+func (clh *cloudHypervisor) resizeVirtioMem(ctx context.Context, reqMemMB uint32) (uint32, MemoryDevice, error) {
+	info, err := clh.vmInfo()
+	if err != nil {
+		return 0, MemoryDevice{}, err
+	}
+
+	bootMem := utils.MemUnit(info.Config.Memory.Size) * utils.Byte
+	currentMem := bootMem + utils.MemUnit(info.Config.Memory.GetHotpluggedSize())*utils.Byte
+	newMem := utils.MemUnit(reqMemMB) * utils.MiB
+
+	if newMem <= bootMem {
+		newMem = bootMem
+	} else {
+		blockSize := utils.MemUnit(clhVirtioMemBlockSizeMB) * utils.MiB
+		newMem = bootMem + (newMem - bootMem).AlignMem(blockSize)
+	}
+
+	if maxMem := bootMem + utils.MemUnit(info.Config.Memory.GetHotplugSize())*utils.Byte; newMem > maxMem {
+		clh.Logger().WithFields(log.Fields{"request": newMem, "max-memory": maxMem}).Warn("virtio-mem region exhausted")
+		newMem = maxMem
+	}
+
+	if currentMem == newMem {
+		clh.Logger().WithField("memory", newMem).Debug("VM already has requested memory")
+		return uint32(currentMem.ToMiB()), MemoryDevice{}, nil
+	}
+
+	ctx, cancelResize := context.WithTimeout(ctx, clhHotPlugAPITimeout*time.Second)
+	defer cancelResize()
+
+	resize := *chclient.NewVmResize()
+	// OpenApi does not support uint64, convert to int64
+	resize.DesiredRam = func(i int64) *int64 { return &i }(int64(newMem.ToBytes()))
+	clh.Logger().WithFields(log.Fields{"current-memory": currentMem, "new-memory": newMem}).Debug("resizing VM memory through virtio-mem")
+	if _, err = clh.client().VmResizePut(ctx, resize); err != nil {
+		return uint32(currentMem.ToMiB()), MemoryDevice{}, fmt.Errorf("Failed to resize memory from %d to %d: %s", currentMem, newMem, openAPIClientError(err))
+	}
+
+	return uint32(newMem.ToMiB()), MemoryDevice{}, nil
+}
+
 func (clh *cloudHypervisor) ResizeVCPUs(ctx context.Context, reqVCPUs uint32) (currentVCPUs uint32, newVCPUs uint32, err error) {
 	cl := clh.client()
 
@@ -883,17 +1088,107 @@ func (clh *cloudHypervisor) Cleanup(ctx context.Context) error {
 }
 
 func (clh *cloudHypervisor) PauseVM(ctx context.Context) error {
+	span, _ := katatrace.Trace(ctx, clh.Logger(), "PauseVM", clhTracingTags, map[string]string{"sandbox_id": clh.id})
+	defer span.End()
+
 	clh.Logger().WithField("function", "PauseVM").Info("Pause Sandbox")
+
+	ctx, cancel := context.WithTimeout(context.Background(), clhAPITimeout*time.Second)
+	defer cancel()
+
+	if _, err := clh.client().PauseVM(ctx); err != nil {
+		return openAPIClientError(err)
+	}
+
 	return nil
 }
 
+// SaveVM snapshots the paused VM to the DevicesStatePath directory. Cloud
+// hypervisor saves the memory of the VM along with the state of its
+// devices, MemoryPath is not used.
 func (clh *cloudHypervisor) SaveVM() error {
-	clh.Logger().WithField("function", "saveSandboxC").Info("Save Sandbox")
-	return nil
+	clh.Logger().WithField("function", "SaveVM").Info("Save Sandbox")
+
+	if clh.config.DevicesStatePath == "" {
+		return errors.New("Missing DevicesStatePath to save the VM")
+	}
+
+	return clh.snapshotVM(clh.ctx, clh.config.DevicesStatePath)
 }
 
 func (clh *cloudHypervisor) ResumeVM(ctx context.Context) error {
+	span, _ := katatrace.Trace(ctx, clh.Logger(), "ResumeVM", clhTracingTags, map[string]string{"sandbox_id": clh.id})
+	defer span.End()
+
 	clh.Logger().WithField("function", "ResumeVM").Info("Resume Sandbox")
+
+	ctx, cancel := context.WithTimeout(context.Background(), clhAPITimeout*time.Second)
+	defer cancel()
+
+	if _, err := clh.client().ResumeVM(ctx); err != nil {
+		return openAPIClientError(err)
+	}
+
+	return nil
+}
+
+// snapshotVM snapshots the paused VM to dir.
+func (clh *cloudHypervisor) snapshotVM(ctx context.Context, dir string) error {
+	span, _ := katatrace.Trace(ctx, clh.Logger(), "snapshotVM", clhTracingTags, map[string]string{"sandbox_id": clh.id})
+	defer span.End()
+
+	clh.Logger().WithField("function", "snapshotVM").WithField("dir", dir).Info("Snapshot Sandbox")
+
+	if err := os.MkdirAll(dir, DirMode); err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clhSnapshotAPITimeout*time.Second)
+	defer cancel()
+
+	snapshot := chclient.NewVmSnapshotConfig()
+	snapshot.SetDestinationUrl("file://" + dir)
+	if _, err := clh.client().VmSnapshotPut(ctx, *snapshot); err != nil {
+		return openAPIClientError(err)
+	}
+
+	return nil
+}
+
+// checkMigrationConfig fails for the configurations cloud-hypervisor can't
+// migrate a VM with, through uri.
+func checkMigrationConfig(hConfig *HypervisorConfig, uri string) error {
+	if !strings.HasPrefix(uri, "unix:") {
+		return fmt.Errorf("cloud-hypervisor only migrates VMs through unix sockets, not %q", uri)
+	}
+
+	if hConfig.EnableJail {
+		return fmt.Errorf("Migration is not supported with a jailed cloud-hypervisor")
+	}
+
+	return nil
+}
+
+// migrateVM sends the running VM to the cloud-hypervisor receiving it at
+// uri, which exits once the migration is complete.
+func (clh *cloudHypervisor) migrateVM(ctx context.Context, uri string) error {
+	span, _ := katatrace.Trace(ctx, clh.Logger(), "migrateVM", clhTracingTags, map[string]string{"sandbox_id": clh.id})
+	defer span.End()
+
+	if err := checkMigrationConfig(&clh.config, uri); err != nil {
+		return err
+	}
+
+	clh.Logger().WithField("function", "migrateVM").WithField("uri", uri).Info("Migrate Sandbox")
+
+	ctx, cancel := context.WithTimeout(context.Background(), clhMigrationAPITimeout*time.Second)
+	defer cancel()
+
+	migration := chclient.NewSendMigrationData(uri)
+	if _, err := clh.client().VmSendMigrationPut(ctx, *migration); err != nil {
+		return openAPIClientError(err)
+	}
+
 	return nil
 }
 
@@ -905,12 +1200,50 @@ func (clh *cloudHypervisor) StopVM(ctx context.Context, waitOnly bool) (err erro
 	return clh.terminate(ctx, waitOnly)
 }
 
+type clhGrpc struct {
+	ID                string
+	APISocket         string
+	PID               int
+	VirtiofsDaemonPid int
+	VmConfig          chclient.VmConfig
+}
+
 func (clh *cloudHypervisor) fromGrpc(ctx context.Context, hypervisorConfig *HypervisorConfig, j []byte) error {
-	return errors.New("cloudHypervisor is not supported by VM cache")
+	var cp clhGrpc
+	if err := json.Unmarshal(j, &cp); err != nil {
+		return err
+	}
+
+	clh.ctx = ctx
+	clh.id = cp.ID
+	clh.config = *hypervisorConfig
+	clh.vmconfig = cp.VmConfig
+	clh.state.apiSocket = cp.APISocket
+	clh.state.PID = cp.PID
+	clh.state.memoryHotplugMethod = cp.VmConfig.Memory.GetHotplugMethod()
+	clh.state.VirtiofsDaemonPid = cp.VirtiofsDaemonPid
+	clh.state.state = clhReady
+	clh.APIClient = clh.newAPIClient()
+
+	virtiofsDaemon, err := clh.loadVirtiofsDaemon(hypervisorConfig.SharedFS)
+	if err != nil {
+		return err
+	}
+	clh.virtiofsDaemon = virtiofsDaemon
+
+	return nil
 }
 
 func (clh *cloudHypervisor) toGrpc(ctx context.Context) ([]byte, error) {
-	return nil, errors.New("cloudHypervisor is not supported by VM cache")
+	cp := clhGrpc{
+		ID:                clh.id,
+		APISocket:         clh.state.apiSocket,
+		PID:               clh.state.PID,
+		VirtiofsDaemonPid: clh.state.VirtiofsDaemonPid,
+		VmConfig:          clh.vmconfig,
+	}
+
+	return json.Marshal(&cp)
 }
 
 func (clh *cloudHypervisor) Save() (s hv.HypervisorState) {
@@ -918,6 +1251,7 @@ func (clh *cloudHypervisor) Save() (s hv.HypervisorState) {
 	s.Type = string(ClhHypervisor)
 	s.VirtiofsDaemonPid = clh.state.VirtiofsDaemonPid
 	s.APISocket = clh.state.apiSocket
+	s.MemoryHotplugMethod = clh.state.memoryHotplugMethod
 	return
 }
 
@@ -925,6 +1259,7 @@ func (clh *cloudHypervisor) Load(s hv.HypervisorState) {
 	clh.state.PID = s.Pid
 	clh.state.VirtiofsDaemonPid = s.VirtiofsDaemonPid
 	clh.state.apiSocket = s.APISocket
+	clh.state.memoryHotplugMethod = s.MemoryHotplugMethod
 }
 
 // Check is the implementation of Check from the Hypervisor interface.
@@ -1061,16 +1396,26 @@ func (clh *cloudHypervisor) GenerateSocket(id string) (interface{}, error) {
 	}, nil
 }
 
+// socketPath returns the host path of a socket of the VM, which is in the
+// root of the jail when cloud-hypervisor is jailed.
+func (clh *cloudHypervisor) socketPath(id, name string) (string, error) {
+	if clh.config.EnableJail {
+		return utils.BuildSocketPath(clh.config.VMStorePath, id, clhJailRoot, name)
+	}
+
+	return utils.BuildSocketPath(clh.config.VMStorePath, id, name)
+}
+
 func (clh *cloudHypervisor) virtioFsSocketPath(id string) (string, error) {
-	return utils.BuildSocketPath(clh.config.VMStorePath, id, virtioFsSocket)
+	return clh.socketPath(id, virtioFsSocket)
 }
 
 func (clh *cloudHypervisor) vsockSocketPath(id string) (string, error) {
-	return utils.BuildSocketPath(clh.config.VMStorePath, id, clhSocket)
+	return clh.socketPath(id, clhSocket)
 }
 
 func (clh *cloudHypervisor) apiSocketPath(id string) (string, error) {
-	return utils.BuildSocketPath(clh.config.VMStorePath, id, clhAPISocket)
+	return clh.socketPath(id, clhAPISocket)
 }
 
 func (clh *cloudHypervisor) waitVMM(timeout uint) error {
@@ -1110,7 +1455,7 @@ func (clh *cloudHypervisor) launchClh() (int, error) {
 		return -1, err
 	}
 
-	args := []string{cscAPIsocket, clh.state.apiSocket}
+	args := []string{cscAPIsocket, clh.jailedPath(clh.state.apiSocket)}
 	if clh.config.Debug {
 		// Cloud hypervisor log levels
 		// 'v' occurrences increase the level
@@ -1144,7 +1489,16 @@ func (clh *cloudHypervisor) launchClh() (int, error) {
 	clh.Logger().WithField("path", clhPath).Info()
 	clh.Logger().WithField("args", strings.Join(args, " ")).Info()
 
-	cmdHypervisor := exec.Command(clhPath, args...)
+	var cmdHypervisor *exec.Cmd
+	if clh.config.EnableJail {
+		// The binary was made available in the jail by setupJail.
+		cmdHypervisor = exec.Command(filepath.Join("/", clhJailBinary), args...)
+		cmdHypervisor.SysProcAttr = clh.jailSysProcAttr()
+		cmdHypervisor.Dir = "/"
+	} else {
+		cmdHypervisor = exec.Command(clhPath, args...)
+	}
+
 	if clh.config.Debug {
 		cmdHypervisor.Env = os.Environ()
 		cmdHypervisor.Env = append(cmdHypervisor.Env, "RUST_BACKTRACE=full")
@@ -1156,11 +1510,23 @@ func (clh *cloudHypervisor) launchClh() (int, error) {
 
 	cmdHypervisor.Stderr = cmdHypervisor.Stdout
 
+	if clh.config.EnableJail {
+		if err := clh.newJailCgroup(); err != nil {
+			return -1, err
+		}
+	}
+
 	err = utils.StartCmd(cmdHypervisor)
 	if err != nil {
 		return -1, err
 	}
 
+	if clh.config.EnableJail {
+		if err := clh.jailCgroup(cmdHypervisor.Process.Pid); err != nil {
+			return -1, err
+		}
+	}
+
 	if err := clh.waitVMM(clhTimeout); err != nil {
 		clh.Logger().WithError(err).Warn("cloud-hypervisor init failed")
 		return -1, err
@@ -1205,6 +1571,201 @@ func kernelParamsToString(params []Param) string {
 //****************************************
 // API calls
 //****************************************
+
+// restoreVM restores the VM from the snapshot in DevicesStatePath, and
+// leaves it paused.
+func (clh *cloudHypervisor) restoreVM() error {
+	dir, err := clh.prepareRestore(clh.config.DevicesStatePath)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), clhSnapshotAPITimeout*time.Second)
+	defer cancel()
+
+	clh.Logger().WithField("snapshot", clh.config.DevicesStatePath).Debug("Restoring VM")
+	restore := chclient.NewRestoreConfig("file://" + dir)
+	if _, err := clh.client().VmRestorePut(ctx, *restore); err != nil {
+		return openAPIClientError(err)
+	}
+
+	info, err := clh.vmInfo()
+	if err != nil {
+		return err
+	}
+
+	clh.Logger().Debugf("VM state after restore: %#v", info)
+
+	if info.State != clhStatePaused {
+		return fmt.Errorf("VM state is not 'Paused' after 'VmRestorePut'")
+	}
+
+	return nil
+}
+
+// receiveVM receives the VM migrated to MigrationIncomingURI, which keeps
+// running once received.
+func (clh *cloudHypervisor) receiveVM() error {
+	ctx, cancel := context.WithTimeout(context.Background(), clhMigrationAPITimeout*time.Second)
+	defer cancel()
+
+	// The virtio-fs devices of the migrated VM connect to the virtiofsd of
+	// this sandbox, started at the same socket path as the source one.
+	clh.Logger().WithField("uri", clh.config.MigrationIncomingURI).Info("Receiving VM")
+	migration := chclient.NewReceiveMigrationData(clh.config.MigrationIncomingURI)
+	if _, err := clh.client().VmReceiveMigrationPut(ctx, *migration); err != nil {
+		return openAPIClientError(err)
+	}
+
+	info, err := clh.vmInfo()
+	if err != nil {
+		return err
+	}
+
+	clh.Logger().Debugf("VM state after migration: %#v", info)
+
+	if info.State != clhStateRunning {
+		return fmt.Errorf("VM state is not 'Running' after 'VmReceiveMigrationPut'")
+	}
+
+	// The migrated VM keeps the configuration of the VM it was migrated
+	// from, which is persisted in place of the one of this sandbox.
+	clh.vmconfig = info.Config
+
+	return clh.moveMigratedVsock()
+}
+
+// moveMigratedVsock moves the hybrid vsock of a migrated VM, still listening
+// at the socket of the VM it was migrated from, to the socket the agent of
+// this sandbox connects to. The directory of the source VM is removed along
+// with it, the socket can't be left there.
+func (clh *cloudHypervisor) moveMigratedVsock() error {
+	vsock, ok := clh.vmconfig.GetVsockOk()
+	if !ok || vsock == nil {
+		return nil
+	}
+
+	path, err := clh.vsockSocketPath(clh.id)
+	if err != nil {
+		return err
+	}
+
+	if vsock.Socket == path {
+		return nil
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	clh.Logger().WithFields(log.Fields{
+		"socket":   path,
+		"migrated": vsock.Socket,
+	}).Debug("Moving the hybrid vsock of the migrated VM")
+
+	if err := os.Rename(vsock.Socket, path); err != nil {
+		return err
+	}
+	vsock.Socket = path
+
+	return nil
+}
+
+// clhSnapshotDeviceFields are the fields of the devices of a snapshot
+// configuration referring to the host resources of the VM the snapshot was
+// taken from, by device list. The devices keep their IDs, which their
+// state in the snapshot is bound to.
+var clhSnapshotDeviceFields = map[string]string{
+	"disks": "path",
+	"pmem":  "file",
+	"net":   "tap",
+	"fs":    "socket",
+}
+
+// prepareRestore returns a directory to restore the VM from the snapshot
+// in snapshotDir. The snapshot refers to the host resources of the VM it
+// was taken from, so its configuration is rewritten to use the ones of this
+// VM and the other files are linked. The devices hot plugged in the VM the
+// snapshot was taken from are not restored.
+func (clh *cloudHypervisor) prepareRestore(snapshotDir string) (string, error) {
+	dir := filepath.Join(clh.config.VMStorePath, clh.id, "restore")
+	if err := os.RemoveAll(dir); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(dir, DirMode); err != nil {
+		return "", err
+	}
+
+	entries, err := os.ReadDir(snapshotDir)
+	if err != nil {
+		return "", err
+	}
+
+	for _, e := range entries {
+		if e.Name() == clhSnapshotConfig {
+			continue
+		}
+		if err := os.Symlink(filepath.Join(snapshotDir, e.Name()), filepath.Join(dir, e.Name())); err != nil {
+			return "", err
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(snapshotDir, clhSnapshotConfig))
+	if err != nil {
+		return "", err
+	}
+
+	// Unmarshal to maps so that the fields unknown to the client are
+	// preserved.
+	var vmConfig map[string]interface{}
+	if err := json.Unmarshal(data, &vmConfig); err != nil {
+		return "", err
+	}
+
+	if data, err = json.Marshal(clh.vmconfig); err != nil {
+		return "", err
+	}
+
+	var ownConfig map[string]interface{}
+	if err := json.Unmarshal(data, &ownConfig); err != nil {
+		return "", err
+	}
+
+	if vsock, ok := vmConfig["vsock"].(map[string]interface{}); ok {
+		ownVsock, ok := ownConfig["vsock"].(map[string]interface{})
+		if !ok {
+			return "", errors.New("the snapshot has a vsock device, the VM has none")
+		}
+		vsock["socket"] = ownVsock["socket"]
+	}
+
+	for list, field := range clhSnapshotDeviceFields {
+		devices, _ := vmConfig[list].([]interface{})
+		ownDevices, _ := ownConfig[list].([]interface{})
+		if len(devices) != len(ownDevices) {
+			return "", fmt.Errorf("the snapshot has %d %s devices, the VM has %d", len(devices), list, len(ownDevices))
+		}
+
+		for i := range devices {
+			device, ok := devices[i].(map[string]interface{})
+			if !ok {
+				return "", fmt.Errorf("invalid %s device in the snapshot configuration", list)
+			}
+			ownDevice, _ := ownDevices[i].(map[string]interface{})
+			device[field] = ownDevice[field]
+		}
+	}
+
+	if data, err = json.Marshal(vmConfig); err != nil {
+		return "", err
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, clhSnapshotConfig), data, 0640); err != nil {
+		return "", err
+	}
+
+	return dir, nil
+}
 func (clh *cloudHypervisor) isClhRunning(timeout uint) (bool, error) {
 
 	pid := clh.state.PID
@@ -1238,6 +1799,27 @@ func (clh *cloudHypervisor) client() clhClient {
 	return clh.APIClient
 }
 
+// newAPIClient returns a client of the API socket of the VMM.
+func (clh *cloudHypervisor) newAPIClient() clhClient {
+	cfg := chclient.NewConfiguration()
+	cfg.HTTPClient = &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, path string) (net.Conn, error) {
+				addr, err := net.ResolveUnixAddr("unix", clh.state.apiSocket)
+				if err != nil {
+					return nil, err
+				}
+
+				return net.DialUnix("unix", nil, addr)
+			},
+		},
+	}
+
+	return &clhClientApi{
+		ApiInternal: chclient.NewAPIClient(cfg).DefaultApi,
+	}
+}
+
 func openAPIClientError(err error) error {
 
 	if err == nil {
@@ -1305,21 +1887,54 @@ func (clh *cloudHypervisor) addVSock(cid int64, path string) {
 		"cid":  cid,
 	}).Info("Adding HybridVSock")
 
-	clh.vmconfig.Vsock = chclient.NewVsockConfig(cid, path)
+	clh.vmconfig.Vsock = chclient.NewVsockConfig(cid, clh.jailedPath(path))
 }
 
-func (clh *cloudHypervisor) addNet(e Endpoint) error {
-	clh.Logger().WithField("endpoint-type", e).Debugf("Adding Endpoint of type %v", e)
+// clhTapInterface returns the TAP interface cloud-hypervisor opens for
+// an endpoint. Endpoints handing file descriptors to the hypervisor,
+// such as macvtap ones, are not supported since the API can't pass them.
+func clhTapInterface(e Endpoint) (*TapInterface, error) {
+	if tap, ok := e.(*TapEndpoint); ok {
+		return &tap.TapInterface, nil
+	}
 
-	mac := e.HardwareAddr()
 	netPair := e.NetworkPair()
 	if netPair == nil {
-		return errors.New("net Pair to be added is nil, needed to get TAP path")
+		return nil, fmt.Errorf("endpoint of type %s has no TAP interface, needed to get TAP path", e.Type())
+	}
+
+	return &netPair.TapInterface, nil
+}
+
+// clhRateLimiter builds the built-in rate limiter of a network device.
+// Cloud-hypervisor applies the same limiter to both directions, so it is
+// only used when the rx and tx rates are the same. Otherwise, the rx and
+// tx rate limiters are set up on the host side of the endpoints.
+func (clh *cloudHypervisor) clhRateLimiter() *chclient.RateLimiterConfig {
+	rate := clh.config.RxRateLimiterMaxRate
+	if rate == 0 || rate != clh.config.TxRateLimiterMaxRate {
+		return nil
 	}
 
-	tapPath := netPair.TapInterface.TAPIface.Name
+	// kata-defined rates are in bits per second, while the token bucket
+	// holds bytes and is refilled every refill_time milliseconds.
+	bandwidth := chclient.NewTokenBucket(int64(rate/8), 1000)
+	limiter := chclient.NewRateLimiterConfig()
+	limiter.Bandwidth = bandwidth
+
+	return limiter
+}
+
+func (clh *cloudHypervisor) netConfig(e Endpoint) (*chclient.NetConfig, error) {
+	mac := e.HardwareAddr()
+	tap, err := clhTapInterface(e)
+	if err != nil {
+		return nil, err
+	}
+
+	tapPath := tap.TAPIface.Name
 	if tapPath == "" {
-		return errors.New("TAP path in network pair is empty")
+		return nil, errors.New("TAP path in network pair is empty")
 	}
 
 	clh.Logger().WithFields(log.Fields{
@@ -1327,9 +1942,24 @@ func (clh *cloudHypervisor) addNet(e Endpoint) error {
 		"tap": tapPath,
 	}).Info("Adding Net")
 
+	id := clhNetID(tap)
 	net := chclient.NewNetConfig()
 	net.Mac = &mac
 	net.Tap = &tapPath
+	net.Id = &id
+	net.RateLimiterConfig = clh.clhRateLimiter()
+
+	return net, nil
+}
+
+func (clh *cloudHypervisor) addNet(e Endpoint) error {
+	clh.Logger().WithField("endpoint-type", e).Debugf("Adding Endpoint of type %v", e)
+
+	net, err := clh.netConfig(e)
+	if err != nil {
+		return err
+	}
+
 	if clh.vmconfig.Net != nil {
 		*clh.vmconfig.Net = append(*clh.vmconfig.Net, *net)
 	} else {
@@ -1358,7 +1988,7 @@ func (clh *cloudHypervisor) addVolume(volume types.Volume) error {
 	numQueues := int32(1)
 	queueSize := int32(1024)
 
-	fs := chclient.NewFsConfig(volume.MountTag, vfsdSockPath, numQueues, queueSize, dax, int64(clh.config.VirtioFSCacheSize<<20))
+	fs := chclient.NewFsConfig(volume.MountTag, clh.jailedPath(vfsdSockPath), numQueues, queueSize, dax, int64(clh.config.VirtioFSCacheSize<<20))
 	clh.vmconfig.Fs = &[]chclient.FsConfig{*fs}
 
 	clh.Logger().Debug("Adding share volume to hypervisor: ", volume.MountTag)
@@ -1381,6 +2011,12 @@ func (clh *cloudHypervisor) cleanupVM(force bool) error {
 		}
 	}
 
+	// The resources mounted in the jail must be released before the
+	// jail is removed along with the vm path.
+	if clh.config.EnableJail {
+		clh.cleanupJail()
+	}
+
 	// Cleanup vm path
 	dir := filepath.Join(clh.config.VMStorePath, clh.id)
 
@@ -1439,5 +2075,5 @@ func (clh *cloudHypervisor) vmInfo() (chclient.VmInfo, error) {
 }
 
 func (clh *cloudHypervisor) IsRateLimiterBuiltin() bool {
-	return false
+	return clh.clhRateLimiter() != nil
 }
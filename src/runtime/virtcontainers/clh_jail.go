@@ -0,0 +1,344 @@
+//go:build linux
+// +build linux
+
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+
+	"github.com/opencontainers/runtime-spec/specs-go"
+	"golang.org/x/sys/unix"
+
+	resCtrl "github.com/kata-containers/kata-containers/src/runtime/pkg/resourcecontrol"
+)
+
+const (
+	// Names of the files within the jail of cloud-hypervisor
+	clhJailRoot   = "root"
+	clhJailBinary = "cloud-hypervisor"
+	clhJailKernel = "vmlinux"
+	clhJailImage  = "image"
+	clhJailInitrd = "initrd"
+
+	// The devices cgroup of jailed cloud-hypervisor processes is
+	// /kata_clh_jail/<sandbox id> with cgroups v1, and kata_clh_jail under
+	// the cgroup of the runtime with cgroups v2.
+	clhJailCgroupID = "kata_clh_jail"
+)
+
+// Host devices opened by a jailed cloud-hypervisor, in addition to the
+// entropy source.
+var clhJailDevices = []string{
+	"/dev/kvm",     // To run virtual machines
+	"/dev/net/tun", // To open the TAP devices of the sandbox
+}
+
+// jailRoot returns the host path of the root of the jail.
+func (clh *cloudHypervisor) jailRoot() string {
+	return filepath.Join(clh.config.VMStorePath, clh.id, clhJailRoot)
+}
+
+// jailedPath returns the path within the jail of a host path under the
+// root of the jail, or the host path when cloud-hypervisor isn't jailed.
+func (clh *cloudHypervisor) jailedPath(path string) string {
+	if !clh.config.EnableJail {
+		return path
+	}
+
+	rel, err := filepath.Rel(clh.jailRoot(), path)
+	if err != nil || rel == ".." || strings.HasPrefix(rel, "../") {
+		return path
+	}
+
+	return filepath.Join("/", rel)
+}
+
+// checkJailConfig fails for the configurations a jailed cloud-hypervisor
+// can't run with.
+func checkJailConfig(config *HypervisorConfig) error {
+	if !config.EnableJail {
+		return nil
+	}
+
+	if config.JailUID == 0 {
+		return errors.New("cloud-hypervisor can't be jailed as root")
+	}
+
+	if config.BootToBeTemplate || config.BootFromTemplate {
+		return errors.New("VM templating is not supported with a jailed cloud-hypervisor")
+	}
+
+	return nil
+}
+
+// setupJail creates the root of the jail, and makes the binary, the
+// devices and the boot assets of cloud-hypervisor available in it.
+func (clh *cloudHypervisor) setupJail(ctx context.Context, clhPath string) error {
+	root := clh.jailRoot()
+	if err := os.MkdirAll(root, DirMode); err != nil {
+		return err
+	}
+
+	// cloud-hypervisor creates its sockets in the root of the jail, and
+	// connects to the virtiofsd socket the runtime chowns to its parent.
+	if err := os.Chown(root, int(clh.config.JailUID), int(clh.config.JailGID)); err != nil {
+		return err
+	}
+
+	// The root is mounted shared so that the resources hotplugged later
+	// propagate to the mount namespace of cloud-hypervisor. It is
+	// remounted without MS_NOEXEC and MS_NODEV, so that cloud-hypervisor
+	// can be executed from it and can open the device nodes it holds.
+	if err := bindMount(ctx, root, root, false, "shared"); err != nil {
+		clh.Logger().WithField("JailRoot", root).WithError(err).Error("bindMount failed")
+		return err
+	}
+	if err := remount(ctx, syscall.MS_NOSUID|syscall.MS_RELATIME, root); err != nil {
+		clh.Logger().WithField("JailRoot", root).WithError(err).Error("Re-mount failed")
+		return err
+	}
+
+	if _, err := clh.jailResource(clhPath, clhJailBinary); err != nil {
+		return err
+	}
+
+	for _, dev := range clh.jailDevices() {
+		if _, err := clh.jailResource(dev, dev); err != nil {
+			return err
+		}
+	}
+
+	var err error
+	if clh.vmconfig.Kernel.Path, err = clh.jailResource(clh.vmconfig.Kernel.Path, clhJailKernel); err != nil {
+		return err
+	}
+
+	if clh.vmconfig.Pmem != nil {
+		for i := range *clh.vmconfig.Pmem {
+			pmem := &(*clh.vmconfig.Pmem)[i]
+			if pmem.File, err = clh.jailResource(pmem.File, clhJailImage); err != nil {
+				return err
+			}
+		}
+	}
+
+	if clh.vmconfig.Disks != nil {
+		for i := range *clh.vmconfig.Disks {
+			disk := &(*clh.vmconfig.Disks)[i]
+			if disk.Path, err = clh.jailResource(disk.Path, clhJailImage); err != nil {
+				return err
+			}
+		}
+	}
+
+	if initrd, ok := clh.vmconfig.GetInitramfsOk(); ok && initrd != nil {
+		if initrd.Path, err = clh.jailResource(initrd.Path, clhJailInitrd); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// jailDevices returns the host devices opened by a jailed cloud-hypervisor.
+func (clh *cloudHypervisor) jailDevices() []string {
+	devices := append([]string{}, clhJailDevices...)
+	if entropy := clh.config.EntropySource; entropy != "" {
+		devices = append(devices, entropy)
+	}
+
+	return devices
+}
+
+// jailResource makes a host file available at dst in the jail, and returns
+// its path within the jail. Device nodes are created in the jail and given
+// to the jail user, leaving the host ones untouched, other files are bind
+// mounted.
+func (clh *cloudHypervisor) jailResource(src, dst string) (string, error) {
+	if src == "" || dst == "" {
+		return "", fmt.Errorf("jailResource: invalid jail locations: src:%v, dst:%v",
+			src, dst)
+	}
+
+	jailedLocation := filepath.Join(clh.jailRoot(), dst)
+
+	var st unix.Stat_t
+	if err := unix.Stat(src, &st); err != nil {
+		return "", fmt.Errorf("Could not stat %s: %v", src, err)
+	}
+
+	switch st.Mode & unix.S_IFMT {
+	case unix.S_IFCHR, unix.S_IFBLK:
+		if err := clh.jailDevice(jailedLocation, st.Mode, st.Rdev); err != nil {
+			clh.Logger().WithField("device", src).WithError(err).Error("Could not create the jailed device")
+			return "", err
+		}
+	default:
+		if err := bindMount(context.Background(), src, jailedLocation, false, "slave"); err != nil {
+			clh.Logger().WithField("bindMount failed", err).Error()
+			return "", err
+		}
+		clh.jailMounts = append(clh.jailMounts, dst)
+	}
+
+	// This is the path within the jail
+	return filepath.Join("/", dst), nil
+}
+
+// jailDevice creates a device node owned by the jail user.
+func (clh *cloudHypervisor) jailDevice(path string, mode uint32, dev uint64) error {
+	if err := os.MkdirAll(filepath.Dir(path), DirMode); err != nil {
+		return err
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	if err := unix.Mknod(path, mode, int(dev)); err != nil {
+		return err
+	}
+
+	return os.Chown(path, int(clh.config.JailUID), int(clh.config.JailGID))
+}
+
+// jailHotplugDrive makes a hotplugged drive available in the jail, and
+// allows cloud-hypervisor to open it when it is a block device.
+func (clh *cloudHypervisor) jailHotplugDrive(file, driveID string) (string, error) {
+	path, err := clh.jailResource(file, driveID)
+	if err != nil {
+		return "", err
+	}
+
+	if fi, err := os.Stat(file); err == nil && fi.Mode()&os.ModeDevice != 0 {
+		if err := clh.allowJailDevice(file); err != nil {
+			clh.releaseJailResource(driveID)
+			return "", err
+		}
+	}
+
+	return path, nil
+}
+
+// releaseJailResource releases a resource hotplugged in the jail.
+func (clh *cloudHypervisor) releaseJailResource(dst string) {
+	path := filepath.Join(clh.jailRoot(), dst)
+	for i, mount := range clh.jailMounts {
+		if mount == dst {
+			umountJailResource(clh.Logger(), clh.jailRoot(), dst)
+			clh.jailMounts = append(clh.jailMounts[:i], clh.jailMounts[i+1:]...)
+			break
+		}
+	}
+
+	if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+		clh.Logger().WithField("resource", path).WithError(err).Warn("Failed to remove resource")
+	}
+}
+
+// jailSysProcAttr returns the attributes of a jailed cloud-hypervisor
+// process: it is chrooted in the jail, runs in its own mount and PID
+// namespaces as the jail user, and only keeps CAP_NET_ADMIN, needed to
+// attach to the TAP devices created by the runtime.
+func (clh *cloudHypervisor) jailSysProcAttr() *syscall.SysProcAttr {
+	return &syscall.SysProcAttr{
+		Chroot:     clh.jailRoot(),
+		Cloneflags: syscall.CLONE_NEWNS | syscall.CLONE_NEWPID,
+		Credential: &syscall.Credential{
+			Uid:    clh.config.JailUID,
+			Gid:    clh.config.JailGID,
+			Groups: []uint32{},
+		},
+		AmbientCaps: []uintptr{unix.CAP_NET_ADMIN},
+	}
+}
+
+// jailCgroupPath returns the path of the devices cgroup of the jail. With
+// cgroups v2, a process belongs to a single cgroup, so the jail is a child
+// of the cgroup of the runtime, in which cloud-hypervisor is started and
+// whose resources still account for it.
+func (clh *cloudHypervisor) jailCgroupPath() (string, error) {
+	if !resCtrl.IsCgroupV2() {
+		return fmt.Sprintf("/%s/%s", clhJailCgroupID, clh.id), nil
+	}
+
+	group, err := resCtrl.CurrentUnifiedCgroup()
+	if err != nil {
+		return "", err
+	}
+
+	return filepath.Join(group, clhJailCgroupID), nil
+}
+
+// newJailCgroup creates the devices cgroup of the jail, before
+// cloud-hypervisor is started.
+func (clh *cloudHypervisor) newJailCgroup() error {
+	path, err := clh.jailCgroupPath()
+	if err != nil {
+		return err
+	}
+
+	controller, err := resCtrl.NewResourceController(path, &specs.LinuxResources{
+		Devices: resCtrl.JailDevices(clh.jailDevices()),
+	})
+	if err != nil {
+		return fmt.Errorf("Could not create the jail devices cgroup: %v", err)
+	}
+
+	clh.jailController = controller
+
+	return nil
+}
+
+// jailCgroup confines cloud-hypervisor to the devices of the jail, through
+// the devices cgroup of the jail. Its other resources remain controlled by
+// the sandbox, and its threads stay in the devices cgroup when they are
+// constrained.
+func (clh *cloudHypervisor) jailCgroup(pid int) error {
+	return clh.jailController.AddProcess(pid, "devices")
+}
+
+// allowJailDevice allows a jailed cloud-hypervisor to open a hotplugged
+// block device.
+func (clh *cloudHypervisor) allowJailDevice(path string) error {
+	if clh.jailController == nil {
+		return errors.New("The jail devices cgroup is not set up")
+	}
+
+	return clh.jailController.AddDevice(path)
+}
+
+// cleanupJail releases all the jail artifacts, through the teardown of the
+// firecracker jail, before the VM directory holding the jail is removed.
+func (clh *cloudHypervisor) cleanupJail() {
+	cleanupJailRoot(clh.Logger(), clh.jailRoot(), clh.jailMounts, true, clh.jailRoot())
+	clh.jailMounts = nil
+
+	controller := clh.jailController
+	if controller == nil {
+		path, err := clh.jailCgroupPath()
+		if err != nil {
+			return
+		}
+
+		if controller, err = resCtrl.LoadResourceController(path); err != nil {
+			return
+		}
+	}
+
+	if err := controller.Delete(); err != nil {
+		clh.Logger().WithError(err).Warn("Failed to delete the jail devices cgroup")
+	}
+	clh.jailController = nil
+}
@@ -0,0 +1,101 @@
+//go:build linux
+// +build linux
+
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const clhMetricsNS = "kata_clh"
+
+// prometheus metrics of the virtio device counters Cloud Hypervisor exposes.
+var (
+	clhBlockDeviceMetrics = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: clhMetricsNS,
+		Name:      "block",
+		Help:      "Block device counters.",
+	},
+		[]string{"device", "item"},
+	)
+
+	clhNetDeviceMetrics = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: clhMetricsNS,
+		Name:      "net",
+		Help:      "Network device counters.",
+	},
+		[]string{"device", "item"},
+	)
+
+	clhDeviceMetrics = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: clhMetricsNS,
+		Name:      "device",
+		Help:      "Counters of the other virtio devices.",
+	},
+		[]string{"device", "item"},
+	)
+
+	registerClhMetricsOnce sync.Once
+)
+
+// registerCloudHypervisorMetrics register all metrics to prometheus.
+func registerCloudHypervisorMetrics() {
+	registerClhMetricsOnce.Do(func() {
+		prometheus.MustRegister(clhBlockDeviceMetrics)
+		prometheus.MustRegister(clhNetDeviceMetrics)
+		prometheus.MustRegister(clhDeviceMetrics)
+	})
+}
+
+// clhDeviceGaugeVec returns the metrics of a device, told by its counters.
+func clhDeviceGaugeVec(counters map[string]int64) *prometheus.GaugeVec {
+	if _, ok := counters["read_bytes"]; ok {
+		return clhBlockDeviceMetrics
+	}
+
+	if _, ok := counters["rx_bytes"]; ok {
+		return clhNetDeviceMetrics
+	}
+
+	return clhDeviceMetrics
+}
+
+// updateCloudHypervisorMetrics update all metrics to the latest values.
+// The metrics are reset first, so that the devices unplugged since the
+// last update aren't reported anymore.
+func updateCloudHypervisorMetrics(counters map[string]map[string]int64) {
+	clhBlockDeviceMetrics.Reset()
+	clhNetDeviceMetrics.Reset()
+	clhDeviceMetrics.Reset()
+
+	for device, deviceCounters := range counters {
+		metrics := clhDeviceGaugeVec(deviceCounters)
+		for item, value := range deviceCounters {
+			metrics.WithLabelValues(device, item).Set(float64(value))
+		}
+	}
+}
+
+// updateVMMetrics scrapes the device counters of the VM.
+func (clh *cloudHypervisor) updateVMMetrics() error {
+	ctx, cancel := context.WithTimeout(context.Background(), clhAPITimeout*time.Second)
+	defer cancel()
+
+	counters, _, err := clh.client().VmCountersGet(ctx)
+	if err != nil {
+		return openAPIClientError(err)
+	}
+
+	updateCloudHypervisorMetrics(counters)
+
+	return nil
+}
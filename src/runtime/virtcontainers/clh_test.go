@@ -10,20 +10,28 @@ package virtcontainers
 
 import (
 	"context"
+	"encoding/json"
+	"net"
 	"net/http"
 	"os"
 	"path/filepath"
 	"reflect"
+	"runtime"
 	"strings"
+	"syscall"
 	"testing"
 
+	ktu "github.com/kata-containers/kata-containers/src/runtime/pkg/katatestutils"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/device/config"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist"
 	chclient "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/cloud-hypervisor/client"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/utils"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
 	"github.com/stretchr/testify/assert"
+	"golang.org/x/sys/unix"
 )
 
 const (
@@ -67,7 +75,15 @@ func newClhConfig() (HypervisorConfig, error) {
 }
 
 type clhClientMock struct {
-	vmInfo chclient.VmInfo
+	vmInfo         chclient.VmInfo
+	snapshotURL    string
+	restoreURL     string
+	netConfig      *chclient.NetConfig
+	removedDevices []string
+	migrationURL   string
+	migratedConfig chclient.VmConfig
+	counters       map[string]map[string]int64
+	desiredRAM     int64
 }
 
 func (c *clhClientMock) VmmPingGet(ctx context.Context) (chclient.VmmPingResponse, *http.Response, error) {
@@ -95,6 +111,7 @@ func (c *clhClientMock) BootVM(ctx context.Context) (*http.Response, error) {
 
 //nolint:golint
 func (c *clhClientMock) VmResizePut(ctx context.Context, vmResize chclient.VmResize) (*http.Response, error) {
+	c.desiredRAM = vmResize.GetDesiredRam()
 	return nil, nil
 }
 
@@ -108,8 +125,57 @@ func (c *clhClientMock) VmAddDiskPut(ctx context.Context, diskConfig chclient.Di
 	return chclient.PciDeviceInfo{Bdf: "0000:00:0a.0"}, nil, nil
 }
 
+//nolint:golint
+func (c *clhClientMock) VmAddNetPut(ctx context.Context, netConfig chclient.NetConfig) (chclient.PciDeviceInfo, *http.Response, error) {
+	c.netConfig = &netConfig
+	return chclient.PciDeviceInfo{Bdf: "0000:00:0b.0"}, nil, nil
+}
+
 //nolint:golint
 func (c *clhClientMock) VmRemoveDevicePut(ctx context.Context, vmRemoveDevice chclient.VmRemoveDevice) (*http.Response, error) {
+	c.removedDevices = append(c.removedDevices, vmRemoveDevice.GetId())
+	return nil, nil
+}
+
+func (c *clhClientMock) PauseVM(ctx context.Context) (*http.Response, error) {
+	c.vmInfo.State = clhStatePaused
+	return nil, nil
+}
+
+func (c *clhClientMock) ResumeVM(ctx context.Context) (*http.Response, error) {
+	c.vmInfo.State = clhStateRunning
+	return nil, nil
+}
+
+//nolint:golint
+func (c *clhClientMock) VmSnapshotPut(ctx context.Context, vmSnapshotConfig chclient.VmSnapshotConfig) (*http.Response, error) {
+	c.snapshotURL = vmSnapshotConfig.GetDestinationUrl()
+	return nil, nil
+}
+
+//nolint:golint
+func (c *clhClientMock) VmRestorePut(ctx context.Context, restoreConfig chclient.RestoreConfig) (*http.Response, error) {
+	c.restoreURL = restoreConfig.GetSourceUrl()
+	c.vmInfo.State = clhStatePaused
+	return nil, nil
+}
+
+//nolint:golint
+func (c *clhClientMock) VmCountersGet(ctx context.Context) (map[string]map[string]int64, *http.Response, error) {
+	return c.counters, nil, nil
+}
+
+//nolint:golint
+func (c *clhClientMock) VmSendMigrationPut(ctx context.Context, sendMigrationData chclient.SendMigrationData) (*http.Response, error) {
+	c.migrationURL = sendMigrationData.GetDestinationUrl()
+	return nil, nil
+}
+
+//nolint:golint
+func (c *clhClientMock) VmReceiveMigrationPut(ctx context.Context, receiveMigrationData chclient.ReceiveMigrationData) (*http.Response, error) {
+	c.migrationURL = receiveMigrationData.GetReceiverUrl()
+	c.vmInfo.State = clhStateRunning
+	c.vmInfo.Config = c.migratedConfig
 	return nil, nil
 }
 
@@ -191,6 +257,77 @@ func TestCloudHypervisorAddNetCheckEnpointTypes(t *testing.T) {
 	}
 }
 
+func TestCloudHypervisorAddNetRateLimiter(t *testing.T) {
+	assert := assert.New(t)
+
+	e := &VethEndpoint{}
+	e.NetPair.TapInterface.TAPIface.Name = "/path/to/tap"
+
+	clh := &cloudHypervisor{}
+	assert.NoError(clh.addNet(e))
+	assert.Nil((*clh.vmconfig.Net)[0].RateLimiterConfig)
+	assert.False(clh.IsRateLimiterBuiltin())
+
+	// Different rx and tx rates are applied on the host side.
+	clh = &cloudHypervisor{
+		config: HypervisorConfig{
+			RxRateLimiterMaxRate: 80000,
+			TxRateLimiterMaxRate: 8000,
+		},
+	}
+	assert.NoError(clh.addNet(e))
+	assert.Nil((*clh.vmconfig.Net)[0].RateLimiterConfig)
+	assert.False(clh.IsRateLimiterBuiltin())
+
+	clh.config.RxRateLimiterMaxRate = 0
+	assert.False(clh.IsRateLimiterBuiltin())
+
+	// The same rate in both directions, converted to bytes, is applied by
+	// the built-in limiter.
+	clh = &cloudHypervisor{
+		config: HypervisorConfig{
+			RxRateLimiterMaxRate: 8000,
+			TxRateLimiterMaxRate: 8000,
+		},
+	}
+	assert.NoError(clh.addNet(e))
+	assert.True(clh.IsRateLimiterBuiltin())
+	limiter := (*clh.vmconfig.Net)[0].RateLimiterConfig
+	assert.NotNil(limiter)
+	assert.Equal(int64(1000), limiter.Bandwidth.Size)
+	assert.Equal(int64(1000), limiter.Bandwidth.RefillTime)
+}
+
+func TestCloudHypervisorHotplugNetDevice(t *testing.T) {
+	assert := assert.New(t)
+
+	mock := &clhClientMock{}
+	clh := &cloudHypervisor{APIClient: mock}
+
+	e := &TapEndpoint{}
+	e.TapInterface.ID = "uuid"
+	e.TapInterface.TAPIface.Name = "tap0_kata"
+	e.TapInterface.TAPIface.HardAddr = "02:00:ca:fe:00:01"
+
+	_, err := clh.HotplugAddDevice(context.Background(), e, NetDev)
+	assert.NoError(err)
+	assert.NotNil(mock.netConfig)
+	assert.Equal("tap0_kata", mock.netConfig.GetTap())
+	assert.Equal("02:00:ca:fe:00:01", mock.netConfig.GetMac())
+	assert.Equal("clh_net_uuid", mock.netConfig.GetId())
+	assert.Equal("0b", e.PciPath().String())
+
+	_, err = clh.HotplugRemoveDevice(context.Background(), e, NetDev)
+	assert.NoError(err)
+	assert.Equal([]string{"clh_net_uuid"}, mock.removedDevices)
+
+	// Endpoints without a TAP interface can't be plugged.
+	_, err = clh.HotplugAddDevice(context.Background(), &MacvtapEndpoint{}, NetDev)
+	assert.Error(err)
+	_, err = clh.HotplugRemoveDevice(context.Background(), &MacvtapEndpoint{}, NetDev)
+	assert.Error(err)
+}
+
 func TestCloudHypervisorBootVM(t *testing.T) {
 	clh := &cloudHypervisor{}
 	clh.APIClient = &clhClientMock{}
@@ -297,6 +434,41 @@ func TestClhCreateVM(t *testing.T) {
 	err = clh.CreateVM(context.Background(), sandbox.id, network, &sandbox.config.HypervisorConfig)
 	assert.NoError(err)
 	assert.Exactly(clhConfig, clh.config)
+	assert.Equal(clhHotplugMethodAcpi, clh.vmconfig.Memory.GetHotplugMethod())
+	assert.Equal(clhHotplugMethodAcpi, clh.Save().MemoryHotplugMethod)
+}
+
+func TestClhCreateVMVirtioMem(t *testing.T) {
+	assert := assert.New(t)
+
+	clhConfig, err := newClhConfig()
+	assert.NoError(err)
+
+	store, err := persist.GetDriver()
+	assert.NoError(err)
+
+	clhConfig.VMStorePath = store.RunVMStoragePath()
+	clhConfig.RunStorePath = store.RunStoragePath()
+	clhConfig.VirtioMem = true
+
+	network, err := NewNetwork()
+	assert.NoError(err)
+
+	clh := &cloudHypervisor{}
+	err = clh.CreateVM(context.Background(), "testSandbox", network, &clhConfig)
+	assert.NoError(err)
+
+	// The virtio-mem region is made of whole blocks.
+	assert.Equal(clhHotplugMethodVirtioMem, clh.vmconfig.Memory.GetHotplugMethod())
+	blockSize := int64(utils.MemUnit(clhVirtioMemBlockSizeMB) * utils.MiB)
+	assert.Zero(clh.vmconfig.Memory.GetHotplugSize() % blockSize)
+
+	// The method is persisted.
+	state := clh.Save()
+	assert.Equal(clhHotplugMethodVirtioMem, state.MemoryHotplugMethod)
+	loaded := &cloudHypervisor{}
+	loaded.Load(state)
+	assert.Equal(clhHotplugMethodVirtioMem, loaded.state.memoryHotplugMethod)
 }
 
 func TestCloudHypervisorStartSandbox(t *testing.T) {
@@ -320,6 +492,261 @@ func TestCloudHypervisorStartSandbox(t *testing.T) {
 	assert.NoError(err)
 }
 
+func TestCloudHypervisorStartSandboxFromTemplate(t *testing.T) {
+	assert := assert.New(t)
+	clhConfig, err := newClhConfig()
+	assert.NoError(err)
+
+	clhConfig.VMStorePath = t.TempDir()
+	clhConfig.RunStorePath = t.TempDir()
+
+	// The snapshot of a VM, whose host resources are the ones of the
+	// checkpointed sandbox.
+	snapshot := t.TempDir()
+	assert.NoError(os.WriteFile(filepath.Join(snapshot, clhSnapshotConfig), []byte(`{
+		"cpus": {"boot_vcpus": 1},
+		"vsock": {"cid": 3, "socket": "/checkpointed/clh.sock"},
+		"fs": [{"tag": "kataShared", "socket": "/checkpointed/virtiofsd.sock"}],
+		"pmem": [{"id": "_pmem0", "file": "/checkpointed/image"}],
+		"net": [{"id": "clh_net_0", "tap": "tap0_checkpointed", "mac": "02:00:ca:fe:00:04"}]
+	}`), 0640))
+	assert.NoError(os.WriteFile(filepath.Join(snapshot, "state.json"), []byte("{}"), 0640))
+
+	clhConfig.BootFromTemplate = true
+	clhConfig.MemoryPath = snapshot
+	clhConfig.DevicesStatePath = snapshot
+
+	mockClient := &clhClientMock{}
+	clh := &cloudHypervisor{
+		id:             "testSandbox",
+		config:         clhConfig,
+		APIClient:      mockClient,
+		virtiofsDaemon: &virtiofsdMock{},
+	}
+
+	vsockPath, err := clh.vsockSocketPath(clh.id)
+	assert.NoError(err)
+	virtiofsPath, err := clh.virtioFsSocketPath(clh.id)
+	assert.NoError(err)
+
+	// The configuration of the restoring VM, as built when it is created.
+	clh.vmconfig = *chclient.NewVmConfig(*chclient.NewKernelConfig("/kernel"))
+	clh.vmconfig.Vsock = chclient.NewVsockConfig(3, vsockPath)
+	clh.vmconfig.Fs = &[]chclient.FsConfig{*chclient.NewFsConfig("kataShared", virtiofsPath, 1, 1024, false, 0)}
+	clh.vmconfig.Pmem = &[]chclient.PmemConfig{*chclient.NewPmemConfig("/image")}
+	tap := "tap0_kata"
+	mac := "02:00:ca:fe:00:05"
+	clh.vmconfig.Net = &[]chclient.NetConfig{{Tap: &tap, Mac: &mac}}
+
+	err = clh.StartVM(context.Background(), 10)
+	assert.NoError(err)
+
+	// The VM is restored paused, and not booted.
+	restoreDir := filepath.Join(clhConfig.VMStorePath, clh.id, "restore")
+	assert.Equal("file://"+restoreDir, mockClient.restoreURL)
+	assert.Equal(clhStatePaused, mockClient.vmInfo.State)
+
+	// The configuration refers to the host resources of the restored VM,
+	// the devices keep the IDs and addresses of the snapshot.
+	data, err := os.ReadFile(filepath.Join(restoreDir, clhSnapshotConfig))
+	assert.NoError(err)
+	var vmConfig chclient.VmConfig
+	assert.NoError(json.Unmarshal(data, &vmConfig))
+	assert.Equal(vsockPath, vmConfig.Vsock.Socket)
+	assert.Equal(virtiofsPath, (*vmConfig.Fs)[0].Socket)
+	assert.Equal("/image", (*vmConfig.Pmem)[0].File)
+	assert.Equal("_pmem0", (*vmConfig.Pmem)[0].GetId())
+	assert.Equal(tap, (*vmConfig.Net)[0].GetTap())
+	assert.Equal("clh_net_0", (*vmConfig.Net)[0].GetId())
+	assert.Equal("02:00:ca:fe:00:04", (*vmConfig.Net)[0].GetMac())
+	assert.Equal(int32(1), vmConfig.Cpus.BootVcpus)
+
+	target, err := os.Readlink(filepath.Join(restoreDir, "state.json"))
+	assert.NoError(err)
+	assert.Equal(filepath.Join(snapshot, "state.json"), target)
+
+	assert.NoError(clh.ResumeVM(context.Background()))
+	assert.Equal(clhStateRunning, mockClient.vmInfo.State)
+}
+
+func TestCloudHypervisorPrepareRestoreHotpluggedDisk(t *testing.T) {
+	assert := assert.New(t)
+
+	// The drives hot plugged in the checkpointed sandbox are not restored.
+	snapshot := t.TempDir()
+	assert.NoError(os.WriteFile(filepath.Join(snapshot, clhSnapshotConfig), []byte(`{
+		"disks": [{"id": "clh_drive_0", "path": "/dev/dm-1"}]
+	}`), 0640))
+
+	clh := &cloudHypervisor{
+		id:       "testSandbox",
+		config:   HypervisorConfig{VMStorePath: t.TempDir()},
+		vmconfig: *chclient.NewVmConfig(*chclient.NewKernelConfig("/kernel")),
+	}
+
+	_, err := clh.prepareRestore(snapshot)
+	assert.Error(err)
+}
+
+func TestCloudHypervisorStartSandboxMigrationIncoming(t *testing.T) {
+	assert := assert.New(t)
+	clhConfig, err := newClhConfig()
+	assert.NoError(err)
+
+	clhConfig.VMStorePath = t.TempDir()
+	clhConfig.RunStorePath = t.TempDir()
+	clhConfig.MigrationIncomingURI = "unix:" + filepath.Join(t.TempDir(), "migration.sock")
+
+	// The migrated VM keeps the hybrid vsock of the VM it was migrated from.
+	migratedVsock := filepath.Join(t.TempDir(), clhSocket)
+	listener, err := net.Listen("unix", migratedVsock)
+	assert.NoError(err)
+	defer listener.Close()
+
+	mockClient := &clhClientMock{
+		migratedConfig: chclient.VmConfig{
+			Vsock: chclient.NewVsockConfig(3, migratedVsock),
+		},
+	}
+	clh := &cloudHypervisor{
+		id:             "testSandbox",
+		config:         clhConfig,
+		APIClient:      mockClient,
+		virtiofsDaemon: &virtiofsdMock{},
+	}
+	assert.NoError(os.MkdirAll(filepath.Join(clhConfig.VMStorePath, clh.id), DirMode))
+
+	err = clh.StartVM(context.Background(), 10)
+	assert.NoError(err)
+
+	// The VM is received running, and not booted.
+	assert.Equal(clhConfig.MigrationIncomingURI, mockClient.migrationURL)
+	assert.Equal(clhStateRunning, mockClient.vmInfo.State)
+
+	// The agent reaches the migrated VM through the usual socket, moved
+	// out of the directory of the source VM.
+	vsockPath, err := clh.vsockSocketPath(clh.id)
+	assert.NoError(err)
+	assert.Equal(vsockPath, clh.vmconfig.Vsock.Socket)
+	assert.NoFileExists(migratedVsock)
+
+	conn, err := net.Dial("unix", vsockPath)
+	assert.NoError(err)
+	conn.Close()
+}
+
+func TestClhMigrateVM(t *testing.T) {
+	assert := assert.New(t)
+
+	mockClient := &clhClientMock{}
+	clh := &cloudHypervisor{
+		APIClient: mockClient,
+	}
+
+	// Only unix sockets are supported.
+	assert.Error(clh.migrateVM(context.Background(), "tcp:0:4444"))
+
+	// A jailed cloud-hypervisor can't migrate its VM.
+	clh.config.EnableJail = true
+	assert.Error(clh.migrateVM(context.Background(), "unix:/run/vc/migration.sock"))
+	assert.Empty(mockClient.migrationURL)
+
+	// virtio-fs, the default shared filesystem, is migrated with the VM.
+	clh.config.EnableJail = false
+	clh.config.SharedFS = config.VirtioFS
+	assert.NoError(clh.migrateVM(context.Background(), "unix:/run/vc/migration.sock"))
+	assert.Equal("unix:/run/vc/migration.sock", mockClient.migrationURL)
+}
+
+func TestCloudHypervisorUpdateVMMetrics(t *testing.T) {
+	assert := assert.New(t)
+
+	mockClient := &clhClientMock{
+		counters: map[string]map[string]int64{
+			"_disk0": {"read_bytes": 4096, "write_bytes": 512},
+			"_net1":  {"rx_bytes": 1024, "tx_frames": 3},
+			"__rng":  {"read_bytes_total": 64},
+		},
+	}
+	clh := &cloudHypervisor{
+		APIClient: mockClient,
+	}
+
+	assert.NoError(clh.updateVMMetrics())
+
+	gaugeValue := func(vec *prometheus.GaugeVec, device, item string) float64 {
+		var m dto.Metric
+		assert.NoError(vec.WithLabelValues(device, item).Write(&m))
+		return m.GetGauge().GetValue()
+	}
+
+	assert.Equal(float64(4096), gaugeValue(clhBlockDeviceMetrics, "_disk0", "read_bytes"))
+	assert.Equal(float64(512), gaugeValue(clhBlockDeviceMetrics, "_disk0", "write_bytes"))
+	assert.Equal(float64(1024), gaugeValue(clhNetDeviceMetrics, "_net1", "rx_bytes"))
+	assert.Equal(float64(3), gaugeValue(clhNetDeviceMetrics, "_net1", "tx_frames"))
+	assert.Equal(float64(64), gaugeValue(clhDeviceMetrics, "__rng", "read_bytes_total"))
+
+	// The metrics of an unplugged device are removed.
+	delete(mockClient.counters, "_net1")
+	assert.NoError(clh.updateVMMetrics())
+	assert.False(clhNetDeviceMetrics.DeleteLabelValues("_net1", "rx_bytes"))
+	assert.True(clhBlockDeviceMetrics.DeleteLabelValues("_disk0", "read_bytes"))
+}
+
+func TestCloudHypervisorPauseResumeSave(t *testing.T) {
+	assert := assert.New(t)
+
+	mockClient := &clhClientMock{}
+	clh := &cloudHypervisor{
+		ctx:       context.Background(),
+		APIClient: mockClient,
+	}
+
+	assert.NoError(clh.PauseVM(context.Background()))
+	assert.Equal(clhStatePaused, mockClient.vmInfo.State)
+
+	// The state path is required to save the VM.
+	assert.Error(clh.SaveVM())
+
+	clh.config.DevicesStatePath = filepath.Join(t.TempDir(), "state")
+	assert.NoError(clh.SaveVM())
+	assert.DirExists(clh.config.DevicesStatePath)
+	assert.Equal("file://"+clh.config.DevicesStatePath, mockClient.snapshotURL)
+
+	assert.NoError(clh.ResumeVM(context.Background()))
+	assert.Equal(clhStateRunning, mockClient.vmInfo.State)
+}
+
+func TestCloudHypervisorGrpc(t *testing.T) {
+	assert := assert.New(t)
+
+	clh := &cloudHypervisor{
+		id: "testSandbox",
+		state: CloudHypervisorState{
+			apiSocket:         "/run/clh-api.sock",
+			PID:               42,
+			VirtiofsDaemonPid: 43,
+		},
+		vmconfig: *chclient.NewVmConfig(*chclient.NewKernelConfig("/kernel")),
+	}
+
+	j, err := clh.toGrpc(context.Background())
+	assert.NoError(err)
+
+	hConfig := HypervisorConfig{SharedFS: config.VirtioFS}
+	restored := &cloudHypervisor{}
+	assert.NoError(restored.fromGrpc(context.Background(), &hConfig, j))
+
+	assert.Equal(clh.id, restored.id)
+	assert.Equal(clh.state.apiSocket, restored.state.apiSocket)
+	assert.Equal(clh.state.PID, restored.state.PID)
+	assert.Equal(clh.state.VirtiofsDaemonPid, restored.state.VirtiofsDaemonPid)
+	assert.Equal(clhReady, restored.state.state)
+	assert.Equal(clh.vmconfig, restored.vmconfig)
+	assert.Equal(hConfig, restored.config)
+	assert.NotNil(restored.APIClient)
+}
+
 func TestCloudHypervisorResizeMemory(t *testing.T) {
 	assert := assert.New(t)
 	clhConfig, err := newClhConfig()
@@ -375,6 +802,51 @@ func TestCloudHypervisorResizeMemory(t *testing.T) {
 	}
 }
 
+func TestCloudHypervisorResizeVirtioMem(t *testing.T) {
+	assert := assert.New(t)
+
+	bootMemMB := uint32(2048)
+	tests := []struct {
+		name          string
+		hotpluggedMB  int64
+		reqMemMB      uint32
+		expectedMemMB uint32
+	}{
+		{"Grow to aligned size", 0, bootMemMB + 128, bootMemMB + 128},
+		{"Grow to NOT aligned size", 0, bootMemMB + 129, bootMemMB + 256},
+		{"Shrink", 512, bootMemMB + 128, bootMemMB + 128},
+		{"Shrink below the boot memory", 512, bootMemMB - 512, bootMemMB},
+		{"Grow beyond the virtio-mem region", 0, bootMemMB + 2048, bootMemMB + 1024},
+		{"Same size", 256, bootMemMB + 256, bootMemMB + 256},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockClient := &clhClientMock{}
+			mockClient.vmInfo.Config = *chclient.NewVmConfig(*chclient.NewKernelConfig(""))
+			mockClient.vmInfo.Config.Memory = chclient.NewMemoryConfig(int64(utils.MemUnit(bootMemMB) * utils.MiB))
+			mockClient.vmInfo.Config.Memory.SetHotplugMethod(clhHotplugMethodVirtioMem)
+			mockClient.vmInfo.Config.Memory.SetHotplugSize(int64(utils.GiB))
+			mockClient.vmInfo.Config.Memory.SetHotpluggedSize(int64(utils.MemUnit(tt.hotpluggedMB) * utils.MiB))
+
+			clh := cloudHypervisor{
+				APIClient: mockClient,
+			}
+			clh.state.memoryHotplugMethod = clhHotplugMethodVirtioMem
+
+			newMem, memDev, err := clh.ResizeMemory(context.Background(), tt.reqMemMB, 128, false)
+			assert.NoError(err)
+			assert.Equal(tt.expectedMemMB, newMem)
+			assert.Equal(MemoryDevice{}, memDev)
+
+			if tt.expectedMemMB != bootMemMB+uint32(tt.hotpluggedMB) {
+				assert.Equal(int64(utils.MemUnit(tt.expectedMemMB)*utils.MiB), mockClient.desiredRAM)
+			} else {
+				assert.Zero(mockClient.desiredRAM)
+			}
+		})
+	}
+}
+
 func TestCloudHypervisorHotplugAddBlockDevice(t *testing.T) {
 	assert := assert.New(t)
 
@@ -413,7 +885,7 @@ func TestCloudHypervisorHotplugRemoveDevice(t *testing.T) {
 	_, err = clh.HotplugRemoveDevice(context.Background(), &config.VFIODev{}, VfioDev)
 	assert.NoError(err, "Hotplug remove vfio block device expected no error")
 
-	_, err = clh.HotplugRemoveDevice(context.Background(), nil, NetDev)
+	_, err = clh.HotplugRemoveDevice(context.Background(), nil, CpuDev)
 	assert.Error(err, "Hotplug remove pmem block device expected error")
 }
 
@@ -463,3 +935,165 @@ func TestClhSetConfig(t *testing.T) {
 
 	assert.Equal(clh.config, config)
 }
+
+func TestClhSetConfigJail(t *testing.T) {
+	assert := assert.New(t)
+
+	config, err := newClhConfig()
+	assert.NoError(err)
+
+	clh := &cloudHypervisor{}
+	config.EnableJail = true
+
+	// The jail user can't be root.
+	assert.Error(clh.setConfig(&config))
+
+	config.JailUID = 1000
+	config.JailGID = 1000
+	assert.NoError(clh.setConfig(&config))
+
+	config.BootToBeTemplate = true
+	assert.Error(clh.setConfig(&config))
+
+	// A jailed cloud-hypervisor can't receive a migrated VM.
+	config.BootToBeTemplate = false
+	config.SharedFS = ""
+	config.MigrationIncomingURI = "unix:/run/vc/migration.sock"
+	assert.Error(clh.setConfig(&config))
+}
+
+func TestClhJailedPaths(t *testing.T) {
+	assert := assert.New(t)
+
+	clh := &cloudHypervisor{id: "c"}
+	clh.config.VMStorePath = "/run/vc/vm"
+
+	sock, err := clh.apiSocketPath(clh.id)
+	assert.NoError(err)
+	assert.Equal("/run/vc/vm/c/clh-api.sock", sock)
+	assert.Equal(sock, clh.jailedPath(sock))
+
+	clh.config.EnableJail = true
+
+	// The sockets are created in the jail, and are given to
+	// cloud-hypervisor with their path within it.
+	sock, err = clh.apiSocketPath(clh.id)
+	assert.NoError(err)
+	assert.Equal("/run/vc/vm/c/root/clh-api.sock", sock)
+	assert.Equal("/clh-api.sock", clh.jailedPath(sock))
+
+	clh.addVSock(1, filepath.Join(clh.jailRoot(), clhSocket))
+	assert.Equal("/"+clhSocket, clh.vmconfig.Vsock.Socket)
+
+	// Paths out of the jail are left untouched.
+	assert.Equal("/run/vc/vm/c/clh.sock", clh.jailedPath("/run/vc/vm/c/clh.sock"))
+
+	attr := clh.jailSysProcAttr()
+	assert.Equal(clh.jailRoot(), attr.Chroot)
+	assert.NotNil(attr.Credential)
+	assert.NotZero(attr.Cloneflags)
+}
+
+func TestClhJailResource(t *testing.T) {
+	if tc.NotValid(ktu.NeedRoot()) {
+		t.Skip(testDisabledAsNonRoot)
+	}
+
+	assert := assert.New(t)
+
+	clh := &cloudHypervisor{id: "c"}
+	clh.config.VMStorePath = t.TempDir()
+	clh.config.EnableJail = true
+	clh.config.JailUID = 1000
+	clh.config.JailGID = 1000
+	assert.NoError(os.MkdirAll(clh.jailRoot(), DirMode))
+	defer clh.cleanupJail()
+
+	// Files are bind mounted in the jail.
+	kernel := filepath.Join(t.TempDir(), "kernel")
+	assert.NoError(os.WriteFile(kernel, []byte("kernel"), 0644))
+	path, err := clh.jailResource(kernel, clhJailKernel)
+	assert.NoError(err)
+	assert.Equal("/"+clhJailKernel, path)
+
+	data, err := os.ReadFile(filepath.Join(clh.jailRoot(), clhJailKernel))
+	assert.NoError(err)
+	assert.Equal("kernel", string(data))
+
+	// Device nodes are created in the jail, for the jail user.
+	path, err = clh.jailResource("/dev/null", "/dev/null")
+	assert.NoError(err)
+	assert.Equal("/dev/null", path)
+
+	fi, err := os.Stat(filepath.Join(clh.jailRoot(), "dev", "null"))
+	assert.NoError(err)
+	assert.NotZero(fi.Mode() & os.ModeCharDevice)
+	assert.Equal(uint32(1000), fi.Sys().(*syscall.Stat_t).Uid)
+
+	clh.releaseJailResource(clhJailKernel)
+	assert.NoFileExists(filepath.Join(clh.jailRoot(), clhJailKernel))
+	assert.FileExists(kernel)
+
+	// The jail teardown releases the resources left in the jail.
+	_, err = clh.jailResource(kernel, clhJailImage)
+	assert.NoError(err)
+
+	clh.cleanupJail()
+	assert.NoDirExists(clh.jailRoot())
+	assert.FileExists(kernel)
+}
+
+func TestClhJailVirtiofsSocket(t *testing.T) {
+	if tc.NotValid(ktu.NeedRoot()) {
+		t.Skip(testDisabledAsNonRoot)
+	}
+
+	assert := assert.New(t)
+
+	const jailID = 1000
+
+	clh := &cloudHypervisor{id: "c"}
+	clh.config.VMStorePath = t.TempDir()
+	clh.config.EnableJail = true
+	clh.config.JailUID = jailID
+	clh.config.JailGID = jailID
+
+	// Let the jail user reach the socket from the host, as it does from
+	// the root of the jail.
+	assert.NoError(os.MkdirAll(clh.jailRoot(), DirMode))
+	for dir := clh.jailRoot(); dir != filepath.Dir(filepath.Dir(clh.config.VMStorePath)); dir = filepath.Dir(dir) {
+		assert.NoError(os.Chmod(dir, 0755))
+	}
+	assert.NoError(os.Chown(clh.jailRoot(), jailID, jailID))
+
+	sock, err := clh.virtioFsSocketPath(clh.id)
+	assert.NoError(err)
+
+	// The socket is created by the runtime, running as root, for
+	// virtiofsd.
+	vfsd := &virtiofsd{socketPath: sock}
+	fd, err := vfsd.getSocketFD()
+	assert.NoError(err)
+	defer fd.Close()
+
+	// Connect as the jail user, from a thread which exits afterwards.
+	errCh := make(chan error)
+	go func() {
+		runtime.LockOSThread()
+
+		for _, call := range []uintptr{unix.SYS_SETRESGID, unix.SYS_SETRESUID} {
+			if _, _, errno := unix.RawSyscall(call, jailID, jailID, jailID); errno != 0 {
+				errCh <- errno
+				return
+			}
+		}
+
+		conn, err := net.Dial("unix", sock)
+		if err == nil {
+			conn.Close()
+		}
+		errCh <- err
+	}()
+
+	assert.NoError(<-errCh)
+}
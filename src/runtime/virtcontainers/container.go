@@ -627,31 +627,31 @@ func (c *Container) createBlockDevices(ctx context.Context) error {
 				c.Logger().WithError(err).Error("error writing sandbox info")
 			}
 
-			readonly := false
-			for _, flag := range mntInfo.Options {
-				if flag == "ro" {
-					readonly = true
-					break
-				}
+			if mntInfo.VolumeType == volume.DirectoryVolumeType {
+				// Passed through the shared file system as any bind mount.
+				c.mounts[i].Source = mntInfo.Device
+				c.mounts[i].ReadOnly = c.mounts[i].ReadOnly || mntInfo.ReadOnly()
+				continue
 			}
 
 			c.mounts[i].Source = mntInfo.Device
 			c.mounts[i].Type = mntInfo.FsType
 			c.mounts[i].Options = mntInfo.Options
-			c.mounts[i].ReadOnly = readonly
+			c.mounts[i].ReadOnly = mntInfo.ReadOnly()
 		}
 
 		var stat unix.Stat_t
-		if err := unix.Stat(c.mounts[i].Source, &stat); err != nil {
-			return fmt.Errorf("stat %q failed: %v", c.mounts[i].Source, err)
-		}
-
 		var di *config.DeviceInfo
 		var err error
 
-		// Check if mount is a block device file. If it is, the block device will be attached to the host
-		// instead of passing this as a shared mount.
-		if stat.Mode&unix.S_IFBLK == unix.S_IFBLK {
+		if mntInfo != nil && mntInfo.VolumeType == volume.VhostUserBlkVolumeType {
+			// The vhost-user socket is attached as is.
+			di = vhostUserSocketDeviceInfo(c.mounts[i].Source, c.mounts[i].Destination, c.mounts[i].ReadOnly)
+		} else if err := unix.Stat(c.mounts[i].Source, &stat); err != nil {
+			return fmt.Errorf("stat %q failed: %v", c.mounts[i].Source, err)
+		} else if stat.Mode&unix.S_IFBLK == unix.S_IFBLK {
+			// Check if mount is a block device file. If it is, the block device will be attached to the host
+			// instead of passing this as a shared mount.
 			di = &config.DeviceInfo{
 				HostPath:      c.mounts[i].Source,
 				ContainerPath: c.mounts[i].Destination,
@@ -660,6 +660,7 @@ func (c *Container) createBlockDevices(ctx context.Context) error {
 				Minor:         int64(unix.Minor(uint64(stat.Rdev))),
 				ReadOnly:      c.mounts[i].ReadOnly,
 			}
+			di.IOLimits = blockIOLimits(c.config.Resources.BlockIO, di.Major, di.Minor)
 			// Check whether source can be used as a pmem device
 		} else if di, err = config.PmemDeviceInfo(c.mounts[i].Source, c.mounts[i].Destination); err != nil {
 			c.Logger().WithError(err).
@@ -775,6 +776,10 @@ func (c *Container) createDevices(contConfig *ContainerConfig) error {
 	// from the configuration. This should happen at create.
 	var storedDevices []ContainerDevice
 	for _, info := range contConfig.DeviceInfos {
+		if info.DevType == "b" {
+			info.IOLimits = blockIOLimits(contConfig.Resources.BlockIO, info.Major, info.Minor)
+		}
+
 		dev, err := c.sandbox.devManager.NewDevice(info)
 		if err != nil {
 			return err
@@ -1164,6 +1169,13 @@ func (c *Container) update(ctx context.Context, resources specs.LinuxResources)
 		return err
 	}
 
+	if resources.BlockIO != nil {
+		if err := c.updateBlockIOLimits(ctx, resources.BlockIO); err != nil {
+			return err
+		}
+		c.config.Resources.BlockIO = resources.BlockIO
+	}
+
 	// There currently isn't a notion of cpusets.cpus or mems being tracked
 	// inside of the guest. Make sure we clear these before asking agent to update
 	// the container's cgroups.
@@ -1175,6 +1187,80 @@ func (c *Container) update(ctx context.Context, resources specs.LinuxResources)
 	return c.sandbox.agent.updateContainer(ctx, c.sandbox, *c, resources)
 }
 
+// blockIOLimits returns the limits of the block device major:minor from the
+// OCI blkio throttling settings.
+func blockIOLimits(blockIO *specs.LinuxBlockIO, major, minor int64) config.BlockIOLimits {
+	var limits config.BlockIOLimits
+	if blockIO == nil {
+		return limits
+	}
+
+	rate := func(devices []specs.LinuxThrottleDevice) uint64 {
+		for _, d := range devices {
+			if d.Major == major && d.Minor == minor {
+				return d.Rate
+			}
+		}
+		return 0
+	}
+
+	limits.ReadBps = rate(blockIO.ThrottleReadBpsDevice)
+	limits.WriteBps = rate(blockIO.ThrottleWriteBpsDevice)
+	limits.ReadIOPS = rate(blockIO.ThrottleReadIOPSDevice)
+	limits.WriteIOPS = rate(blockIO.ThrottleWriteIOPSDevice)
+
+	return limits
+}
+
+// updateBlockIOLimits applies the blkio throttling settings blockIO to the
+// block devices of the container, if the hypervisor is able to. A block
+// device shared with another container gets the limits of the last
+// container updated.
+func (c *Container) updateBlockIOLimits(ctx context.Context, blockIO *specs.LinuxBlockIO) error {
+	throttler, ok := c.sandbox.hypervisor.(blockIOThrottler)
+	if !ok {
+		return nil
+	}
+
+	devIDs := []string{c.state.BlockDeviceID}
+	for _, m := range c.mounts {
+		devIDs = append(devIDs, m.BlockDeviceID)
+	}
+	for _, d := range c.devices {
+		devIDs = append(devIDs, d.ID)
+	}
+
+	for _, id := range devIDs {
+		if id == "" {
+			continue
+		}
+
+		dev := c.sandbox.devManager.GetDeviceByID(id)
+		if dev == nil || dev.DeviceType() != config.DeviceBlock {
+			continue
+		}
+
+		// The drive is only known once the device is attached.
+		drive, ok := dev.GetDeviceInfo().(*config.BlockDrive)
+		if !ok || drive == nil {
+			continue
+		}
+
+		major, minor := dev.GetMajorMinor()
+		limits := blockIOLimits(blockIO, major, minor)
+		if limits == drive.IOLimits {
+			continue
+		}
+
+		if err := throttler.updateBlockIOLimits(ctx, drive, limits); err != nil {
+			return err
+		}
+		drive.IOLimits = limits
+	}
+
+	return nil
+}
+
 func (c *Container) pause(ctx context.Context) error {
 	if err := c.checkSandboxRunning("pause"); err != nil {
 		return err
@@ -1284,12 +1370,15 @@ func (c *Container) plugDevice(ctx context.Context, devicePath string) error {
 	}
 
 	if c.checkBlockDeviceSupport(ctx) && stat.Mode&unix.S_IFBLK == unix.S_IFBLK {
+		major := int64(unix.Major(uint64(stat.Rdev)))
+		minor := int64(unix.Minor(uint64(stat.Rdev)))
 		b, err := c.sandbox.devManager.NewDevice(config.DeviceInfo{
 			HostPath:      devicePath,
 			ContainerPath: filepath.Join(kataGuestSharedDir(), c.id),
 			DevType:       "b",
-			Major:         int64(unix.Major(uint64(stat.Rdev))),
-			Minor:         int64(unix.Minor(uint64(stat.Rdev))),
+			Major:         major,
+			Minor:         minor,
+			IOLimits:      blockIOLimits(c.config.Resources.BlockIO, major, minor),
 		})
 		if err != nil {
 			return fmt.Errorf("device manager failed to create rootfs device for %q: %v", devicePath, err)
@@ -7,6 +7,7 @@ package virtcontainers
 
 import (
 	"context"
+	"errors"
 	"os"
 	"os/exec"
 	"path/filepath"
@@ -21,6 +22,7 @@ import (
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/device/manager"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/stretchr/testify/assert"
 )
 
@@ -124,6 +126,101 @@ func TestContainerRemoveDrive(t *testing.T) {
 	assert.Nil(t, err, "remove drive should succeed")
 }
 
+func newThrottleDevice(major, minor int64, rate uint64) specs.LinuxThrottleDevice {
+	d := specs.LinuxThrottleDevice{Rate: rate}
+	d.Major = major
+	d.Minor = minor
+	return d
+}
+
+func TestBlockIOLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	assert.Equal(config.BlockIOLimits{}, blockIOLimits(nil, 8, 0))
+
+	blockIO := &specs.LinuxBlockIO{
+		ThrottleReadBpsDevice: []specs.LinuxThrottleDevice{
+			newThrottleDevice(8, 0, 1<<20),
+			newThrottleDevice(8, 16, 2<<20),
+		},
+		ThrottleWriteIOPSDevice: []specs.LinuxThrottleDevice{
+			newThrottleDevice(8, 0, 100),
+		},
+	}
+
+	assert.Equal(config.BlockIOLimits{ReadBps: 1 << 20, WriteIOPS: 100}, blockIOLimits(blockIO, 8, 0))
+	assert.Equal(config.BlockIOLimits{ReadBps: 2 << 20}, blockIOLimits(blockIO, 8, 16))
+	assert.Equal(config.BlockIOLimits{}, blockIOLimits(blockIO, 253, 0))
+}
+
+type mockBlockIOThrottler struct {
+	mockHypervisor
+	limits map[string]config.BlockIOLimits
+	err    error
+}
+
+func (m *mockBlockIOThrottler) updateBlockIOLimits(ctx context.Context, drive *config.BlockDrive, limits config.BlockIOLimits) error {
+	if m.err != nil {
+		return m.err
+	}
+	m.limits[drive.ID] = limits
+	return nil
+}
+
+func TestContainerUpdateBlockIOLimits(t *testing.T) {
+	assert := assert.New(t)
+
+	throttler := &mockBlockIOThrottler{limits: map[string]config.BlockIOLimits{}}
+	sandbox := &Sandbox{
+		ctx:        context.Background(),
+		id:         "sandbox",
+		devManager: manager.NewDeviceManager(config.VirtioSCSI, false, "", nil),
+		config:     &SandboxConfig{},
+		hypervisor: throttler,
+	}
+
+	device, err := sandbox.devManager.NewDevice(config.DeviceInfo{
+		HostPath:      "/dev/sda",
+		ContainerPath: "/dev/sda",
+		DevType:       "b",
+		Major:         8,
+		Minor:         0,
+	})
+	assert.NoError(err)
+	err = device.Attach(sandbox.ctx, &api.MockDeviceReceiver{})
+	assert.NoError(err)
+	drive := device.GetDeviceInfo().(*config.BlockDrive)
+
+	container := Container{
+		sandbox: sandbox,
+		id:      "testContainer",
+		devices: []ContainerDevice{{ID: device.DeviceID()}},
+	}
+
+	blockIO := &specs.LinuxBlockIO{
+		ThrottleWriteBpsDevice: []specs.LinuxThrottleDevice{
+			newThrottleDevice(8, 0, 1<<20),
+		},
+	}
+
+	err = container.updateBlockIOLimits(sandbox.ctx, blockIO)
+	assert.NoError(err)
+	assert.Equal(config.BlockIOLimits{WriteBps: 1 << 20}, throttler.limits[drive.ID])
+	assert.Equal(config.BlockIOLimits{WriteBps: 1 << 20}, drive.IOLimits)
+
+	// Unchanged limits aren't updated again.
+	delete(throttler.limits, drive.ID)
+	err = container.updateBlockIOLimits(sandbox.ctx, blockIO)
+	assert.NoError(err)
+	assert.NotContains(throttler.limits, drive.ID)
+
+	// The limits of a drive the hypervisor fails to update are kept.
+	throttler.err = errors.New("update failed")
+	err = container.updateBlockIOLimits(sandbox.ctx, &specs.LinuxBlockIO{})
+	assert.Error(err)
+	assert.Equal(config.BlockIOLimits{WriteBps: 1 << 20}, drive.IOLimits)
+}
+
 func TestUnmountHostMountsRemoveBindHostPath(t *testing.T) {
 	if tc.NotValid(ktu.NeedRoot()) {
 		t.Skip(testDisabledAsNonRoot)
@@ -145,6 +145,18 @@ type DeviceInfo struct {
 	// ColdPlug specifies whether the device must be cold plugged (true)
 	// or hot plugged (false).
 	ColdPlug bool
+
+	// IOLimits of a block device.
+	IOLimits BlockIOLimits
+}
+
+// BlockIOLimits are the I/O limits of a block device. Bandwidths are in bytes
+// per second, and a zero limit means unlimited.
+type BlockIOLimits struct {
+	ReadBps   uint64
+	WriteBps  uint64
+	ReadIOPS  uint64
+	WriteIOPS uint64
 }
 
 // BlockDrive represents a block storage drive which may be used in case the storage
@@ -193,6 +205,9 @@ type BlockDrive struct {
 
 	// This block device is for swap
 	Swap bool
+
+	// IOLimits applied by the hypervisor to the drive
+	IOLimits BlockIOLimits
 }
 
 // VFIOMode indicates e behaviour mode for handling devices in the VM
@@ -68,6 +68,7 @@ func (device *BlockDevice) Attach(ctx context.Context, devReceiver api.DeviceRec
 		Index:    index,
 		Pmem:     device.DeviceInfo.Pmem,
 		ReadOnly: device.DeviceInfo.ReadOnly,
+		IOLimits: device.DeviceInfo.IOLimits,
 	}
 
 	if fs, ok := device.DeviceInfo.DriverOptions[config.FsTypeOpt]; ok {
@@ -76,6 +76,25 @@ func NewDeviceManager(blockDriver string, vhostUserStoreEnabled bool, vhostUserS
 	return dm
 }
 
+// findDevice returns the device already created for devInfo. The vhost-user
+// devices are identified by their socket, as they may be given without a
+// device node, the other devices by their major and minor numbers.
+func (dm *deviceManager) findDevice(devInfo config.DeviceInfo) api.Device {
+	if isVhostUserBlk(devInfo) || isVhostUserSCSI(devInfo) {
+		return dm.findDeviceByHostPath(devInfo.HostPath)
+	}
+	return dm.findDeviceByMajorMinor(devInfo.Major, devInfo.Minor)
+}
+
+func (dm *deviceManager) findDeviceByHostPath(hostPath string) api.Device {
+	for _, dev := range dm.devices {
+		if dev.GetHostPath() == hostPath {
+			return dev
+		}
+	}
+	return nil
+}
+
 func (dm *deviceManager) findDeviceByMajorMinor(major, minor int64) api.Device {
 	for _, dev := range dm.devices {
 		dma, dmi := dev.GetMajorMinor()
@@ -88,9 +107,10 @@ func (dm *deviceManager) findDeviceByMajorMinor(major, minor int64) api.Device {
 
 // createDevice creates one device based on DeviceInfo
 func (dm *deviceManager) createDevice(devInfo config.DeviceInfo) (dev api.Device, err error) {
-	// pmem device may points to block devices or raw files,
-	// do not change its HostPath.
-	if !devInfo.Pmem {
+	// pmem device may points to block devices or raw files, and
+	// vhost-user sockets may be given directly instead of through
+	// the vhost-user store, do not change their HostPath.
+	if !devInfo.Pmem && !isVhostUserSocket(devInfo) {
 		path, err := config.GetHostPathFunc(devInfo, dm.vhostUserStoreEnabled, dm.vhostUserStorePath)
 		if err != nil {
 			return nil, err
@@ -104,7 +124,7 @@ func (dm *deviceManager) createDevice(devInfo config.DeviceInfo) (dev api.Device
 		}
 	}()
 
-	if existingDev := dm.findDeviceByMajorMinor(devInfo.Major, devInfo.Minor); existingDev != nil {
+	if existingDev := dm.findDevice(devInfo); existingDev != nil {
 		return existingDev, nil
 	}
 
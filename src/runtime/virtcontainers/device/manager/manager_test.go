@@ -8,6 +8,7 @@ package manager
 
 import (
 	"context"
+	"net"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -257,3 +258,41 @@ func TestAttachDetachDevice(t *testing.T) {
 	err = dm.RemoveDevice(device.DeviceID())
 	assert.Nil(t, err)
 }
+
+func TestNewVhostUserSocketDevice(t *testing.T) {
+	dm := &deviceManager{
+		blockDriver: config.VirtioBlock,
+		devices:     make(map[string]api.Device),
+	}
+
+	dir := t.TempDir()
+	var sockets []string
+	for _, name := range []string{"blk0.sock", "blk1.sock"} {
+		socketPath := filepath.Join(dir, name)
+		l, err := net.Listen("unix", socketPath)
+		assert.NoError(t, err)
+		defer l.Close()
+		sockets = append(sockets, socketPath)
+	}
+
+	newDevice := func(socketPath, containerPath string) api.Device {
+		device, err := dm.NewDevice(config.DeviceInfo{
+			HostPath:      socketPath,
+			ContainerPath: containerPath,
+			DevType:       "b",
+			Major:         config.VhostUserBlkMajor,
+		})
+		assert.NoError(t, err)
+		_, ok := device.(*drivers.VhostUserBlkDevice)
+		assert.True(t, ok)
+		return device
+	}
+
+	// The sockets share the same major and minor numbers, they are
+	// told apart by their path.
+	device := newDevice(sockets[0], "/volume")
+	assert.Equal(t, sockets[0], device.GetHostPath())
+	assert.Equal(t, device, newDevice(sockets[0], "/other"))
+	assert.NotEqual(t, device, newDevice(sockets[1], "/volume"))
+	assert.Len(t, dm.devices, 2)
+}
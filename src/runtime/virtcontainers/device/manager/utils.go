@@ -7,6 +7,7 @@
 package manager
 
 import (
+	"os"
 	"path/filepath"
 	"strings"
 
@@ -45,3 +46,14 @@ func isVhostUserBlk(devInfo config.DeviceInfo) bool {
 func isVhostUserSCSI(devInfo config.DeviceInfo) bool {
 	return devInfo.DevType == "b" && devInfo.Major == config.VhostUserSCSIMajor
 }
+
+// isVhostUserSocket checks if the device is a vhost-user storage device given
+// by its socket, rather than by a device node of the vhost-user store.
+func isVhostUserSocket(devInfo config.DeviceInfo) bool {
+	if !isVhostUserBlk(devInfo) && !isVhostUserSCSI(devInfo) {
+		return false
+	}
+
+	fi, err := os.Stat(devInfo.HostPath)
+	return err == nil && fi.Mode()&os.ModeSocket != 0
+}
@@ -7,6 +7,8 @@
 package manager
 
 import (
+	"net"
+	"path/filepath"
 	"testing"
 
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/device/config"
@@ -103,3 +105,35 @@ func TestIsVhostUserSCSI(t *testing.T) {
 		assert.Equal(t, d.expected, isVhostUserSCSI)
 	}
 }
+
+func TestIsVhostUserSocket(t *testing.T) {
+	dir := t.TempDir()
+	socketPath := filepath.Join(dir, "blk.sock")
+	l, err := net.Listen("unix", socketPath)
+	assert.NoError(t, err)
+	defer l.Close()
+
+	type testData struct {
+		hostPath string
+		major    int64
+		expected bool
+	}
+
+	data := []testData{
+		{socketPath, config.VhostUserBlkMajor, true},
+		{socketPath, config.VhostUserSCSIMajor, true},
+		{socketPath, 240, false},
+		{dir, config.VhostUserBlkMajor, false},
+		{filepath.Join(dir, "missing.sock"), config.VhostUserBlkMajor, false},
+	}
+
+	for _, d := range data {
+		isVhostUserSocket := isVhostUserSocket(
+			config.DeviceInfo{
+				HostPath: d.hostPath,
+				DevType:  "b",
+				Major:    d.major,
+			})
+		assert.Equal(t, d.expected, isVhostUserSocket)
+	}
+}
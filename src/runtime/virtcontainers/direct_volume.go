@@ -0,0 +1,133 @@
+//go:build linux
+// +build linux
+
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"fmt"
+
+	volume "github.com/kata-containers/kata-containers/src/runtime/pkg/direct-volume"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/device/api"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/device/config"
+	"golang.org/x/sys/unix"
+)
+
+// vhostUserSocketDeviceInfo returns the device info of a vhost-user-blk
+// backend given by its socket. Such a socket is not registered in the
+// vhost-user store and has no minor number: the device manager shares the
+// device between the containers using the same socket.
+func vhostUserSocketDeviceInfo(socketPath, containerPath string, readOnly bool) *config.DeviceInfo {
+	return &config.DeviceInfo{
+		HostPath:      socketPath,
+		ContainerPath: containerPath,
+		DevType:       "b",
+		Major:         config.VhostUserBlkMajor,
+		ReadOnly:      readOnly,
+	}
+}
+
+// directVolumeDeviceInfo returns the device backing a direct assigned volume,
+// or nil for a directory volume, which is passed through the shared file
+// system instead.
+func directVolumeDeviceInfo(mntInfo *volume.MountInfo, containerPath string) (*config.DeviceInfo, error) {
+	switch mntInfo.VolumeType {
+	case volume.DirectoryVolumeType:
+		return nil, nil
+	case volume.VhostUserBlkVolumeType:
+		return vhostUserSocketDeviceInfo(mntInfo.Device, containerPath, mntInfo.ReadOnly()), nil
+	}
+
+	var stat unix.Stat_t
+	if err := unix.Stat(mntInfo.Device, &stat); err != nil {
+		return nil, fmt.Errorf("stat %q failed: %v", mntInfo.Device, err)
+	}
+
+	if stat.Mode&unix.S_IFBLK != unix.S_IFBLK {
+		return nil, fmt.Errorf("%s is not a block device", mntInfo.Device)
+	}
+
+	return &config.DeviceInfo{
+		HostPath:      mntInfo.Device,
+		ContainerPath: containerPath,
+		DevType:       "b",
+		Major:         int64(unix.Major(uint64(stat.Rdev))),
+		Minor:         int64(unix.Minor(uint64(stat.Rdev))),
+		ReadOnly:      mntInfo.ReadOnly(),
+	}, nil
+}
+
+// AttachDirectVolume hot plugs the device backing a direct assigned volume
+// into the sandbox VM. The containers created later with the volume use the
+// attached device, which is held until DetachDirectVolume. Directory volumes
+// have no device to attach, they are only recorded as used by the sandbox.
+func (s *Sandbox) AttachDirectVolume(ctx context.Context, volumePath string) (err error) {
+	if _, ok := s.directVolumes[volumePath]; ok {
+		return fmt.Errorf("volume %s is already attached", volumePath)
+	}
+
+	mntInfo, err := volume.VolumeMountInfo(volumePath)
+	if err != nil {
+		return err
+	}
+
+	di, err := directVolumeDeviceInfo(mntInfo, volumePath)
+	if err != nil {
+		return err
+	}
+
+	if di != nil {
+		var dev api.Device
+		if dev, err = s.AddDevice(ctx, *di); err != nil {
+			return err
+		}
+
+		if s.directVolumes == nil {
+			s.directVolumes = make(map[string]string)
+		}
+		s.directVolumes[volumePath] = dev.DeviceID()
+		defer func() {
+			if err != nil {
+				delete(s.directVolumes, volumePath)
+				s.releaseDevice(ctx, dev.DeviceID())
+			}
+		}()
+	}
+
+	if err = volume.RecordSandboxId(s.id, volumePath); err != nil {
+		return err
+	}
+
+	return s.Save()
+}
+
+// DetachDirectVolume releases the device attached for a direct assigned
+// volume by AttachDirectVolume. The device is unplugged from the VM once no
+// container uses it anymore. Nothing is done for a volume which was not
+// attached.
+func (s *Sandbox) DetachDirectVolume(ctx context.Context, volumePath string) error {
+	id, ok := s.directVolumes[volumePath]
+	if !ok {
+		return nil
+	}
+
+	if err := s.releaseDevice(ctx, id); err != nil {
+		return err
+	}
+	delete(s.directVolumes, volumePath)
+
+	return s.Save()
+}
+
+// releaseDevice drops an attachment and a reference of the device id.
+func (s *Sandbox) releaseDevice(ctx context.Context, id string) error {
+	if err := s.devManager.DetachDevice(ctx, id, s); err != nil {
+		return err
+	}
+	return s.devManager.RemoveDevice(id)
+}
@@ -0,0 +1,94 @@
+//go:build linux
+// +build linux
+
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	volume "github.com/kata-containers/kata-containers/src/runtime/pkg/direct-volume"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/device/config"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/device/manager"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDirectVolumeDeviceInfo(t *testing.T) {
+	assert := assert.New(t)
+
+	di, err := directVolumeDeviceInfo(&volume.MountInfo{
+		VolumeType: volume.DirectoryVolumeType,
+		Device:     "/mnt/nfs/vol0",
+	}, "/volume")
+	assert.NoError(err)
+	assert.Nil(di)
+
+	mntInfo := &volume.MountInfo{
+		VolumeType: volume.VhostUserBlkVolumeType,
+		Device:     "/run/vhost/blk0.sock",
+		FsType:     "ext4",
+		Options:    []string{"ro"},
+	}
+	di, err = directVolumeDeviceInfo(mntInfo, "/volume")
+	assert.NoError(err)
+	assert.Equal("/run/vhost/blk0.sock", di.HostPath)
+	assert.Equal("b", di.DevType)
+	assert.Equal(int64(config.VhostUserBlkMajor), di.Major)
+	assert.True(di.ReadOnly)
+
+	assert.Equal(int64(0), di.Minor)
+
+	file := filepath.Join(t.TempDir(), "file")
+	assert.NoError(os.WriteFile(file, nil, 0600))
+	_, err = directVolumeDeviceInfo(&volume.MountInfo{VolumeType: volume.BlockVolumeType, Device: file}, "/volume")
+	assert.Error(err)
+	_, err = directVolumeDeviceInfo(&volume.MountInfo{VolumeType: volume.BlockVolumeType, Device: "/does/not/exist"}, "/volume")
+	assert.Error(err)
+}
+
+func TestDirectVolumeDeviceRelease(t *testing.T) {
+	assert := assert.New(t)
+
+	socketPath := filepath.Join(t.TempDir(), "blk0.sock")
+	l, err := net.Listen("unix", socketPath)
+	assert.NoError(err)
+	defer l.Close()
+
+	sandbox := &Sandbox{
+		id:         "100",
+		hypervisor: &mockHypervisor{},
+		devManager: manager.NewDeviceManager(config.VirtioBlock, false, "", nil),
+		ctx:        context.Background(),
+		config:     &SandboxConfig{},
+		state:      types.SandboxState{BlockIndexMap: make(map[int]struct{})},
+	}
+
+	// A volume which was not attached has nothing to release.
+	assert.NoError(sandbox.DetachDirectVolume(context.Background(), "/volume"))
+
+	di := vhostUserSocketDeviceInfo(socketPath, "/volume", false)
+	dev, err := sandbox.AddDevice(context.Background(), *di)
+	assert.NoError(err)
+
+	// A container using the volume shares the device.
+	shared, err := sandbox.devManager.NewDevice(*vhostUserSocketDeviceInfo(socketPath, "/data", false))
+	assert.NoError(err)
+	assert.Equal(dev.DeviceID(), shared.DeviceID())
+	assert.NoError(sandbox.devManager.AttachDevice(context.Background(), shared.DeviceID(), sandbox))
+
+	assert.NoError(sandbox.releaseDevice(context.Background(), dev.DeviceID()))
+	assert.NotNil(sandbox.devManager.GetDeviceByID(dev.DeviceID()))
+	assert.True(sandbox.devManager.IsDeviceAttached(dev.DeviceID()))
+
+	assert.NoError(sandbox.releaseDevice(context.Background(), dev.DeviceID()))
+	assert.Nil(sandbox.devManager.GetDeviceByID(dev.DeviceID()))
+}
@@ -57,6 +57,7 @@ const (
 	notReady vmmState = iota
 	cfReady
 	vmReady
+	vmPaused
 )
 
 const (
@@ -88,9 +89,17 @@ const (
 	storagePathSuffix = "vc"
 )
 
+// fcResizeErr is returned for the requests to grow a running VM, as
+// firecracker has no vCPU nor memory hotplug.
+var fcResizeErr = errors.New("firecracker can't resize a running VM")
+
 // Specify the minimum version of firecracker supported
 var fcMinSupportedVersion = semver.MustParse("0.21.1")
 
+// Specify the minimum version of firecracker providing the version 2 of the
+// metadata service
+var fcMmdsV2MinVersion = semver.MustParse("1.0.0")
+
 var fcKernelParams = append(commonVirtioblkKernelRootParams, []Param{
 	// The boot source is the first partition of the first block device added
 	{"pci", "off"},
@@ -113,6 +122,8 @@ func (s vmmState) String() string {
 		return "FC configure ready"
 	case vmReady:
 		return "FC VM ready"
+	case vmPaused:
+		return "FC VM paused"
 	}
 
 	return ""
@@ -334,6 +345,10 @@ func (fc *firecracker) checkVersion(version string) error {
 		return fmt.Errorf("version %v is not supported. Minimum supported version of firecracker is %v", v.String(), fcMinSupportedVersion.String())
 	}
 
+	if fc.config.EnableMmds && v.LT(fcMmdsV2MinVersion) {
+		return fmt.Errorf("version %v does not provide MMDS version 2. Minimum version of firecracker for enable_mmds is %v", v.String(), fcMmdsV2MinVersion.String())
+	}
+
 	return nil
 }
 
@@ -762,6 +777,8 @@ func (fc *firecracker) fcInitConfiguration(ctx context.Context) error {
 		}
 	}
 
+	fc.fcSetMmdsConfig(ctx)
+
 	// register firecracker specificed metrics
 	registerFirecrackerMetrics()
 
@@ -854,36 +871,48 @@ func (fc *firecracker) createDiskPool(ctx context.Context) error {
 	return nil
 }
 
-func (fc *firecracker) umountResource(jailedPath string) {
-	hostPath := filepath.Join(fc.jailerRoot, jailedPath)
-	fc.Logger().WithField("resource", hostPath).Debug("Unmounting resource")
+// umountJailResource unmounts a resource bind mounted in the root of a jail.
+func umountJailResource(logger *logrus.Entry, jailRoot, jailedPath string) {
+	hostPath := filepath.Join(jailRoot, jailedPath)
+	logger.WithField("resource", hostPath).Debug("Unmounting resource")
 	err := syscall.Unmount(hostPath, syscall.MNT_DETACH)
 	if err != nil {
-		fc.Logger().WithError(err).Error("Failed to umount resource")
+		logger.WithError(err).Error("Failed to umount resource")
+	}
+}
+
+// cleanupJailRoot unmounts the resources bind mounted in the root of a jail,
+// then the root itself when it is mounted, and removes jailPath, holding the
+// jail.
+func cleanupJailRoot(logger *logrus.Entry, jailRoot string, resources []string, mountedRoot bool, jailPath string) {
+	for _, resource := range resources {
+		umountJailResource(logger, jailRoot, resource)
+	}
+
+	if mountedRoot {
+		if err := syscall.Unmount(jailRoot, syscall.MNT_DETACH); err != nil {
+			logger.WithField("JailerRoot", jailRoot).WithError(err).Error("Failed to umount")
+		}
+	}
+
+	logger.WithField("cleaningJail", jailPath).Info()
+	if err := os.RemoveAll(jailPath); err != nil {
+		logger.WithField("cleanupJail failed", err).Error()
 	}
 }
 
+func (fc *firecracker) umountResource(jailedPath string) {
+	umountJailResource(fc.Logger(), fc.jailerRoot, jailedPath)
+}
+
 // cleanup all jail artifacts
 func (fc *firecracker) cleanupJail(ctx context.Context) {
 	span, _ := katatrace.Trace(ctx, fc.Logger(), "cleanupJail", fcTracingTags, map[string]string{"sandbox_id": fc.id})
 	defer span.End()
 
-	fc.umountResource(fcKernel)
-	fc.umountResource(fcRootfs)
-	fc.umountResource(fcLogFifo)
-	fc.umountResource(fcMetricsFifo)
-	fc.umountResource(defaultFcConfig)
 	// if running with jailer, we also need to umount fc.jailerRoot
-	if fc.config.JailerPath != "" {
-		if err := syscall.Unmount(fc.jailerRoot, syscall.MNT_DETACH); err != nil {
-			fc.Logger().WithField("JailerRoot", fc.jailerRoot).WithError(err).Error("Failed to umount")
-		}
-	}
-
-	fc.Logger().WithField("cleaningJail", fc.vmPath).Info()
-	if err := os.RemoveAll(fc.vmPath); err != nil {
-		fc.Logger().WithField("cleanupJail failed", err).Error()
-	}
+	cleanupJailRoot(fc.Logger(), fc.jailerRoot, []string{fcKernel, fcRootfs, fcLogFifo, fcMetricsFifo, defaultFcConfig},
+		fc.config.JailerPath != "", fc.vmPath)
 }
 
 // stopSandbox will stop the Sandbox's VM.
@@ -894,7 +923,86 @@ func (fc *firecracker) StopVM(ctx context.Context, waitOnly bool) (err error) {
 	return fc.fcEnd(ctx, waitOnly)
 }
 
+// fcPatchVM sets the running state of the VM, one of the models.VMState*
+// values.
+func (fc *firecracker) fcPatchVM(ctx context.Context, state string) error {
+	span, _ := katatrace.Trace(ctx, fc.Logger(), "fcPatchVM", fcTracingTags, map[string]string{"sandbox_id": fc.id})
+	defer span.End()
+
+	param := ops.NewPatchVMParams()
+	param.SetBody(&models.VM{
+		State: &state,
+	})
+
+	if _, err := fc.client(ctx).Operations.PatchVM(param); err != nil {
+		return fmt.Errorf("Failed to set VM state to %s: %v", state, err)
+	}
+
+	return nil
+}
+
+// fcSetMmdsConfig enables the version 2 of the metadata service on all the
+// network interfaces of the VM, which must have been added already.
+func (fc *firecracker) fcSetMmdsConfig(ctx context.Context) {
+	span, _ := katatrace.Trace(ctx, fc.Logger(), "fcSetMmdsConfig", fcTracingTags, map[string]string{"sandbox_id": fc.id})
+	defer span.End()
+
+	if !fc.config.EnableMmds {
+		return
+	}
+
+	version := models.MmdsConfigVersionV2
+	mmdsConfig := &models.MmdsConfig{
+		Version: &version,
+	}
+
+	for _, iface := range fc.fcConfig.NetworkInterfaces {
+		mmdsConfig.NetworkInterfaces = append(mmdsConfig.NetworkInterfaces, *iface.IfaceID)
+	}
+
+	if fc.config.MmdsIPv4Address != "" {
+		mmdsConfig.IPV4Address = &fc.config.MmdsIPv4Address
+	}
+
+	fc.fcConfig.MmdsConfig = mmdsConfig
+}
+
+// publishMetadata replaces the content of the MMDS data store, from which
+// the guest fetches its metadata.
+func (fc *firecracker) publishMetadata(ctx context.Context, metadata interface{}) error {
+	span, _ := katatrace.Trace(ctx, fc.Logger(), "publishMetadata", fcTracingTags, map[string]string{"sandbox_id": fc.id})
+	defer span.End()
+
+	if !fc.config.EnableMmds {
+		return nil
+	}
+
+	param := ops.NewPutMmdsParams()
+	param.SetBody(metadata)
+
+	if _, err := fc.client(ctx).Operations.PutMmds(param); err != nil {
+		return fmt.Errorf("Failed to publish the MMDS metadata: %v", err)
+	}
+
+	return nil
+}
+
 func (fc *firecracker) PauseVM(ctx context.Context) error {
+	span, _ := katatrace.Trace(ctx, fc.Logger(), "PauseVM", fcTracingTags, map[string]string{"sandbox_id": fc.id})
+	defer span.End()
+
+	fc.state.Lock()
+	defer fc.state.Unlock()
+
+	if fc.state.state != vmReady {
+		return fmt.Errorf("Can not pause VM: %s", fc.state.state)
+	}
+
+	if err := fc.fcPatchVM(ctx, models.VMStatePaused); err != nil {
+		return err
+	}
+
+	fc.state.state = vmPaused
 	return nil
 }
 
@@ -903,6 +1011,21 @@ func (fc *firecracker) SaveVM() error {
 }
 
 func (fc *firecracker) ResumeVM(ctx context.Context) error {
+	span, _ := katatrace.Trace(ctx, fc.Logger(), "ResumeVM", fcTracingTags, map[string]string{"sandbox_id": fc.id})
+	defer span.End()
+
+	fc.state.Lock()
+	defer fc.state.Unlock()
+
+	if fc.state.state != vmPaused {
+		return fmt.Errorf("Can not resume VM: %s", fc.state.state)
+	}
+
+	if err := fc.fcPatchVM(ctx, models.VMStateResumed); err != nil {
+		return err
+	}
+
+	fc.state.state = vmReady
 	return nil
 }
 
@@ -926,48 +1049,42 @@ func (fc *firecracker) fcAddVsock(ctx context.Context, hvs types.HybridVSock) {
 	fc.fcConfig.Vsock = vsock
 }
 
+// fcRateLimiter returns the rate limiter of a kata-defined rate, which a
+// zero rate disables.
+func fcRateLimiter(rate uint64) *models.RateLimiter {
+	// The implementation of rate limiter is based on TBF.
+	// Rate Limiter defines a token bucket with a maximum capacity (size) to store tokens, and an interval for refilling purposes (refill_time).
+	// The refill-rate is derived from size and refill_time, and it is the constant rate at which the tokens replenish.
+	refillTime := uint64(1000)
+
+	// kata-defined rate is in bits with scaling factors of 1000, but firecracker-defined
+	// size is in bytes with scaling factors of 1024, need reversion.
+	size := revertBytes(rate / 8)
+
+	return &models.RateLimiter{
+		Bandwidth: &models.TokenBucket{
+			RefillTime: &refillTime,
+			Size:       &size,
+		},
+	}
+}
+
 func (fc *firecracker) fcAddNetDevice(ctx context.Context, endpoint Endpoint) {
 	span, _ := katatrace.Trace(ctx, fc.Logger(), "fcAddNetDevice", fcTracingTags, map[string]string{"sandbox_id": fc.id})
 	defer span.End()
 
 	ifaceID := endpoint.Name()
 
-	// The implementation of rate limiter is based on TBF.
-	// Rate Limiter defines a token bucket with a maximum capacity (size) to store tokens, and an interval for refilling purposes (refill_time).
-	// The refill-rate is derived from size and refill_time, and it is the constant rate at which the tokens replenish.
-	refillTime := uint64(1000)
-	var rxRateLimiter models.RateLimiter
-	rxSize := fc.config.RxRateLimiterMaxRate
-	if rxSize > 0 {
+	rxRateLimiter := &models.RateLimiter{}
+	if fc.config.RxRateLimiterMaxRate > 0 {
 		fc.Logger().Info("Add rx rate limiter")
-
-		// kata-defined rxSize is in bits with scaling factors of 1000, but firecracker-defined
-		// rxSize is in bytes with scaling factors of 1024, need reversion.
-		rxSize = revertBytes(rxSize / 8)
-		rxTokenBucket := models.TokenBucket{
-			RefillTime: &refillTime,
-			Size:       &rxSize,
-		}
-		rxRateLimiter = models.RateLimiter{
-			Bandwidth: &rxTokenBucket,
-		}
+		rxRateLimiter = fcRateLimiter(fc.config.RxRateLimiterMaxRate)
 	}
 
-	var txRateLimiter models.RateLimiter
-	txSize := fc.config.TxRateLimiterMaxRate
-	if txSize > 0 {
+	txRateLimiter := &models.RateLimiter{}
+	if fc.config.TxRateLimiterMaxRate > 0 {
 		fc.Logger().Info("Add tx rate limiter")
-
-		// kata-defined txSize is in bits with scaling factors of 1000, but firecracker-defined
-		// txSize is in bytes with scaling factors of 1024, need reversion.
-		txSize = revertBytes(txSize / 8)
-		txTokenBucket := models.TokenBucket{
-			RefillTime: &refillTime,
-			Size:       &txSize,
-		}
-		txRateLimiter = models.RateLimiter{
-			Bandwidth: &txTokenBucket,
-		}
+		txRateLimiter = fcRateLimiter(fc.config.TxRateLimiterMaxRate)
 	}
 
 	ifaceCfg := &models.NetworkInterface{
@@ -975,8 +1092,8 @@ func (fc *firecracker) fcAddNetDevice(ctx context.Context, endpoint Endpoint) {
 		GuestMac:          endpoint.HardwareAddr(),
 		IfaceID:           &ifaceID,
 		HostDevName:       &endpoint.NetworkPair().TapInterface.TAPIface.Name,
-		RxRateLimiter:     &rxRateLimiter,
-		TxRateLimiter:     &txRateLimiter,
+		RxRateLimiter:     rxRateLimiter,
+		TxRateLimiter:     txRateLimiter,
 	}
 
 	fc.fcConfig.NetworkInterfaces = append(fc.fcConfig.NetworkInterfaces, ifaceCfg)
@@ -1030,6 +1147,41 @@ func (fc *firecracker) fcUpdateBlockDrive(ctx context.Context, path, id string)
 	return nil
 }
 
+// updateNetRateLimiters updates the built-in rate limiters of the network
+// interfaces of the running VM.
+func (fc *firecracker) updateNetRateLimiters(ctx context.Context, endpoints []Endpoint, rxRate, txRate uint64) error {
+	span, _ := katatrace.Trace(ctx, fc.Logger(), "updateNetRateLimiters", fcTracingTags, map[string]string{"sandbox_id": fc.id})
+	defer span.End()
+
+	fc.state.Lock()
+	defer fc.state.Unlock()
+
+	if fc.state.state != vmReady && fc.state.state != vmPaused {
+		return fmt.Errorf("Can not update the rate limiters of a VM in state %s", fc.state.state)
+	}
+
+	for _, endpoint := range endpoints {
+		ifaceID := endpoint.Name()
+
+		param := ops.NewPatchGuestNetworkInterfaceByIDParams()
+		param.SetIfaceID(ifaceID)
+		param.SetBody(&models.PartialNetworkInterface{
+			IfaceID:       &ifaceID,
+			RxRateLimiter: fcRateLimiter(rxRate),
+			TxRateLimiter: fcRateLimiter(txRate),
+		})
+
+		if _, err := fc.client(ctx).Operations.PatchGuestNetworkInterfaceByID(param); err != nil {
+			return fmt.Errorf("Failed to update the rate limiters of %s: %v", ifaceID, err)
+		}
+	}
+
+	fc.config.RxRateLimiterMaxRate = rxRate
+	fc.config.TxRateLimiterMaxRate = txRate
+
+	return nil
+}
+
 // addDevice will add extra devices to firecracker.  Limited to configure before the
 // virtual machine starts.  Devices include drivers and network interfaces only.
 func (fc *firecracker) AddDevice(ctx context.Context, devInfo interface{}, devType DeviceType) error {
@@ -1156,6 +1308,7 @@ func (fc *firecracker) Capabilities(ctx context.Context) types.Capabilities {
 	defer span.End()
 	var caps types.Capabilities
 	caps.SetBlockDeviceHotplugSupport()
+	caps.SetVMPauseSupport()
 
 	return caps
 }
@@ -1164,12 +1317,26 @@ func (fc *firecracker) HypervisorConfig() HypervisorConfig {
 	return fc.config
 }
 
+// ResizeMemory returns the memory of the VM, since firecracker has no memory
+// hotplug. The VM is rather sized before booting for all the containers of
+// the sandbox, and requests exceeding this size fail with fcResizeErr.
 func (fc *firecracker) ResizeMemory(ctx context.Context, reqMemMB uint32, memoryBlockSizeMB uint32, probe bool) (uint32, MemoryDevice, error) {
-	return 0, MemoryDevice{}, nil
+	if reqMemMB > fc.config.MemorySize {
+		return fc.config.MemorySize, MemoryDevice{}, errors.Wrapf(fcResizeErr, "%d MiB of memory requested, %d MiB booted", reqMemMB, fc.config.MemorySize)
+	}
+
+	return fc.config.MemorySize, MemoryDevice{}, nil
 }
 
+// ResizeVCPUs returns the vCPUs of the VM, since firecracker has no vCPU
+// hotplug. The VM is rather sized before booting for all the containers of
+// the sandbox, and requests exceeding this size fail with fcResizeErr.
 func (fc *firecracker) ResizeVCPUs(ctx context.Context, reqVCPUs uint32) (currentVCPUs uint32, newVCPUs uint32, err error) {
-	return 0, 0, nil
+	if reqVCPUs > fc.config.NumVCPUs {
+		return fc.config.NumVCPUs, fc.config.NumVCPUs, errors.Wrapf(fcResizeErr, "%d vCPUs requested, %d booted", reqVCPUs, fc.config.NumVCPUs)
+	}
+
+	return fc.config.NumVCPUs, fc.config.NumVCPUs, nil
 }
 
 // This is used to apply cgroup information on the host.
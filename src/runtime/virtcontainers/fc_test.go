@@ -9,9 +9,17 @@
 package virtcontainers
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"path/filepath"
 	"strings"
+	"sync"
 	"testing"
 
+	models "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/firecracker/client/models"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
 	"github.com/stretchr/testify/assert"
 )
@@ -93,3 +101,211 @@ func TestFcSetConfig(t *testing.T) {
 
 	assert.Equal(fc.config, config)
 }
+
+// fcTestRequest is a request received by the test firecracker API server.
+type fcTestRequest struct {
+	method string
+	path   string
+	body   map[string]interface{}
+}
+
+// newFCTestServer serves the firecracker API on a unix socket, replying
+// status to every request. It returns the socket path along with the
+// requests received so far.
+func newFCTestServer(t *testing.T, status int) (string, func() []fcTestRequest) {
+	var (
+		mu       sync.Mutex
+		requests []fcTestRequest
+	)
+
+	sock := filepath.Join(t.TempDir(), "fc.sock")
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	srv := &http.Server{
+		Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			req := fcTestRequest{
+				method: r.Method,
+				path:   r.URL.Path,
+			}
+			if err := json.NewDecoder(r.Body).Decode(&req.body); err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			mu.Lock()
+			requests = append(requests, req)
+			mu.Unlock()
+			w.WriteHeader(status)
+		}),
+	}
+	go srv.Serve(l)
+	t.Cleanup(func() { srv.Close() })
+
+	return sock, func() []fcTestRequest {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]fcTestRequest{}, requests...)
+	}
+}
+
+// fcTestVMStates returns the VM states set by requests.
+func fcTestVMStates(requests []fcTestRequest) []string {
+	var states []string
+	for _, r := range requests {
+		if r.method == http.MethodPatch && r.path == "/vm" {
+			states = append(states, r.body["state"].(string))
+		}
+	}
+	return states
+}
+
+func TestFCPauseResumeVM(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	sock, requests := newFCTestServer(t, http.StatusNoContent)
+	fc := &firecracker{socketPath: sock}
+
+	// Only a running VM can be paused.
+	assert.Error(fc.PauseVM(ctx))
+	assert.Error(fc.ResumeVM(ctx))
+
+	fc.state.set(vmReady)
+	assert.Error(fc.ResumeVM(ctx))
+
+	assert.NoError(fc.PauseVM(ctx))
+	assert.Equal(vmPaused, fc.state.state)
+	assert.Error(fc.PauseVM(ctx))
+
+	assert.NoError(fc.ResumeVM(ctx))
+	assert.Equal(vmReady, fc.state.state)
+
+	assert.Equal([]string{"Paused", "Resumed"}, fcTestVMStates(requests()))
+}
+
+func TestFCPauseVMFailure(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	sock, requests := newFCTestServer(t, http.StatusBadRequest)
+	fc := &firecracker{socketPath: sock}
+	fc.state.set(vmReady)
+
+	// The VM keeps running when Firecracker fails to pause it.
+	assert.Error(fc.PauseVM(ctx))
+	assert.Equal(vmReady, fc.state.state)
+	assert.Equal([]string{"Paused"}, fcTestVMStates(requests()))
+}
+
+func TestFCPublishMetadata(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	sock, requests := newFCTestServer(t, http.StatusNoContent)
+	fc := &firecracker{socketPath: sock}
+
+	metadata := map[string]string{"name": "pod"}
+
+	// Nothing is published unless MMDS is enabled.
+	assert.NoError(fc.publishMetadata(ctx, metadata))
+	assert.Empty(requests())
+
+	fc.config.EnableMmds = true
+	assert.NoError(fc.publishMetadata(ctx, metadata))
+
+	reqs := requests()
+	assert.Len(reqs, 1)
+	assert.Equal(http.MethodPut, reqs[0].method)
+	assert.Equal("/mmds", reqs[0].path)
+	assert.Equal(map[string]interface{}{"name": "pod"}, reqs[0].body)
+}
+
+func TestFCSetMmdsConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	ifaceID := "eth0"
+	fc := &firecracker{fcConfig: &types.FcConfig{
+		NetworkInterfaces: []*models.NetworkInterface{{IfaceID: &ifaceID}},
+	}}
+	fc.config.MmdsIPv4Address = "169.254.170.2"
+	fc.fcSetMmdsConfig(context.Background())
+	assert.Nil(fc.fcConfig.MmdsConfig)
+
+	fc.config.EnableMmds = true
+	fc.fcSetMmdsConfig(context.Background())
+	assert.NotNil(fc.fcConfig.MmdsConfig)
+	assert.Equal(models.MmdsConfigVersionV2, *fc.fcConfig.MmdsConfig.Version)
+	assert.Equal([]string{"eth0"}, fc.fcConfig.MmdsConfig.NetworkInterfaces)
+	assert.Equal("169.254.170.2", *fc.fcConfig.MmdsConfig.IPV4Address)
+}
+
+func TestFCCheckVersionMmds(t *testing.T) {
+	assert := assert.New(t)
+
+	fc := &firecracker{}
+	assert.NoError(fc.checkVersion("0.23.4"))
+
+	// MMDS version 2 is only provided by firecracker 1.0 and later.
+	fc.config.EnableMmds = true
+	assert.Error(fc.checkVersion("0.23.4"))
+	assert.NoError(fc.checkVersion("1.0.0"))
+}
+
+func TestFCResizeVM(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	fc := &firecracker{}
+	fc.config.NumVCPUs = 2
+	fc.config.MemorySize = 2048
+
+	// The VM already has the requested resources.
+	cur, next, err := fc.ResizeVCPUs(ctx, 2)
+	assert.NoError(err)
+	assert.Equal(uint32(2), cur)
+	assert.Equal(uint32(2), next)
+
+	mem, _, err := fc.ResizeMemory(ctx, 1024, 128, false)
+	assert.NoError(err)
+	assert.Equal(uint32(2048), mem)
+
+	// A running VM can't grow, and keeps its size.
+	_, next, err = fc.ResizeVCPUs(ctx, 4)
+	assert.True(errors.Is(err, fcResizeErr))
+	assert.Equal(uint32(2), next)
+
+	mem, _, err = fc.ResizeMemory(ctx, 4096, 128, false)
+	assert.True(errors.Is(err, fcResizeErr))
+	assert.Equal(uint32(2048), mem)
+}
+
+func TestFCUpdateNetRateLimiters(t *testing.T) {
+	assert := assert.New(t)
+	ctx := context.Background()
+
+	sock, requests := newFCTestServer(t, http.StatusNoContent)
+	fc := &firecracker{socketPath: sock}
+
+	endpoint := &VethEndpoint{}
+	endpoint.NetPair.VirtIface.Name = "eth0"
+	endpoints := []Endpoint{endpoint}
+
+	// Only a running VM can be updated.
+	assert.Error(fc.updateNetRateLimiters(ctx, endpoints, 8000, 0))
+	assert.Empty(requests())
+
+	fc.state.set(vmReady)
+	assert.NoError(fc.updateNetRateLimiters(ctx, endpoints, 8000, 0))
+	assert.Equal(uint64(8000), fc.config.RxRateLimiterMaxRate)
+	assert.Equal(uint64(0), fc.config.TxRateLimiterMaxRate)
+
+	reqs := requests()
+	assert.Len(reqs, 1)
+	assert.Equal(http.MethodPatch, reqs[0].method)
+	assert.Equal("/network-interfaces/eth0", reqs[0].path)
+	assert.Equal("eth0", reqs[0].body["iface_id"])
+	assert.Contains(reqs[0].body, "rx_rate_limiter")
+	assert.Contains(reqs[0].body, "tx_rate_limiter")
+}
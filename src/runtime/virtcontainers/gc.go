@@ -0,0 +1,249 @@
+//go:build linux
+// +build linux
+
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"syscall"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/gc"
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/katautils/katatrace"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist"
+	vcTypes "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
+	"github.com/moby/sys/mountinfo"
+	"github.com/vishvananda/netlink"
+)
+
+// kataLinkSuffix is the suffix of the names of the links created by the
+// runtime in the network namespace of a sandbox.
+const kataLinkSuffix = "_kata"
+
+// ListHostSandboxes returns the sandboxes found in the persist storage and in
+// the host shared directory, whether they are running or not.
+func ListHostSandboxes() ([]gc.Sandbox, error) {
+	store, err := persist.GetDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	sandboxes := make(map[string]*gc.Sandbox)
+	for _, dir := range []string{store.RunStoragePath(), kataHostSharedDir()} {
+		entries, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+
+		for _, entry := range entries {
+			if !entry.IsDir() {
+				continue
+			}
+
+			info, err := entry.Info()
+			if err != nil {
+				// Removed in the meantime
+				continue
+			}
+
+			sandbox, ok := sandboxes[entry.Name()]
+			if !ok {
+				sandbox = &gc.Sandbox{
+					ID:    entry.Name(),
+					VMDir: filepath.Join(store.RunVMStoragePath(), entry.Name()),
+				}
+				sandboxes[entry.Name()] = sandbox
+			}
+			if info.ModTime().After(sandbox.ModTime) {
+				sandbox.ModTime = info.ModTime()
+			}
+		}
+	}
+
+	var list []gc.Sandbox
+	for _, sandbox := range sandboxes {
+		// The drivers may cache the last restored state.
+		store, err := persist.GetSandboxDriver(sandbox.ID)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, _, err := store.FromDisk(sandbox.ID); err == nil {
+			sandbox.Restorable = true
+		}
+
+		list = append(list, *sandbox)
+	}
+
+	sort.Slice(list, func(i, j int) bool {
+		return list[i].ID < list[j].ID
+	})
+
+	return list, nil
+}
+
+// CleanupSandbox stops and deletes a sandbox left behind by a dead shim, with
+// all its containers. The leftovers which cannot be released through the
+// sandbox, e.g. when its state cannot be restored, are removed from the host:
+// the links created in the network namespace netNSPath, the shared directory
+// mounts, the VM directory and the persisted state.
+// The processes of the sandbox are expected to be already killed.
+func CleanupSandbox(ctx context.Context, sandboxID, netNSPath string) error {
+	span, ctx := katatrace.Trace(ctx, virtLog, "CleanupSandbox", apiTracingTags)
+	defer span.End()
+
+	if sandboxID == "" {
+		return vcTypes.ErrNeedSandboxID
+	}
+
+	logger := virtLog.WithField("sandbox", sandboxID)
+
+	// There is nothing to lock without a persisted state.
+	unlock, err := rwLockSandbox(sandboxID)
+	if err == nil {
+		defer unlock()
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	if s, err := fetchSandbox(ctx, sandboxID); err == nil {
+		deleteFetchedSandbox(ctx, s)
+		s.Release(ctx)
+	} else {
+		logger.WithError(err).Warn("failed to restore the sandbox, removing its leftovers")
+
+		if netNSPath != "" {
+			if err := removeNetworkLeftovers(netNSPath); err != nil {
+				return err
+			}
+		}
+	}
+
+	return removeSandboxLeftovers(sandboxID)
+}
+
+// deleteFetchedSandbox releases the resources of a sandbox, ignoring errors
+// as the VM and the agent may be gone.
+func deleteFetchedSandbox(ctx context.Context, s *Sandbox) {
+	for _, c := range s.GetAllContainers() {
+		if _, err := s.StopContainer(ctx, c.ID(), true); err != nil {
+			s.Logger().WithError(err).WithField("container", c.ID()).Warn("failed to stop container")
+		}
+		if _, err := s.DeleteContainer(ctx, c.ID()); err != nil {
+			s.Logger().WithError(err).WithField("container", c.ID()).Warn("failed to delete container")
+		}
+	}
+
+	if err := s.Stop(ctx, true); err != nil {
+		s.Logger().WithError(err).Warn("failed to stop sandbox")
+	}
+
+	if err := s.Delete(ctx); err != nil {
+		s.Logger().WithError(err).Warn("failed to delete sandbox")
+	}
+}
+
+func removeSandboxLeftovers(sandboxID string) error {
+	logger := virtLog.WithField("sandbox", sandboxID)
+
+	sandboxPath := getSandboxPath(sandboxID)
+	mounts, err := mountinfo.GetMounts(mountinfo.PrefixFilter(sandboxPath))
+	if err != nil {
+		return err
+	}
+
+	// Unmount the nested mounts first.
+	sort.Slice(mounts, func(i, j int) bool {
+		return len(mounts[i].Mountpoint) > len(mounts[j].Mountpoint)
+	})
+	for _, m := range mounts {
+		if err := syscall.Unmount(m.Mountpoint, syscall.MNT_DETACH); err != nil {
+			logger.WithField("mountpoint", m.Mountpoint).WithError(err).Warn("failed to unmount")
+		}
+	}
+
+	if err := os.RemoveAll(sandboxPath); err != nil {
+		return err
+	}
+
+	store, err := persist.GetSandboxDriver(sandboxID)
+	if err != nil {
+		return err
+	}
+
+	if err := os.RemoveAll(filepath.Join(store.RunVMStoragePath(), sandboxID)); err != nil {
+		return err
+	}
+
+	return store.Destroy(sandboxID)
+}
+
+// removeNetworkLeftovers removes the links created by the runtime in the
+// network namespace netNSPath, and the traffic control rules redirecting the
+// other links to them. The namespace is deleted when only the loopback is
+// left, as it was then created by the runtime rather than by the CNI plugins.
+func removeNetworkLeftovers(netNSPath string) error {
+	logger := virtLog.WithField("netns", netNSPath)
+
+	remaining := 0
+	err := doNetNS(netNSPath, func(_ ns.NetNS) error {
+		netHandle, err := netlink.NewHandle()
+		if err != nil {
+			return err
+		}
+		defer netHandle.Close()
+
+		links, err := netHandle.LinkList()
+		if err != nil {
+			return err
+		}
+
+		for _, link := range links {
+			name := link.Attrs().Name
+			if strings.HasSuffix(name, kataLinkSuffix) {
+				if err := netHandle.LinkDel(link); err != nil {
+					return fmt.Errorf("Could not remove link %s: %s", name, err)
+				}
+				logger.WithField("link", name).Info("removed link")
+				continue
+			}
+
+			if link.Attrs().Flags&net.FlagLoopback != 0 {
+				continue
+			}
+			remaining++
+
+			if err := removeRedirectTCFilter(link); err != nil {
+				return err
+			}
+			if err := removeQdiscIngress(link); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if remaining > 0 {
+		return nil
+	}
+
+	logger.Info("deleting network namespace")
+	return deleteNetNS(netNSPath)
+}
@@ -62,6 +62,8 @@ const (
 
 	defaultBridges = 1
 
+	defaultBalloonReclaimLimit = 50
+
 	defaultBlockDriver = config.VirtioSCSI
 
 	// port numbers below 1024 are called privileged ports. Only a process with
@@ -304,6 +306,15 @@ type HypervisorConfig struct {
 	// BootFromTemplate is true.
 	DevicesStatePath string
 
+	// MigrationIncomingURI is the URI the VM waits at for the live
+	// migration of a running VM, rather than booting.
+	MigrationIncomingURI string
+
+	// MigrationIncomingStatePath is the directory the state of the
+	// migrated sandbox is loaded from, as saved by the source of the
+	// migration.
+	MigrationIncomingStatePath string
+
 	// EntropySource is the path to a host source of
 	// entropy (/dev/random, /dev/urandom or real hardware RNG device)
 	EntropySource string
@@ -348,6 +359,15 @@ type HypervisorConfig struct {
 	// SELinux label for the VM
 	SELinuxProcessLabel string
 
+	// MmdsIPv4Address is the link-local address the guest reaches the
+	// metadata service at. The hypervisor default is used when empty.
+	MmdsIPv4Address string
+
+	// MmdsSecretsPath is a host directory whose files are published as
+	// secrets through the metadata service, and republished on change.
+	// It is only set from the sandbox annotations.
+	MmdsSecretsPath string
+
 	// HypervisorPathList is the list of hypervisor paths names allowed in annotations
 	HypervisorPathList []string
 
@@ -369,6 +389,14 @@ type HypervisorConfig struct {
 	// Enable annotations by name
 	EnableAnnotations []string
 
+	// MmdsAnnotations is the list of pod annotations published through
+	// the metadata service
+	MmdsAnnotations []string
+
+	// MmdsSecretsPathList is the list of valid metadata secrets
+	// directories for annotations
+	MmdsSecretsPathList []string
+
 	// FileBackedMemRootList is the list of valid root directories values for annotations
 	FileBackedMemRootList []string
 
@@ -429,6 +457,25 @@ type HypervisorConfig struct {
 	// Group ID.
 	Gid uint32
 
+	// JailUID is the user ID of the jailed hypervisor process.
+	JailUID uint32
+
+	// JailGID is the group ID of the jailed hypervisor process.
+	JailGID uint32
+
+	// BalloonReclaimInterval is the period, in seconds, at which the
+	// memory of an idle guest is reclaimed by inflating the balloon.
+	// 0 disables the reclaim.
+	BalloonReclaimInterval uint32
+
+	// BalloonReclaimMinFree is the memory in MiB left available to an
+	// idle guest when its balloon is inflated.
+	BalloonReclaimMinFree uint32
+
+	// BalloonReclaimLimit is the maximum share of the VM memory,
+	// in percent, that the balloon may hold.
+	BalloonReclaimLimit uint32
+
 	// BlockDeviceCacheSet specifies cache-related options will be set to block devices or not.
 	BlockDeviceCacheSet bool
 
@@ -460,6 +507,13 @@ type HypervisorConfig struct {
 	// VirtioMem is used to enable/disable virtio-mem
 	VirtioMem bool
 
+	// EnableBalloon adds a memory balloon device to the VM.
+	EnableBalloon bool
+
+	// FreePageReporting lets the guest report its free pages
+	// through the balloon device, for the host to reclaim them.
+	FreePageReporting bool
+
 	// IOMMU specifies if the VM should have a vIOMMU
 	IOMMU bool
 
@@ -510,6 +564,14 @@ type HypervisorConfig struct {
 	// Disable selinux from the hypervisor process
 	DisableSeLinux bool
 
+	// EnableMmds publishes the pod metadata to the guest through the
+	// hypervisor metadata service
+	EnableMmds bool
+
+	// EnableJail runs the hypervisor process in a per-sandbox jail, as
+	// the JailUID user and JailGID group.
+	EnableJail bool
+
 	// Unikernel used to indicate that the bundle contains unikernel
 	Unikernel bool
 }
@@ -558,6 +620,24 @@ func (conf *HypervisorConfig) Valid() error {
 		return err
 	}
 
+	if conf.MigrationIncomingURI != "" {
+		if conf.BootToBeTemplate || conf.BootFromTemplate {
+			return fmt.Errorf("Cannot migrate a VM template")
+		}
+
+		if err := validMigrationURI(conf.MigrationIncomingURI); err != nil {
+			return err
+		}
+
+		if conf.MigrationIncomingStatePath == "" {
+			return fmt.Errorf("Missing the state path of the migrated sandbox")
+		}
+	}
+
+	if err := conf.checkBalloonConfig(); err != nil {
+		return err
+	}
+
 	if conf.NumVCPUs == 0 {
 		conf.NumVCPUs = defaultVCPUs
 	}
@@ -592,6 +672,28 @@ func (conf *HypervisorConfig) Valid() error {
 	return nil
 }
 
+func (conf *HypervisorConfig) checkBalloonConfig() error {
+	if conf.EnableBalloon {
+		if conf.BalloonReclaimLimit == 0 {
+			conf.BalloonReclaimLimit = defaultBalloonReclaimLimit
+		} else if conf.BalloonReclaimLimit >= 100 {
+			return fmt.Errorf("Invalid balloon reclaim limit %d%%, must be below 100%%", conf.BalloonReclaimLimit)
+		}
+
+		return nil
+	}
+
+	if conf.FreePageReporting {
+		return fmt.Errorf("Free page reporting requires the memory balloon to be enabled")
+	}
+
+	if conf.BalloonReclaimInterval != 0 {
+		return fmt.Errorf("Memory reclaim requires the memory balloon to be enabled")
+	}
+
+	return nil
+}
+
 // AddKernelParam allows the addition of new kernel parameters to an existing
 // hypervisor configuration.
 func (conf *HypervisorConfig) AddKernelParam(p Param) error {
@@ -994,3 +1096,66 @@ type Hypervisor interface {
 	// check if hypervisor supports built-in rate limiter.
 	IsRateLimiterBuiltin() bool
 }
+
+// metadataPublisher is implemented by the hypervisors providing a metadata
+// service to the guest, such as the Firecracker MMDS.
+type metadataPublisher interface {
+	// publishMetadata replaces the metadata served to the guest.
+	publishMetadata(ctx context.Context, metadata interface{}) error
+}
+
+// netRateLimiterUpdater is implemented by the hypervisors with a built-in
+// rate limiter able to update it for a running VM.
+type netRateLimiterUpdater interface {
+	// updateNetRateLimiters sets the rates of the endpoints interfaces.
+	updateNetRateLimiters(ctx context.Context, endpoints []Endpoint, rxRate, txRate uint64) error
+}
+
+// vmMetricsUpdater is implemented by the hypervisors exposing metrics of
+// the VM in addition to those of the hypervisor process.
+type vmMetricsUpdater interface {
+	// updateVMMetrics updates the metrics of the VM to their latest
+	// values.
+	updateVMMetrics() error
+}
+
+// vmBalloon is implemented by the hypervisors able to resize the memory
+// balloon of a VM started with EnableBalloon.
+type vmBalloon interface {
+	// resizeBalloon sets the memory held by the balloon to sizeMB MiB.
+	resizeBalloon(ctx context.Context, sizeMB uint32) error
+}
+
+// blockIOThrottler is implemented by the hypervisors limiting the I/O of the
+// hotplugged block drives.
+type blockIOThrottler interface {
+	// updateBlockIOLimits sets the I/O limits of a running drive.
+	updateBlockIOLimits(ctx context.Context, drive *config.BlockDrive, limits config.BlockIOLimits) error
+}
+
+// vmMigrator is implemented by the hypervisors able to live migrate a
+// running VM to a VM started with MigrationIncomingURI.
+type vmMigrator interface {
+	// migrateVM migrates the VM to the destination listening at uri,
+	// and returns once the VM runs on the destination.
+	migrateVM(ctx context.Context, uri string) error
+}
+
+// validMigrationURI checks the URI of a live migration channel.
+func validMigrationURI(uri string) error {
+	for _, prefix := range []string{"unix:", "tcp:"} {
+		if strings.HasPrefix(uri, prefix) && len(uri) > len(prefix) {
+			return nil
+		}
+	}
+
+	return fmt.Errorf("Invalid migration URI %q, only unix: and tcp: URIs are supported", uri)
+}
+
+// vmSnapshotter is implemented by the hypervisors able to snapshot a VM to
+// a directory, from which it is restored by booting it from template with
+// DevicesStatePath set to the directory.
+type vmSnapshotter interface {
+	// snapshotVM snapshots the paused VM to dir.
+	snapshotVM(ctx context.Context, dir string) error
+}
@@ -180,6 +180,52 @@ func TestHypervisorConfigValidTemplateConfig(t *testing.T) {
 	testHypervisorConfigValid(t, hypervisorConfig, false)
 }
 
+func TestHypervisorConfigValidMigrationConfig(t *testing.T) {
+	hypervisorConfig := &HypervisorConfig{
+		KernelPath:           fmt.Sprintf("%s/%s", testDir, testKernel),
+		ImagePath:            fmt.Sprintf("%s/%s", testDir, testImage),
+		HypervisorPath:       fmt.Sprintf("%s/%s", testDir, testHypervisor),
+		MigrationIncomingURI: "tcp:0:4444",
+	}
+	testHypervisorConfigValid(t, hypervisorConfig, false)
+
+	hypervisorConfig.MigrationIncomingStatePath = "/run/migration"
+	testHypervisorConfigValid(t, hypervisorConfig, true)
+
+	hypervisorConfig.MigrationIncomingURI = "unix:/run/vc/migration.sock"
+	testHypervisorConfigValid(t, hypervisorConfig, true)
+
+	hypervisorConfig.MigrationIncomingURI = "exec:cat /tmp/state"
+	testHypervisorConfigValid(t, hypervisorConfig, false)
+
+	hypervisorConfig.MigrationIncomingURI = "tcp:"
+	testHypervisorConfigValid(t, hypervisorConfig, false)
+
+	hypervisorConfig.MigrationIncomingURI = "tcp:0:4444"
+	hypervisorConfig.BootToBeTemplate = true
+	hypervisorConfig.MemoryPath = "foobar"
+	testHypervisorConfigValid(t, hypervisorConfig, false)
+}
+
+func TestHypervisorConfigValidBalloonConfig(t *testing.T) {
+	assert := assert.New(t)
+	hypervisorConfig := &HypervisorConfig{
+		KernelPath:             fmt.Sprintf("%s/%s", testDir, testKernel),
+		ImagePath:              fmt.Sprintf("%s/%s", testDir, testImage),
+		HypervisorPath:         fmt.Sprintf("%s/%s", testDir, testHypervisor),
+		FreePageReporting:      true,
+		BalloonReclaimInterval: 30,
+	}
+	testHypervisorConfigValid(t, hypervisorConfig, false)
+
+	hypervisorConfig.EnableBalloon = true
+	testHypervisorConfigValid(t, hypervisorConfig, true)
+	assert.Equal(uint32(defaultBalloonReclaimLimit), hypervisorConfig.BalloonReclaimLimit)
+
+	hypervisorConfig.BalloonReclaimLimit = 100
+	testHypervisorConfigValid(t, hypervisorConfig, false)
+}
+
 func TestHypervisorConfigDefaults(t *testing.T) {
 	assert := assert.New(t)
 	hypervisorConfig := &HypervisorConfig{
@@ -35,7 +35,7 @@ type VCSandbox interface {
 	GetAllContainers() []VCContainer
 	GetAnnotations() map[string]string
 	GetContainer(containerID string) VCContainer
-	Agent() agent
+	GetExecData() ExecData
 	ID() string
 	SetAnnotations(annotations map[string]string) error
 
@@ -43,6 +43,9 @@ type VCSandbox interface {
 
 	Start(ctx context.Context) error
 	Stop(ctx context.Context, force bool) error
+	Pause(ctx context.Context) error
+	Resume(ctx context.Context) error
+	Checkpoint(ctx context.Context, dir string) error
 	Release(ctx context.Context) error
 	Monitor(ctx context.Context) (chan error, error)
 	Delete(ctx context.Context) error
@@ -58,6 +61,8 @@ type VCSandbox interface {
 	ResumeContainer(ctx context.Context, containerID string) error
 	EnterContainer(ctx context.Context, containerID string, cmd types.Cmd) (VCContainer, *Process, error)
 	UpdateContainer(ctx context.Context, containerID string, resources specs.LinuxResources) error
+	UpdateNetworkRateLimiter(ctx context.Context, rxRate, txRate uint64) error
+	Migrate(ctx context.Context, uri, dir string) error
 	WaitProcess(ctx context.Context, containerID, processID string) (int32, error)
 	SignalProcess(ctx context.Context, containerID, processID string, signal syscall.Signal, all bool) error
 	WinsizeProcess(ctx context.Context, containerID, processID string, height, width uint32) error
@@ -80,6 +85,8 @@ type VCSandbox interface {
 
 	GuestVolumeStats(ctx context.Context, volumePath string) ([]byte, error)
 	ResizeGuestVolume(ctx context.Context, volumePath string, size uint64) error
+	AttachDirectVolume(ctx context.Context, volumePath string) error
+	DetachDirectVolume(ctx context.Context, volumePath string) error
 }
 
 // VCContainer is the Container interface
@@ -739,6 +739,12 @@ func (k *kataAgent) startSandbox(ctx context.Context, sandbox *Sandbox) error {
 		return err
 	}
 
+	// The sandbox already runs in a migrated or restored VM, only its
+	// network needed to be plumbed again.
+	if sandbox.restoresVM() {
+		return nil
+	}
+
 	storages := setupStorages(ctx, sandbox)
 
 	kmodules := setupKernelModules(k.kmodules)
@@ -1268,6 +1274,11 @@ func (k *kataAgent) createContainer(ctx context.Context, sandbox *Sandbox, c *Co
 		SandboxPidns: sharedPidNs,
 	}
 
+	if sandbox.isRestoredContainer(c.id) {
+		k.Logger().WithField("container", c.id).Info("Container already created in the restored VM")
+		return buildProcessFromExecID(req.ExecId)
+	}
+
 	if _, err = k.sendReq(ctx, req); err != nil {
 		return nil, err
 	}
@@ -1621,6 +1632,12 @@ func (k *kataAgent) startContainer(ctx context.Context, sandbox *Sandbox, c *Con
 	span, ctx := katatrace.Trace(ctx, k.Logger(), "startContainer", kataAgentTracingTags)
 	defer span.End()
 
+	if sandbox.isRestoredContainer(c.id) {
+		k.Logger().WithField("container", c.id).Info("Container already started in the restored VM")
+		delete(sandbox.restoredContainers, c.id)
+		return nil
+	}
+
 	req := &grpc.StartContainerRequest{
 		ContainerId: c.id,
 	}
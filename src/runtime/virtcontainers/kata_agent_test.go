@@ -144,6 +144,13 @@ func TestKataAgentSendReq(t *testing.T) {
 	err = k.startContainer(ctx, sandbox, container)
 	assert.Nil(err)
 
+	// Restored containers are already started in the guest.
+	sandbox.restoredContainers = map[string]bool{"restored": true}
+	restoredAgent := &kataAgent{ctx: context.Background()}
+	err = restoredAgent.startContainer(ctx, sandbox, &Container{id: "restored"})
+	assert.Nil(err)
+	assert.Empty(sandbox.restoredContainers)
+
 	err = k.signalProcess(ctx, container, execid, syscall.SIGKILL, true)
 	assert.Nil(err)
 
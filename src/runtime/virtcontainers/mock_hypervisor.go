@@ -70,6 +70,14 @@ func (m *mockHypervisor) SaveVM() error {
 	return nil
 }
 
+func (m *mockHypervisor) snapshotVM(ctx context.Context, dir string) error {
+	return os.MkdirAll(dir, DirMode)
+}
+
+func (m *mockHypervisor) migrateVM(ctx context.Context, uri string) error {
+	return nil
+}
+
 func (m *mockHypervisor) AddDevice(ctx context.Context, devInfo interface{}, devType DeviceType) error {
 	return nil
 }
@@ -10,6 +10,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
 	"github.com/pkg/errors"
 )
 
@@ -31,6 +32,11 @@ type monitor struct {
 	stopCh        chan bool
 	checkInterval time.Duration
 
+	// vmPausedLock is held by the agent check, so that the VM is not
+	// paused while it runs.
+	vmPausedLock sync.RWMutex
+	vmPaused     bool
+
 	running bool
 }
 
@@ -42,6 +48,7 @@ func newMonitor(s *Sandbox) *monitor {
 		sandbox:       s,
 		checkInterval: defaultCheckInterval,
 		stopCh:        make(chan bool, 1),
+		vmPaused:      s.state.State == types.StatePaused,
 	}
 }
 
@@ -139,7 +146,24 @@ func (m *monitor) stop() {
 	}
 }
 
+// setVMPaused stops the agent checks while the VM is paused, waiting for a
+// running one to complete.
+func (m *monitor) setVMPaused(paused bool) {
+	m.vmPausedLock.Lock()
+	defer m.vmPausedLock.Unlock()
+
+	m.vmPaused = paused
+}
+
 func (m *monitor) watchAgent(ctx context.Context) {
+	m.vmPausedLock.RLock()
+	defer m.vmPausedLock.RUnlock()
+
+	// The agent can't answer while the VM is paused.
+	if m.vmPaused {
+		return
+	}
+
 	err := m.sandbox.agent.check(ctx)
 	if err != nil {
 		// TODO: define and export error types
@@ -98,6 +98,8 @@ func LoadNetwork(netInfo persistapi.NetworkInfo) Network {
 			ep = &TapEndpoint{}
 		case IPVlanEndpointType:
 			ep = &IPVlanEndpoint{}
+		case VdpaEndpointType:
+			ep = &VdpaEndpoint{}
 		default:
 			networkLogger().WithField("endpoint-type", e.Type).Error("unknown endpoint type")
 			continue
@@ -127,7 +129,25 @@ func (n *LinuxNetwork) addSingleEndpoint(ctx context.Context, s *Sandbox, netInf
 		return nil, err
 	}
 
-	if isPhysical {
+	// The interface may be backed by a vhost-vdpa device, as reported by
+	// the CNI plugins or found from the physical interface, in which case
+	// it is handed to the VM through that device.
+	bdf, vhostDev, err := findCNIVdpaDevice(s, netInfo.Iface.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	if vhostDev == "" && isPhysical {
+		bdf, vhostDev, err = findVdpaDevice(netInfo.Iface.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if vhostDev != "" {
+		networkLogger().WithField("interface", netInfo.Iface.Name).Info("vhost-vdpa network interface found")
+		endpoint, err = createVdpaEndpoint(netInfo, bdf, vhostDev)
+	} else if isPhysical {
 		networkLogger().WithField("interface", netInfo.Iface.Name).Info("Physical network interface found")
 		endpoint, err = createPhysicalEndpoint(netInfo)
 	} else {
@@ -186,7 +206,7 @@ func (n *LinuxNetwork) addSingleEndpoint(ctx context.Context, s *Sandbox, netInf
 
 	networkLogger().WithField("endpoint-type", endpoint.Type()).WithField("hotplug", hotplug).Info("Attaching endpoint")
 	if hotplug {
-		if err := endpoint.HotAttach(ctx, s.hypervisor); err != nil {
+		if err := endpoint.HotAttach(ctx, s); err != nil {
 			return nil, err
 		}
 	} else {
@@ -195,21 +215,8 @@ func (n *LinuxNetwork) addSingleEndpoint(ctx context.Context, s *Sandbox, netInf
 		}
 	}
 
-	if !s.hypervisor.IsRateLimiterBuiltin() {
-		rxRateLimiterMaxRate := s.hypervisor.HypervisorConfig().RxRateLimiterMaxRate
-		if rxRateLimiterMaxRate > 0 {
-			networkLogger().Info("Add Rx Rate Limiter")
-			if err := addRxRateLimiter(endpoint, rxRateLimiterMaxRate); err != nil {
-				return nil, err
-			}
-		}
-		txRateLimiterMaxRate := s.hypervisor.HypervisorConfig().TxRateLimiterMaxRate
-		if txRateLimiterMaxRate > 0 {
-			networkLogger().Info("Add Tx Rate Limiter")
-			if err := addTxRateLimiter(endpoint, txRateLimiterMaxRate); err != nil {
-				return nil, err
-			}
-		}
+	if err := addRateLimiters(endpoint, s.hypervisor); err != nil {
+		return nil, err
 	}
 
 	n.eps = append(n.eps, endpoint)
@@ -244,7 +251,7 @@ func (n *LinuxNetwork) removeSingleEndpoint(ctx context.Context, s *Sandbox, idx
 	// if required.
 	networkLogger().WithField("endpoint-type", endpoint.Type()).Info("Detaching endpoint")
 	if hotplug && s != nil {
-		if err := endpoint.HotDetach(ctx, s.hypervisor, n.netNSCreated, n.netNSPath); err != nil {
+		if err := endpoint.HotDetach(ctx, s, n.netNSCreated, n.netNSPath); err != nil {
 			return err
 		}
 	} else {
@@ -1090,6 +1097,32 @@ func networkInfoFromLink(handle *netlink.Handle, link netlink.Link) (NetworkInfo
 	}, nil
 }
 
+// addRateLimiters applies the configured rate limiters of the hypervisor
+// to the host side of the endpoint, for hypervisors which don't implement
+// rate limiter in itself.
+func addRateLimiters(endpoint Endpoint, h Hypervisor) error {
+	if h.IsRateLimiterBuiltin() {
+		return nil
+	}
+
+	rxRateLimiterMaxRate := h.HypervisorConfig().RxRateLimiterMaxRate
+	if rxRateLimiterMaxRate > 0 {
+		networkLogger().Info("Add Rx Rate Limiter")
+		if err := addRxRateLimiter(endpoint, rxRateLimiterMaxRate); err != nil {
+			return err
+		}
+	}
+	txRateLimiterMaxRate := h.HypervisorConfig().TxRateLimiterMaxRate
+	if txRateLimiterMaxRate > 0 {
+		networkLogger().Info("Add Tx Rate Limiter")
+		if err := addTxRateLimiter(endpoint, txRateLimiterMaxRate); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
 // func addRxRateLmiter implements tc-based rx rate limiter to control network I/O inbound traffic
 // on VM level for hypervisors which don't implement rate limiter in itself, like qemu, etc.
 func addRxRateLimiter(endpoint Endpoint, maxRate uint64) error {
@@ -1262,6 +1295,9 @@ func addTxRateLimiter(endpoint Endpoint, maxRate uint64) error {
 			if err != nil {
 				return err
 			}
+			if err := endpoint.SetTxRateLimiter(); err != nil {
+				return err
+			}
 			return addHTBQdisc(link.Attrs().Index, maxRate)
 		case NetXConnectMacVtapModel, NetXConnectNoneModel:
 			linkName = netPair.TapInterface.TAPIface.Name
@@ -6,7 +6,11 @@
 package virtcontainers
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
 
 	hv "github.com/kata-containers/kata-containers/src/runtime/pkg/hypervisors"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/device/api"
@@ -20,6 +24,16 @@ var (
 	errContainerPersistNotExist = errors.New("container doesn't exist in persist data")
 )
 
+// checkpointStateFile is the file of a checkpoint holding the persisted
+// state of the sandbox, next to the snapshot of its VM.
+const checkpointStateFile = "sandbox.json"
+
+// sandboxCheckpoint is the persisted state of a checkpointed sandbox.
+type sandboxCheckpoint struct {
+	Sandbox    persistapi.SandboxState
+	Containers map[string]persistapi.ContainerState
+}
+
 func (s *Sandbox) dumpVersion(ss *persistapi.SandboxState) {
 	// New created sandbox has a uninitialized `PersistVersion` which should be set to current version when do the first saving;
 	// Old restored sandbox should keep its original version and shouldn't be modified any more after it's initialized.
@@ -36,6 +50,7 @@ func (s *Sandbox) dumpState(ss *persistapi.SandboxState, cs map[string]persistap
 	ss.State = string(s.state.State)
 	ss.SandboxCgroupPath = s.state.SandboxCgroupPath
 	ss.OverheadCgroupPath = s.state.OverheadCgroupPath
+	ss.VMPausedContainers = s.state.VMPausedContainers
 
 	for id, cont := range s.containers {
 		state := persistapi.ContainerState{}
@@ -205,6 +220,11 @@ func (s *Sandbox) dumpConfig(ss *persistapi.SandboxState) {
 		MemSlots:                sconfig.HypervisorConfig.MemSlots,
 		MemOffset:               sconfig.HypervisorConfig.MemOffset,
 		VirtioMem:               sconfig.HypervisorConfig.VirtioMem,
+		EnableBalloon:           sconfig.HypervisorConfig.EnableBalloon,
+		FreePageReporting:       sconfig.HypervisorConfig.FreePageReporting,
+		BalloonReclaimInterval:  sconfig.HypervisorConfig.BalloonReclaimInterval,
+		BalloonReclaimMinFree:   sconfig.HypervisorConfig.BalloonReclaimMinFree,
+		BalloonReclaimLimit:     sconfig.HypervisorConfig.BalloonReclaimLimit,
 		VirtioFSCacheSize:       sconfig.HypervisorConfig.VirtioFSCacheSize,
 		KernelPath:              sconfig.HypervisorConfig.KernelPath,
 		ImagePath:               sconfig.HypervisorConfig.ImagePath,
@@ -271,7 +291,7 @@ func (s *Sandbox) dumpConfig(ss *persistapi.SandboxState) {
 	}
 }
 
-func (s *Sandbox) Save() error {
+func (s *Sandbox) dump() (persistapi.SandboxState, map[string]persistapi.ContainerState) {
 	var (
 		ss = persistapi.SandboxState{}
 		cs = make(map[string]persistapi.ContainerState)
@@ -287,6 +307,12 @@ func (s *Sandbox) Save() error {
 	s.dumpNetwork(&ss)
 	s.dumpConfig(&ss)
 
+	return ss, cs
+}
+
+func (s *Sandbox) Save() error {
+	ss, cs := s.dump()
+
 	if err := s.store.ToDisk(ss, cs); err != nil {
 		return err
 	}
@@ -294,6 +320,65 @@ func (s *Sandbox) Save() error {
 	return nil
 }
 
+// saveCheckpoint saves the state of the sandbox to the checkpoint in dir.
+func (s *Sandbox) saveCheckpoint(dir string) error {
+	ss, cs := s.dump()
+
+	data, err := json.Marshal(sandboxCheckpoint{
+		Sandbox:    ss,
+		Containers: cs,
+	})
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(filepath.Join(dir, checkpointStateFile), data, 0640)
+}
+
+// loadCheckpoint loads the state of the sandbox from the checkpoint in dir.
+// Only the state of the guest is restored: the host resources of the
+// sandbox are created anew, and the containers of the checkpoint, already
+// running in the restored VM, are not created again in the guest.
+func (s *Sandbox) loadCheckpoint(dir string) error {
+	data, err := os.ReadFile(filepath.Join(dir, checkpointStateFile))
+	if err != nil {
+		return err
+	}
+
+	var cp sandboxCheckpoint
+	if err := json.Unmarshal(data, &cp); err != nil {
+		return err
+	}
+
+	// The agent of the restored VM knows the sandbox and its containers
+	// by the IDs they were checkpointed with.
+	if cp.Sandbox.SandboxContainer != s.id {
+		return fmt.Errorf("checkpoint of sandbox %s can't be restored as sandbox %s", cp.Sandbox.SandboxContainer, s.id)
+	}
+
+	for _, c := range s.config.Containers {
+		if _, ok := cp.Containers[c.ID]; !ok {
+			return fmt.Errorf("container %s is not in the checkpoint of sandbox %s", c.ID, s.id)
+		}
+	}
+
+	// The containers created in the sandbox later on, such as the
+	// application containers of a pod, run in the restored VM as well.
+	s.restoredContainers = map[string]bool{}
+	for id := range cp.Containers {
+		s.restoredContainers[id] = true
+	}
+
+	s.state.GuestMemoryBlockSizeMB = cp.Sandbox.GuestMemoryBlockSizeMB
+	s.state.GuestMemoryHotplugProbe = cp.Sandbox.GuestMemoryHotplugProbe
+	if cp.Sandbox.HypervisorState.BlockIndexMap != nil {
+		s.state.BlockIndexMap = cp.Sandbox.HypervisorState.BlockIndexMap
+	}
+	s.checkpointNetwork = LoadNetwork(cp.Sandbox.Network)
+
+	return nil
+}
+
 func (s *Sandbox) loadState(ss persistapi.SandboxState) {
 	s.state.PersistVersion = ss.PersistVersion
 	s.state.GuestMemoryBlockSizeMB = ss.GuestMemoryBlockSizeMB
@@ -302,6 +387,7 @@ func (s *Sandbox) loadState(ss persistapi.SandboxState) {
 	s.state.SandboxCgroupPath = ss.SandboxCgroupPath
 	s.state.OverheadCgroupPath = ss.OverheadCgroupPath
 	s.state.GuestMemoryHotplugProbe = ss.GuestMemoryHotplugProbe
+	s.state.VMPausedContainers = ss.VMPausedContainers
 }
 
 func (c *Container) loadContState(cs persistapi.ContainerState) {
@@ -401,9 +487,15 @@ func (c *Container) Restore() error {
 }
 
 func loadSandboxConfig(id string) (*SandboxConfig, error) {
-	store, err := persist.GetDriver()
+	// The sandbox keeps the driver it was created with.
+	driverName, err := persist.SandboxDriverName(id)
+	if err != nil {
+		return nil, err
+	}
+
+	store, err := persist.GetConfiguredDriver(driverName)
 	if err != nil || store == nil {
-		return nil, errors.New("failed to get fs persist driver")
+		return nil, fmt.Errorf("failed to get %s persist driver: %v", driverName, err)
 	}
 
 	ss, _, err := store.FromDisk(id)
@@ -414,6 +506,7 @@ func loadSandboxConfig(id string) (*SandboxConfig, error) {
 	savedConf := ss.Config
 	sconfig := &SandboxConfig{
 		ID:             id,
+		PersistDriver:  driverName,
 		HypervisorType: HypervisorType(savedConf.HypervisorType),
 		NetworkConfig: NetworkConfig{
 			NetworkID:         savedConf.NetworkConfig.NetworkID,
@@ -444,6 +537,11 @@ func loadSandboxConfig(id string) (*SandboxConfig, error) {
 		MemSlots:                hconf.MemSlots,
 		MemOffset:               hconf.MemOffset,
 		VirtioMem:               hconf.VirtioMem,
+		EnableBalloon:           hconf.EnableBalloon,
+		FreePageReporting:       hconf.FreePageReporting,
+		BalloonReclaimInterval:  hconf.BalloonReclaimInterval,
+		BalloonReclaimMinFree:   hconf.BalloonReclaimMinFree,
+		BalloonReclaimLimit:     hconf.BalloonReclaimLimit,
 		VirtioFSCacheSize:       hconf.VirtioFSCacheSize,
 		KernelPath:              hconf.KernelPath,
 		ImagePath:               hconf.ImagePath,
@@ -184,6 +184,25 @@ type HypervisorConfig struct {
 	// VirtioMem is used to enable/disable virtio-mem
 	VirtioMem bool
 
+	// EnableBalloon adds a memory balloon device to the VM.
+	EnableBalloon bool
+
+	// FreePageReporting lets the guest report its free pages
+	// through the balloon device, for the host to reclaim them.
+	FreePageReporting bool
+
+	// BalloonReclaimInterval is the period, in seconds, at which the
+	// memory of an idle guest is reclaimed by inflating the balloon.
+	BalloonReclaimInterval uint32
+
+	// BalloonReclaimMinFree is the memory in MiB left available to an
+	// idle guest when its balloon is inflated.
+	BalloonReclaimMinFree uint32
+
+	// BalloonReclaimLimit is the maximum share of the VM memory,
+	// in percent, that the balloon may hold.
+	BalloonReclaimLimit uint32
+
 	// DisableNestingChecks is used to override customizations performed
 	// when running on top of another VMM.
 	DisableNestingChecks bool
@@ -79,6 +79,14 @@ type VhostUserEndpoint struct {
 	PCIPath   vcTypes.PciPath
 }
 
+type VdpaEndpoint struct {
+	IfaceName string
+	HardAddr  string
+	BDF       string
+	VhostDev  string
+	PCIPath   vcTypes.PciPath
+}
+
 // NetworkEndpoint contains network interface information
 type NetworkEndpoint struct {
 	// One and only one of these below are not nil according to Type.
@@ -90,6 +98,7 @@ type NetworkEndpoint struct {
 	Tap       *TapEndpoint       `json:",omitempty"`
 	IPVlan    *IPVlanEndpoint    `json:",omitempty"`
 	Tuntap    *TuntapEndpoint    `json:",omitempty"`
+	Vdpa      *VdpaEndpoint      `json:",omitempty"`
 
 	Type string
 }
@@ -61,4 +61,7 @@ type SandboxState struct {
 
 	// GuestMemoryHotplugProbe determines whether guest kernel supports memory hotplug probe interface
 	GuestMemoryHotplugProbe bool
+
+	// VMPausedContainers are the containers paused along with the VM
+	VMPausedContainers []string
 }
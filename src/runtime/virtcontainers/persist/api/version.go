@@ -16,4 +16,9 @@ const (
 	// requires a bump of CurPersistVersion or not, do it for peace!
 	// --@WeiZhang555
 	CurPersistVersion uint = 2
+
+	// CurPersistFileVersion is the version of the envelope written around
+	// the persisted states by the file system drivers, which carries a
+	// checksum of the state. Files without envelope are read as version 0.
+	CurPersistFileVersion uint = 1
 )
@@ -0,0 +1,323 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package bolt
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	persistapi "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/api"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/fs"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/utils"
+	"github.com/sirupsen/logrus"
+	bolt "go.etcd.io/bbolt"
+)
+
+// dbFile is the name of the database holding the state of a sandbox, in the
+// sandbox directory
+const dbFile = "persist.db"
+
+// dirMode is the permission bits used for creating a directory
+const dirMode = os.FileMode(0700) | os.ModeDir
+
+// fileMode is the permission bits used for creating the database
+const fileMode = os.FileMode(0600)
+
+// openTimeout bounds the time waiting for another process to release the
+// database, which is only held for the duration of a single transaction.
+const openTimeout = 10 * time.Second
+
+var (
+	// sandboxBucket holds the state of the sandbox.
+	sandboxBucket = []byte("sandbox")
+	// containersBucket holds the state of the containers of the sandbox,
+	// keyed by container ID.
+	containersBucket = []byte("containers")
+	// stateKey is the key of the sandbox state in the sandbox bucket.
+	stateKey = []byte("state")
+)
+
+var boltLog = logrus.WithField("source", "virtcontainers/persist/bolt")
+
+// Bolt is a persist driver saving the sandbox and container states in a
+// BoltDB database, so that each ToDisk call is applied atomically. Each
+// sandbox has its own database, in the sandbox directory, so that runtimes
+// handling different sandboxes never wait for each other.
+//
+// The sandbox directories, their locks and the global data are still handled
+// by the wrapped file system driver, as the hypervisors and the agent keep
+// their sockets in these directories.
+type Bolt struct {
+	persistapi.PersistDriver
+}
+
+// sandboxLocker is implemented by the file system drivers, to take the lock
+// of a sandbox without waiting for it.
+type sandboxLocker interface {
+	TryLock(sid string) (func() error, error)
+}
+
+// Logger returns a logrus logger appropriate for logging Store messages
+func (b *Bolt) Logger() *logrus.Entry {
+	return boltLog.WithFields(logrus.Fields{
+		"subsystem": "persist",
+		"driver":    "bolt",
+	})
+}
+
+// New returns a Bolt driver storing the databases in the sandbox directories
+// of the file system driver base.
+func New(base persistapi.PersistDriver) (persistapi.PersistDriver, error) {
+	if base == nil {
+		return nil, fmt.Errorf("Could not create Bolt driver: missing base driver")
+	}
+
+	return &Bolt{PersistDriver: base}, nil
+}
+
+func dbPath(base persistapi.PersistDriver, sid string) string {
+	return filepath.Join(base.RunStoragePath(), sid, dbFile)
+}
+
+// Saved returns whether the state of the sandbox sid is saved by a Bolt
+// driver on top of base.
+func Saved(base persistapi.PersistDriver, sid string) bool {
+	_, err := os.Stat(dbPath(base, sid))
+	return err == nil
+}
+
+// DBPath returns the path of the database of the sandbox sid.
+func (b *Bolt) DBPath(sid string) string {
+	return dbPath(b.PersistDriver, sid)
+}
+
+func (b *Bolt) open(sid string, readOnly bool) (*bolt.DB, error) {
+	db, err := bolt.Open(b.DBPath(sid), fileMode, &bolt.Options{Timeout: openTimeout, ReadOnly: readOnly})
+	if errors.Is(err, bolt.ErrInvalid) || errors.Is(err, bolt.ErrChecksum) || errors.Is(err, bolt.ErrVersionMismatch) {
+		return nil, fmt.Errorf("%s: %w: %v", b.DBPath(sid), fs.ErrCorruptedState, err)
+	}
+
+	return db, err
+}
+
+// ToDisk replaces the saved state of the sandbox in a single transaction.
+func (b *Bolt) ToDisk(ss persistapi.SandboxState, cs map[string]persistapi.ContainerState) error {
+	id := ss.SandboxContainer
+	if id == "" {
+		return fmt.Errorf("sandbox container id required")
+	}
+
+	// The sandbox directory is used for locking and by the hypervisors.
+	if err := utils.MkdirAllWithInheritedOwner(filepath.Join(b.RunStoragePath(), id), dirMode); err != nil {
+		return err
+	}
+
+	sandbox, err := json.Marshal(ss)
+	if err != nil {
+		return err
+	}
+
+	containers := make(map[string][]byte, len(cs))
+	for cid, cstate := range cs {
+		if containers[cid], err = json.Marshal(cstate); err != nil {
+			return err
+		}
+	}
+
+	db, err := b.open(id, false)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	return db.Update(func(tx *bolt.Tx) error {
+		sbucket, err := tx.CreateBucketIfNotExists(sandboxBucket)
+		if err != nil {
+			return err
+		}
+
+		if err := sbucket.Put(stateKey, sandbox); err != nil {
+			return err
+		}
+
+		// Removed containers go away with the previous state.
+		if err := tx.DeleteBucket(containersBucket); err != nil && err != bolt.ErrBucketNotFound {
+			return err
+		}
+
+		cbucket, err := tx.CreateBucket(containersBucket)
+		if err != nil {
+			return err
+		}
+
+		for cid, cstate := range containers {
+			if err := cbucket.Put([]byte(cid), cstate); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// FromDisk restores state for sandbox with name sid
+func (b *Bolt) FromDisk(sid string) (persistapi.SandboxState, map[string]persistapi.ContainerState, error) {
+	ss := persistapi.SandboxState{}
+	if sid == "" {
+		return ss, nil, fmt.Errorf("restore requires sandbox id")
+	}
+
+	if !Saved(b.PersistDriver, sid) {
+		return ss, nil, &os.PathError{Op: "restore", Path: sid, Err: os.ErrNotExist}
+	}
+
+	db, err := b.open(sid, true)
+	if err != nil {
+		return ss, nil, err
+	}
+	defer db.Close()
+
+	cs := make(map[string]persistapi.ContainerState)
+	err = db.View(func(tx *bolt.Tx) error {
+		if err := b.decodeSandbox(sid, tx, &ss); err != nil {
+			return err
+		}
+
+		cbucket := tx.Bucket(containersBucket)
+		if cbucket == nil {
+			return nil
+		}
+
+		return cbucket.ForEach(func(k, v []byte) error {
+			var cstate persistapi.ContainerState
+			if err := b.decode(sid, string(k), v, &cstate); err != nil {
+				return err
+			}
+			cs[string(k)] = cstate
+			return nil
+		})
+	})
+	if err != nil {
+		return persistapi.SandboxState{}, nil, err
+	}
+
+	return ss, cs, nil
+}
+
+// decodeSandbox decodes the saved state of the sandbox sid.
+func (b *Bolt) decodeSandbox(sid string, tx *bolt.Tx, ss *persistapi.SandboxState) error {
+	sbucket := tx.Bucket(sandboxBucket)
+	if sbucket == nil {
+		return fmt.Errorf("%s: %w: missing sandbox state", b.DBPath(sid), fs.ErrCorruptedState)
+	}
+
+	return b.decode(sid, "", sbucket.Get(stateKey), ss)
+}
+
+// decode decodes the saved state of the sandbox sid, or of its container
+// cid, reporting undecodable states as corrupted.
+func (b *Bolt) decode(sid, cid string, data []byte, state interface{}) error {
+	if err := json.Unmarshal(data, state); err != nil {
+		key := sid
+		if cid != "" {
+			key = sid + "/" + cid
+		}
+		return fmt.Errorf("%s: %s: %w: %v", b.DBPath(sid), key, fs.ErrCorruptedState, err)
+	}
+
+	return nil
+}
+
+// RepairSandbox removes the container states of the sandbox sid which cannot
+// be restored, and reports the sandbox if its own state cannot be. The
+// sandbox is skipped if it is locked by a runtime, and nothing is removed
+// when dryRun is set. The database itself is never left half-written, so
+// that grace, the minimum age of a sandbox without state to consider it
+// orphaned, only applies to the file system driver.
+func (b *Bolt) RepairSandbox(sid string, dryRun bool, grace time.Duration) ([]fs.RepairAction, error) {
+	locker, ok := b.PersistDriver.(sandboxLocker)
+	if !ok {
+		return nil, fmt.Errorf("the base persist driver does not support repair")
+	}
+
+	// Do not block on, nor race with, a runtime using the sandbox.
+	unlock, err := locker.TryLock(sid)
+	if err != nil {
+		return nil, err
+	}
+	if unlock == nil {
+		b.Logger().WithField("sandbox", sid).Info("sandbox in use, skipping repair")
+		return nil, nil
+	}
+	defer unlock()
+
+	var actions []fs.RepairAction
+	report := func(action fs.RepairAction) {
+		b.Logger().WithField("sandbox", action.SandboxID).WithField("container", action.ContainerID).
+			WithField("path", action.Path).WithField("dry-run", dryRun).Warn(action.Problem)
+		actions = append(actions, action)
+	}
+
+	sandboxDir := filepath.Join(b.RunStoragePath(), sid)
+
+	db, err := b.open(sid, dryRun)
+	if errors.Is(err, fs.ErrCorruptedState) {
+		report(fs.RepairAction{SandboxID: sid, Path: sandboxDir, Problem: fs.ProblemCorruptedSandbox, TearDown: true})
+		return actions, nil
+	} else if err != nil {
+		return nil, err
+	}
+	defer db.Close()
+
+	var ss persistapi.SandboxState
+	var corrupted []string
+	err = db.View(func(tx *bolt.Tx) error {
+		if err := b.decodeSandbox(sid, tx, &ss); err != nil {
+			return err
+		}
+
+		cbucket := tx.Bucket(containersBucket)
+		if cbucket == nil {
+			return nil
+		}
+
+		return cbucket.ForEach(func(k, v []byte) error {
+			var cstate persistapi.ContainerState
+			if err := b.decode(sid, string(k), v, &cstate); err != nil {
+				corrupted = append(corrupted, string(k))
+			}
+			return nil
+		})
+	})
+	if errors.Is(err, fs.ErrCorruptedState) {
+		report(fs.RepairAction{SandboxID: sid, Path: sandboxDir, Problem: fs.ProblemCorruptedSandbox, TearDown: true})
+		return actions, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	for _, cid := range corrupted {
+		report(fs.RepairAction{SandboxID: sid, ContainerID: cid, Path: b.DBPath(sid), Problem: fs.ProblemCorruptedContainer})
+	}
+
+	if dryRun || len(corrupted) == 0 {
+		return actions, nil
+	}
+
+	return actions, db.Update(func(tx *bolt.Tx) error {
+		cbucket := tx.Bucket(containersBucket)
+		for _, cid := range corrupted {
+			if err := cbucket.Delete([]byte(cid)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
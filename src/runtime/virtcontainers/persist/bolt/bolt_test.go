@@ -0,0 +1,162 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package bolt
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	persistapi "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/api"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/fs"
+	"github.com/stretchr/testify/assert"
+	bolt "go.etcd.io/bbolt"
+)
+
+func getBoltDriver(t *testing.T) *Bolt {
+	base, err := fs.MockFSInit()
+	assert.NoError(t, err)
+
+	driver, err := New(base)
+	assert.NoError(t, err)
+
+	b, ok := driver.(*Bolt)
+	assert.True(t, ok)
+
+	return b
+}
+
+func TestBoltDBPath(t *testing.T) {
+	b := getBoltDriver(t)
+	assert.Equal(t, filepath.Join(fs.MockRunStoragePath(), "sid", dbFile), b.DBPath("sid"))
+	assert.Equal(t, fs.MockRunStoragePath(), b.RunStoragePath())
+}
+
+func TestBoltDriver(t *testing.T) {
+	defer fs.MockStorageDestroy()
+	assert := assert.New(t)
+
+	b := getBoltDriver(t)
+	sid := "test-bolt-driver"
+
+	// Nothing saved yet
+	_, _, err := b.FromDisk(sid)
+	assert.True(os.IsNotExist(err))
+	assert.False(Saved(b.PersistDriver, sid))
+
+	ss := persistapi.SandboxState{
+		SandboxContainer: sid,
+		State:            "running",
+	}
+	cs := map[string]persistapi.ContainerState{
+		"c1": {State: "running"},
+		"c2": {State: "stopped"},
+	}
+	assert.NoError(b.ToDisk(ss, cs))
+	assert.True(Saved(b.PersistDriver, sid))
+
+	// The sandbox directory is still created for locking, and holds the
+	// database, which isn't locked while the sandbox is.
+	unlock, err := b.Lock(sid, true)
+	assert.NoError(err)
+	rss, rcs, err := b.FromDisk(sid)
+	assert.NoError(err)
+	assert.Equal(ss, rss)
+	assert.Equal(cs, rcs)
+	assert.NoError(unlock())
+
+	// Removed containers are dropped from the saved state.
+	delete(cs, "c2")
+	ss.State = "paused"
+	assert.NoError(b.ToDisk(ss, cs))
+
+	rss, rcs, err = b.FromDisk(sid)
+	assert.NoError(err)
+	assert.Equal("paused", rss.State)
+	assert.Equal(cs, rcs)
+
+	_, _, err = b.FromDisk("other-sandbox")
+	assert.True(os.IsNotExist(err))
+
+	assert.NoError(b.Destroy(sid))
+	_, _, err = b.FromDisk(sid)
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(b.RunStoragePath(), sid))
+	assert.True(os.IsNotExist(err))
+
+	assert.Error(b.ToDisk(persistapi.SandboxState{}, nil))
+	assert.Error(b.Destroy(""))
+}
+
+func TestBoltCorruptedState(t *testing.T) {
+	defer fs.MockStorageDestroy()
+	assert := assert.New(t)
+
+	b := getBoltDriver(t)
+	sid := "test-bolt-corrupted"
+	assert.NoError(os.MkdirAll(filepath.Join(b.RunStoragePath(), sid), dirMode))
+	assert.NoError(os.WriteFile(b.DBPath(sid), []byte("not a database"), fileMode))
+
+	_, _, err := b.FromDisk(sid)
+	assert.True(errors.Is(err, fs.ErrCorruptedState))
+
+	// The sandbox is left to the garbage collector.
+	actions, err := b.RepairSandbox(sid, false, time.Minute)
+	assert.NoError(err)
+	assert.Equal([]fs.RepairAction{
+		{SandboxID: sid, Path: filepath.Join(b.RunStoragePath(), sid), Problem: fs.ProblemCorruptedSandbox, TearDown: true},
+	}, actions)
+	assert.True(Saved(b.PersistDriver, sid))
+}
+
+func TestBoltRepairSandbox(t *testing.T) {
+	defer fs.MockStorageDestroy()
+	assert := assert.New(t)
+
+	b := getBoltDriver(t)
+	sid := "test-bolt-repair"
+	cs := map[string]persistapi.ContainerState{
+		"good": {State: "running"},
+		"bad":  {State: "running"},
+	}
+	assert.NoError(b.ToDisk(persistapi.SandboxState{SandboxContainer: sid}, cs))
+
+	db, err := bolt.Open(b.DBPath(sid), fileMode, nil)
+	assert.NoError(err)
+	assert.NoError(db.Update(func(tx *bolt.Tx) error {
+		return tx.Bucket(containersBucket).Put([]byte("bad"), []byte("{"))
+	}))
+	assert.NoError(db.Close())
+
+	_, _, err = b.FromDisk(sid)
+	assert.True(errors.Is(err, fs.ErrCorruptedState))
+
+	expected := []fs.RepairAction{
+		{SandboxID: sid, ContainerID: "bad", Path: b.DBPath(sid), Problem: fs.ProblemCorruptedContainer},
+	}
+
+	actions, err := b.RepairSandbox(sid, true, time.Minute)
+	assert.NoError(err)
+	assert.Equal(expected, actions)
+
+	actions, err = b.RepairSandbox(sid, false, time.Minute)
+	assert.NoError(err)
+	assert.Equal(expected, actions)
+
+	_, rcs, err := b.FromDisk(sid)
+	assert.NoError(err)
+	assert.Equal(map[string]persistapi.ContainerState{"good": {State: "running"}}, rcs)
+
+	// Locked sandboxes are skipped.
+	unlock, err := b.Lock(sid, false)
+	assert.NoError(err)
+	actions, err = b.RepairSandbox(sid, false, time.Minute)
+	assert.NoError(err)
+	assert.Empty(actions)
+	assert.NoError(unlock())
+}
@@ -7,7 +7,7 @@
 package fs
 
 import (
-	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"os"
@@ -103,13 +103,7 @@ func (fs *FS) ToDisk(ss persistapi.SandboxState, cs map[string]persistapi.Contai
 
 	// persist sandbox configuration data
 	sandboxFile := filepath.Join(sandboxDir, persistFile)
-	f, err := os.OpenFile(sandboxFile, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, fileMode)
-	if err != nil {
-		return err
-	}
-	defer f.Close()
-
-	if err := json.NewEncoder(f).Encode(fs.sandboxState); err != nil {
+	if err := writeState(sandboxFile, fs.sandboxState); err != nil {
 		return err
 	}
 
@@ -131,13 +125,7 @@ func (fs *FS) ToDisk(ss persistapi.SandboxState, cs map[string]persistapi.Contai
 		createdDirs = append(createdDirs, cdir)
 
 		cfile := filepath.Join(cdir, persistFile)
-		cf, err := os.OpenFile(cfile, os.O_RDWR|os.O_CREATE|os.O_TRUNC, fileMode)
-		if err != nil {
-			return err
-		}
-
-		defer cf.Close()
-		if err := json.NewEncoder(cf).Encode(cstate); err != nil {
+		if err := writeState(cfile, cstate); err != nil {
 			return err
 		}
 	}
@@ -180,13 +168,7 @@ func (fs *FS) FromDisk(sid string) (persistapi.SandboxState, map[string]persista
 
 	// get sandbox configuration from persist data
 	sandboxFile := filepath.Join(sandboxDir, persistFile)
-	f, err := os.OpenFile(sandboxFile, os.O_RDONLY, fileMode)
-	if err != nil {
-		return ss, nil, err
-	}
-	defer f.Close()
-
-	if err := json.NewDecoder(f).Decode(fs.sandboxState); err != nil {
+	if err := readState(sandboxFile, fs.sandboxState); err != nil {
 		return ss, nil, err
 	}
 
@@ -203,8 +185,8 @@ func (fs *FS) FromDisk(sid string) (persistapi.SandboxState, map[string]persista
 
 		cid := file.Name()
 		cfile := filepath.Join(sandboxDir, cid, persistFile)
-		cf, err := os.OpenFile(cfile, os.O_RDONLY, fileMode)
-		if err != nil {
+		var cstate persistapi.ContainerState
+		if err := readState(cfile, &cstate); err != nil {
 			// if persist.json doesn't exist, ignore and go to next
 			if os.IsNotExist(err) {
 				continue
@@ -212,12 +194,6 @@ func (fs *FS) FromDisk(sid string) (persistapi.SandboxState, map[string]persista
 			return ss, nil, err
 		}
 
-		defer cf.Close()
-		var cstate persistapi.ContainerState
-		if err := json.NewDecoder(cf).Decode(&cstate); err != nil {
-			return ss, nil, err
-		}
-
 		fs.containerState[cid] = cstate
 	}
 
@@ -241,6 +217,79 @@ func (fs *FS) Destroy(sandboxID string) error {
 	return nil
 }
 
+// RemoveState removes the saved states of a sandbox and of its containers,
+// keeping the sandbox directory, which is also used for locking.
+func (fs *FS) RemoveState(sandboxID string) error {
+	if sandboxID == "" {
+		return fmt.Errorf("sandbox container id required")
+	}
+
+	sandboxDir, err := fs.sandboxDir(sandboxID)
+	if err != nil {
+		return err
+	}
+
+	files, err := os.ReadDir(sandboxDir)
+	if err != nil {
+		return err
+	}
+
+	// The container directories only hold their state.
+	for _, file := range files {
+		if !file.IsDir() {
+			continue
+		}
+
+		cdir := filepath.Join(sandboxDir, file.Name())
+		if _, err := os.Stat(filepath.Join(cdir, persistFile)); os.IsNotExist(err) {
+			continue
+		}
+
+		if err := os.RemoveAll(cdir); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Remove(filepath.Join(sandboxDir, persistFile)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	return nil
+}
+
+// TryLock takes the exclusive lock of a sandbox without waiting for it.
+// The returned unlock function is nil when the lock is held by another
+// process.
+func (fs *FS) TryLock(sandboxID string) (func() error, error) {
+	if sandboxID == "" {
+		return nil, fmt.Errorf("sandbox container id required")
+	}
+
+	sandboxDir, err := fs.sandboxDir(sandboxID)
+	if err != nil {
+		return nil, err
+	}
+
+	f, err := os.Open(sandboxDir)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := syscall.Flock(int(f.Fd()), syscall.LOCK_EX|syscall.LOCK_NB); err != nil {
+		f.Close()
+		if errors.Is(err, syscall.EWOULDBLOCK) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	unlockFunc := func() error {
+		defer f.Close()
+		return syscall.Flock(int(f.Fd()), syscall.LOCK_UN)
+	}
+	return unlockFunc, nil
+}
+
 func (fs *FS) Lock(sandboxID string, exclusive bool) (func() error, error) {
 	if sandboxID == "" {
 		return nil, fmt.Errorf("sandbox container id required")
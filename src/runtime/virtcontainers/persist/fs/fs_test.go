@@ -6,9 +6,12 @@
 package fs
 
 import (
+	"errors"
 	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
+	"time"
 
 	persistapi "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/api"
 	"github.com/stretchr/testify/assert"
@@ -88,6 +91,61 @@ func TestFsLockExclusive(t *testing.T) {
 	assert.NotNil(t, unlockFunc())
 }
 
+func TestFsTryLock(t *testing.T) {
+	defer initTestDir()()
+	assert := assert.New(t)
+
+	fs, err := getFsDriver()
+	assert.NoError(err)
+
+	sid := "test-fs-driver"
+	sandboxDir, err := fs.sandboxDir(sid)
+	assert.NoError(err)
+	assert.NoError(os.MkdirAll(sandboxDir, dirMode))
+
+	unlockFunc, err := fs.Lock(sid, false)
+	assert.NoError(err)
+
+	// The lock is held, so TryLock doesn't take it.
+	tryUnlockFunc, err := fs.TryLock(sid)
+	assert.NoError(err)
+	assert.Nil(tryUnlockFunc)
+	assert.NoError(unlockFunc())
+
+	tryUnlockFunc, err = fs.TryLock(sid)
+	assert.NoError(err)
+	assert.NotNil(tryUnlockFunc)
+	assert.NoError(tryUnlockFunc())
+}
+
+func TestFsRemoveState(t *testing.T) {
+	defer initTestDir()()
+	assert := assert.New(t)
+
+	fs, err := getFsDriver()
+	assert.NoError(err)
+
+	sid := "test-fs-driver"
+	cs := map[string]persistapi.ContainerState{"c1": {State: "running"}}
+	assert.NoError(fs.ToDisk(persistapi.SandboxState{SandboxContainer: sid}, cs))
+
+	sandboxDir, err := fs.sandboxDir(sid)
+	assert.NoError(err)
+	otherDir := filepath.Join(sandboxDir, "other")
+	assert.NoError(os.MkdirAll(otherDir, dirMode))
+
+	assert.NoError(fs.RemoveState(sid))
+
+	_, _, err = fs.FromDisk(sid)
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(sandboxDir, "c1"))
+	assert.True(os.IsNotExist(err))
+
+	// Only the states are removed.
+	_, err = os.Stat(otherDir)
+	assert.NoError(err)
+}
+
 func TestFsDriver(t *testing.T) {
 	defer initTestDir()()
 
@@ -182,3 +240,121 @@ func TestGlobalReadWrite(t *testing.T) {
 	assert.NotNil(t, err)
 	assert.Nil(t, out)
 }
+
+func TestFsStateFile(t *testing.T) {
+	defer initTestDir()()
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	path := filepath.Join(dir, persistFile)
+
+	ss := persistapi.SandboxState{SandboxContainer: "test-fs-state", State: "running"}
+	assert.NoError(writeState(path, ss))
+
+	var rss persistapi.SandboxState
+	assert.NoError(readState(path, &rss))
+	assert.Equal(ss, rss)
+
+	// No temporary file is left behind.
+	tmpFiles, err := filepath.Glob(filepath.Join(dir, tmpFilePattern))
+	assert.NoError(err)
+	assert.Empty(tmpFiles)
+
+	// Files written by older runtimes hold the bare state.
+	assert.NoError(os.WriteFile(path, []byte(`{"SandboxContainer":"legacy","State":"ready"}`), fileMode))
+	assert.NoError(readState(path, &rss))
+	assert.Equal("legacy", rss.SandboxContainer)
+	assert.Equal("ready", rss.State)
+
+	// Truncated file
+	assert.NoError(os.WriteFile(path, []byte(`{"SandboxContainer":"leg`), fileMode))
+	assert.True(errors.Is(readState(path, &rss), ErrCorruptedState))
+
+	// Checksum mismatch
+	assert.NoError(os.WriteFile(path, []byte(`{"fileVersion":1,"sha256":"00","payload":{"State":"ready"}}`), fileMode))
+	assert.True(errors.Is(readState(path, &rss), ErrCorruptedState))
+
+	// Newer envelope
+	assert.NoError(os.WriteFile(path, []byte(`{"fileVersion":100,"payload":{}}`), fileMode))
+	err = readState(path, &rss)
+	assert.Error(err)
+	assert.False(errors.Is(err, ErrCorruptedState))
+}
+
+func TestFsRepair(t *testing.T) {
+	defer initTestDir()()
+	assert := assert.New(t)
+
+	fs, err := getFsDriver()
+	assert.NoError(err)
+
+	cs := map[string]persistapi.ContainerState{
+		"good": {State: "running"},
+		"bad":  {State: "running"},
+	}
+	assert.NoError(fs.ToDisk(persistapi.SandboxState{SandboxContainer: "healthy"}, cs))
+	assert.NoError(fs.ToDisk(persistapi.SandboxState{SandboxContainer: "corrupted"}, nil))
+
+	healthyDir := filepath.Join(fs.RunStoragePath(), "healthy")
+	corruptedDir := filepath.Join(fs.RunStoragePath(), "corrupted")
+	orphanedDir := filepath.Join(fs.RunStoragePath(), "orphaned")
+	recentDir := filepath.Join(fs.RunStoragePath(), "recent")
+
+	tmpFile := filepath.Join(healthyDir, persistFile+".tmp-123")
+	assert.NoError(os.WriteFile(tmpFile, []byte("{"), fileMode))
+	assert.NoError(os.WriteFile(filepath.Join(healthyDir, "bad", persistFile), []byte("{"), fileMode))
+	assert.NoError(os.WriteFile(filepath.Join(corruptedDir, persistFile), []byte(""), fileMode))
+	assert.NoError(os.MkdirAll(orphanedDir, dirMode))
+	old := time.Now().Add(-time.Hour)
+	assert.NoError(os.Chtimes(orphanedDir, old, old))
+	assert.NoError(os.MkdirAll(recentDir, dirMode))
+
+	// The sandbox with a corrupted container state cannot be restored.
+	_, _, err = fs.FromDisk("healthy")
+	assert.Error(err)
+
+	expected := []RepairAction{
+		{SandboxID: "corrupted", Path: corruptedDir, Problem: ProblemCorruptedSandbox, TearDown: true},
+		{SandboxID: "healthy", Path: tmpFile, Problem: ProblemTempFile},
+		{SandboxID: "healthy", ContainerID: "bad", Path: filepath.Join(healthyDir, "bad"), Problem: ProblemCorruptedContainer},
+		{SandboxID: "orphaned", Path: orphanedDir, Problem: ProblemOrphanedSandbox},
+	}
+
+	actions, err := fs.Repair(true, time.Minute)
+	assert.NoError(err)
+	assert.Equal(expected, actions)
+
+	// Dry run did not change anything.
+	_, err = os.Stat(corruptedDir)
+	assert.NoError(err)
+
+	actions, err = fs.Repair(false, time.Minute)
+	assert.NoError(err)
+	assert.Equal(expected, actions)
+
+	for _, path := range []string{orphanedDir, tmpFile} {
+		_, err = os.Stat(path)
+		assert.True(os.IsNotExist(err), path)
+	}
+
+	// The corrupted sandbox is left to the garbage collector.
+	for _, path := range []string{recentDir, corruptedDir} {
+		_, err = os.Stat(path)
+		assert.NoError(err, path)
+	}
+
+	fs, err = getFsDriver()
+	assert.NoError(err)
+	_, rcs, err := fs.FromDisk("healthy")
+	assert.NoError(err)
+	assert.Equal(map[string]persistapi.ContainerState{"good": {State: "running"}}, rcs)
+
+	// Locked sandboxes are skipped.
+	assert.NoError(os.WriteFile(tmpFile, []byte("{"), fileMode))
+	unlock, err := fs.Lock("healthy", false)
+	assert.NoError(err)
+	actions, err = fs.Repair(false, time.Minute)
+	assert.NoError(err)
+	assert.Equal(expected[:1], actions)
+	assert.NoError(unlock())
+}
@@ -0,0 +1,178 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package fs
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"time"
+
+	persistapi "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/api"
+)
+
+// Problems found by Repair.
+const (
+	// ProblemTempFile is a temporary file left by an interrupted write.
+	ProblemTempFile = "leftover temporary file"
+
+	// ProblemCorruptedContainer is a container state which cannot be
+	// restored. The container directory is removed, so that the sandbox
+	// can be restored and deleted.
+	ProblemCorruptedContainer = "corrupted container state"
+
+	// ProblemCorruptedSandbox is a sandbox state which cannot be restored.
+	// The sandbox is kept, as its hypervisor, mounts and network namespace
+	// may still be around: it is left to the garbage collector, which
+	// tears down the sandboxes of dead shims.
+	ProblemCorruptedSandbox = "corrupted sandbox state"
+
+	// ProblemOrphanedSandbox is a sandbox directory without any state,
+	// left by a runtime which failed before saving it. The sandbox
+	// directory is removed.
+	ProblemOrphanedSandbox = "orphaned sandbox"
+)
+
+// RepairAction describes a problem found in the saved states, which has been
+// fixed by removing Path, unless the sandbox has to be torn down.
+type RepairAction struct {
+	SandboxID   string
+	ContainerID string
+	Path        string
+	Problem     string
+
+	// TearDown tells that the sandbox is left to the garbage collector.
+	TearDown bool
+}
+
+// Repair scans RunStoragePath() for the leftovers of interrupted writes and
+// for the states which cannot be restored, and removes them, except the
+// sandbox states, which are only reported (see ProblemCorruptedSandbox).
+// Sandboxes without any state are only removed once they have not been
+// modified for grace, as the runtime creating them may not have saved them
+// yet. Sandboxes locked by a runtime are skipped. Nothing is removed when
+// dryRun is set.
+func (fs *FS) Repair(dryRun bool, grace time.Duration) ([]RepairAction, error) {
+	entries, err := os.ReadDir(fs.RunStoragePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var actions []RepairAction
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		sandboxActions, err := fs.RepairSandbox(entry.Name(), dryRun, grace)
+		actions = append(actions, sandboxActions...)
+		if err != nil {
+			return actions, err
+		}
+	}
+
+	return actions, nil
+}
+
+// RepairSandbox repairs the saved state of the sandbox sid, as Repair does.
+func (fs *FS) RepairSandbox(sid string, dryRun bool, grace time.Duration) ([]RepairAction, error) {
+	sandboxDir, err := fs.sandboxDir(sid)
+	if err != nil {
+		return nil, err
+	}
+
+	// Do not block on, nor race with, a runtime using the sandbox.
+	unlock, err := fs.TryLock(sid)
+	if err != nil {
+		return nil, err
+	}
+	if unlock == nil {
+		fs.Logger().WithField("sandbox", sid).Info("sandbox in use, skipping repair")
+		return nil, nil
+	}
+	defer unlock()
+
+	var actions []RepairAction
+	remove := func(action RepairAction) error {
+		fs.Logger().WithField("sandbox", action.SandboxID).WithField("container", action.ContainerID).
+			WithField("path", action.Path).WithField("dry-run", dryRun).Warn(action.Problem)
+		actions = append(actions, action)
+		if dryRun || action.TearDown {
+			return nil
+		}
+		return os.RemoveAll(action.Path)
+	}
+
+	var ss persistapi.SandboxState
+	err = readState(filepath.Join(sandboxDir, persistFile), &ss)
+	switch {
+	case errors.Is(err, ErrCorruptedState):
+		return actions, remove(RepairAction{SandboxID: sid, Path: sandboxDir, Problem: ProblemCorruptedSandbox, TearDown: true})
+	case os.IsNotExist(err):
+		info, err := os.Stat(sandboxDir)
+		if err != nil {
+			return actions, err
+		}
+		if time.Since(info.ModTime()) < grace {
+			return actions, nil
+		}
+		return actions, remove(RepairAction{SandboxID: sid, Path: sandboxDir, Problem: ProblemOrphanedSandbox})
+	case err != nil:
+		return actions, err
+	}
+
+	if err := fs.removeTempFiles(sid, "", sandboxDir, remove); err != nil {
+		return actions, err
+	}
+
+	entries, err := os.ReadDir(sandboxDir)
+	if err != nil {
+		return actions, err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		cid := entry.Name()
+		cdir := filepath.Join(sandboxDir, cid)
+
+		var cstate persistapi.ContainerState
+		err := readState(filepath.Join(cdir, persistFile), &cstate)
+		if errors.Is(err, ErrCorruptedState) {
+			if err := remove(RepairAction{SandboxID: sid, ContainerID: cid, Path: cdir, Problem: ProblemCorruptedContainer}); err != nil {
+				return actions, err
+			}
+			continue
+		} else if err != nil && !os.IsNotExist(err) {
+			return actions, err
+		}
+
+		if err := fs.removeTempFiles(sid, cid, cdir, remove); err != nil {
+			return actions, err
+		}
+	}
+
+	return actions, nil
+}
+
+func (fs *FS) removeTempFiles(sid, cid, dir string, remove func(RepairAction) error) error {
+	tmpFiles, err := filepath.Glob(filepath.Join(dir, tmpFilePattern))
+	if err != nil {
+		return err
+	}
+
+	for _, path := range tmpFiles {
+		if err := remove(RepairAction{SandboxID: sid, ContainerID: cid, Path: path, Problem: ProblemTempFile}); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,137 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package fs
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	persistapi "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/api"
+)
+
+// tmpFilePattern is the pattern of the temporary files written before
+// atomically replacing a persist file
+const tmpFilePattern = persistFile + ".tmp-*"
+
+// ErrCorruptedState is returned when a persist file cannot be decoded or
+// does not match its checksum, e.g. after a crash of an older runtime in
+// the middle of a write.
+var ErrCorruptedState = errors.New("corrupted persist data")
+
+// persistEnvelope is the content of a persist file.
+type persistEnvelope struct {
+	SHA256      string          `json:"sha256"`
+	Payload     json.RawMessage `json:"payload"`
+	FileVersion uint            `json:"fileVersion"`
+}
+
+func checksum(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// writeState saves state to path, so that a crash leaves either the previous
+// or the new state.
+func writeState(path string, state interface{}) error {
+	payload, err := json.Marshal(state)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.Marshal(persistEnvelope{
+		FileVersion: persistapi.CurPersistFileVersion,
+		SHA256:      checksum(payload),
+		Payload:     payload,
+	})
+	if err != nil {
+		return err
+	}
+
+	return writeFileAtomic(path, data)
+}
+
+// readState restores state from path, checking that the file is complete.
+func readState(path string, state interface{}) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var envelope persistEnvelope
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return fmt.Errorf("%s: %w: %v", path, ErrCorruptedState, err)
+	}
+
+	if envelope.FileVersion > persistapi.CurPersistFileVersion {
+		return fmt.Errorf("%s: unsupported persist file version %d", path, envelope.FileVersion)
+	}
+
+	// Files written before the envelope was introduced hold the state itself.
+	payload := data
+	if envelope.Payload != nil {
+		if checksum(envelope.Payload) != envelope.SHA256 {
+			return fmt.Errorf("%s: %w: checksum mismatch", path, ErrCorruptedState)
+		}
+		payload = envelope.Payload
+	}
+
+	if err := json.Unmarshal(payload, state); err != nil {
+		return fmt.Errorf("%s: %w: %v", path, ErrCorruptedState, err)
+	}
+
+	return nil
+}
+
+// writeFileAtomic writes data to a temporary file in the directory of path,
+// flushes it and renames it to path.
+func writeFileAtomic(path string, data []byte) (err error) {
+	dir := filepath.Dir(path)
+
+	f, err := os.CreateTemp(dir, tmpFilePattern)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			f.Close()
+			os.Remove(f.Name())
+		}
+	}()
+
+	if err = f.Chmod(fileMode); err != nil {
+		return err
+	}
+
+	if _, err = f.Write(data); err != nil {
+		return err
+	}
+
+	if err = f.Sync(); err != nil {
+		return err
+	}
+
+	if err = f.Close(); err != nil {
+		return err
+	}
+
+	if err = os.Rename(f.Name(), path); err != nil {
+		return err
+	}
+
+	// Persist the rename itself.
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+
+	return d.Sync()
+}
@@ -8,8 +8,11 @@ package persist
 
 import (
 	"fmt"
+	"os"
+	"time"
 
 	persistapi "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/api"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/bolt"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/fs"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/rootless"
 )
@@ -19,6 +22,7 @@ type initFunc (func() (persistapi.PersistDriver, error))
 const (
 	RootFSName     = "fs"
 	RootlessFSName = "rootlessfs"
+	BoltName       = "bolt"
 )
 
 var (
@@ -27,10 +31,40 @@ var (
 
 		RootFSName:     fs.Init,
 		RootlessFSName: fs.RootlessInit,
+		BoltName:       boltInit,
 	}
 	mockTesting = false
 )
 
+// boltInit returns a Bolt driver on top of the file system driver
+// GetDriver would otherwise use.
+func boltInit() (persistapi.PersistDriver, error) {
+	var base persistapi.PersistDriver
+	var err error
+
+	if rootless.IsRootless() {
+		base, err = fs.RootlessInit()
+	} else {
+		base, err = fs.Init()
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return bolt.New(base)
+}
+
+// ValidDriverName returns whether name is a driver which can be selected by
+// the configuration, an empty name selecting the file system driver.
+func ValidDriverName(name string) bool {
+	switch name {
+	case "", RootFSName, BoltName:
+		return true
+	}
+
+	return false
+}
+
 func EnableMockTesting() {
 	mockTesting = true
 }
@@ -72,3 +106,195 @@ func GetDriver() (persistapi.PersistDriver, error) {
 
 	return nil, fmt.Errorf("Could not find a FS driver")
 }
+
+// GetConfiguredDriver returns the PersistDriver selected by the configuration,
+// the file system one returned by GetDriver if name is empty.
+func GetConfiguredDriver(name string) (persistapi.PersistDriver, error) {
+	if !ValidDriverName(name) {
+		return nil, fmt.Errorf("unsupported persist driver %q", name)
+	}
+
+	base, err := GetDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	if name == BoltName {
+		return bolt.New(base)
+	}
+
+	return base, nil
+}
+
+// SandboxDriverName returns the name of the driver saving the state of the
+// sandbox sid, which is the file system driver if it has no saved state.
+func SandboxDriverName(sid string) (string, error) {
+	base, err := GetDriver()
+	if err != nil {
+		return "", err
+	}
+
+	if bolt.Saved(base, sid) {
+		return BoltName, nil
+	}
+
+	return RootFSName, nil
+}
+
+// GetSandboxDriver returns the driver saving the state of the sandbox sid.
+func GetSandboxDriver(sid string) (persistapi.PersistDriver, error) {
+	name, err := SandboxDriverName(sid)
+	if err != nil {
+		return nil, err
+	}
+
+	return GetConfiguredDriver(name)
+}
+
+// sandboxRepairer is implemented by the drivers able to repair the saved
+// state of a sandbox.
+type sandboxRepairer interface {
+	RepairSandbox(sid string, dryRun bool, grace time.Duration) ([]fs.RepairAction, error)
+}
+
+// Repair repairs the saved states of all the sandboxes, each one with the
+// driver saving it, as fs.FS.Repair does.
+func Repair(dryRun bool, grace time.Duration) ([]fs.RepairAction, error) {
+	base, err := GetDriver()
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir(base.RunStoragePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var actions []fs.RepairAction
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		sid := entry.Name()
+		driver, err := GetSandboxDriver(sid)
+		if err != nil {
+			return actions, err
+		}
+
+		repairer, ok := driver.(sandboxRepairer)
+		if !ok {
+			return actions, fmt.Errorf("the persist driver of sandbox %s does not support repair", sid)
+		}
+
+		sandboxActions, err := repairer.RepairSandbox(sid, dryRun, grace)
+		actions = append(actions, sandboxActions...)
+		if err != nil {
+			return actions, err
+		}
+	}
+
+	return actions, nil
+}
+
+// stateRemover is implemented by the file system drivers, to remove the
+// states they saved.
+type stateRemover interface {
+	RemoveState(sid string) error
+}
+
+// Migrate moves the state of all the sandboxes saved by the from file system
+// driver to the to driver, and returns the IDs of the migrated sandboxes.
+// Each sandbox is locked while its state is copied, and the state saved by
+// the from driver is removed once copied. Both drivers must share the same
+// run storage path, e.g. the file system driver and the Bolt driver wrapping
+// it.
+func Migrate(from, to string) ([]string, error) {
+	if from == to {
+		return nil, fmt.Errorf("cannot migrate the %q driver to itself", from)
+	}
+
+	fromInit, ok := supportedDrivers[from]
+	if !ok {
+		return nil, fmt.Errorf("failed to get storage driver %q", from)
+	}
+
+	toDriver, err := GetDriverByName(to)
+	if err != nil {
+		return nil, err
+	}
+
+	fromDriver, err := fromInit()
+	if err != nil {
+		return nil, err
+	}
+
+	if _, ok := fromDriver.(stateRemover); !ok {
+		return nil, fmt.Errorf("cannot migrate from the %q driver", from)
+	}
+
+	if fromDriver.RunStoragePath() != toDriver.RunStoragePath() {
+		return nil, fmt.Errorf("cannot migrate between run storage paths %q and %q", fromDriver.RunStoragePath(), toDriver.RunStoragePath())
+	}
+
+	entries, err := os.ReadDir(fromDriver.RunStoragePath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	var migrated []string
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		// The file system driver caches the last restored state, so
+		// use a new instance for each sandbox.
+		fromDriver, err := fromInit()
+		if err != nil {
+			return migrated, err
+		}
+
+		sid := entry.Name()
+		ok, err := migrateSandbox(fromDriver, toDriver, sid)
+		if err != nil {
+			return migrated, fmt.Errorf("failed to migrate sandbox %s: %v", sid, err)
+		}
+		if ok {
+			migrated = append(migrated, sid)
+		}
+	}
+
+	return migrated, nil
+}
+
+func migrateSandbox(from, to persistapi.PersistDriver, sid string) (bool, error) {
+	unlock, err := from.Lock(sid, true)
+	if err != nil {
+		return false, err
+	}
+	defer unlock()
+
+	ss, cs, err := from.FromDisk(sid)
+	if os.IsNotExist(err) {
+		// Directory without saved state
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	if err := to.ToDisk(ss, cs); err != nil {
+		return false, err
+	}
+
+	// Only the state saved by the to driver is left.
+	if err := from.(stateRemover).RemoveState(sid); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
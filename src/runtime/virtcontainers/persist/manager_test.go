@@ -8,9 +8,11 @@ package persist
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	persistapi "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/api"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/bolt"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/fs"
 	"github.com/stretchr/testify/assert"
 )
@@ -55,3 +57,127 @@ func TestGetDriver(t *testing.T) {
 	assert.NoError(err)
 	assert.Equal(expectedFS, fsd)
 }
+
+func TestGetConfiguredDriver(t *testing.T) {
+	assert := assert.New(t)
+	orgMockTesting := mockTesting
+	defer func() {
+		mockTesting = orgMockTesting
+	}()
+	defer fs.MockStorageDestroy()
+
+	mockTesting = true
+
+	assert.True(ValidDriverName(""))
+	assert.True(ValidDriverName(BoltName))
+	assert.False(ValidDriverName(RootlessFSName))
+
+	_, err := GetConfiguredDriver("non-exist")
+	assert.Error(err)
+
+	driver, err := GetConfiguredDriver("")
+	assert.NoError(err)
+	assert.IsType(&fs.MockFS{}, driver)
+
+	driver, err = GetConfiguredDriver(BoltName)
+	assert.NoError(err)
+	assert.IsType(&bolt.Bolt{}, driver)
+
+	// Sandboxes keep the driver which saved them.
+	sid := "test-sandbox-driver"
+	assert.NoError(driver.ToDisk(persistapi.SandboxState{SandboxContainer: sid}, nil))
+
+	name, err := SandboxDriverName(sid)
+	assert.NoError(err)
+	assert.Equal(BoltName, name)
+
+	name, err = SandboxDriverName("other-sandbox")
+	assert.NoError(err)
+	assert.Equal(RootFSName, name)
+
+	driver, err = GetSandboxDriver(sid)
+	assert.NoError(err)
+	assert.IsType(&bolt.Bolt{}, driver)
+}
+
+func TestRepair(t *testing.T) {
+	assert := assert.New(t)
+	orgMockTesting := mockTesting
+	defer func() {
+		mockTesting = orgMockTesting
+	}()
+	defer fs.MockStorageDestroy()
+
+	mockTesting = true
+
+	fsDriver, err := GetConfiguredDriver("")
+	assert.NoError(err)
+	boltDriver, err := GetConfiguredDriver(BoltName)
+	assert.NoError(err)
+
+	assert.NoError(fsDriver.ToDisk(persistapi.SandboxState{SandboxContainer: "fs-sandbox"}, nil))
+	assert.NoError(boltDriver.ToDisk(persistapi.SandboxState{SandboxContainer: "bolt-sandbox"}, nil))
+
+	orphanedDir := filepath.Join(fs.MockRunStoragePath(), "orphaned")
+	assert.NoError(os.MkdirAll(orphanedDir, 0700))
+
+	// The Bolt sandboxes, without file system state, are not orphaned.
+	actions, err := Repair(true, 0)
+	assert.NoError(err)
+	assert.Equal([]fs.RepairAction{{SandboxID: "orphaned", Path: orphanedDir, Problem: fs.ProblemOrphanedSandbox}}, actions)
+}
+
+func TestMigrate(t *testing.T) {
+	assert := assert.New(t)
+	defer fs.MockStorageDestroy()
+
+	orgDrivers := supportedDrivers
+	defer func() {
+		supportedDrivers = orgDrivers
+	}()
+
+	mockBoltInit := func() (persistapi.PersistDriver, error) {
+		base, err := fs.MockFSInit()
+		if err != nil {
+			return nil, err
+		}
+		return bolt.New(base)
+	}
+	supportedDrivers = map[string]initFunc{
+		RootFSName: fs.MockFSInit,
+		BoltName:   mockBoltInit,
+	}
+
+	from, err := fs.MockFSInit()
+	assert.NoError(err)
+
+	sid := "test-migrate"
+	cs := map[string]persistapi.ContainerState{"c1": {State: "running"}}
+	assert.NoError(from.ToDisk(persistapi.SandboxState{SandboxContainer: sid, State: "running"}, cs))
+
+	// Directories without any saved state are skipped.
+	assert.NoError(os.MkdirAll(filepath.Join(fs.MockRunStoragePath(), "no-state"), 0700))
+
+	migrated, err := Migrate(RootFSName, BoltName)
+	assert.NoError(err)
+	assert.Equal([]string{sid}, migrated)
+
+	// The file system state is removed once migrated.
+	sandboxDir := filepath.Join(fs.MockRunStoragePath(), sid)
+	_, err = os.Stat(filepath.Join(sandboxDir, "persist.json"))
+	assert.True(os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(sandboxDir, "c1"))
+	assert.True(os.IsNotExist(err))
+
+	to, err := mockBoltInit()
+	assert.NoError(err)
+	ss, rcs, err := to.FromDisk(sid)
+	assert.NoError(err)
+	assert.Equal("running", ss.State)
+	assert.Equal(cs, rcs)
+
+	_, err = Migrate("non-exist", BoltName)
+	assert.Error(err)
+	_, err = Migrate(BoltName, BoltName)
+	assert.Error(err)
+}
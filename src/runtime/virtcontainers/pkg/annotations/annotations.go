@@ -49,6 +49,9 @@ const (
 	// JailerPath is a sandbox annotation for passing a per container path pointing at the jailer that will constrain the container VM.
 	JailerPath = kataAnnotHypervisorPrefix + "jailer_path"
 
+	// MmdsSecretsPath is a sandbox annotation for passing a per sandbox host directory whose files are published as secrets through the metadata service.
+	MmdsSecretsPath = kataAnnotHypervisorPrefix + "mmds_secrets_path"
+
 	// CtlPath is a sandbox annotation for passing a per container path pointing at the acrn ctl binary
 	CtlPath = kataAnnotHypervisorPrefix + "ctlpath"
 
@@ -257,6 +260,14 @@ const (
 	// VfioMode is a sandbox annotation to specify how attached VFIO devices should be treated
 	// Overrides the runtime.vfio_mode parameter in the global configuration.toml
 	VfioMode = kataAnnotRuntimePrefix + "vfio_mode"
+
+	// MigrationIncomingURI is a sandbox annotation to create the sandbox from
+	// the live migration of a running sandbox, received at the given URI.
+	MigrationIncomingURI = kataAnnotRuntimePrefix + "migration_incoming_uri"
+
+	// MigrationIncomingStatePath is a sandbox annotation for the directory
+	// the state of the migrated sandbox is loaded from.
+	MigrationIncomingStatePath = kataAnnotRuntimePrefix + "migration_incoming_state_path"
 )
 
 // Agent related annotations
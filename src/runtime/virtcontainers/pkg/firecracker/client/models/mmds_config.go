@@ -6,9 +6,13 @@ package models
 // Editing this file might prove futile when you re-run the swagger generate command
 
 import (
+	"encoding/json"
+
 	strfmt "github.com/go-openapi/strfmt"
 
+	"github.com/go-openapi/errors"
 	"github.com/go-openapi/swag"
+	"github.com/go-openapi/validate"
 )
 
 // MmdsConfig Defines the MMDS configuration.
@@ -17,10 +21,69 @@ type MmdsConfig struct {
 
 	// A valid IPv4 link-local address.
 	IPV4Address *string `json:"ipv4_address,omitempty"`
+
+	// List of the network interface IDs capable of forwarding packets to the MMDS.
+	NetworkInterfaces []string `json:"network_interfaces"`
+
+	// Enumeration indicating the MMDS version to be configured.
+	// Enum: [V1 V2]
+	Version *string `json:"version,omitempty"`
 }
 
 // Validate validates this mmds config
 func (m *MmdsConfig) Validate(formats strfmt.Registry) error {
+	var res []error
+
+	if err := m.validateVersion(formats); err != nil {
+		res = append(res, err)
+	}
+
+	if len(res) > 0 {
+		return errors.CompositeValidationError(res...)
+	}
+	return nil
+}
+
+var mmdsConfigTypeVersionPropEnum []interface{}
+
+func init() {
+	var res []string
+	if err := json.Unmarshal([]byte(`["V1","V2"]`), &res); err != nil {
+		panic(err)
+	}
+	for _, v := range res {
+		mmdsConfigTypeVersionPropEnum = append(mmdsConfigTypeVersionPropEnum, v)
+	}
+}
+
+const (
+
+	// MmdsConfigVersionV1 captures enum value "V1"
+	MmdsConfigVersionV1 string = "V1"
+
+	// MmdsConfigVersionV2 captures enum value "V2"
+	MmdsConfigVersionV2 string = "V2"
+)
+
+// prop value enum
+func (m *MmdsConfig) validateVersionEnum(path, location string, value string) error {
+	if err := validate.Enum(path, location, value, mmdsConfigTypeVersionPropEnum); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (m *MmdsConfig) validateVersion(formats strfmt.Registry) error {
+
+	if swag.IsZero(m.Version) { // not required
+		return nil
+	}
+
+	// value enum
+	if err := m.validateVersionEnum("version", "body", *m.Version); err != nil {
+		return err
+	}
+
 	return nil
 }
 
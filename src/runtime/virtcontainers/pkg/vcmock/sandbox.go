@@ -47,6 +47,11 @@ func (s *Sandbox) GetNetNs() string {
 	return s.MockNetNs
 }
 
+// GetExecData implements the VCSandbox function of the same name.
+func (s *Sandbox) GetExecData() vc.ExecData {
+	return s.MockExecData
+}
+
 // GetAllContainers implements the VCSandbox function of the same name.
 func (s *Sandbox) GetAllContainers() []vc.VCContainer {
 	var ifa = make([]vc.VCContainer, len(s.MockContainers))
@@ -84,12 +89,26 @@ func (s *Sandbox) Stop(ctx context.Context, force bool) error {
 }
 
 // Pause implements the VCSandbox function of the same name.
-func (s *Sandbox) Pause() error {
+func (s *Sandbox) Pause(ctx context.Context) error {
+	if s.PauseFunc != nil {
+		return s.PauseFunc()
+	}
 	return nil
 }
 
 // Resume implements the VCSandbox function of the same name.
-func (s *Sandbox) Resume() error {
+func (s *Sandbox) Resume(ctx context.Context) error {
+	if s.ResumeFunc != nil {
+		return s.ResumeFunc()
+	}
+	return nil
+}
+
+// Checkpoint implements the VCSandbox function of the same name.
+func (s *Sandbox) Checkpoint(ctx context.Context, dir string) error {
+	if s.CheckpointFunc != nil {
+		return s.CheckpointFunc(dir)
+	}
 	return nil
 }
 
@@ -128,6 +147,9 @@ func (s *Sandbox) KillContainer(ctx context.Context, contID string, signal sysca
 
 // StatusContainer implements the VCSandbox function of the same name.
 func (s *Sandbox) StatusContainer(contID string) (vc.ContainerStatus, error) {
+	if s.StatusContainerFunc != nil {
+		return s.StatusContainerFunc(contID)
+	}
 	return vc.ContainerStatus{}, nil
 }
 
@@ -141,6 +163,9 @@ func (s *Sandbox) StatsContainer(ctx context.Context, contID string) (vc.Contain
 
 // PauseContainer implements the VCSandbox function of the same name.
 func (s *Sandbox) PauseContainer(ctx context.Context, contID string) error {
+	if s.PauseContainerFunc != nil {
+		return s.PauseContainerFunc(contID)
+	}
 	return nil
 }
 
@@ -169,6 +194,22 @@ func (s *Sandbox) UpdateContainer(ctx context.Context, containerID string, resou
 	return nil
 }
 
+// UpdateNetworkRateLimiter implements the VCSandbox function of the same name.
+func (s *Sandbox) UpdateNetworkRateLimiter(ctx context.Context, rxRate, txRate uint64) error {
+	if s.UpdateNetworkRateLimiterFunc != nil {
+		return s.UpdateNetworkRateLimiterFunc(rxRate, txRate)
+	}
+	return nil
+}
+
+// Migrate implements the VCSandbox function of the same name.
+func (s *Sandbox) Migrate(ctx context.Context, uri, dir string) error {
+	if s.MigrateFunc != nil {
+		return s.MigrateFunc(uri, dir)
+	}
+	return nil
+}
+
 // WaitProcess implements the VCSandbox function of the same name.
 func (s *Sandbox) WaitProcess(ctx context.Context, containerID, processID string) (int32, error) {
 	logF := logrus.Fields{"src": "uruncio", "file": "vc/pkg/vcmock/sandbox.go", "func": "WaitProcess"}
@@ -266,3 +307,11 @@ func (s *Sandbox) GuestVolumeStats(ctx context.Context, path string) ([]byte, er
 func (s *Sandbox) ResizeGuestVolume(ctx context.Context, path string, size uint64) error {
 	return nil
 }
+
+func (s *Sandbox) AttachDirectVolume(ctx context.Context, path string) error {
+	return nil
+}
+
+func (s *Sandbox) DetachDirectVolume(ctx context.Context, path string) error {
+	return nil
+}
@@ -27,6 +27,7 @@ type Sandbox struct {
 	MockAnnotations map[string]string
 	MockContainers  []*Container
 	MockNetNs       string
+	MockExecData    vc.ExecData
 
 	// functions for mocks
 	AnnotationsFunc          func(key string) (string, error)
@@ -40,6 +41,7 @@ type Sandbox struct {
 	StopFunc                 func(force bool) error
 	PauseFunc                func() error
 	ResumeFunc               func() error
+	CheckpointFunc           func(dir string) error
 	DeleteFunc               func() error
 	CreateContainerFunc      func(conf vc.ContainerConfig) (vc.VCContainer, error)
 	DeleteContainerFunc      func(contID string) (vc.VCContainer, error)
@@ -68,6 +70,9 @@ type Sandbox struct {
 	GetAgentMetricsFunc      func() (string, error)
 	StatsFunc                func() (vc.SandboxStats, error)
 	GetAgentURLFunc          func() (string, error)
+
+	UpdateNetworkRateLimiterFunc func(rxRate, txRate uint64) error
+	MigrateFunc                  func(uri, dir string) error
 }
 
 // Container is a fake Container type used for testing
@@ -126,9 +126,13 @@ const (
 
 	scsiControllerID         = "scsi0"
 	rngID                    = "rng0"
+	balloonID                = "balloon0"
 	fallbackFileBackedMemDir = "/dev/shm"
 
 	qemuStopSandboxTimeoutSecs = 15
+
+	// live migrations copy the whole memory of the VM over the network
+	qmpLiveMigrationWaitTimeout = 10 * time.Minute
 )
 
 // agnostic list of kernel parameters
@@ -442,6 +446,11 @@ func (q *qemu) setupTemplate(knobs *govmmQemu.Knobs, memory *govmmQemu.Memory) g
 		}
 	}
 
+	// The URI of the live migration is set once QMP is up.
+	if q.config.MigrationIncomingURI != "" {
+		incoming.MigrationType = govmmQemu.MigrationDefer
+	}
+
 	return incoming
 }
 
@@ -677,6 +686,14 @@ func (q *qemu) CreateVM(ctx context.Context, id string, network Network, hypervi
 		}
 	}
 
+	// Add memory balloon device to hypervisor
+	if q.config.EnableBalloon {
+		qemuConfig.Devices, err = q.arch.appendBalloonDevice(ctx, qemuConfig.Devices, balloonID, q.config.FreePageReporting)
+		if err != nil {
+			return err
+		}
+	}
+
 	// Add PCIe Root Port devices to hypervisor
 	// The pcie.0 bus do not support hot-plug, but PCIe device can be hot-plugged into PCIe Root Port.
 	// For more details, please see https://github.com/qemu/qemu/blob/master/docs/pcie.txt
@@ -893,6 +910,12 @@ func (q *qemu) StartVM(ctx context.Context, timeout int) error {
 		}
 	}
 
+	if q.config.MigrationIncomingURI != "" {
+		if err = q.migrateIncoming(ctx); err != nil {
+			return err
+		}
+	}
+
 	if q.config.VirtioMem {
 		err = q.setupVirtioMem(ctx)
 	}
@@ -919,6 +942,46 @@ func (q *qemu) bootFromTemplate() error {
 	return q.waitMigration()
 }
 
+// migrateIncoming waits for the live migration of a running VM at
+// MigrationIncomingURI, and resumes the migrated VM.
+func (q *qemu) migrateIncoming(ctx context.Context) error {
+	span, _ := katatrace.Trace(ctx, q.Logger(), "migrateIncoming", qemuTracingTags, map[string]string{"sandbox_id": q.id})
+	defer span.End()
+
+	if err := q.qmpSetup(); err != nil {
+		return err
+	}
+
+	q.Logger().WithField("uri", q.config.MigrationIncomingURI).Info("Waiting for the VM migration")
+	if err := q.qmpMonitorCh.qmp.ExecuteMigrationIncoming(q.qmpMonitorCh.ctx, q.config.MigrationIncomingURI); err != nil {
+		return err
+	}
+
+	if err := q.waitMigrationTimeout(qmpLiveMigrationWaitTimeout); err != nil {
+		return err
+	}
+
+	return q.qmpMonitorCh.qmp.ExecuteCont(q.qmpMonitorCh.ctx)
+}
+
+// migrateVM live migrates the running VM to uri.
+func (q *qemu) migrateVM(ctx context.Context, uri string) error {
+	span, _ := katatrace.Trace(ctx, q.Logger(), "migrateVM", qemuTracingTags, map[string]string{"sandbox_id": q.id})
+	defer span.End()
+
+	if err := q.qmpSetup(); err != nil {
+		return err
+	}
+
+	q.Logger().WithField("uri", uri).Info("Migrating the VM")
+	if err := q.qmpMonitorCh.qmp.ExecSetMigrateArguments(q.qmpMonitorCh.ctx, uri); err != nil {
+		q.Logger().WithError(err).Error("live migration")
+		return err
+	}
+
+	return q.waitMigrationTimeout(qmpLiveMigrationWaitTimeout)
+}
+
 // waitVM will wait for the Sandbox's VM to be up and running.
 func (q *qemu) waitVM(ctx context.Context, timeout int) error {
 	span, _ := katatrace.Trace(ctx, q.Logger(), "waitVM", qemuTracingTags, map[string]string{"sandbox_id": q.id})
@@ -1296,12 +1359,25 @@ func (q *qemu) hotplugAddBlockDevice(ctx context.Context, drive *config.BlockDri
 		return nil
 	}
 
+	// Every drive gets its own throttle group, even without limits, so
+	// that they can be set while the drive is in use.
+	throttleGroupID := throttleGroupID(drive.ID)
+	if err = q.qmpMonitorCh.qmp.ExecuteThrottleGroupAdd(q.qmpMonitorCh.ctx, throttleGroupID, throttleLimits(drive.IOLimits)); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			q.qmpMonitorCh.qmp.ExecuteThrottleGroupDel(q.qmpMonitorCh.ctx, throttleGroupID)
+		}
+	}()
+
 	if drive.Swap {
-		err = q.qmpMonitorCh.qmp.ExecuteBlockdevAddWithDriverCache(q.qmpMonitorCh.ctx, "file", drive.File, drive.ID, false, false, false)
+		err = q.qmpMonitorCh.qmp.ExecuteBlockdevAddWithThrottle(q.qmpMonitorCh.ctx, "file", drive.File, drive.ID, throttleGroupID, false, false, false)
 	} else if q.config.BlockDeviceCacheSet {
-		err = q.qmpMonitorCh.qmp.ExecuteBlockdevAddWithCache(q.qmpMonitorCh.ctx, drive.File, drive.ID, q.config.BlockDeviceCacheDirect, q.config.BlockDeviceCacheNoflush, drive.ReadOnly)
+		err = q.qmpMonitorCh.qmp.ExecuteBlockdevAddWithThrottle(q.qmpMonitorCh.ctx, "host_device", drive.File, drive.ID, throttleGroupID, q.config.BlockDeviceCacheDirect, q.config.BlockDeviceCacheNoflush, drive.ReadOnly)
 	} else {
-		err = q.qmpMonitorCh.qmp.ExecuteBlockdevAdd(q.qmpMonitorCh.ctx, drive.File, drive.ID, drive.ReadOnly)
+		err = q.qmpMonitorCh.qmp.ExecuteBlockdevAddWithThrottle(q.qmpMonitorCh.ctx, "host_device", drive.File, drive.ID, throttleGroupID, false, false, drive.ReadOnly)
 	}
 	if err != nil {
 		return err
@@ -1447,7 +1523,46 @@ func (q *qemu) hotplugBlockDevice(ctx context.Context, drive *config.BlockDrive,
 		return err
 	}
 
-	return q.qmpMonitorCh.qmp.ExecuteBlockdevDel(q.qmpMonitorCh.ctx, drive.ID)
+	if err := q.qmpMonitorCh.qmp.ExecuteBlockdevDel(q.qmpMonitorCh.ctx, drive.ID); err != nil {
+		return err
+	}
+
+	// Drives hotplugged by older runtimes have no throttle group.
+	if err := q.qmpMonitorCh.qmp.ExecuteThrottleGroupDel(q.qmpMonitorCh.ctx, throttleGroupID(drive.ID)); err != nil {
+		q.Logger().WithError(err).WithField("drive", drive.ID).Warn("failed to delete throttle group")
+	}
+
+	return nil
+}
+
+// throttleGroupID returns the ID of the throttle group of a drive.
+func throttleGroupID(driveID string) string {
+	return "throttle-" + driveID
+}
+
+// throttleLimits converts the I/O limits of a drive to those of its
+// throttle group.
+func throttleLimits(limits config.BlockIOLimits) govmmQemu.ThrottleLimits {
+	return govmmQemu.ThrottleLimits{
+		BpsRead:   limits.ReadBps,
+		BpsWrite:  limits.WriteBps,
+		IopsRead:  limits.ReadIOPS,
+		IopsWrite: limits.WriteIOPS,
+	}
+}
+
+// updateBlockIOLimits sets the limits of the throttle group of a hotplugged
+// drive. Drives backing NVDIMM devices aren't throttled.
+func (q *qemu) updateBlockIOLimits(ctx context.Context, drive *config.BlockDrive, limits config.BlockIOLimits) error {
+	if q.config.BlockDeviceDriver == config.Nvdimm || drive.Pmem {
+		return nil
+	}
+
+	if err := q.qmpSetup(); err != nil {
+		return err
+	}
+
+	return q.qmpMonitorCh.qmp.ExecuteThrottleGroupSet(q.qmpMonitorCh.ctx, throttleGroupID(drive.ID), throttleLimits(limits))
 }
 
 func (q *qemu) hotplugVhostUserDevice(ctx context.Context, vAttr *config.VhostUserDeviceAttrs, op Operation) error {
@@ -1665,6 +1780,8 @@ func (q *qemu) hotplugNetDevice(ctx context.Context, endpoint Endpoint, op Opera
 	var tap TapInterface
 
 	switch endpoint.Type() {
+	case VdpaEndpointType:
+		return q.hotplugVdpaDevice(ctx, endpoint.(*VdpaEndpoint), op)
 	case VethEndpointType:
 		drive := endpoint.(*VethEndpoint)
 		tap = drive.NetPair.TapInterface
@@ -1733,6 +1850,73 @@ func (q *qemu) hotplugNetDevice(ctx context.Context, endpoint Endpoint, op Opera
 	return q.qmpMonitorCh.qmp.ExecuteNetdevDel(q.qmpMonitorCh.ctx, tap.Name)
 }
 
+// hotplugVdpaDevice hot plugs or unplugs a vhost-vdpa endpoint. The netdev
+// and the bridge slot are named after the vhost-vdpa device, unique on the
+// host.
+func (q *qemu) hotplugVdpaDevice(ctx context.Context, endpoint *VdpaEndpoint, op Operation) (err error) {
+	id := filepath.Base(endpoint.VhostDev)
+	devID := "virtio-" + id
+
+	if op == AddDevice {
+		if err = q.qmpMonitorCh.qmp.ExecuteNetdevAddVhostVdpa(q.qmpMonitorCh.ctx, id, endpoint.VhostDev); err != nil {
+			return err
+		}
+
+		defer func() {
+			if err != nil {
+				q.qmpMonitorCh.qmp.ExecuteNetdevDel(q.qmpMonitorCh.ctx, id)
+			}
+		}()
+
+		var addr string
+		var bridge types.Bridge
+		addr, bridge, err = q.arch.addDeviceToBridge(ctx, id, types.PCI)
+		if err != nil {
+			return err
+		}
+
+		defer func() {
+			if err != nil {
+				q.arch.removeDeviceFromBridge(id)
+			}
+		}()
+
+		var bridgeSlot, devSlot vcTypes.PciSlot
+		if bridgeSlot, err = vcTypes.PciSlotFromInt(bridge.Addr); err != nil {
+			return err
+		}
+		if devSlot, err = vcTypes.PciSlotFromString(addr); err != nil {
+			return err
+		}
+
+		var pciPath vcTypes.PciPath
+		if pciPath, err = vcTypes.PciPathFromSlots(bridgeSlot, devSlot); err != nil {
+			return err
+		}
+		endpoint.SetPciPath(pciPath)
+
+		var machine govmmQemu.Machine
+		if machine, err = q.getQemuMachine(); err != nil {
+			return err
+		}
+		if machine.Type == QemuCCWVirtio {
+			devNoHotplug := fmt.Sprintf("fe.%x.%x", bridge.Addr, addr)
+			return q.qmpMonitorCh.qmp.ExecuteNetCCWDeviceAdd(q.qmpMonitorCh.ctx, id, devID, endpoint.HardwareAddr(), devNoHotplug, 0)
+		}
+		return q.qmpMonitorCh.qmp.ExecuteNetPCIDeviceAdd(q.qmpMonitorCh.ctx, id, devID, endpoint.HardwareAddr(), addr, bridge.ID, romFile, 0, defaultDisableModern)
+	}
+
+	if err := q.arch.removeDeviceFromBridge(id); err != nil {
+		return err
+	}
+
+	if err := q.qmpMonitorCh.qmp.ExecuteDeviceDel(q.qmpMonitorCh.ctx, devID); err != nil {
+		return err
+	}
+
+	return q.qmpMonitorCh.qmp.ExecuteNetdevDel(q.qmpMonitorCh.ctx, id)
+}
+
 func (q *qemu) hotplugDevice(ctx context.Context, devInfo interface{}, devType DeviceType, op Operation) (interface{}, error) {
 	switch devType {
 	case BlockDev:
@@ -2098,7 +2282,11 @@ func (q *qemu) SaveVM() error {
 }
 
 func (q *qemu) waitMigration() error {
-	t := time.NewTimer(qmpMigrationWaitTimeout)
+	return q.waitMigrationTimeout(qmpMigrationWaitTimeout)
+}
+
+func (q *qemu) waitMigrationTimeout(timeout time.Duration) error {
+	t := time.NewTimer(timeout)
 	defer t.Stop()
 	for {
 		status, err := q.qmpMonitorCh.qmp.ExecuteQueryMigration(q.qmpMonitorCh.ctx)
@@ -2109,11 +2297,15 @@ func (q *qemu) waitMigration() error {
 		if status.Status == "completed" {
 			break
 		}
+		if status.Status == "failed" || status.Status == "cancelled" {
+			q.Logger().WithField("migration-status", status).Error("qemu migration failed")
+			return fmt.Errorf("qemu migration %s", status.Status)
+		}
 
 		select {
 		case <-t.C:
 			q.Logger().WithField("migration-status", status).Error("timeout waiting for qemu migration")
-			return fmt.Errorf("timed out after %d seconds waiting for qemu migration", qmpMigrationWaitTimeout)
+			return fmt.Errorf("timed out after %d seconds waiting for qemu migration", int(timeout.Seconds()))
 		default:
 			// migration in progress
 			q.Logger().WithField("migration-status", status).Debug("migration in progress")
@@ -2131,6 +2323,31 @@ func (q *qemu) Disconnect(ctx context.Context) {
 	q.qmpShutdown()
 }
 
+// resizeBalloon sets the balloon size to sizeMB, by asking QEMU for the
+// memory the guest is left with.
+func (q *qemu) resizeBalloon(ctx context.Context, sizeMB uint32) error {
+	span, ctx := katatrace.Trace(ctx, q.Logger(), "resizeBalloon", qemuTracingTags, map[string]string{"sandbox_id": q.id})
+	defer span.End()
+
+	if !q.config.EnableBalloon {
+		return fmt.Errorf("Memory balloon is not enabled")
+	}
+
+	currentMemory := q.config.MemorySize + uint32(q.state.HotpluggedMemory)
+	if sizeMB >= currentMemory {
+		return fmt.Errorf("Balloon size %dMB exceeds the VM memory %dMB", sizeMB, currentMemory)
+	}
+
+	if err := q.qmpSetup(); err != nil {
+		return err
+	}
+
+	target := uint64(currentMemory-sizeMB) << utils.MibToBytesShift
+	q.Logger().WithField("balloon", sizeMB).Debug("resize memory balloon")
+
+	return q.qmpMonitorCh.qmp.ExecuteBalloon(q.qmpMonitorCh.ctx, target)
+}
+
 // resizeMemory get a request to update the VM memory to reqMemMB
 // Memory update is managed with two approaches
 // Add memory to VM:
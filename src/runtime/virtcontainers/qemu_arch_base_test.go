@@ -484,6 +484,24 @@ func TestQemuArchBaseAppendSCSIController(t *testing.T) {
 	assert.NoError(err)
 }
 
+func TestQemuArchBaseAppendBalloonDevice(t *testing.T) {
+	var devices []govmmQemu.Device
+	assert := assert.New(t)
+	qemuArchBase := newQemuArchBase()
+
+	expectedOut := []govmmQemu.Device{
+		govmmQemu.BalloonDevice{
+			ID:                balloonID,
+			DeflateOnOOM:      true,
+			FreePageReporting: true,
+		},
+	}
+
+	devices, err := qemuArchBase.appendBalloonDevice(context.Background(), devices, balloonID, true)
+	assert.NoError(err)
+	assert.Equal(expectedOut, devices)
+}
+
 func TestQemuArchBaseAppendNetwork(t *testing.T) {
 	var devices []govmmQemu.Device
 	var err error
@@ -519,6 +537,13 @@ func TestQemuArchBaseAppendNetwork(t *testing.T) {
 		},
 	}
 
+	vdpaEp := &VdpaEndpoint{
+		IfaceName:    "eth5",
+		HardAddr:     macAddr.String(),
+		EndpointType: VdpaEndpointType,
+		VhostDev:     "/dev/vhost-vdpa-0",
+	}
+
 	expectedOut := []govmmQemu.Device{
 		govmmQemu.NetDevice{
 			Type:       networkModelToQemuType(macvlanEp.NetPair.NetInterworkingModel),
@@ -542,12 +567,22 @@ func TestQemuArchBaseAppendNetwork(t *testing.T) {
 			FDs:        macvtapEp.VMFds,
 			VhostFDs:   macvtapEp.VhostFds,
 		},
+		govmmQemu.NetDevice{
+			Type:       govmmQemu.VHOSTVDPA,
+			Driver:     govmmQemu.VirtioNet,
+			ID:         fmt.Sprintf("network-%d", 2),
+			IFName:     vdpaEp.Name(),
+			MACAddress: vdpaEp.HardwareAddr(),
+			VhostDev:   vdpaEp.VhostDev,
+		},
 	}
 
 	devices, err = qemuArchBase.appendNetwork(context.Background(), devices, macvlanEp)
 	assert.NoError(err)
 	devices, err = qemuArchBase.appendNetwork(context.Background(), devices, macvtapEp)
 	assert.NoError(err)
+	devices, err = qemuArchBase.appendNetwork(context.Background(), devices, vdpaEp)
+	assert.NoError(err)
 	assert.Equal(expectedOut, devices)
 }
 
@@ -239,6 +239,28 @@ func (q *qemuS390x) appendRNGDevice(ctx context.Context, devices []govmmQemu.Dev
 	return devices, nil
 }
 
+func (q *qemuS390x) appendBalloonDevice(ctx context.Context, devices []govmmQemu.Device, id string, freePageReporting bool) ([]govmmQemu.Device, error) {
+	addr, b, err := q.addDeviceToBridge(ctx, id, types.CCW)
+	if err != nil {
+		return devices, fmt.Errorf("Failed to append balloon device %v", err)
+	}
+	devno, err := b.AddressFormatCCW(addr)
+	if err != nil {
+		return devices, fmt.Errorf("Failed to append balloon device %v", err)
+	}
+
+	devices = append(devices,
+		govmmQemu.BalloonDevice{
+			ID:                id,
+			DeflateOnOOM:      true,
+			FreePageReporting: freePageReporting,
+			DevNo:             devno,
+		},
+	)
+
+	return devices, nil
+}
+
 func (q *qemuS390x) append9PVolume(ctx context.Context, devices []govmmQemu.Device, volume types.Volume) ([]govmmQemu.Device, error) {
 	if volume.MountTag == "" || volume.HostPath == "" {
 		return devices, nil
@@ -74,6 +74,25 @@ func TestQemuKernelParameters(t *testing.T) {
 	testQemuKernelParameters(t, params, expectedOut, false)
 }
 
+func TestQemuSetupTemplateMigrationIncoming(t *testing.T) {
+	assert := assert.New(t)
+
+	q := &qemu{
+		config: newQemuConfig(),
+	}
+
+	knobs := govmmQemu.Knobs{}
+	memory := govmmQemu.Memory{}
+	incoming := q.setupTemplate(&knobs, &memory)
+	assert.Equal(govmmQemu.Incoming{}, incoming)
+
+	// The VM waits for the migration rather than booting.
+	q.config.MigrationIncomingURI = "tcp:0:4444"
+	incoming = q.setupTemplate(&knobs, &memory)
+	assert.Equal(govmmQemu.MigrationDefer, incoming.MigrationType)
+	assert.False(knobs.FileBackedMem)
+}
+
 func TestQemuCreateVM(t *testing.T) {
 	qemuConfig := newQemuConfig()
 	assert := assert.New(t)
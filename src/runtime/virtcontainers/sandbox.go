@@ -21,6 +21,7 @@ import (
 	"sync"
 	"syscall"
 
+	"github.com/fsnotify/fsnotify"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -140,6 +141,10 @@ type SandboxConfig struct {
 
 	Hostname string
 
+	// PersistDriver is the name of the persist driver saving the state of
+	// the sandbox, the file system driver being used if it is empty.
+	PersistDriver string
+
 	HypervisorType HypervisorType
 
 	AgentConfig KataAgentConfig
@@ -208,11 +213,30 @@ type Sandbox struct {
 	wg              *sync.WaitGroup
 	cw              *consoleWatcher
 
+	// metadataWatcher republishes the pod metadata when its secrets change.
+	metadataWatcher *fsnotify.Watcher
+
+	// balloonReclaimer reclaims the memory of the guest while idle.
+	balloonReclaimer *balloonReclaimer
+
 	sandboxController  resCtrl.ResourceController
 	overheadController resCtrl.ResourceController
 
 	containers map[string]*Container
 
+	// directVolumes are the IDs of the devices attached for the direct
+	// assigned volumes, by volume path.
+	directVolumes map[string]string
+
+	// restoredContainers are the containers of a sandbox created with an
+	// incoming migration or from a checkpoint, already running in the
+	// migrated or restored VM.
+	restoredContainers map[string]bool
+
+	// checkpointNetwork is the network a sandbox created from a
+	// checkpoint was checkpointed with.
+	checkpointNetwork Network
+
 	id string
 
 	network Network
@@ -239,6 +263,11 @@ func (s *Sandbox) Agent() agent {
 	return s.agent
 }
 
+// GetExecData returns what the agent knows of the unikernel to run.
+func (s *Sandbox) GetExecData() ExecData {
+	return s.agent.GetExecData()
+}
+
 // Logger returns a logrus logger appropriate for logging Sandbox messages
 func (s *Sandbox) Logger() *logrus.Entry {
 	return virtLog.WithFields(logrus.Fields{
@@ -612,8 +641,8 @@ func newSandbox(ctx context.Context, sandboxConfig SandboxConfig, factory Factor
 	}
 	s.fsShare = fsShare
 
-	if s.store, err = persist.GetDriver(); err != nil || s.store == nil {
-		return nil, fmt.Errorf("failed to get fs persist driver: %v", err)
+	if s.store, err = persist.GetConfiguredDriver(s.config.PersistDriver); err != nil || s.store == nil {
+		return nil, fmt.Errorf("failed to get persist driver: %v", err)
 	}
 	defer func() {
 		if retErr != nil {
@@ -644,6 +673,14 @@ func newSandbox(ctx context.Context, sandboxConfig SandboxConfig, factory Factor
 		s.Logger().WithError(err).Debug("restore sandbox failed")
 	}
 
+	// A new sandbox created from a checkpoint restores the state of the
+	// guest along with its VM.
+	if s.state.State == "" && s.checkpointIncoming() {
+		if err := s.loadCheckpoint(sandboxConfig.HypervisorConfig.DevicesStatePath); err != nil {
+			return nil, err
+		}
+	}
+
 	// store doesn't require hypervisor to be stored immediately
 	if err = s.hypervisor.CreateVM(ctx, s.id, s.network, &sandboxConfig.HypervisorConfig); err != nil {
 		return nil, err
@@ -890,6 +927,27 @@ func (s *Sandbox) createNetwork(ctx context.Context) error {
 	return nil
 }
 
+// restoreCheckpointNetwork gives the network interfaces of a sandbox created
+// from a checkpoint the hardware addresses the guest of the restored VM
+// knows them by.
+func (s *Sandbox) restoreCheckpointNetwork() error {
+	endpoints := s.network.Endpoints()
+	checkpointed := s.checkpointNetwork.Endpoints()
+	if len(endpoints) != len(checkpointed) {
+		return fmt.Errorf("sandbox %s has %d network endpoints, its checkpoint has %d", s.id, len(endpoints), len(checkpointed))
+	}
+
+	for i, endpoint := range endpoints {
+		netPair := endpoint.NetworkPair()
+		if netPair == nil {
+			return fmt.Errorf("restoring a %s endpoint from a checkpoint is not supported", endpoint.Type())
+		}
+		netPair.TAPIface.HardAddr = checkpointed[i].HardwareAddr()
+	}
+
+	return nil
+}
+
 func (s *Sandbox) postCreatedNetwork(ctx context.Context) error {
 	if s.factory != nil {
 		return nil
@@ -1267,11 +1325,28 @@ func (s *Sandbox) startVM(ctx context.Context) (err error) {
 			return vm.assignSandbox(s)
 		}
 
-		return s.hypervisor.StartVM(ctx, VmStartTimeout)
+		if err := s.hypervisor.StartVM(ctx, VmStartTimeout); err != nil {
+			return err
+		}
+
+		// A VM restored from a checkpoint is paused.
+		if s.checkpointIncoming() {
+			return s.hypervisor.ResumeVM(ctx)
+		}
+
+		return nil
 	}); err != nil {
 		return err
 	}
 
+	// The source of a migration saves the state of the sandbox before
+	// migrating its VM, the state is known once the VM is received.
+	if s.migrationIncoming() {
+		if err := s.loadCheckpoint(s.config.HypervisorConfig.MigrationIncomingStatePath); err != nil {
+			return err
+		}
+	}
+
 	// In case of vm factory, network interfaces are hotplugged
 	// after vm is started.
 	if s.factory != nil {
@@ -1290,6 +1365,16 @@ func (s *Sandbox) startVM(ctx context.Context) (err error) {
 		}
 	}
 
+	if err := s.startMetadataService(ctx); err != nil {
+		return err
+	}
+
+	defer func() {
+		if err != nil {
+			s.stopMetadataService()
+		}
+	}()
+
 	// Once the hypervisor is done starting the sandbox,
 	// we want to guarantee that it is manageable.
 	// For that we need to ask the agent to start the
@@ -1300,6 +1385,8 @@ func (s *Sandbox) startVM(ctx context.Context) (err error) {
 
 	s.Logger().Info("Agent started in the sandbox")
 
+	s.startBalloonReclaimer()
+
 	defer func() {
 		if err != nil {
 			if e := s.agent.stopSandbox(ctx, s); e != nil {
@@ -1316,6 +1403,9 @@ func (s *Sandbox) stopVM(ctx context.Context) error {
 	span, ctx := katatrace.Trace(ctx, s.Logger(), "stopVM", sandboxTracingTags, map[string]string{"sandbox_id": s.id})
 	defer span.End()
 
+	s.stopMetadataService()
+	s.stopBalloonReclaimer()
+
 	s.Logger().Info("Stopping sandbox in the VM")
 	if err := s.agent.stopSandbox(ctx, s); err != nil {
 		s.Logger().WithError(err).WithField("sandboxid", s.id).Warning("Agent did not stop sandbox")
@@ -1609,8 +1699,8 @@ func (s *Sandbox) Stats(ctx context.Context) (SandboxStats, error) {
 	stats := SandboxStats{}
 
 	// TODO Do we want to aggregate the overhead cgroup stats to the sandbox ones?
-	stats.CgroupStats.CPUStats.CPUUsage.TotalUsage = metrics.CPU.Usage.Total
-	stats.CgroupStats.MemoryStats.Usage.Usage = metrics.Memory.Usage.Usage
+	stats.CgroupStats.CPUStats.CPUUsage.TotalUsage = metrics.CPUUsage()
+	stats.CgroupStats.MemoryStats.Usage.Usage = metrics.MemoryUsage()
 	tids, err := s.hypervisor.GetThreadIDs(ctx)
 	if err != nil {
 		return stats, err
@@ -1658,6 +1748,170 @@ func (s *Sandbox) ResumeContainer(ctx context.Context, containerID string) error
 	return nil
 }
 
+// Pause pauses the VM of the sandbox, and with it all the running
+// containers. It returns ErrVMPauseNotSupported when the containers of the
+// sandbox are to be paused one by one instead.
+func (s *Sandbox) Pause(ctx context.Context) error {
+	span, ctx := katatrace.Trace(ctx, s.Logger(), "Pause", sandboxTracingTags, map[string]string{"sandbox_id": s.id})
+	defer span.End()
+
+	if caps := s.hypervisor.Capabilities(ctx); !caps.IsVMPauseSupported() {
+		return vcTypes.ErrVMPauseNotSupported
+	}
+
+	return s.pauseVM(ctx)
+}
+
+// Resume resumes the VM of a sandbox paused by Pause, and the containers
+// it paused.
+func (s *Sandbox) Resume(ctx context.Context) error {
+	span, ctx := katatrace.Trace(ctx, s.Logger(), "Resume", sandboxTracingTags, map[string]string{"sandbox_id": s.id})
+	defer span.End()
+
+	if caps := s.hypervisor.Capabilities(ctx); !caps.IsVMPauseSupported() {
+		return vcTypes.ErrVMPauseNotSupported
+	}
+
+	return s.resumeVM(ctx)
+}
+
+func (s *Sandbox) pauseVM(ctx context.Context) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.state.ValidTransition(s.state.State, types.StatePaused); err != nil {
+		return err
+	}
+
+	var running []*Container
+	for _, c := range s.containers {
+		if c.state.State == types.StateRunning {
+			running = append(running, c)
+		}
+	}
+
+	s.setMonitorVMPaused(true)
+	if err := s.hypervisor.PauseVM(ctx); err != nil {
+		s.setMonitorVMPaused(false)
+		return err
+	}
+
+	if err := setContainersState(running, types.StatePaused); err != nil {
+		if resumeErr := s.hypervisor.ResumeVM(ctx); resumeErr != nil {
+			s.Logger().WithError(resumeErr).Error("rollback failed ResumeVM()")
+		} else {
+			s.setMonitorVMPaused(false)
+		}
+		return err
+	}
+
+	s.state.VMPausedContainers = nil
+	for _, c := range running {
+		s.state.VMPausedContainers = append(s.state.VMPausedContainers, c.id)
+	}
+
+	if err := s.setSandboxState(types.StatePaused); err != nil {
+		return err
+	}
+
+	return s.storeSandbox(ctx)
+}
+
+func (s *Sandbox) resumeVM(ctx context.Context) error {
+	s.Lock()
+	defer s.Unlock()
+
+	if err := s.state.ValidTransition(s.state.State, types.StateRunning); err != nil {
+		return err
+	}
+
+	var paused []*Container
+	for _, id := range s.state.VMPausedContainers {
+		if c, ok := s.containers[id]; ok && c.state.State == types.StatePaused {
+			paused = append(paused, c)
+		}
+	}
+
+	if err := s.hypervisor.ResumeVM(ctx); err != nil {
+		return err
+	}
+	s.setMonitorVMPaused(false)
+
+	if err := setContainersState(paused, types.StateRunning); err != nil {
+		s.setMonitorVMPaused(true)
+		if pauseErr := s.hypervisor.PauseVM(ctx); pauseErr != nil {
+			s.Logger().WithError(pauseErr).Error("rollback failed PauseVM()")
+			s.setMonitorVMPaused(false)
+		}
+		return err
+	}
+	s.state.VMPausedContainers = nil
+
+	if err := s.setSandboxState(types.StateRunning); err != nil {
+		return err
+	}
+
+	return s.storeSandbox(ctx)
+}
+
+// setMonitorVMPaused tells the monitor whether the agent can be checked.
+func (s *Sandbox) setMonitorVMPaused(paused bool) {
+	if s.monitor != nil {
+		s.monitor.setVMPaused(paused)
+	}
+}
+
+// setContainersState moves containers to state. On failure, the containers
+// are moved back to their previous state.
+func setContainersState(containers []*Container, state types.StateString) error {
+	for i, c := range containers {
+		previous := c.state.State
+		if err := c.setContainerState(state); err != nil {
+			for _, done := range containers[:i+1] {
+				if rollbackErr := done.setContainerState(previous); rollbackErr != nil {
+					done.Logger().WithError(rollbackErr).Error("rollback failed setContainerState()")
+				}
+			}
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Checkpoint snapshots the VM of the sandbox to dir, along with the state
+// of the sandbox. The sandbox is paused while the snapshot is taken, and
+// resumed afterwards.
+func (s *Sandbox) Checkpoint(ctx context.Context, dir string) error {
+	span, ctx := katatrace.Trace(ctx, s.Logger(), "Checkpoint", sandboxTracingTags, map[string]string{"sandbox_id": s.id})
+	defer span.End()
+
+	snapshotter, ok := s.hypervisor.(vmSnapshotter)
+	if !ok {
+		return fmt.Errorf("%s does not support checkpointing the VM", s.config.HypervisorType)
+	}
+
+	if err := s.pauseVM(ctx); err != nil {
+		return err
+	}
+
+	// The state of the sandbox is saved along with the snapshot of its VM,
+	// to be restored with it.
+	err := snapshotter.snapshotVM(ctx, dir)
+	if err == nil {
+		err = s.saveCheckpoint(dir)
+	}
+
+	if resumeErr := s.resumeVM(ctx); resumeErr != nil {
+		s.Logger().WithError(resumeErr).Error("failed to resume the sandbox after checkpointing it")
+		if err == nil {
+			err = resumeErr
+		}
+	}
+
+	return err
+}
+
 // createContainers registers all containers, create the
 // containers in the guest and starts one shim per container.
 func (s *Sandbox) createContainers(ctx context.Context) error {
@@ -1747,6 +2001,13 @@ func (s *Sandbox) Stop(ctx context.Context, force bool) error {
 		return err
 	}
 
+	// The agent can't stop the containers of a paused VM.
+	if s.state.State == types.StatePaused {
+		if err := s.resumeVM(ctx); err != nil && !force {
+			return err
+		}
+	}
+
 	for _, c := range s.containers {
 		if err := c.stop(ctx, force); err != nil {
 			return err
@@ -2022,6 +2283,10 @@ func (s *Sandbox) updateResources(ctx context.Context) error {
 		return fmt.Errorf("sandbox config is nil")
 	}
 
+	// The balloon reclaimer doesn't resize the balloon meanwhile.
+	s.Lock()
+	defer s.Unlock()
+
 	if s.config.StaticResourceMgmt {
 		s.Logger().Debug("no resources updated: static resource management is set")
 		return nil
@@ -2030,13 +2295,21 @@ func (s *Sandbox) updateResources(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
+
+	baseVCPUs, baseMemMB := s.hypervisor.HypervisorConfig().NumVCPUs, s.hypervisor.HypervisorConfig().MemorySize
+	// The VM booted with the resources of its containers.
+	if s.config.SandboxResources.BaseCPUs > 0 {
+		baseVCPUs = s.config.SandboxResources.BaseCPUs
+		baseMemMB = s.config.SandboxResources.BaseMemMB
+	}
+
 	// Add default vcpus for sandbox
-	sandboxVCPUs += s.hypervisor.HypervisorConfig().NumVCPUs
+	sandboxVCPUs += baseVCPUs
 
 	sandboxMemoryByte, sandboxneedPodSwap, sandboxSwapByte := s.calculateSandboxMemory()
 
 	// Add default / rsvd memory for sandbox.
-	hypervisorMemoryByteI64 := int64(s.hypervisor.HypervisorConfig().MemorySize) << utils.MibToBytesShift
+	hypervisorMemoryByteI64 := int64(baseMemMB) << utils.MibToBytesShift
 	hypervisorMemoryByte := uint64(hypervisorMemoryByteI64)
 	sandboxMemoryByte += hypervisorMemoryByte
 	if sandboxneedPodSwap {
@@ -2056,7 +2329,11 @@ func (s *Sandbox) updateResources(ctx context.Context) error {
 	s.Logger().WithField("cpus-sandbox", sandboxVCPUs).Debugf("Request to hypervisor to update vCPUs")
 	oldCPUs, newCPUs, err := s.hypervisor.ResizeVCPUs(ctx, sandboxVCPUs)
 	if err != nil {
-		return err
+		if errors.Is(err, fcResizeErr) {
+			s.Logger().Warnf("%s, vCPU specifications cannot be guaranteed", err)
+		} else {
+			return err
+		}
 	}
 
 	s.Logger().Debugf("Request to hypervisor to update oldCPUs/newCPUs: %d/%d", oldCPUs, newCPUs)
@@ -2075,7 +2352,7 @@ func (s *Sandbox) updateResources(ctx context.Context) error {
 	newMemoryMB := uint32(sandboxMemoryByte >> utils.MibToBytesShift)
 	newMemory, updatedMemoryDevice, err := s.hypervisor.ResizeMemory(ctx, newMemoryMB, s.state.GuestMemoryBlockSizeMB, s.state.GuestMemoryHotplugProbe)
 	if err != nil {
-		if err == noGuestMemHotplugErr {
+		if err == noGuestMemHotplugErr || errors.Is(err, fcResizeErr) {
 			s.Logger().Warnf("%s, memory specifications cannot be guaranteed", err)
 		} else {
 			return err
@@ -2096,6 +2373,141 @@ func (s *Sandbox) updateResources(ctx context.Context) error {
 	return nil
 }
 
+// UpdateNetworkRateLimiter updates the network I/O bandwidth limits of the
+// sandbox interfaces, in bits per second.
+func (s *Sandbox) UpdateNetworkRateLimiter(ctx context.Context, rxRate, txRate uint64) error {
+	span, ctx := katatrace.Trace(ctx, s.Logger(), "UpdateNetworkRateLimiter", sandboxTracingTags, map[string]string{"sandbox_id": s.id})
+	defer span.End()
+
+	updater, ok := s.hypervisor.(netRateLimiterUpdater)
+	if !ok || !s.hypervisor.IsRateLimiterBuiltin() {
+		return fmt.Errorf("Updating the network rate limiter is not supported by %s", s.config.HypervisorType)
+	}
+
+	if err := updater.updateNetRateLimiters(ctx, s.network.Endpoints(), rxRate, txRate); err != nil {
+		return err
+	}
+
+	s.config.HypervisorConfig.RxRateLimiterMaxRate = rxRate
+	s.config.HypervisorConfig.TxRateLimiterMaxRate = txRate
+
+	return s.Save()
+}
+
+// migrationIncoming returns whether the VM of the sandbox is migrated from
+// another host rather than booted.
+func (s *Sandbox) migrationIncoming() bool {
+	return s.config.HypervisorConfig.MigrationIncomingURI != ""
+}
+
+// checkpointIncoming returns whether the VM of the sandbox is restored from
+// a checkpoint rather than booted.
+func (s *Sandbox) checkpointIncoming() bool {
+	return s.config.HypervisorConfig.BootFromTemplate
+}
+
+// restoresVM returns whether the VM of the sandbox, along with the
+// containers running in it, is migrated from another host or restored
+// from a checkpoint rather than booted.
+func (s *Sandbox) restoresVM() bool {
+	return s.migrationIncoming() || s.checkpointIncoming()
+}
+
+// isRestoredContainer returns whether the container already runs in the
+// migrated or restored VM of the sandbox.
+func (s *Sandbox) isRestoredContainer(id string) bool {
+	return s.restoredContainers[id]
+}
+
+// Migrate live migrates the VM of a running sandbox to the VM of a sandbox
+// created with an incoming migration at uri, on this host or another one.
+// The containers keep running in the migrated VM, and the sandbox is
+// stopped once the migration completes. The state of the sandbox is saved
+// to dir, for the destination sandbox to load it.
+func (s *Sandbox) Migrate(ctx context.Context, uri, dir string) error {
+	span, ctx := katatrace.Trace(ctx, s.Logger(), "Migrate", sandboxTracingTags, map[string]string{"sandbox_id": s.id})
+	defer span.End()
+
+	if err := validMigrationURI(uri); err != nil {
+		return err
+	}
+
+	migrator, ok := s.hypervisor.(vmMigrator)
+	if !ok {
+		return fmt.Errorf("Live migration is not supported by %s", s.config.HypervisorType)
+	}
+
+	if s.state.State != types.StateRunning {
+		return fmt.Errorf("Sandbox not running, impossible to migrate it")
+	}
+
+	// The incoming VM is created from the sandbox configuration, which
+	// doesn't describe the devices hotplugged since.
+	for _, c := range s.containers {
+		if c.state.BlockDeviceID != "" || len(c.devices) > 0 {
+			return fmt.Errorf("Container %s has hotplugged devices, impossible to migrate the sandbox", c.id)
+		}
+		for _, m := range c.mounts {
+			if m.BlockDeviceID != "" {
+				return fmt.Errorf("Container %s has hotplugged volumes, impossible to migrate the sandbox", c.id)
+			}
+		}
+	}
+
+	// Nor the vCPUs and memory hot added to honour the container limits.
+	if hs := s.hypervisor.Save(); len(hs.HotpluggedVCPUs) > 0 || hs.HotpluggedMemory > 0 {
+		return fmt.Errorf("Sandbox has hotplugged vCPUs or memory, impossible to migrate it")
+	}
+
+	if err := s.Save(); err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(dir, DirMode); err != nil {
+		return err
+	}
+
+	if err := s.saveCheckpoint(dir); err != nil {
+		return err
+	}
+
+	if err := migrator.migrateVM(ctx, uri); err != nil {
+		return err
+	}
+
+	s.Logger().WithField("uri", uri).Info("Sandbox migrated")
+
+	// The VM now runs on the destination: release it without stopping
+	// the containers, which keep running there.
+	if err := s.agent.disconnect(ctx); err != nil {
+		s.Logger().WithError(err).Warn("Could not disconnect from the agent")
+	}
+
+	// The paused source VM is stopped without asking its agent to stop
+	// the sandbox.
+	s.stopMetadataService()
+	s.stopBalloonReclaimer()
+	if err := s.hypervisor.StopVM(ctx, s.disableVMShutdown); err != nil {
+		return err
+	}
+
+	if err := s.removeNetwork(ctx); err != nil {
+		s.Logger().WithError(err).Warn("Could not remove the network of the migrated sandbox")
+	}
+
+	for _, c := range s.containers {
+		if err := c.setContainerState(types.StateStopped); err != nil {
+			return err
+		}
+	}
+
+	if err := s.setSandboxState(types.StateStopped); err != nil {
+		return err
+	}
+
+	return s.Save()
+}
+
 func (s *Sandbox) calculateSandboxMemory() (uint64, bool, int64) {
 	memorySandbox := uint64(0)
 	needPodSwap := false
@@ -2260,9 +2672,18 @@ func (s *Sandbox) constrainHypervisor(ctx context.Context) error {
 		return fmt.Errorf("failed to get thread ids from hypervisor: %v", err)
 	}
 
+	// The vCPU threads of a jailed hypervisor stay in the devices cgroup
+	// of its jail.
+	var subsystems []string
+	if s.config.HypervisorConfig.EnableJail {
+		if subsystems, err = resCtrl.SubsystemsExcept("devices"); err != nil {
+			return err
+		}
+	}
+
 	// All vCPU threads move to the sandbox controller.
 	for _, i := range tids.vcpus {
-		if err := s.sandboxController.AddThread(i); err != nil {
+		if err := s.sandboxController.AddThread(i, subsystems...); err != nil {
 			return err
 		}
 	}
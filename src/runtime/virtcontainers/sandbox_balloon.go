@@ -0,0 +1,217 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/agent/protocols/grpc"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/utils"
+	"github.com/prometheus/common/expfmt"
+)
+
+const (
+	// guestIdleLoad is the 1 minute load average of the guest under
+	// which the sandbox is considered idle.
+	guestIdleLoad = 0.1
+
+	// balloonResizeThresholdMB is the smallest change of the balloon
+	// size worth a resize, so that the balloon does not follow every
+	// fluctuation of the guest memory.
+	balloonResizeThresholdMB = 64
+
+	guestMeminfoMetric = "kata_guest_meminfo"
+	guestLoadMetric    = "kata_guest_load"
+)
+
+// guestMemoryStats is the memory usage of the guest, as reported by the
+// agent metrics.
+type guestMemoryStats struct {
+	totalMB     uint32
+	availableMB uint32
+	load        float64
+}
+
+// parseGuestMemoryStats extracts the memory usage and the load of the guest
+// from the agent metrics.
+func parseGuestMemoryStats(metrics string) (guestMemoryStats, error) {
+	var stats guestMemoryStats
+	var parser expfmt.TextParser
+
+	mfs, err := parser.TextToMetricFamilies(strings.NewReader(metrics))
+	if err != nil {
+		return stats, err
+	}
+
+	items := func(name string) map[string]float64 {
+		values := map[string]float64{}
+		mf, ok := mfs[name]
+		if !ok {
+			return values
+		}
+
+		for _, m := range mf.GetMetric() {
+			for _, label := range m.GetLabel() {
+				if label.GetName() == "item" && m.GetGauge() != nil {
+					values[label.GetValue()] = m.GetGauge().GetValue()
+				}
+			}
+		}
+
+		return values
+	}
+
+	meminfo := items(guestMeminfoMetric)
+	total, ok := meminfo["mem_total"]
+	if !ok || total == 0 {
+		return stats, fmt.Errorf("Missing guest memory statistics in agent metrics")
+	}
+
+	// The guest reports its memory in bytes.
+	stats.totalMB = uint32(uint64(total) >> utils.MibToBytesShift)
+	stats.availableMB = uint32(uint64(meminfo["mem_available"]) >> utils.MibToBytesShift)
+	stats.load = items(guestLoadMetric)["load1"]
+
+	return stats, nil
+}
+
+// balloonReclaimTarget returns the balloon size, in MiB, for a guest with
+// the given memory usage and a balloon of currentMB.
+//
+// A busy guest gets all its memory back. An idle guest keeps minFreeMB
+// available, the rest being reclaimed within the limit of maxPercent of
+// its memory. As the balloon deflates on OOM, the guest total memory still
+// accounts for the balloon.
+func balloonReclaimTarget(stats guestMemoryStats, currentMB, minFreeMB, maxPercent uint32) uint32 {
+	if stats.load >= guestIdleLoad {
+		return 0
+	}
+
+	target := currentMB
+	if stats.availableMB > minFreeMB {
+		target += stats.availableMB - minFreeMB
+	} else if deficit := minFreeMB - stats.availableMB; deficit < target {
+		target -= deficit
+	} else {
+		target = 0
+	}
+
+	if limit := uint32(uint64(stats.totalMB) * uint64(maxPercent) / 100); target > limit {
+		target = limit
+	}
+
+	return target
+}
+
+// balloonReclaimer periodically resizes the memory balloon of the sandbox
+// to reclaim the memory its guest does not use.
+type balloonReclaimer struct {
+	sandbox *Sandbox
+	balloon vmBalloon
+	stopCh  chan struct{}
+	wg      sync.WaitGroup
+	sizeMB  uint32
+}
+
+// reclaim resizes the balloon from the current guest memory usage.
+func (r *balloonReclaimer) reclaim(ctx context.Context) error {
+	// The balloon is resized in between the sandbox operations changing
+	// the state or the memory of the VM.
+	r.sandbox.Lock()
+	defer r.sandbox.Unlock()
+
+	// The agent can't answer while the VM is paused.
+	if r.sandbox.state.State == types.StatePaused {
+		return nil
+	}
+
+	metrics, err := r.sandbox.agent.getAgentMetrics(ctx, &grpc.GetMetricsRequest{})
+	if err != nil {
+		return err
+	}
+
+	stats, err := parseGuestMemoryStats(metrics.Metrics)
+	if err != nil {
+		return err
+	}
+
+	balloonMetrics.WithLabelValues("guest_mem_total").Set(float64(uint64(stats.totalMB) << utils.MibToBytesShift))
+	balloonMetrics.WithLabelValues("guest_mem_available").Set(float64(uint64(stats.availableMB) << utils.MibToBytesShift))
+
+	hConfig := r.sandbox.config.HypervisorConfig
+	target := balloonReclaimTarget(stats, r.sizeMB, hConfig.BalloonReclaimMinFree, hConfig.BalloonReclaimLimit)
+	if target == r.sizeMB {
+		return nil
+	}
+
+	// Always deflate a balloon for a busy guest, however small.
+	if target != 0 && target < r.sizeMB+balloonResizeThresholdMB && r.sizeMB < target+balloonResizeThresholdMB {
+		return nil
+	}
+
+	r.sandbox.Logger().WithField("from", r.sizeMB).WithField("to", target).Debug("resizing memory balloon")
+	if err := r.balloon.resizeBalloon(ctx, target); err != nil {
+		return err
+	}
+
+	r.sizeMB = target
+	balloonMetrics.WithLabelValues("size").Set(float64(uint64(target) << utils.MibToBytesShift))
+	balloonMetrics.WithLabelValues("resizes").Inc()
+
+	return nil
+}
+
+// startBalloonReclaimer starts reclaiming the memory of the guest, if the
+// hypervisor drives a memory balloon and the reclaim is enabled.
+func (s *Sandbox) startBalloonReclaimer() {
+	balloon, ok := s.hypervisor.(vmBalloon)
+	interval := s.config.HypervisorConfig.BalloonReclaimInterval
+	if !ok || !s.config.HypervisorConfig.EnableBalloon || interval == 0 {
+		return
+	}
+
+	r := &balloonReclaimer{
+		sandbox: s,
+		balloon: balloon,
+		stopCh:  make(chan struct{}),
+	}
+
+	r.wg.Add(1)
+	go func() {
+		defer r.wg.Done()
+
+		tick := time.NewTicker(time.Duration(interval) * time.Second)
+		defer tick.Stop()
+
+		for {
+			select {
+			case <-r.stopCh:
+				return
+			case <-tick.C:
+				if err := r.reclaim(context.Background()); err != nil {
+					s.Logger().WithError(err).Warn("failed to reclaim guest memory")
+					balloonMetrics.WithLabelValues("failures").Inc()
+				}
+			}
+		}
+	}()
+
+	s.balloonReclaimer = r
+}
+
+// stopBalloonReclaimer stops reclaiming the memory of the guest.
+func (s *Sandbox) stopBalloonReclaimer() {
+	if s.balloonReclaimer != nil {
+		close(s.balloonReclaimer.stopCh)
+		s.balloonReclaimer.wg.Wait()
+		s.balloonReclaimer = nil
+	}
+}
@@ -0,0 +1,67 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testAgentMetrics = `# HELP kata_guest_load Guest system load.
+# TYPE kata_guest_load gauge
+kata_guest_load{item="load1"} 0.05
+kata_guest_load{item="load5"} 0.2
+kata_guest_load{item="load15"} 0.3
+# HELP kata_guest_meminfo Statistics about memory usage in the system.
+# TYPE kata_guest_meminfo gauge
+kata_guest_meminfo{item="mem_total"} 2147483648
+kata_guest_meminfo{item="mem_free"} 1073741824
+kata_guest_meminfo{item="mem_available"} 1610612736
+`
+
+func TestParseGuestMemoryStats(t *testing.T) {
+	assert := assert.New(t)
+
+	stats, err := parseGuestMemoryStats(testAgentMetrics)
+	assert.NoError(err)
+	assert.Equal(guestMemoryStats{
+		totalMB:     2048,
+		availableMB: 1536,
+		load:        0.05,
+	}, stats)
+
+	_, err = parseGuestMemoryStats(`kata_guest_load{item="load1"} 0.05`)
+	assert.Error(err)
+
+	_, err = parseGuestMemoryStats("not metrics")
+	assert.Error(err)
+}
+
+func TestBalloonReclaimTarget(t *testing.T) {
+	assert := assert.New(t)
+
+	idle := guestMemoryStats{
+		totalMB:     2048,
+		availableMB: 1536,
+	}
+
+	// An idle guest keeps its minimum free memory.
+	assert.Equal(uint32(1280), balloonReclaimTarget(idle, 0, 256, 75))
+
+	// Within the reclaim limit.
+	assert.Equal(uint32(1024), balloonReclaimTarget(idle, 0, 256, 50))
+
+	// The balloon deflates to give the guest its minimum free memory back.
+	idle.availableMB = 128
+	assert.Equal(uint32(384), balloonReclaimTarget(idle, 512, 256, 50))
+	assert.Equal(uint32(0), balloonReclaimTarget(idle, 64, 256, 50))
+
+	// A busy guest gets all its memory back.
+	busy := idle
+	busy.load = 1.5
+	assert.Equal(uint32(0), balloonReclaimTarget(busy, 1024, 256, 50))
+}
@@ -0,0 +1,205 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+
+	ctrAnnotations "github.com/containerd/containerd/pkg/cri/annotations"
+	crioAnnotations "github.com/cri-o/cri-o/pkg/annotations"
+	"github.com/fsnotify/fsnotify"
+)
+
+// kubernetesPodNamespaceLabel is the label CRI-O sets to the namespace
+// of the pod.
+const kubernetesPodNamespaceLabel = "io.kubernetes.pod.namespace"
+
+// podMetadata is the pod metadata published to the guest through the
+// hypervisor metadata service.
+type podMetadata struct {
+	Labels      map[string]string `json:"labels,omitempty"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+	Secrets     map[string]string `json:"secrets,omitempty"`
+	Name        string            `json:"name,omitempty"`
+	Namespace   string            `json:"namespace,omitempty"`
+}
+
+// newPodMetadata builds the metadata of the pod from the CRI annotations of
+// the sandbox container, and the secrets found in the secrets directory.
+func newPodMetadata(config *SandboxConfig) (*podMetadata, error) {
+	var annotations map[string]string
+	if len(config.Containers) > 0 {
+		annotations = config.Containers[0].Annotations
+	}
+
+	metadata := &podMetadata{
+		Name:      annotations[ctrAnnotations.SandboxName],
+		Namespace: annotations[ctrAnnotations.SandboxNamespace],
+	}
+
+	if labels, ok := annotations[crioAnnotations.Labels]; ok {
+		if err := json.Unmarshal([]byte(labels), &metadata.Labels); err != nil {
+			return nil, err
+		}
+	}
+
+	if metadata.Name == "" {
+		metadata.Name = annotations[crioAnnotations.KubeName]
+	}
+
+	if metadata.Namespace == "" {
+		metadata.Namespace = metadata.Labels[kubernetesPodNamespaceLabel]
+	}
+
+	// CRI-O passes the pod annotations as a single JSON annotation, while
+	// containerd passes them as is.
+	podAnnotations := map[string]string{}
+	if value, ok := annotations[crioAnnotations.Annotations]; ok {
+		if err := json.Unmarshal([]byte(value), &podAnnotations); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, key := range config.HypervisorConfig.MmdsAnnotations {
+		value, ok := annotations[key]
+		if !ok {
+			value, ok = podAnnotations[key]
+		}
+
+		if !ok {
+			continue
+		}
+
+		if metadata.Annotations == nil {
+			metadata.Annotations = map[string]string{}
+		}
+		metadata.Annotations[key] = value
+	}
+
+	secrets, err := readMetadataSecrets(config.HypervisorConfig.MmdsSecretsPath)
+	if err != nil {
+		return nil, err
+	}
+	metadata.Secrets = secrets
+
+	return metadata, nil
+}
+
+// readMetadataSecrets returns the content of the regular files of dir,
+// indexed by name. Hidden entries are skipped, as Kubernetes uses them to
+// swap the content of secret volumes atomically.
+func readMetadataSecrets(dir string) (map[string]string, error) {
+	if dir == "" {
+		return nil, nil
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	secrets := map[string]string{}
+	for _, entry := range entries {
+		if strings.HasPrefix(entry.Name(), ".") {
+			continue
+		}
+
+		path := filepath.Join(dir, entry.Name())
+		info, err := os.Stat(path)
+		if err != nil {
+			return nil, err
+		}
+
+		if !info.Mode().IsRegular() {
+			continue
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		secrets[entry.Name()] = string(content)
+	}
+
+	return secrets, nil
+}
+
+// publishMetadata publishes the pod metadata to the guest, if the
+// hypervisor provides a metadata service and it is enabled.
+func (s *Sandbox) publishMetadata(ctx context.Context) error {
+	publisher, ok := s.hypervisor.(metadataPublisher)
+	if !ok || !s.config.HypervisorConfig.EnableMmds {
+		return nil
+	}
+
+	metadata, err := newPodMetadata(s.config)
+	if err != nil {
+		return err
+	}
+
+	return publisher.publishMetadata(ctx, metadata)
+}
+
+// startMetadataService publishes the pod metadata, and republishes it
+// whenever the secrets change.
+func (s *Sandbox) startMetadataService(ctx context.Context) error {
+	if err := s.publishMetadata(ctx); err != nil {
+		return err
+	}
+
+	dir := s.config.HypervisorConfig.MmdsSecretsPath
+	if !s.config.HypervisorConfig.EnableMmds || dir == "" {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				s.Logger().WithField("event", event).Debug("metadata secrets changed")
+				if err := s.publishMetadata(context.Background()); err != nil {
+					s.Logger().WithError(err).Error("failed to republish the metadata")
+				}
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				s.Logger().WithError(err).Warn("metadata secrets watcher error")
+			}
+		}
+	}()
+
+	s.metadataWatcher = watcher
+
+	return nil
+}
+
+// stopMetadataService stops republishing the pod metadata.
+func (s *Sandbox) stopMetadataService() {
+	if s.metadataWatcher != nil {
+		s.metadataWatcher.Close()
+		s.metadataWatcher = nil
+	}
+}
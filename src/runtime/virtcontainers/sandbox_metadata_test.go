@@ -0,0 +1,155 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	ctrAnnotations "github.com/containerd/containerd/pkg/cri/annotations"
+	crioAnnotations "github.com/cri-o/cri-o/pkg/annotations"
+	"github.com/stretchr/testify/assert"
+)
+
+type metadataMockHypervisor struct {
+	mockHypervisor
+	published chan *podMetadata
+}
+
+func (m *metadataMockHypervisor) publishMetadata(ctx context.Context, metadata interface{}) error {
+	m.published <- metadata.(*podMetadata)
+	return nil
+}
+
+func TestNewPodMetadataContainerd(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{
+			MmdsAnnotations: []string{"example.com/owner", "example.com/missing"},
+		},
+		Containers: []ContainerConfig{
+			{
+				Annotations: map[string]string{
+					ctrAnnotations.SandboxName:      "pod",
+					ctrAnnotations.SandboxNamespace: "default",
+					"example.com/owner":             "team",
+					"example.com/other":             "value",
+				},
+			},
+		},
+	}
+
+	metadata, err := newPodMetadata(config)
+	assert.NoError(err)
+	assert.Equal("pod", metadata.Name)
+	assert.Equal("default", metadata.Namespace)
+	assert.Empty(metadata.Labels)
+	assert.Equal(map[string]string{"example.com/owner": "team"}, metadata.Annotations)
+	assert.Empty(metadata.Secrets)
+}
+
+func TestNewPodMetadataCRIO(t *testing.T) {
+	assert := assert.New(t)
+
+	config := &SandboxConfig{
+		HypervisorConfig: HypervisorConfig{
+			MmdsAnnotations: []string{"example.com/owner"},
+		},
+		Containers: []ContainerConfig{
+			{
+				Annotations: map[string]string{
+					crioAnnotations.KubeName:    "pod",
+					crioAnnotations.Labels:      `{"app":"web","io.kubernetes.pod.namespace":"default"}`,
+					crioAnnotations.Annotations: `{"example.com/owner":"team"}`,
+				},
+			},
+		},
+	}
+
+	metadata, err := newPodMetadata(config)
+	assert.NoError(err)
+	assert.Equal("pod", metadata.Name)
+	assert.Equal("default", metadata.Namespace)
+	assert.Equal("web", metadata.Labels["app"])
+	assert.Equal(map[string]string{"example.com/owner": "team"}, metadata.Annotations)
+
+	config.Containers[0].Annotations[crioAnnotations.Labels] = "{"
+	_, err = newPodMetadata(config)
+	assert.Error(err)
+}
+
+func TestReadMetadataSecrets(t *testing.T) {
+	assert := assert.New(t)
+
+	secrets, err := readMetadataSecrets("")
+	assert.NoError(err)
+	assert.Nil(secrets)
+
+	dir := t.TempDir()
+	_, err = readMetadataSecrets(filepath.Join(dir, "missing"))
+	assert.Error(err)
+
+	// Kubernetes secret volumes link the secrets to a hidden directory.
+	data := filepath.Join(dir, "..data")
+	assert.NoError(os.Mkdir(data, DirMode))
+	assert.NoError(os.WriteFile(filepath.Join(data, "token"), []byte("secret"), 0600))
+	assert.NoError(os.Symlink(filepath.Join("..data", "token"), filepath.Join(dir, "token")))
+	assert.NoError(os.Mkdir(filepath.Join(dir, "subdir"), DirMode))
+
+	secrets, err = readMetadataSecrets(dir)
+	assert.NoError(err)
+	assert.Equal(map[string]string{"token": "secret"}, secrets)
+}
+
+func TestSandboxMetadataService(t *testing.T) {
+	assert := assert.New(t)
+
+	dir := t.TempDir()
+	h := &metadataMockHypervisor{published: make(chan *podMetadata, 10)}
+	s := &Sandbox{
+		id:         testSandboxID,
+		hypervisor: h,
+		config: &SandboxConfig{
+			HypervisorConfig: HypervisorConfig{
+				MmdsSecretsPath: dir,
+			},
+		},
+	}
+
+	// Nothing is published unless the metadata service is enabled.
+	assert.NoError(s.startMetadataService(context.Background()))
+	assert.Nil(s.metadataWatcher)
+	assert.Empty(h.published)
+
+	s.config.HypervisorConfig.EnableMmds = true
+	assert.NoError(s.startMetadataService(context.Background()))
+	defer s.stopMetadataService()
+	assert.NotNil(s.metadataWatcher)
+
+	metadata := <-h.published
+	assert.Empty(metadata.Secrets)
+
+	// Rotated secrets are republished.
+	assert.NoError(os.WriteFile(filepath.Join(dir, "token"), []byte("secret"), 0600))
+	for {
+		select {
+		case metadata = <-h.published:
+		case <-time.After(5 * time.Second):
+			t.Fatal("metadata not republished")
+		}
+
+		if metadata.Secrets["token"] == "secret" {
+			break
+		}
+	}
+
+	s.stopMetadataService()
+	assert.Nil(s.metadataWatcher)
+}
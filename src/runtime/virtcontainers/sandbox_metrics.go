@@ -74,6 +74,15 @@ var (
 		[]string{"action"},
 	)
 
+	// memory balloon
+	balloonMetrics = prometheus.NewGaugeVec(prometheus.GaugeOpts{
+		Namespace: namespaceKatashim,
+		Name:      "balloon",
+		Help:      "Memory balloon and guest memory reclaim statistics.",
+	},
+		[]string{"item"},
+	)
+
 	// virtiofsd
 	virtiofsdThreads = prometheus.NewGauge(prometheus.GaugeOpts{
 		Namespace: namespaceVirtiofsd,
@@ -122,6 +131,8 @@ func RegisterMetrics() {
 	prometheus.MustRegister(hypervisorOpenFDs)
 	// agent
 	prometheus.MustRegister(agentRPCDurationsHistogram)
+	// memory balloon
+	prometheus.MustRegister(balloonMetrics)
 	// virtiofsd
 	prometheus.MustRegister(virtiofsdThreads)
 	prometheus.MustRegister(virtiofsdProcStatus)
@@ -173,6 +184,13 @@ func (s *Sandbox) UpdateRuntimeMetrics() error {
 		mutils.SetGaugeVecProcIO(hypervisorIOStat, ioStat)
 	}
 
+	// VM metrics, which don't keep the other metrics from being updated
+	if updater, ok := s.hypervisor.(vmMetricsUpdater); ok {
+		if err := updater.updateVMMetrics(); err != nil {
+			s.Logger().WithError(err).Warn("failed to update the VM metrics")
+		}
+	}
+
 	// virtiofs metrics
 	err = s.UpdateVirtiofsdMetrics()
 	if err != nil {
@@ -16,6 +16,7 @@ import (
 	"syscall"
 	"testing"
 
+	hv "github.com/kata-containers/kata-containers/src/runtime/pkg/hypervisors"
 	ktu "github.com/kata-containers/kata-containers/src/runtime/pkg/katatestutils"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/device/config"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/device/drivers"
@@ -1301,6 +1302,232 @@ func TestGetNetNs(t *testing.T) {
 	assert.Equal(t, netNs, expected)
 }
 
+func TestSandboxPauseResume(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := testCreateSandbox(t, testSandboxID, MockHypervisor, newHypervisorConfig(nil, nil), NetworkConfig{}, nil, nil)
+	assert.NoError(err)
+	defer cleanUp()
+
+	// The mock hypervisor does not pause the sandbox through its VM.
+	assert.Equal(types.ErrVMPauseNotSupported, s.Pause(context.Background()))
+	assert.Equal(types.ErrVMPauseNotSupported, s.Resume(context.Background()))
+
+	// Only a running sandbox can be paused.
+	assert.Error(s.pauseVM(context.Background()))
+
+	assert.NoError(s.Start(context.Background()))
+
+	contID := "999"
+	_, err = s.CreateContainer(context.Background(), newTestContainerConfigNoop(contID))
+	assert.NoError(err)
+	_, err = s.StartContainer(context.Background(), contID)
+	assert.NoError(err)
+
+	assert.NoError(s.pauseVM(context.Background()))
+	assert.Equal(types.StatePaused, s.state.State)
+	assert.Equal(types.StatePaused, s.containers[contID].state.State)
+	assert.Equal([]string{contID}, s.state.VMPausedContainers)
+	assert.Error(s.pauseVM(context.Background()))
+
+	// The containers to resume are persisted.
+	ss, _, err := s.store.FromDisk(s.id)
+	assert.NoError(err)
+	assert.Equal([]string{contID}, ss.VMPausedContainers)
+
+	assert.NoError(s.resumeVM(context.Background()))
+	assert.Equal(types.StateRunning, s.state.State)
+	assert.Equal(types.StateRunning, s.containers[contID].state.State)
+	assert.Empty(s.state.VMPausedContainers)
+
+	// A paused sandbox is resumed before being stopped.
+	assert.NoError(s.pauseVM(context.Background()))
+	assert.NoError(s.Stop(context.Background(), false))
+	assert.Equal(types.StateStopped, s.state.State)
+}
+
+func TestSandboxCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := testCreateSandbox(t, testSandboxID, MockHypervisor, newHypervisorConfig(nil, nil), NetworkConfig{}, nil, nil)
+	assert.NoError(err)
+	defer cleanUp()
+
+	assert.NoError(s.Start(context.Background()))
+
+	// The sandbox keeps running once checkpointed.
+	dir := filepath.Join(t.TempDir(), "checkpoint")
+	assert.NoError(s.Checkpoint(context.Background(), dir))
+	assert.DirExists(dir)
+	assert.Equal(types.StateRunning, s.state.State)
+
+	// Only a running sandbox can be checkpointed.
+	assert.NoError(s.pauseVM(context.Background()))
+	assert.Error(s.Checkpoint(context.Background(), dir))
+	assert.Equal(types.StatePaused, s.state.State)
+}
+
+func TestSandboxRestoreCheckpoint(t *testing.T) {
+	assert := assert.New(t)
+	ctx := WithNewAgentFunc(context.Background(), newMockAgent)
+
+	contID := "100"
+	s, err := testCreateSandbox(t, testSandboxID, MockHypervisor, newHypervisorConfig(nil, nil), NetworkConfig{}, []ContainerConfig{newTestContainerConfigNoop(contID)}, nil)
+	assert.NoError(err)
+	defer cleanUp()
+
+	assert.NoError(s.Start(ctx))
+	blockIndex, err := s.getAndSetSandboxBlockIndex()
+	assert.NoError(err)
+
+	dir := filepath.Join(t.TempDir(), "checkpoint")
+	assert.NoError(s.Checkpoint(ctx, dir))
+	assert.FileExists(filepath.Join(dir, checkpointStateFile))
+
+	assert.NoError(s.Stop(ctx, true))
+	assert.NoError(s.Delete(ctx))
+
+	hConfig := newHypervisorConfig(nil, nil)
+	hConfig.BootFromTemplate = true
+	hConfig.MemoryPath = dir
+	hConfig.DevicesStatePath = dir
+
+	sconfig := SandboxConfig{
+		ID:               testSandboxID,
+		HypervisorType:   MockHypervisor,
+		HypervisorConfig: hConfig,
+		Containers:       []ContainerConfig{newTestContainerConfigNoop(contID)},
+		Annotations:      sandboxAnnotations,
+	}
+
+	// The agent of the restored VM knows the sandbox and its containers
+	// by their checkpointed IDs.
+	otherConfig := sconfig
+	otherConfig.ID = "other"
+	_, err = createSandboxFromConfig(ctx, otherConfig, nil)
+	assert.Error(err)
+
+	otherConfig = sconfig
+	otherConfig.Containers = []ContainerConfig{newTestContainerConfigNoop("999")}
+	_, err = createSandboxFromConfig(ctx, otherConfig, nil)
+	assert.Error(err)
+
+	restored, err := createSandboxFromConfig(ctx, sconfig, nil)
+	assert.NoError(err)
+
+	// The containers already run in the restored VM, they are neither
+	// created nor started again in the guest.
+	assert.True(restored.restoresVM())
+	assert.True(restored.isRestoredContainer(contID))
+	assert.Contains(restored.state.BlockIndexMap, blockIndex)
+
+	assert.NoError(restored.Start(ctx))
+	assert.Equal(types.StateRunning, restored.state.State)
+	assert.Equal(types.StateRunning, restored.containers[contID].state.State)
+}
+
+func TestSandboxMigrate(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := testCreateSandbox(t, testSandboxID, MockHypervisor, newHypervisorConfig(nil, nil), NetworkConfig{}, nil, nil)
+	assert.NoError(err)
+	defer cleanUp()
+
+	dir := filepath.Join(t.TempDir(), "migration")
+
+	// Only a running sandbox can be migrated.
+	assert.Error(s.Migrate(context.Background(), "tcp:0:4444", dir))
+
+	assert.NoError(s.Start(context.Background()))
+
+	contID := "999"
+	_, err = s.CreateContainer(context.Background(), newTestContainerConfigNoop(contID))
+	assert.NoError(err)
+	_, err = s.StartContainer(context.Background(), contID)
+	assert.NoError(err)
+
+	assert.Error(s.Migrate(context.Background(), "exec:cat", dir))
+	assert.Equal(types.StateRunning, s.state.State)
+
+	// The containers keep running in the migrated VM.
+	assert.NoError(s.Migrate(context.Background(), "tcp:0:4444", dir))
+	assert.Equal(types.StateStopped, s.state.State)
+	assert.Equal(types.StateStopped, s.containers[contID].state.State)
+	assert.FileExists(filepath.Join(dir, checkpointStateFile))
+}
+
+// resizedHypervisor is a hypervisor whose VM was resized to honour the
+// container limits.
+type resizedHypervisor struct {
+	mockHypervisor
+}
+
+func (h *resizedHypervisor) Save() (s hv.HypervisorState) {
+	s.HotpluggedVCPUs = []hv.CPUDevice{{ID: "cpu-0"}}
+	return
+}
+
+func TestSandboxMigrateHotplugged(t *testing.T) {
+	assert := assert.New(t)
+
+	s, err := testCreateSandbox(t, testSandboxID, MockHypervisor, newHypervisorConfig(nil, nil), NetworkConfig{}, nil, nil)
+	assert.NoError(err)
+	defer cleanUp()
+
+	assert.NoError(s.Start(context.Background()))
+
+	// The incoming VM boots without the hotplugged vCPUs.
+	s.hypervisor = &resizedHypervisor{}
+	dir := filepath.Join(t.TempDir(), "migration")
+	assert.Error(s.Migrate(context.Background(), "tcp:0:4444", dir))
+	assert.Equal(types.StateRunning, s.state.State)
+	assert.NoFileExists(filepath.Join(dir, checkpointStateFile))
+}
+
+func TestSandboxMigratedContainers(t *testing.T) {
+	assert := assert.New(t)
+	ctx := WithNewAgentFunc(context.Background(), newMockAgent)
+
+	contID := "100"
+	s, err := testCreateSandbox(t, testSandboxID, MockHypervisor, newHypervisorConfig(nil, nil), NetworkConfig{}, []ContainerConfig{newTestContainerConfigNoop(contID)}, nil)
+	assert.NoError(err)
+	defer cleanUp()
+
+	assert.NoError(s.Start(ctx))
+
+	appID := "999"
+	_, err = s.CreateContainer(ctx, newTestContainerConfigNoop(appID))
+	assert.NoError(err)
+	_, err = s.StartContainer(ctx, appID)
+	assert.NoError(err)
+
+	dir := filepath.Join(t.TempDir(), "migration")
+	assert.NoError(s.Migrate(ctx, "tcp:0:4444", dir))
+	assert.NoError(s.Delete(ctx))
+
+	hConfig := newHypervisorConfig(nil, nil)
+	hConfig.MigrationIncomingURI = "unix:/run/vc/migration.sock"
+	hConfig.MigrationIncomingStatePath = dir
+
+	sconfig := SandboxConfig{
+		ID:               testSandboxID,
+		HypervisorType:   MockHypervisor,
+		HypervisorConfig: hConfig,
+		Containers:       []ContainerConfig{newTestContainerConfigNoop(contID)},
+		Annotations:      sandboxAnnotations,
+	}
+
+	migrated, err := createSandboxFromConfig(ctx, sconfig, nil)
+	assert.NoError(err)
+
+	// All the containers of the source sandbox, including the ones
+	// created after it, already run in the migrated VM.
+	assert.True(migrated.migrationIncoming())
+	assert.True(migrated.isRestoredContainer(contID))
+	assert.True(migrated.isRestoredContainer(appID))
+	assert.False(migrated.isRestoredContainer("101"))
+}
+
 func TestSandboxStopStopped(t *testing.T) {
 	s := &Sandbox{
 		ctx:   context.Background(),
@@ -1394,6 +1621,43 @@ func TestSandboxUpdateResources(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+// fixedSizeHypervisor is a hypervisor unable to resize a running VM.
+type fixedSizeHypervisor struct {
+	mockHypervisor
+	err error
+}
+
+func (h *fixedSizeHypervisor) ResizeMemory(ctx context.Context, memMB uint32, memorySectionSizeMB uint32, probe bool) (uint32, MemoryDevice, error) {
+	return 0, MemoryDevice{}, h.err
+}
+
+func (h *fixedSizeHypervisor) ResizeVCPUs(ctx context.Context, cpus uint32) (uint32, uint32, error) {
+	return 0, 0, h.err
+}
+
+func TestSandboxUpdateResourcesFixedSize(t *testing.T) {
+	contConfig := newTestContainerConfigNoop("cont-00001")
+	hConfig := newHypervisorConfig(nil, nil)
+
+	defer cleanUp()
+	s, err := testCreateSandbox(t,
+		testSandboxID,
+		MockHypervisor,
+		hConfig,
+		NetworkConfig{},
+		[]ContainerConfig{contConfig},
+		nil)
+	assert.NoError(t, err)
+
+	// The requests firecracker can't fulfil are only reported.
+	hypervisor := &fixedSizeHypervisor{err: fmt.Errorf("%w: 4 vCPUs requested, 2 booted", fcResizeErr)}
+	s.hypervisor = hypervisor
+	assert.NoError(t, s.updateResources(context.Background()))
+
+	hypervisor.err = fmt.Errorf("resize failed")
+	assert.Error(t, s.updateResources(context.Background()))
+}
+
 func TestSandboxExperimentalFeature(t *testing.T) {
 	testFeature := exp.Feature{
 		Name:        "mock",
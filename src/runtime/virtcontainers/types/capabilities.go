@@ -10,6 +10,7 @@ const (
 	blockDeviceHotplugSupport
 	multiQueueSupport
 	fsSharingSupported
+	vmPauseSupported
 )
 
 // Capabilities describe a virtcontainers hypervisor capabilities
@@ -57,3 +58,13 @@ func (caps *Capabilities) IsFsSharingSupported() bool {
 func (caps *Capabilities) SetFsSharingSupport() {
 	caps.flags |= fsSharingSupported
 }
+
+// IsVMPauseSupported tells if an hypervisor can pause and resume the VM.
+func (caps *Capabilities) IsVMPauseSupported() bool {
+	return caps.flags&vmPauseSupported != 0
+}
+
+// SetVMPauseSupport sets the VM pause capability to true.
+func (caps *Capabilities) SetVMPauseSupport() {
+	caps.flags |= vmPauseSupported
+}
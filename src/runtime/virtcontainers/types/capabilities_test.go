@@ -35,6 +35,14 @@ func TestFsSharingCapability(t *testing.T) {
 	assert.True(t, caps.IsFsSharingSupported())
 }
 
+func TestVMPauseCapability(t *testing.T) {
+	var caps Capabilities
+
+	assert.False(t, caps.IsVMPauseSupported())
+	caps.SetVMPauseSupport()
+	assert.True(t, caps.IsVMPauseSupported())
+}
+
 func TestMultiQueueCapability(t *testing.T) {
 	assert := assert.New(t)
 	var caps Capabilities
@@ -17,4 +17,8 @@ var (
 	ErrNeedState         = errors.New("State cannot be empty")
 	ErrNoSuchContainer   = errors.New("Container does not exist")
 	ErrInvalidConfigType = errors.New("Invalid config type")
+
+	// ErrVMPauseNotSupported is returned when pausing the VM of a
+	// hypervisor without the VM pause capability.
+	ErrVMPauseNotSupported = errors.New("Hypervisor can not pause the VM")
 )
@@ -23,4 +23,6 @@ type FcConfig struct {
 	Drives []*models.Drive `json:"drives,omitempty"`
 
 	NetworkInterfaces []*models.NetworkInterface `json:"network-interfaces,omitempty"`
+
+	MmdsConfig *models.MmdsConfig `json:"mmds-config,omitempty"`
 }
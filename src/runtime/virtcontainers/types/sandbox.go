@@ -60,6 +60,10 @@ type SandboxState struct {
 	// cgroup.
 	OverheadCgroupPath string `json:"overheadCgroupPath,omitempty"`
 
+	// VMPausedContainers are the containers paused along with the VM,
+	// to be resumed with it.
+	VMPausedContainers []string `json:"vmPausedContainers,omitempty"`
+
 	// PersistVersion indicates current storage api version.
 	// It's also known as ABI version of kata-runtime.
 	// Note: it won't be written to disk
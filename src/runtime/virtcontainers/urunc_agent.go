@@ -8,19 +8,17 @@ package virtcontainers
 import (
 	"errors"
 	"fmt"
-	"io"
-	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 	"syscall"
 	"time"
 
-	osexec "os/exec"
-
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/urunc"
 	persistapi "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/api"
 	pbTypes "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/agent/protocols"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/agent/protocols/grpc"
+	vcAnnotations "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/annotations"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
 	vcTypes "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
 	specs "github.com/opencontainers/runtime-spec/specs-go"
@@ -28,17 +26,16 @@ import (
 	"golang.org/x/net/context"
 )
 
-// This is intented to pass the required data back to containerd-shim
+// ExecData is what the shim needs to run the unikernel of a container.
 type ExecData struct {
-	BinaryType string
-	BinaryPath string
-	IPAddress  string
-	Mask       string
-	Tap        string
-	Gateway    string
-	Container  *Container
-	NetNs      string
-	BlkDevice  string
+	// Unikernel is found in the container rootfs by createContainer.
+	Unikernel *urunc.Unikernel
+	Network   urunc.Network
+	Container *Container
+	NetNs     string
+	// BlkDevice is the devmapper device backing the rootfs, attached
+	// to the unikernel as its disk.
+	BlkDevice string
 }
 
 // uruncAgent is an empty Agent implementation, for deploying unikernels
@@ -47,35 +44,13 @@ type uruncAgent struct {
 	ExecData ExecData
 }
 
-// helper function to parse ls results
-func cleanLsRes(res string) string {
-	res = strings.ReplaceAll(res, "\n", " ")
-	res = strings.ReplaceAll(res, "  ", " ")
-	res = strings.TrimSpace(res)
-	return res
-}
-
 func (u *uruncAgent) Logger() *logrus.Entry {
 	return virtLog.WithField("subsystem", "urunc_agent")
 }
 
-func newExecData() ExecData {
-	return ExecData{
-		BinaryType: "",
-		BinaryPath: "",
-		IPAddress:  "",
-		Mask:       "",
-		Gateway:    "",
-		Tap:        "",
-		NetNs:      "",
-		BlkDevice:  "",
-	}
-}
-
 // nolint:golint
 func NewUruncAgent() agent {
-	data := newExecData()
-	return &uruncAgent{ExecData: data}
+	return &uruncAgent{}
 }
 
 func (u *uruncAgent) GetExecData() ExecData {
@@ -138,7 +113,7 @@ func (u *uruncAgent) addNetworkData(ctx context.Context, sandbox *Sandbox) error
 	logF := logrus.Fields{"src": "uruncio", "file": "vc/urunc_agent.go", "func": "addNetworkData"}
 	logrus.WithFields(logF).Error("")
 
-	if u.ExecData.IPAddress == "" {
+	if u.ExecData.Network.IPAddress == "" {
 		logrus.WithFields(logF).Error("IP not set, generating...")
 		interfaces, routes, _, err := generateVCNetworkStructures(ctx, sandbox.network)
 		if err != nil {
@@ -149,13 +124,28 @@ func (u *uruncAgent) addNetworkData(ctx context.Context, sandbox *Sandbox) error
 
 		// usually there are 2 routes and 1 interface, so I take the first of each one
 		if len(routes) >= 1 && len(interfaces) >= 1 {
-			u.ExecData.IPAddress = interfaces[0].IPAddresses[0].Address
-			u.ExecData.Mask = interfaces[0].IPAddresses[0].Mask
-			u.ExecData.Tap = interfaces[0].Device
-			u.ExecData.Gateway = routes[0].Gateway
+			// The interface describes the guest side, the monitor
+			// needs the host device the hypervisor recorded for it.
+			h, ok := sandbox.hypervisor.(*uruncHypervisor)
+			if !ok {
+				return fmt.Errorf("urunc agent requires the urunc hypervisor, got %T", sandbox.hypervisor)
+			}
+			dev, ok := h.netDevice(interfaces[0].Device)
+			if !ok {
+				return fmt.Errorf("no host device recorded for interface %s", interfaces[0].Device)
+			}
+
+			u.ExecData.Network = urunc.Network{
+				Tap:       dev.Tap,
+				HardAddr:  dev.HardAddr,
+				Fds:       dev.Fds,
+				IPAddress: interfaces[0].IPAddresses[0].Address,
+				Mask:      interfaces[0].IPAddresses[0].Mask,
+				Gateway:   routes[0].Gateway,
+			}
 			u.ExecData.NetNs = sandbox.GetNetNs()
 
-			netData := logrus.Fields{"IP": interfaces[0].IPAddresses[0].Address, "mask": interfaces[0].IPAddresses[0].Mask, "tap": interfaces[0].Device, "gw": routes[0].Gateway, "ns": sandbox.GetNetNs()}
+			netData := logrus.Fields{"IP": interfaces[0].IPAddresses[0].Address, "mask": interfaces[0].IPAddresses[0].Mask, "tap": dev.Tap, "gw": routes[0].Gateway, "ns": sandbox.GetNetNs()}
 			logrus.WithFields(logF).WithFields(netData).Error("")
 		} else {
 			logrus.WithFields(logF).Error("Network creation failed")
@@ -167,6 +157,22 @@ func (u *uruncAgent) addNetworkData(ctx context.Context, sandbox *Sandbox) error
 	return nil
 }
 
+// uruncRootfsPath returns the host path of the container rootfs, in its
+// bundle.
+func uruncRootfsPath(c *Container) (string, error) {
+	bundle, ok := c.GetAnnotations()[vcAnnotations.BundlePathKey]
+	if !ok || bundle == "" {
+		return "", fmt.Errorf("no bundle path for container %s", c.id)
+	}
+	return filepath.Join(bundle, c.rootfsSuffix), nil
+}
+
+// uruncCopyPath returns the directory the content of a block device rootfs
+// is staged in, while the device is unmounted.
+func uruncCopyPath(rootFsPath string) string {
+	return filepath.Join(filepath.Dir(rootFsPath), "tmp")
+}
+
 // createContainer retrieves the net data, mounts rootfs if necessary and
 // populates the uruncAgent exec data fields
 func (u *uruncAgent) createContainer(ctx context.Context, sandbox *Sandbox, c *Container) (*Process, error) {
@@ -174,41 +180,15 @@ func (u *uruncAgent) createContainer(ctx context.Context, sandbox *Sandbox, c *C
 
 	// Get the network data
 	u.ExecData.Container = c
-	u.addNetworkData(ctx, sandbox)
-	if u.ExecData.IPAddress != "" {
-		logrus.WithFields(logF).WithField("IP", u.ExecData.IPAddress).Error("Network data added")
-	} else {
-		logrus.WithFields(logF).Error("Network creation failed")
+	if err := u.addNetworkData(ctx, sandbox); err != nil {
+		logrus.WithFields(logF).WithError(err).Error("Network creation failed")
 	}
 
-	// Find the bundle data
-	logrus.WithFields(logF).WithField("CID", c.ID()).Error("")
-	logrus.WithFields(logF).WithField("SID", sandbox.ID()).Error("")
-
-	lsPrefix := "."
-
-	// Find cwd
-	cwdPath, err := os.Getwd()
+	rootFsPath, err := uruncRootfsPath(c)
 	if err != nil {
-		logrus.WithFields(logF).WithField("cwdErr", err.Error()).Error("")
-	} else {
-		logrus.WithFields(logF).WithField("cwd", string(cwdPath)).Error("")
-	}
-	// Remove whitespaces
-	cwdPath = strings.ReplaceAll(cwdPath, " ", "")
-	cwdPath = strings.TrimSpace(cwdPath)
-
-	// Check if sandboxID eq containerID
-	if c.ID() != sandbox.ID() {
-		logrus.WithFields(logF).Error("Sandbox already exists")
-		cwdPath = strings.ReplaceAll(cwdPath, sandbox.ID(), c.ID())
-		lsPrefix = cwdPath
+		return &Process{}, err
 	}
 
-	// Our rootfs path
-	rootFsPath := cwdPath + "/" + c.rootfsSuffix
-
-	// Let's find out more info from c.Rootfs
 	tempFields := logrus.Fields{
 		"rootFs_source": c.rootFs.Source,
 		"rootFs_target": c.rootFs.Target,
@@ -218,107 +198,49 @@ func (u *uruncAgent) createContainer(ctx context.Context, sandbox *Sandbox, c *C
 	logrus.WithFields(logF).WithFields(tempFields).Error("rootfs info")
 
 	// check if is devmapper
-	if strings.Contains(c.rootFs.Source, "dm") {
-		u.ExecData.BlkDevice = c.rootFs.Source
-		mntOut, err := osexec.Command("mount", "-t", c.rootFs.Type, c.rootFs.Source, rootFsPath).CombinedOutput()
-		if err != nil {
-			logrus.WithFields(logF).WithField("mountErr", err.Error()).Error("")
-			logrus.WithFields(logF).WithField("mountErr", string(mntOut)).Error("")
-			return &Process{}, errors.New("failed to mount")
+	devmapper := strings.Contains(c.rootFs.Source, "dm")
+	if devmapper {
+		if err := syscall.Mount(c.rootFs.Source, rootFsPath, c.rootFs.Type, 0, ""); err != nil {
+			return &Process{}, fmt.Errorf("failed to mount %s: %v", c.rootFs.Source, err)
 		}
 		c.rootFs.Mounted = true
 		c.rootFs.Target = rootFsPath
-		logrus.WithFields(logF).Error("device mounted")
 	}
 
-	// check if pause
-	lsCmd, err := osexec.Command("ls", lsPrefix+"/rootfs").Output()
-	lsRes := cleanLsRes(string(lsCmd))
+	unikernel, err := urunc.Inspect(rootFsPath)
 	if err != nil {
-		logrus.WithFields(logF).WithField("ls2err", err.Error()).Error("")
-	} else {
-		logrus.WithFields(logF).WithField("ls2", lsRes).Error("")
-	}
-
-	// if is pause, don't unmount and return
-	if strings.Contains(lsRes, "pause") {
-		u.ExecData.BinaryType = "pause"
-		u.ExecData.BinaryPath = rootFsPath + "/pause"
-		logrus.WithFields(logF).WithField("file", u.ExecData.BinaryPath).Error("")
-		logrus.WithFields(logF).WithField("type", u.ExecData.BinaryType).Error("")
-		return &Process{}, nil
-	}
-
-	// check if image is supported and populate execData
-	if strings.Contains(lsRes, "unikernel") {
-		logrus.WithFields(logF).WithField("type", u.ExecData.BinaryType).Error("")
-		lsCmd, err := osexec.Command("ls", lsPrefix+"/rootfs/unikernel").Output()
-		lsRes := cleanLsRes(string(lsCmd))
-		if err != nil {
-			logrus.WithFields(logF).WithField("ls2err", err.Error()).Error("")
-		} else {
-			logrus.WithFields(logF).WithField("ls2", lsRes).Error("")
+		if err == urunc.ErrNotUnikernel {
+			err = errors.New("requested image not supported")
 		}
-		u.ExecData.BinaryPath = rootFsPath + "/unikernel/" + lsRes
-
-	} else {
-		// image not compatible
-		return &Process{}, errors.New("requested image not supported")
+		return &Process{}, err
 	}
+	u.ExecData.Unikernel = unikernel
+	logrus.WithFields(logF).WithField("file", unikernel.Path).WithField("type", unikernel.Type).Error("unikernel found")
 
-	// check file type and populate
-	if strings.Contains(u.ExecData.BinaryPath, ".hvt") {
-		u.ExecData.BinaryType = "hvt"
-	} else if strings.Contains(u.ExecData.BinaryPath, ".qm") {
-		u.ExecData.BinaryType = "qemu"
-	} else {
-		// if type is binary return
-		u.ExecData.BinaryType = "binary"
+	// The pause and binary images are run from the mounted rootfs.
+	if !devmapper || (unikernel.Type != urunc.HvtType && unikernel.Type != urunc.QemuType) {
 		return &Process{}, nil
 	}
 
-	// at this point, image is valid and type is qm or hvt
-	// so we need to copy files from blk device and unmount
-
-	// copy everything from rootFsPath to newDir
-	newDir := strings.ReplaceAll(rootFsPath, "rootfs", "") + "tmp"
-	logrus.WithFields(logF).WithField("newDir", string(newDir)).Error("")
-	err = CopyDir(rootFsPath, newDir)
-	if err != nil {
-		logrus.WithFields(logF).WithField("cpdir", err.Error()).Error("Err copying1")
-		return &Process{}, errors.New("failed to copy block device content")
-	}
-	logrus.WithFields(logF).Error("copying1 done")
-
-	// unmount dev
-	uMntOut, err := osexec.Command("umount", c.rootFs.Source).CombinedOutput()
-	if err != nil {
-		logrus.WithFields(logF).WithField("unmountErr", err.Error()).Error("")
-		logrus.WithFields(logF).WithField("unmountErr", string(uMntOut)).Error("")
+	// The block device is given to the unikernel as its disk, so its
+	// content is copied to the rootfs directory and it is unmounted.
+	newDir := uruncCopyPath(rootFsPath)
+	if err := urunc.CopyDir(rootFsPath, newDir); err != nil {
+		return &Process{}, fmt.Errorf("failed to copy block device content: %v", err)
 	}
-	logrus.WithFields(logF).Error("unmount done")
+	defer os.RemoveAll(newDir)
 
-	// rm rootfs in order to copy
-	err = os.RemoveAll(rootFsPath)
-	if err != nil {
-		logrus.WithFields(logF).WithField("rmdir", err.Error()).Error("Err rming")
+	if err := syscall.Unmount(rootFsPath, 0); err != nil {
+		return &Process{}, fmt.Errorf("failed to unmount %s: %v", c.rootFs.Source, err)
 	}
-	logrus.WithFields(logF).Error("rming done")
+	c.rootFs.Mounted = false
 
-	// copy from newDir to rootfsPath
-	err = CopyDir(newDir, rootFsPath)
-	// err = CopyDir()
-	if err != nil {
-		logrus.WithFields(logF).WithField("cpdir", err.Error()).Error("Err copying2")
+	if err := os.RemoveAll(rootFsPath); err != nil {
+		return &Process{}, err
 	}
-	logrus.WithFields(logF).Error("copying2 done")
-
-	// rm newDir
-	err = os.RemoveAll(newDir)
-	if err != nil {
-		logrus.WithFields(logF).WithField("rmdir", err.Error()).Error("Err rming")
+	if err := urunc.CopyDir(newDir, rootFsPath); err != nil {
+		return &Process{}, fmt.Errorf("failed to copy block device content: %v", err)
 	}
-	logrus.WithFields(logF).Error("rming done")
 
 	// pass device to execData
 	u.ExecData.BlkDevice = c.rootFs.Source
@@ -345,72 +267,29 @@ func (u *uruncAgent) stopContainer(ctx context.Context, sandbox *Sandbox, c Cont
 	logF := logrus.Fields{"src": "uruncio", "file": "vc/urunc_agent.go", "func": "stopContainer"}
 	logrus.WithFields(logF).WithField("cid", c.id).Error("")
 
-	// psOut, _ := osexec.Command("ps", "-ef", "|", "grep", c.id).Output()
-	// logrus.WithFields(logF).WithField("psOut", string(psOut)).Error("")
-
-	// pcs, err := ps.Processes()
-	// if err == nil {
-	// 	for _, pc := range pcs {
-	// 		logrus.WithFields(logF).WithField("process", "mew").Error("ps")
-	// 		p := pc.Executable()
-	// 		logrus.WithFields(logF).WithField("pid", p).Error("ps")
-	// 	}
-	// }
-	// logrus.WithFields(logF).WithField("ps-go", "end").Error("")
-
-	rootfsSourcePath := c.rootFs.Source
-	u.Logger().WithFields(logF).WithField("rootfsSourcePath", rootfsSourcePath).Error("stopContainer 1")
-
-	rootfsGuestPath := filepath.Join(kataGuestSharedDir(), c.id, c.rootfsSuffix)
-	u.Logger().WithFields(logF).WithField("rootfsGuestPath", rootfsGuestPath).Error("createContainer 2")
-
-	// rootFsPath := "/run/containerd/io.containerd.runtime.v2.task/default/" + c.id + "/" + c.rootfsSuffix
-	// This needs to change to the cwd path. the default ns is not used.
-	// rootFsPath := "/run/containerd/io.containerd.runtime.v2.task/default/" + c.id + "/" + c.rootfsSuffix
-
-	cwdPath, _ := os.Getwd()
-	cwdPath = strings.ReplaceAll(cwdPath, " ", "")
-	cwdPath = strings.TrimSpace(cwdPath)
-	rootFsPath := cwdPath + c.id + "/" + c.rootfsSuffix
-	u.Logger().WithFields(logF).WithField("rootFsPath", rootFsPath).Error("")
-
-	if rootfsSourcePath != "" {
-
-		umnt1Out, err := osexec.Command("umount", "-f", "/run/kata-containers/shared/sandboxes/"+c.id+"/shared").Output()
-		if err != nil {
-			u.Logger().WithFields(logF).WithField("errmsg", err.Error()).Error("unmount 1 error")
-		} else {
-			u.Logger().WithFields(logF).WithField("out", string(umnt1Out)).Error("unmount 1 OK")
-		}
-
-		//  This unmount is also handled earlier by kata, but I left it just in case.
-		//umnt2Out, err := osexec.Command("umount", "-f", rootFsPath).Output()
-		//if err != nil {
-		//	u.Logger().WithFields(logF).WithField("errmsg", err.Error()).Error("unmount 2 error")
-		//} else {
-		//	u.Logger().WithFields(logF).WithField("out", string(umnt2Out)).Error("unmount 2 OK")
-		//}
+	if c.rootFs.Source == "" {
+		return nil
+	}
 
-		// remove garbage dirs
-		rmOut, err := osexec.Command("rm", "-rf", "/run/kata-containers/shared/containers/"+c.id).Output()
-		if err != nil {
-			u.Logger().WithFields(logF).WithField("errmsg", err.Error()).Error("rm container error")
-		} else {
-			u.Logger().WithFields(logF).WithField("out", string(rmOut)).Error("rm container OK")
-		}
+	// The bundle and the shared directories are not owned by urunc: only
+	// undo the block device mount and copy done by createContainer.
+	rootFsPath, err := uruncRootfsPath(&c)
+	if err != nil {
+		return nil
+	}
 
-		rmOut, err = osexec.Command("rm", "-rf", "/run/containerd/io.containerd.runtime.v2.task/default/"+c.id).Output()
-		if err != nil {
-			u.Logger().WithFields(logF).WithField("errmsg", err.Error()).Error("rm sandbox error")
-		} else {
-			u.Logger().WithFields(logF).WithField("out", string(rmOut)).Error("rm sandbox OK")
-		}
+	var mounts []string
+	if c.rootFs.Mounted {
+		mounts = append(mounts, rootFsPath)
+	}
+	if err := urunc.Cleanup(mounts, []string{uruncCopyPath(rootFsPath)}); err != nil {
+		u.Logger().WithFields(logF).WithError(err).Error("cleanup failed")
+		return nil
+	}
 
-		rmOut, err = osexec.Command("rm", "-rf", "/run/kata-containers/shared/sandboxes/"+c.id).Output()
-		if err != nil {
-			u.Logger().WithFields(logF).WithField("errmsg", err.Error()).Error("rm sandbox error")
-		} else {
-			u.Logger().WithFields(logF).WithField("out", string(rmOut)).Error("rm sandbox OK")
+	if u.ExecData.BlkDevice != "" && u.ExecData.BlkDevice == c.rootFs.Source {
+		if err := urunc.RemoveContents(rootFsPath); err != nil {
+			u.Logger().WithFields(logF).WithError(err).Error("failed to remove the block device copy")
 		}
 	}
 
@@ -585,191 +464,3 @@ func (u *uruncAgent) getGuestVolumeStats(ctx context.Context, volumeGuestPath st
 func (u *uruncAgent) resizeGuestVolume(ctx context.Context, volumeGuestPath string, size uint64) error {
 	return nil
 }
-
-// URUNC SPECIFIC LOGIC
-
-// type Command struct {
-// 	cmdString string
-// 	id        string
-// 	stdin     string
-// 	stdout    string
-// 	stderr    string
-// 	bundle    string
-// 	exec      *osexec.Cmd
-// }
-
-// func (u *uruncAgent) CreateCommand(execData ExecData) *Command {
-// 	logF := logrus.Fields{"src": "uruncio", "file": "vc/urunc_agent.go", "func": "CreateCommand"}
-// 	cmdString := CmdLine(execData)
-// 	logrus.WithField("BinaryType", execData.BinaryType).WithFields(logF).Error("exec info")
-// 	logrus.WithField("cmdString", cmdString).WithFields(logF).Error("exec info")
-
-// 	args := strings.Split(cmdString, " ")
-// 	var newCmd *osexec.Cmd
-// 	if len(args) == 1 {
-// 		logrus.WithField("cmdString", args[0]).WithFields(logF).Error("exec info")
-// 		newCmd = osexec.Command(args[0])
-// 	} else {
-// 		name, args := args[0], args[1:]
-// 		newCmd = osexec.Command(name, args...)
-// 	}
-// 	return &Command{cmdString: cmdString, container: container, id: container.id, stdin: container.stdin, stdout: container.stdout, stderr: container.stderr, bundle: container.bundle, exec: newCmd}
-// }
-
-// func CmdLine(execData ExecData) string {
-
-// 	// BinaryType string
-// 	// BinaryPath string
-// 	// IPAddress  string
-// 	// Mask       string
-// 	// Tap        string
-// 	// Container  *Container
-// 	logF := logrus.Fields{"src": "uruncio", "file": "vc/urunc_agent.go", "func": "CmdLine"}
-// 	logrus.WithField("BinaryType", execData.BinaryType).WithFields(logF).Error("ExecData")
-// 	logrus.WithField("BinaryPath", execData.BinaryPath).WithFields(logF).Error("ExecData")
-// 	logrus.WithField("IPAddress", execData.IPAddress).WithFields(logF).Error("ExecData")
-// 	logrus.WithField("Mask", execData.Mask).WithFields(logF).Error("ExecData")
-// 	logrus.WithField("Tap", execData.Tap).WithFields(logF).Error("ExecData")
-// 	logrus.WithField("Container", execData.Container.ID()).WithFields(logF).Error("ExecData")
-
-// 	switch execData.BinaryType {
-// 	case "pause":
-// 		return execData.BinaryPath
-// 	case "hvt":
-// 		return HvtCmd(execData)
-// 	case "qemu":
-// 		return QemuCmd(execData)
-// 	case "binary":
-// 		return execData.BinaryPath
-// 	default:
-// 		return ""
-// 	}
-// }
-
-// func HvtCmd(execData ExecData) string {
-// 	// ./tenders/hvt/solo5-hvt --net:service0=tap192  tests/test_net/test_net.hvt
-// 	return "HvtMonitor" + "--net:service0=" + execData.Tap + " " + execData.BinaryPath
-// }
-
-// func QemuCmd(execData ExecData) string {
-// 	qemuCmd := "qemu-system-x86_64 -cpu host"
-// 	qemuCmd += " -enable-kvm"
-// 	qemuCmd += " -m 128"
-// 	qemuCmd += " -nodefaults -no-acpi "
-// 	qemuCmd += " -display none -serial stdio "
-// 	qemuCmd += " -device isa-debug-exit "
-// 	qemuCmd += " -net nic,model=virtio "
-// 	qemuCmd += " -net tap,script=no,ifname=" + execData.Tap
-// 	qemuCmd += " -kernel " + execData.BinaryPath
-// 	qemuCmd += " -append \"netdev.ipv4_addr=" + execData.IPAddress + "netdev.ipv4_gw_addr=" + execData.Gateway + " netdev.ipv4_subnet_mask=255.255.255.255 --"
-
-// 	// qemu-system-x86_64 \
-// 	//     -cpu host \
-// 	//     -enable-kvm \
-// 	//     -m 128 \
-// 	//     -nodefaults -no-acpi \
-// 	//     -display none -serial stdio \
-// 	//     -device isa-debug-exit \
-// 	//     -net nic,model=virtio \
-// 	//     -net tap,script=no,ifname=tap106 \
-// 	//     -kernel /app-helloworld_kvm-x86_64 \
-// 	//     -append "netdev.ipv4_addr=$IP netdev.ipv4_gw_addr=169.254.1.1 netdev.ipv4_subnet_mask=255.255.255.255 --"
-// 	return qemuCmd
-// }
-
-func CopyFile(src, dst string) (err error) {
-	in, err := os.Open(src)
-	if err != nil {
-		return
-	}
-	defer in.Close()
-
-	out, err := os.Create(dst)
-	if err != nil {
-		return
-	}
-	defer func() {
-		if e := out.Close(); e != nil {
-			err = e
-		}
-	}()
-
-	_, err = io.Copy(out, in)
-	if err != nil {
-		return
-	}
-
-	err = out.Sync()
-	if err != nil {
-		return
-	}
-
-	si, err := os.Stat(src)
-	if err != nil {
-		return
-	}
-	err = os.Chmod(dst, si.Mode())
-	if err != nil {
-		return
-	}
-
-	return
-}
-
-// CopyDir recursively copies a directory tree, attempting to preserve permissions.
-// Source directory must exist, destination directory must *not* exist.
-// Symlinks are ignored and skipped.
-func CopyDir(src string, dst string) (err error) {
-	src = filepath.Clean(src)
-	dst = filepath.Clean(dst)
-
-	si, err := os.Stat(src)
-	if err != nil {
-		return err
-	}
-	if !si.IsDir() {
-		return fmt.Errorf("source is not a directory")
-	}
-
-	_, err = os.Stat(dst)
-	if err != nil && !os.IsNotExist(err) {
-		return
-	}
-	if err == nil {
-		return fmt.Errorf("destination already exists")
-	}
-
-	err = os.MkdirAll(dst, si.Mode())
-	if err != nil {
-		return
-	}
-
-	entries, err := ioutil.ReadDir(src)
-	if err != nil {
-		return
-	}
-
-	for _, entry := range entries {
-		srcPath := filepath.Join(src, entry.Name())
-		dstPath := filepath.Join(dst, entry.Name())
-
-		if entry.IsDir() {
-			err = CopyDir(srcPath, dstPath)
-			if err != nil {
-				return
-			}
-		} else {
-			// Skip symlinks.
-			if entry.Mode()&os.ModeSymlink != 0 {
-				continue
-			}
-
-			err = CopyFile(srcPath, dstPath)
-			if err != nil {
-				return
-			}
-		}
-	}
-
-	return
-}
@@ -0,0 +1,142 @@
+// Copyright (c) 2016 Intel Corporation
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/urunc"
+	"github.com/kata-containers/kata-containers/src/runtime/pkg/urunc/urunctest"
+	vcAnnotations "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/annotations"
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+func newUruncTestContainer(bundle string) *Container {
+	return &Container{
+		id:           "urunc-container",
+		rootfsSuffix: "rootfs",
+		config: &ContainerConfig{
+			Annotations: map[string]string{
+				vcAnnotations.BundlePathKey: bundle,
+			},
+		},
+	}
+}
+
+func newUruncTestSandbox() *Sandbox {
+	return &Sandbox{
+		network:    &LinuxNetwork{},
+		hypervisor: &uruncHypervisor{},
+	}
+}
+
+func TestUruncAgentCreateContainer(t *testing.T) {
+	for _, typ := range urunctest.Types {
+		t.Run(typ, func(t *testing.T) {
+			assert := assert.New(t)
+
+			bundle := urunctest.Bundle(t, typ)
+			c := newUruncTestContainer(bundle)
+
+			u := &uruncAgent{}
+			_, err := u.createContainer(context.Background(), newUruncTestSandbox(), c)
+			assert.NoError(err)
+
+			execData := u.GetExecData()
+			assert.Equal(c, execData.Container)
+			assert.NotNil(execData.Unikernel)
+			assert.Equal(typ, execData.Unikernel.Type)
+			assert.Equal(filepath.Join(bundle, "rootfs"), execData.Unikernel.Rootfs)
+			assert.Equal("hello world", execData.Unikernel.Cmdline)
+			assert.Empty(execData.BlkDevice)
+		})
+	}
+}
+
+func TestUruncAgentCreateContainerNotUnikernel(t *testing.T) {
+	assert := assert.New(t)
+
+	bundle := t.TempDir()
+	assert.NoError(os.Mkdir(filepath.Join(bundle, "rootfs"), 0755))
+
+	u := &uruncAgent{}
+	_, err := u.createContainer(context.Background(), newUruncTestSandbox(), newUruncTestContainer(bundle))
+	assert.EqualError(err, "requested image not supported")
+	assert.Nil(u.GetExecData().Unikernel)
+
+	// The bundle path is required to find the rootfs.
+	c := newUruncTestContainer("")
+	_, err = u.createContainer(context.Background(), newUruncTestSandbox(), c)
+	assert.Error(err)
+}
+
+func TestUruncAgentNetworkData(t *testing.T) {
+	assert := assert.New(t)
+
+	endpoint, err := createVethNetworkEndpoint(0, "eth0", NetXConnectTCFilterModel)
+	assert.NoError(err)
+	endpoint.NetPair.TAPIface.HardAddr = "02:00:ca:fe:00:01"
+	endpoint.EndpointProperties.Addrs = []netlink.Addr{
+		{IPNet: &net.IPNet{IP: net.ParseIP("10.0.0.2"), Mask: net.CIDRMask(24, 32)}},
+	}
+	endpoint.EndpointProperties.Routes = []netlink.Route{
+		{Gw: net.ParseIP("10.0.0.1")},
+	}
+
+	sandbox := newUruncTestSandbox()
+	sandbox.network = &LinuxNetwork{
+		netNSPath: "/run/netns/urunc",
+		eps:       []Endpoint{endpoint},
+	}
+
+	// The host device of the endpoint is not known to the hypervisor.
+	u := &uruncAgent{}
+	assert.Error(u.addNetworkData(context.Background(), sandbox))
+
+	assert.NoError(sandbox.hypervisor.AddDevice(context.Background(), endpoint, NetDev))
+	assert.NoError(u.startSandbox(context.Background(), sandbox))
+
+	execData := u.GetExecData()
+	assert.Equal(urunc.Network{
+		Tap:       endpoint.NetPair.TAPIface.Name,
+		HardAddr:  "02:00:ca:fe:00:01",
+		IPAddress: "10.0.0.2",
+		Mask:      "24",
+		Gateway:   "10.0.0.1",
+	}, execData.Network)
+	assert.Equal("/run/netns/urunc", execData.NetNs)
+}
+
+func TestUruncAgentStopContainer(t *testing.T) {
+	assert := assert.New(t)
+
+	// Nothing was mounted for a container without rootfs source.
+	u := &uruncAgent{}
+	c := newUruncTestContainer(urunctest.Bundle(t, "hvt"))
+	assert.NoError(u.stopContainer(context.Background(), newUruncTestSandbox(), *c))
+	assert.DirExists(filepath.Join(c.GetAnnotations()[vcAnnotations.BundlePathKey], "rootfs"))
+
+	// Only the copy of the block device content is removed, the bundle
+	// and its rootfs directory are left to their owner.
+	bundle := urunctest.Bundle(t, "hvt")
+	c = newUruncTestContainer(bundle)
+	c.rootFs.Source = "/dev/dm-1"
+	u.ExecData.BlkDevice = c.rootFs.Source
+	assert.NoError(os.MkdirAll(filepath.Join(bundle, "tmp"), 0755))
+
+	assert.NoError(u.stopContainer(context.Background(), newUruncTestSandbox(), *c))
+	assert.DirExists(filepath.Join(bundle, "rootfs"))
+	assert.NoDirExists(filepath.Join(bundle, "tmp"))
+	assert.FileExists(filepath.Join(bundle, "config.json"))
+	entries, err := os.ReadDir(filepath.Join(bundle, "rootfs"))
+	assert.NoError(err)
+	assert.Empty(entries)
+}
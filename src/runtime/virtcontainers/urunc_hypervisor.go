@@ -8,17 +8,37 @@ package virtcontainers
 import (
 	"context"
 	"errors"
+	"fmt"
 	"os"
 
 	hv "github.com/kata-containers/kata-containers/src/runtime/pkg/hypervisors"
 	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/utils"
 	"github.com/sirupsen/logrus"
+	"github.com/vishvananda/netlink"
 )
 
 var UruncHybridVSockPath = "/tmp/kata-mock-hybrid-vsock.socket"
 
+// UruncNetDevice describes the host side of a network endpoint that the
+// unikernel monitor attaches to.
+type UruncNetDevice struct {
+	// Tap is the name of the host TAP (tcfilter) or macvtap interface.
+	Tap string
+	// HardAddr is the MAC address the unikernel must use on this interface.
+	HardAddr string
+	// Fds holds already opened queues for the device. When set, the
+	// monitor must use them instead of opening Tap by name.
+	Fds []*os.File
+}
+
 type uruncHypervisor struct {
-	mockPid int
+	config     HypervisorConfig
+	netDevices map[string]UruncNetDevice
+	// ownedFds are the device descriptors opened by the hypervisor
+	// itself, as opposed to the ones owned by the endpoints.
+	ownedFds []*os.File
+	mockPid  int
 }
 
 func (u *uruncHypervisor) Unikernel() bool {
@@ -36,7 +56,7 @@ func (u *uruncHypervisor) Capabilities(ctx context.Context) types.Capabilities {
 }
 
 func (u *uruncHypervisor) HypervisorConfig() HypervisorConfig {
-	return HypervisorConfig{}
+	return u.config
 }
 
 func (u *uruncHypervisor) setConfig(config *HypervisorConfig) error {
@@ -44,6 +64,8 @@ func (u *uruncHypervisor) setConfig(config *HypervisorConfig) error {
 		return err
 	}
 
+	u.config = *config
+
 	return nil
 }
 
@@ -82,7 +104,7 @@ func (u *uruncHypervisor) AddDevice(ctx context.Context, devInfo interface{}, de
 	switch v := devInfo.(type) {
 	case Endpoint:
 		logrus.WithFields(logF).Error("Endpoint")
-		u.uruncAddNetDevice(ctx, v)
+		return u.uruncAddNetDevice(ctx, v)
 	default:
 		logrus.WithFields(logF).Error("Default")
 	}
@@ -110,14 +132,73 @@ func (u *uruncHypervisor) HotplugRemoveDevice(ctx context.Context, devInfo inter
 	return nil, nil
 }
 
-// This function just logs some Endpoint data
+// uruncAddNetDevice records the host interface backing the endpoint, so that
+// the monitor can later be attached to it. Under the tcfilter model the TAP is
+// persistent and the monitor opens it by name. A macvtap can only be reached
+// through its character device, so its queue is opened here when the endpoint
+// did not already open one.
 func (u *uruncHypervisor) uruncAddNetDevice(ctx context.Context, endpoint Endpoint) error {
 	logF := logrus.Fields{"src": "uruncio", "file": "cs/urunc_hypervisor.go", "func": "uruncAddNetDevice"}
-	netData := logrus.Fields{"GuestMac": endpoint.HardwareAddr(), "ifaceID": endpoint.Name(), "HostDevName": endpoint.NetworkPair().TapInterface.TAPIface.Name}
+
+	dev := UruncNetDevice{
+		HardAddr: endpoint.HardwareAddr(),
+	}
+
+	if ep, ok := endpoint.(*MacvtapEndpoint); ok {
+		dev.Tap = ep.Name()
+		dev.Fds = ep.VMFds
+	} else {
+		netPair := endpoint.NetworkPair()
+		if netPair == nil {
+			return fmt.Errorf("Unsupported endpoint type %s for urunc", endpoint.Type())
+		}
+
+		dev.Tap = netPair.TAPIface.Name
+		dev.Fds = netPair.VMFds
+
+		switch netPair.NetInterworkingModel {
+		case NetXConnectMacVtapModel:
+			if len(dev.Fds) == 0 {
+				fds, err := openUruncMacvtapFds(dev.Tap)
+				if err != nil {
+					return err
+				}
+				u.ownedFds = append(u.ownedFds, fds...)
+				dev.Fds = fds
+			}
+		case NetXConnectTCFilterModel:
+		default:
+			return fmt.Errorf("Unsupported inter-networking model %v for urunc", netPair.NetInterworkingModel)
+		}
+	}
+
+	if u.netDevices == nil {
+		u.netDevices = make(map[string]UruncNetDevice)
+	}
+	u.netDevices[endpoint.Name()] = dev
+
+	netData := logrus.Fields{"GuestMac": dev.HardAddr, "ifaceID": endpoint.Name(), "HostDevName": dev.Tap, "fds": len(dev.Fds)}
 	logrus.WithFields(logF).WithFields(netData).Error("")
 	return nil
 }
 
+// openUruncMacvtapFds opens a single queue of the macvtap named tapName. It
+// must be called from within the network namespace holding the macvtap.
+func openUruncMacvtapFds(tapName string) ([]*os.File, error) {
+	link, err := netlink.LinkByName(tapName)
+	if err != nil {
+		return nil, fmt.Errorf("Could not get macvtap %s: %s", tapName, err)
+	}
+
+	return createMacvtapFds(link.Attrs().Index, 1)
+}
+
+// netDevice returns the host device recorded for the endpoint named ifName.
+func (u *uruncHypervisor) netDevice(ifName string) (UruncNetDevice, bool) {
+	dev, ok := u.netDevices[ifName]
+	return dev, ok
+}
+
 func (u *uruncHypervisor) GetVMConsole(ctx context.Context, sandboxID string) (string, string, error) {
 	return "", "", nil
 }
@@ -138,6 +219,10 @@ func (u *uruncHypervisor) GetThreadIDs(ctx context.Context) (VcpuThreadIDs, erro
 }
 
 func (u *uruncHypervisor) Cleanup(ctx context.Context) error {
+	utils.CleanupFds(u.ownedFds, len(u.ownedFds))
+	u.ownedFds = nil
+	u.netDevices = nil
+
 	return nil
 }
 
@@ -0,0 +1,164 @@
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"os"
+	"testing"
+
+	"github.com/containernetworking/plugins/pkg/ns"
+	"github.com/containernetworking/plugins/pkg/testutils"
+	ktu "github.com/kata-containers/kata-containers/src/runtime/pkg/katatestutils"
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+func TestUruncHypervisorConfig(t *testing.T) {
+	assert := assert.New(t)
+
+	u := &uruncHypervisor{}
+	config := HypervisorConfig{
+		KernelPath:           "/kernel",
+		ImagePath:            "/image",
+		HypervisorPath:       "/hypervisor",
+		RxRateLimiterMaxRate: 1000,
+		TxRateLimiterMaxRate: 2000,
+	}
+
+	assert.NoError(u.setConfig(&config))
+	assert.Equal(config.RxRateLimiterMaxRate, u.HypervisorConfig().RxRateLimiterMaxRate)
+	assert.Equal(config.TxRateLimiterMaxRate, u.HypervisorConfig().TxRateLimiterMaxRate)
+	assert.False(u.IsRateLimiterBuiltin())
+}
+
+func TestUruncHypervisorAddNetDeviceTCFilter(t *testing.T) {
+	assert := assert.New(t)
+
+	endpoint, err := createVethNetworkEndpoint(0, "eth0", NetXConnectTCFilterModel)
+	assert.NoError(err)
+	endpoint.NetPair.TAPIface.HardAddr = "02:00:ca:fe:00:01"
+
+	u := &uruncHypervisor{}
+	err = u.AddDevice(context.Background(), endpoint, NetDev)
+	assert.NoError(err)
+
+	dev, ok := u.netDevice("eth0")
+	assert.True(ok)
+	assert.Equal(endpoint.NetPair.TAPIface.Name, dev.Tap)
+	assert.Equal("02:00:ca:fe:00:01", dev.HardAddr)
+	assert.Empty(dev.Fds)
+
+	_, ok = u.netDevice("eth1")
+	assert.False(ok)
+}
+
+func TestUruncHypervisorAddNetDeviceMacvtapFds(t *testing.T) {
+	assert := assert.New(t)
+
+	f, err := os.CreateTemp(t.TempDir(), "tap")
+	assert.NoError(err)
+	defer f.Close()
+
+	endpoint, err := createVethNetworkEndpoint(0, "eth0", NetXConnectMacVtapModel)
+	assert.NoError(err)
+	// Fds already opened while connecting the endpoint are reused as is.
+	endpoint.NetPair.VMFds = []*os.File{f}
+
+	u := &uruncHypervisor{}
+	err = u.AddDevice(context.Background(), endpoint, NetDev)
+	assert.NoError(err)
+
+	dev, ok := u.netDevice("eth0")
+	assert.True(ok)
+	assert.Equal([]*os.File{f}, dev.Fds)
+	assert.Empty(u.ownedFds)
+
+	assert.NoError(u.Cleanup(context.Background()))
+	_, ok = u.netDevice("eth0")
+	assert.False(ok)
+}
+
+func TestUruncHypervisorAddNetDeviceUnsupported(t *testing.T) {
+	assert := assert.New(t)
+
+	endpoint := &TapEndpoint{
+		TapInterface: TapInterface{Name: "tap0"},
+	}
+
+	u := &uruncHypervisor{}
+	err := u.AddDevice(context.Background(), endpoint, NetDev)
+	assert.Error(err)
+
+	_, ok := u.netDevice("tap0")
+	assert.False(ok)
+}
+
+func TestUruncHypervisorRateLimiters(t *testing.T) {
+	if tc.NotValid(ktu.NeedRoot()) {
+		t.Skip(testDisabledAsNonRoot)
+	}
+
+	assert := assert.New(t)
+
+	n, err := testutils.NewNS()
+	assert.NoError(err)
+	defer n.Close()
+
+	u := &uruncHypervisor{}
+	assert.NoError(u.setConfig(&HypervisorConfig{
+		KernelPath:           "/kernel",
+		ImagePath:            "/image",
+		HypervisorPath:       "/hypervisor",
+		RxRateLimiterMaxRate: 10000000,
+		TxRateLimiterMaxRate: 20000000,
+	}))
+
+	endpoint, err := createVethNetworkEndpoint(0, "eth0", NetXConnectTCFilterModel)
+	assert.NoError(err)
+
+	hasHTB := func(linkName string) bool {
+		link, err := netlink.LinkByName(linkName)
+		assert.NoError(err)
+		qdiscs, err := netlink.QdiscList(link)
+		assert.NoError(err)
+		for _, qdisc := range qdiscs {
+			if _, ok := qdisc.(*netlink.Htb); ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	err = doNetNS(n.Path(), func(_ ns.NetNS) error {
+		veth := &netlink.Veth{LinkAttrs: netlink.LinkAttrs{Name: "eth0"}, PeerName: "eth0-peer"}
+		if err := netlink.LinkAdd(veth); err != nil {
+			return err
+		}
+
+		if err := xConnectVMNetwork(context.Background(), endpoint, u); err != nil {
+			return err
+		}
+		if err := u.AddDevice(context.Background(), endpoint, NetDev); err != nil {
+			return err
+		}
+		if err := addRateLimiters(endpoint, u); err != nil {
+			return err
+		}
+
+		// The rx limiter shapes the TAP the monitor is attached to,
+		// the tx limiter the traffic redirected to the veth.
+		dev, ok := u.netDevice("eth0")
+		assert.True(ok)
+		assert.True(hasHTB(dev.Tap))
+		assert.True(hasHTB("eth0"))
+		assert.True(endpoint.GetRxRateLimiter())
+		assert.True(endpoint.GetTxRateLimiter())
+
+		return nil
+	})
+	assert.NoError(err)
+}
@@ -0,0 +1,304 @@
+//go:build linux
+// +build linux
+
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+
+	persistapi "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/persist/api"
+	vcTypes "github.com/kata-containers/kata-containers/src/runtime/virtcontainers/types"
+	"github.com/safchain/ethtool"
+)
+
+var vdpaTrace = getNetworkTrace(VdpaEndpointType)
+
+// vhostVdpaDevPath is where the vhost-vdpa character devices are created.
+var vhostVdpaDevPath = "/dev"
+
+// cniNetworkStatusAnnotation is the pod annotation the CNI plugins results
+// are reported in by Multus, along with the devices backing the interfaces.
+const cniNetworkStatusAnnotation = "k8s.v1.cni.cncf.io/network-status"
+
+// cniNetworkStatus is an interface of the CNI network status annotation,
+// as defined by the Kubernetes Network Plumbing Working Group.
+type cniNetworkStatus struct {
+	Interface  string         `json:"interface"`
+	DeviceInfo *cniDeviceInfo `json:"device-info,omitempty"`
+}
+
+// cniDeviceInfo is the device backing an interface of the CNI network
+// status annotation.
+type cniDeviceInfo struct {
+	Type string         `json:"type"`
+	Vdpa *cniVdpaDevice `json:"vdpa,omitempty"`
+}
+
+// cniVdpaDevice is a vDPA device of the CNI network status annotation.
+type cniVdpaDevice struct {
+	Driver     string `json:"driver"`
+	Path       string `json:"path"`
+	PciAddress string `json:"pci-address"`
+}
+
+// VdpaEndpoint represents a network interface backed by a vhost-vdpa
+// device, whose datapath is offloaded to the hardware.
+type VdpaEndpoint struct {
+	IfaceName          string
+	HardAddr           string
+	EndpointProperties NetworkInfo
+	EndpointType       EndpointType
+	// BDF of the PCI device the vDPA device is created on
+	BDF string
+	// Path to the vhost-vdpa character device on the host system
+	VhostDev string
+	PCIPath  vcTypes.PciPath
+}
+
+// Properties returns the properties of the interface.
+func (endpoint *VdpaEndpoint) Properties() NetworkInfo {
+	return endpoint.EndpointProperties
+}
+
+// Name returns name of the interface.
+func (endpoint *VdpaEndpoint) Name() string {
+	return endpoint.IfaceName
+}
+
+// HardwareAddr returns the mac address of the vhost-vdpa network interface.
+func (endpoint *VdpaEndpoint) HardwareAddr() string {
+	return endpoint.HardAddr
+}
+
+// Type indentifies the endpoint as a vhost-vdpa endpoint.
+func (endpoint *VdpaEndpoint) Type() EndpointType {
+	return endpoint.EndpointType
+}
+
+// SetProperties sets the properties of the endpoint.
+func (endpoint *VdpaEndpoint) SetProperties(properties NetworkInfo) {
+	endpoint.EndpointProperties = properties
+}
+
+// PciPath returns the PCI path of the endpoint.
+func (endpoint *VdpaEndpoint) PciPath() vcTypes.PciPath {
+	return endpoint.PCIPath
+}
+
+// SetPciPath sets the PCI path of the endpoint.
+func (endpoint *VdpaEndpoint) SetPciPath(pciPath vcTypes.PciPath) {
+	endpoint.PCIPath = pciPath
+}
+
+// NetworkPair returns the network pair of the endpoint.
+func (endpoint *VdpaEndpoint) NetworkPair() *NetworkInterfacePair {
+	return nil
+}
+
+// Attach for vhost-vdpa endpoint adds the vhost-vdpa device to the
+// hypervisor.
+func (endpoint *VdpaEndpoint) Attach(ctx context.Context, s *Sandbox) error {
+	span, ctx := vdpaTrace(ctx, "Attach", endpoint)
+	defer span.End()
+
+	endpoint.allowVhostDev(s)
+
+	return s.hypervisor.AddDevice(ctx, endpoint, NetDev)
+}
+
+// allowVhostDev allows the hypervisor to open the vhost-vdpa device from
+// the sandbox cgroup.
+func (endpoint *VdpaEndpoint) allowVhostDev(s *Sandbox) {
+	if s.sandboxController == nil {
+		return
+	}
+
+	if err := s.sandboxController.AddDevice(endpoint.VhostDev); err != nil {
+		s.Logger().WithError(err).WithField("device", endpoint.VhostDev).
+			Warnf("Could not add device to the %s controller", s.sandboxController)
+	}
+}
+
+// Detach for vhost-vdpa endpoint has nothing to tear down, the vhost-vdpa
+// device is released with the hypervisor.
+func (endpoint *VdpaEndpoint) Detach(ctx context.Context, netNsCreated bool, netNsPath string) error {
+	return nil
+}
+
+// HotAttach for vhost-vdpa endpoint uses hot plug device
+func (endpoint *VdpaEndpoint) HotAttach(ctx context.Context, s *Sandbox) error {
+	networkLogger().Info("Hot attaching vhost-vdpa endpoint")
+
+	span, ctx := vdpaTrace(ctx, "HotAttach", endpoint)
+	defer span.End()
+
+	endpoint.allowVhostDev(s)
+
+	if _, err := s.hypervisor.HotplugAddDevice(ctx, endpoint, NetDev); err != nil {
+		networkLogger().WithError(err).Error("Error attach vhost-vdpa ep")
+		return err
+	}
+	return nil
+}
+
+// HotDetach for vhost-vdpa endpoint uses hot pull device
+func (endpoint *VdpaEndpoint) HotDetach(ctx context.Context, s *Sandbox, netNsCreated bool, netNsPath string) error {
+	networkLogger().Info("Hot detaching vhost-vdpa endpoint")
+
+	span, ctx := vdpaTrace(ctx, "HotDetach", endpoint)
+	defer span.End()
+
+	if _, err := s.hypervisor.HotplugRemoveDevice(ctx, endpoint, NetDev); err != nil {
+		networkLogger().WithError(err).Error("Error detach vhost-vdpa ep")
+		return err
+	}
+
+	if s.sandboxController != nil {
+		if err := s.sandboxController.RemoveDevice(endpoint.VhostDev); err != nil {
+			s.Logger().WithError(err).WithField("device", endpoint.VhostDev).
+				Warnf("Could not remove device from the %s controller", s.sandboxController)
+		}
+	}
+
+	return nil
+}
+
+// vhostVdpaDevice returns the path of the vhost-vdpa character device
+// created on the PCI device bdf, or an empty path if there is none.
+//
+// vDPA devices are children of their parent PCI device, and the vhost-vdpa
+// driver creates its character device as a child of the vDPA device, e.g.
+// /sys/bus/pci/devices/0000:65:00.2/vdpa0/vhost-vdpa-0.
+func vhostVdpaDevice(bdf string) (string, error) {
+	matches, err := filepath.Glob(filepath.Join(sysPCIDevicesPath, bdf, "vdpa*", "vhost-vdpa-*"))
+	if err != nil {
+		return "", err
+	}
+
+	if len(matches) == 0 {
+		return "", nil
+	}
+
+	return filepath.Join(vhostVdpaDevPath, filepath.Base(matches[0])), nil
+}
+
+// findCNIVdpaDevice checks if the CNI plugins reported a vhost-vdpa device
+// backing the interface in the network status annotation of the sandbox,
+// and if they did it returns the BDF of the device and the path to the
+// vhost-vdpa device.
+func findCNIVdpaDevice(s *Sandbox, ifaceName string) (string, string, error) {
+	spec := s.GetPatchedOCISpec()
+	if spec == nil || spec.Annotations[cniNetworkStatusAnnotation] == "" {
+		return "", "", nil
+	}
+
+	var status []cniNetworkStatus
+	if err := json.Unmarshal([]byte(spec.Annotations[cniNetworkStatusAnnotation]), &status); err != nil {
+		return "", "", fmt.Errorf("invalid %s annotation: %v", cniNetworkStatusAnnotation, err)
+	}
+
+	for _, iface := range status {
+		if iface.Interface != ifaceName || iface.DeviceInfo == nil || iface.DeviceInfo.Type != "vdpa" {
+			continue
+		}
+
+		vdpa := iface.DeviceInfo.Vdpa
+		if vdpa == nil || vdpa.Driver != "vhost" || vdpa.Path == "" {
+			return "", "", nil
+		}
+
+		return vdpa.PciAddress, vdpa.Path, nil
+	}
+
+	return "", "", nil
+}
+
+// findVdpaDevice checks if a physical interface backs a vhost-vdpa device,
+// as set up by the SR-IOV CNI plugin for vDPA, and if it does it returns
+// the BDF of the interface and the path to the vhost-vdpa device.
+func findVdpaDevice(ifaceName string) (string, string, error) {
+	ethHandle, err := ethtool.NewEthtool()
+	if err != nil {
+		return "", "", err
+	}
+	defer ethHandle.Close()
+
+	bdf, err := ethHandle.BusInfo(ifaceName)
+	if err != nil {
+		return "", "", err
+	}
+
+	vhostDev, err := vhostVdpaDevice(bdf)
+	if err != nil || vhostDev == "" {
+		return "", "", err
+	}
+
+	return bdf, vhostDev, nil
+}
+
+// Create a vhost-vdpa endpoint
+func createVdpaEndpoint(netInfo NetworkInfo, bdf, vhostDev string) (*VdpaEndpoint, error) {
+	if vhostDev == "" {
+		return nil, fmt.Errorf("missing vhost-vdpa device for interface %s", netInfo.Iface.Name)
+	}
+
+	return &VdpaEndpoint{
+		IfaceName:    netInfo.Iface.Name,
+		HardAddr:     netInfo.Iface.HardwareAddr.String(),
+		EndpointType: VdpaEndpointType,
+		BDF:          bdf,
+		VhostDev:     vhostDev,
+	}, nil
+}
+
+func (endpoint *VdpaEndpoint) save() persistapi.NetworkEndpoint {
+	return persistapi.NetworkEndpoint{
+		Type: string(endpoint.Type()),
+
+		Vdpa: &persistapi.VdpaEndpoint{
+			IfaceName: endpoint.IfaceName,
+			HardAddr:  endpoint.HardAddr,
+			BDF:       endpoint.BDF,
+			VhostDev:  endpoint.VhostDev,
+			PCIPath:   endpoint.PCIPath,
+		},
+	}
+}
+
+func (endpoint *VdpaEndpoint) load(s persistapi.NetworkEndpoint) {
+	endpoint.EndpointType = VdpaEndpointType
+
+	if s.Vdpa != nil {
+		endpoint.IfaceName = s.Vdpa.IfaceName
+		endpoint.HardAddr = s.Vdpa.HardAddr
+		endpoint.BDF = s.Vdpa.BDF
+		endpoint.VhostDev = s.Vdpa.VhostDev
+		endpoint.PCIPath = s.Vdpa.PCIPath
+	}
+}
+
+// unsupported
+func (endpoint *VdpaEndpoint) GetRxRateLimiter() bool {
+	return false
+}
+
+func (endpoint *VdpaEndpoint) SetRxRateLimiter() error {
+	return fmt.Errorf("rx rate limiter is unsupported for vhost-vdpa endpoint")
+}
+
+// unsupported
+func (endpoint *VdpaEndpoint) GetTxRateLimiter() bool {
+	return false
+}
+
+func (endpoint *VdpaEndpoint) SetTxRateLimiter() error {
+	return fmt.Errorf("tx rate limiter is unsupported for vhost-vdpa endpoint")
+}
@@ -0,0 +1,151 @@
+//go:build linux
+// +build linux
+
+// Copyright (c) 2026 The Kata Containers Authors
+//
+// SPDX-License-Identifier: Apache-2.0
+//
+
+package virtcontainers
+
+import (
+	"context"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kata-containers/kata-containers/src/runtime/virtcontainers/pkg/annotations"
+	specs "github.com/opencontainers/runtime-spec/specs-go"
+	"github.com/stretchr/testify/assert"
+	"github.com/vishvananda/netlink"
+)
+
+func TestVhostVdpaDevice(t *testing.T) {
+	assert := assert.New(t)
+
+	savedSysPCIDevicesPath := sysPCIDevicesPath
+	defer func() {
+		sysPCIDevicesPath = savedSysPCIDevicesPath
+	}()
+	sysPCIDevicesPath = t.TempDir()
+
+	// Fake sysfs tree of a VF with a vDPA device bound to vhost-vdpa,
+	// and of a VF without vDPA device.
+	vdpaBDF := "0000:65:00.2"
+	err := os.MkdirAll(filepath.Join(sysPCIDevicesPath, vdpaBDF, "vdpa0", "vhost-vdpa-0"), DirMode)
+	assert.NoError(err)
+
+	plainBDF := "0000:65:00.3"
+	err = os.MkdirAll(filepath.Join(sysPCIDevicesPath, plainBDF, "net", "eth1"), DirMode)
+	assert.NoError(err)
+
+	vhostDev, err := vhostVdpaDevice(vdpaBDF)
+	assert.NoError(err)
+	assert.Equal("/dev/vhost-vdpa-0", vhostDev)
+
+	vhostDev, err = vhostVdpaDevice(plainBDF)
+	assert.NoError(err)
+	assert.Empty(vhostDev)
+
+	vhostDev, err = vhostVdpaDevice("0000:00:00.0")
+	assert.NoError(err)
+	assert.Empty(vhostDev)
+}
+
+func TestCreateVdpaEndpoint(t *testing.T) {
+	assert := assert.New(t)
+	macAddr := net.HardwareAddr{0x02, 0x00, 0xCA, 0xFE, 0x00, 0x04}
+
+	netInfo := NetworkInfo{
+		Iface: NetlinkIface{
+			LinkAttrs: netlink.LinkAttrs{
+				Name:         "eth0",
+				HardwareAddr: macAddr,
+			},
+		},
+	}
+
+	_, err := createVdpaEndpoint(netInfo, "0000:65:00.2", "")
+	assert.Error(err)
+
+	endpoint, err := createVdpaEndpoint(netInfo, "0000:65:00.2", "/dev/vhost-vdpa-0")
+	assert.NoError(err)
+	assert.Equal(&VdpaEndpoint{
+		IfaceName:    "eth0",
+		HardAddr:     macAddr.String(),
+		EndpointType: VdpaEndpointType,
+		BDF:          "0000:65:00.2",
+		VhostDev:     "/dev/vhost-vdpa-0",
+	}, endpoint)
+
+	// The endpoint is restored from its saved state.
+	loaded := &VdpaEndpoint{}
+	loaded.load(endpoint.save())
+	assert.Equal(endpoint, loaded)
+}
+
+func TestVdpaEndpoint_HotAttachDetach(t *testing.T) {
+	assert := assert.New(t)
+	v := &VdpaEndpoint{
+		IfaceName: "eth0",
+		HardAddr:  net.HardwareAddr{0x02, 0x00, 0xca, 0xfe, 0x00, 0x04}.String(),
+		VhostDev:  "/dev/vhost-vdpa-0",
+	}
+
+	s := &Sandbox{hypervisor: &mockHypervisor{}}
+
+	err := v.HotAttach(context.Background(), s)
+	assert.NoError(err)
+
+	err = v.HotDetach(context.Background(), s, true, "")
+	assert.NoError(err)
+}
+
+func TestFindCNIVdpaDevice(t *testing.T) {
+	assert := assert.New(t)
+
+	spec := &specs.Spec{Annotations: map[string]string{}}
+	s := &Sandbox{
+		config: &SandboxConfig{
+			Containers: []ContainerConfig{
+				{
+					Annotations: map[string]string{annotations.ContainerTypeKey: string(PodSandbox)},
+					CustomSpec:  spec,
+				},
+			},
+		},
+	}
+
+	// No network status reported by the CNI plugins.
+	bdf, vhostDev, err := findCNIVdpaDevice(s, "net1")
+	assert.NoError(err)
+	assert.Empty(bdf)
+	assert.Empty(vhostDev)
+
+	spec.Annotations[cniNetworkStatusAnnotation] = "{"
+	_, _, err = findCNIVdpaDevice(s, "net1")
+	assert.Error(err)
+
+	spec.Annotations[cniNetworkStatusAnnotation] = `[
+		{"name": "default", "interface": "eth0", "ips": ["10.244.0.5"]},
+		{"name": "default/vdpa-net", "interface": "net1", "device-info": {
+			"type": "vdpa", "version": "1.0.0",
+			"vdpa": {"parent-device": "vdpa:0000:65:00.2", "driver": "vhost", "path": "/dev/vhost-vdpa-1", "pci-address": "0000:65:00.2"}}},
+		{"name": "default/virtio-net", "interface": "net2", "device-info": {
+			"type": "vdpa", "version": "1.0.0",
+			"vdpa": {"parent-device": "vdpa:0000:65:00.3", "driver": "virtio", "pci-address": "0000:65:00.3"}}}
+	]`
+
+	bdf, vhostDev, err = findCNIVdpaDevice(s, "net1")
+	assert.NoError(err)
+	assert.Equal("0000:65:00.2", bdf)
+	assert.Equal("/dev/vhost-vdpa-1", vhostDev)
+
+	// Only the vhost-vdpa devices are handed to the VM.
+	for _, iface := range []string{"eth0", "net2"} {
+		_, vhostDev, err = findCNIVdpaDevice(s, iface)
+		assert.NoError(err)
+		assert.Empty(vhostDev)
+	}
+}